@@ -0,0 +1,207 @@
+package cookie
+
+import (
+	"crypto/hmac"
+	"encoding/base64"
+	"fmt"
+	"hash"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// AppendRead reads a cookie like Read, appending the decoded value to dst.
+func (m *Manager) AppendRead(dst []byte, r *http.Request, name string) ([]byte, error) {
+	return m.appendMode(dst, r, name, Plain)
+}
+
+// AppendSigned reads a signed cookie like ReadSigned, appending the verified
+// value to dst. For busy read paths: given a dst with spare capacity for the
+// encoded cookie, verifying a value signed with WithSecret or WithSecrets does
+// not allocate. Values using derived keys, expiry, compression, chunking, or
+// other envelope features are read correctly, at the usual cost.
+func (m *Manager) AppendSigned(dst []byte, r *http.Request, name string) ([]byte, error) {
+	return m.appendMode(dst, r, name, Signed)
+}
+
+// appendMode verifies simple envelopes in place within dst, and falls back
+// to readMode for everything else.
+func (m *Manager) appendMode(dst []byte, r *http.Request, name string, mode Mode) ([]byte, error) {
+	raw, ok := cookieValue(r, m.prefix, name)
+	if !ok || !m.canAppend(mode) || (securePrefixed(m.prefix, name) && !m.secureRequest(r)) {
+		return m.appendSlow(dst, r, name, mode)
+	}
+	dst, err := m.appendOpen(dst, r, name, mode, raw)
+	m.logRead(r, name, mode, err)
+	return dst, err
+}
+
+// appendOpen decodes and opens raw, the value of the named cookie, within dst.
+func (m *Manager) appendOpen(dst []byte, r *http.Request, name string, mode Mode, raw string) ([]byte, error) {
+	start := len(dst)
+	dst, err := appendDecode(dst, raw)
+	if err != nil {
+		return dst[:start], fmt.Errorf("%w: cannot decode '%s%s': %w", ErrCookie, m.prefix, name, err)
+	}
+	value := dst[start:]
+	if len(value) < headerLength || string(value[:len(envelopeMagic)]) != envelopeMagic ||
+		value[2] != envelopeV1 || Mode(value[3]) != mode || value[5] != 0 ||
+		len(value) < headerLength+int(value[6]) {
+		// unversioned, flagged, or malformed values take the general path
+		opened, err := m.open(r, mode, m.prefix+name, string(value))
+		return append(dst[:start], opened...), err
+	}
+	end := headerLength + int(value[6])
+	encoded, body := value[:end], value[end:]
+
+	if mode == Signed {
+		h := Hash(value[4])
+		newHash, ok := h.new()
+		if !ok {
+			return dst[:start], fmt.Errorf("%w: unsupported signature algorithm %s", ErrCookie, h)
+		}
+		secretKey, err := m.appendKey(value[headerLength:end])
+		if err != nil {
+			return dst[:start], err
+		}
+		if body, err = m.verifyAppended(dst, h, newHash, secretKey, encoded, name, body); err != nil {
+			return dst[:start], err
+		}
+	}
+	n := copy(dst[start:], body)
+	return dst[:start+n], nil
+}
+
+// canAppend reports whether the Manager's configuration allows the fast path.
+func (m *Manager) canAppend(mode Mode) bool {
+	if mode == Plain {
+		return true
+	}
+	return mode == Signed && m.signer == nil && !m.derive && m.replay == nil &&
+		m.signedMaxAge == 0 && m.cache != nil
+}
+
+// appendKey returns the secret matching a signed value's key ID.
+func (m *Manager) appendKey(id []byte) ([]byte, error) {
+	if ring, ok := m.source.(*KeyRing); ok {
+		if secretKey, ok := ring.lookup(id); ok {
+			return secretKey, nil
+		}
+		return nil, fmt.Errorf("%w: %w %q", ErrCookie, ErrUnknownKey, id)
+	}
+	secretKeys, err := m.secrets()
+	if err != nil {
+		return nil, err
+	}
+	for _, secretKey := range secretKeys {
+		if hasKeyID(secretKey, id) {
+			return secretKey, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %w %x", ErrCookie, ErrUnknownKey, id)
+}
+
+// verifyAppended checks the signature on body, using the spare capacity of
+// dst as scratch space, and returns the signed value.
+func (m *Manager) verifyAppended(dst []byte, h Hash, newHash func() hash.Hash, secretKey, encoded []byte, name string, body []byte) ([]byte, error) {
+	pool := m.cache.macPool(h, newHash, secretKey)
+	mac := pool.Get().(hash.Hash)
+	defer pool.Put(mac)
+	size := mac.Size()
+	if len(body) < size {
+		return nil, fmt.Errorf("%w: %w: signature wrong length", ErrCookie, ErrTooShort)
+	}
+	signature, signed := body[:size], body[size:]
+
+	scratch := append(dst[len(dst):], m.prefix...)
+	scratch = append(scratch, name...)
+	scratch = append(scratch, 0)
+	mac.Reset()
+	mac.Write(encoded)
+	mac.Write(scratch)
+	mac.Write(signed)
+	sum := mac.Sum(scratch[len(scratch):])
+	if !hmac.Equal(signature, sum) {
+		return nil, fmt.Errorf("%w: %w", ErrCookie, ErrSignatureMismatch)
+	}
+	return signed, nil
+}
+
+// appendSlow reads through the general path.
+func (m *Manager) appendSlow(dst []byte, r *http.Request, name string, mode Mode) ([]byte, error) {
+	value, err := m.readMode(r, name, mode)
+	if err != nil {
+		return dst, err
+	}
+	return append(dst, value...), nil
+}
+
+// cookieValue finds the value of the cookie named prefix+name in the
+// request's Cookie headers without allocating.
+func cookieValue(r *http.Request, prefix, name string) (string, bool) {
+	for _, line := range r.Header["Cookie"] {
+		for line != "" {
+			var part string
+			part, line, _ = strings.Cut(line, ";")
+			key, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+			if !ok || len(key) != len(prefix)+len(name) ||
+				!strings.HasPrefix(key, prefix) || key[len(prefix):] != name {
+				continue
+			}
+			if len(value) > 1 && value[0] == '"' && value[len(value)-1] == '"' {
+				value = value[1 : len(value)-1]
+			}
+			return value, true
+		}
+	}
+	return "", false
+}
+
+// securePrefixed reports whether prefix+name starts with a prefix that
+// requires a secure connection.
+func securePrefixed(prefix, name string) bool {
+	return concatHasPrefix(prefix, name, HostPrefix) || concatHasPrefix(prefix, name, SecurePrefix)
+}
+
+func concatHasPrefix(a, b, p string) bool {
+	if len(a) >= len(p) {
+		return strings.HasPrefix(a, p)
+	}
+	return strings.HasPrefix(p, a) && strings.HasPrefix(b, p[len(a):])
+}
+
+// base64URL maps base64url characters to their values; invalid characters map to 0xFF.
+var base64URL = func() (table [256]byte) {
+	for i := range table {
+		table[i] = 0xFF
+	}
+	const alphabet = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789-_"
+	for i := range len(alphabet) {
+		table[alphabet[i]] = byte(i)
+	}
+	return table
+}()
+
+// appendDecode appends the base64url decoding of s, with or without padding, to dst.
+func appendDecode(dst []byte, s string) ([]byte, error) {
+	s = strings.TrimRight(s, "=")
+	if len(s)%4 == 1 {
+		return dst, base64.CorruptInputError(len(s))
+	}
+	dst = slices.Grow(dst, base64.RawURLEncoding.DecodedLen(len(s)))
+	var acc uint32
+	var bits uint
+	for i := range len(s) {
+		v := base64URL[s[i]]
+		if v == 0xFF {
+			return dst, base64.CorruptInputError(i)
+		}
+		acc = acc<<6 | uint32(v)
+		bits += 6
+		if bits >= 8 {
+			bits -= 8
+			dst = append(dst, byte(acc>>bits))
+		}
+	}
+	return dst, nil
+}
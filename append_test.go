@@ -0,0 +1,123 @@
+package cookie
+
+import (
+	"crypto/tls"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAppendSigned(t *testing.T) {
+	m := newTestManager(t)
+	prefixed := newTestManager(t, WithPrefix(HostPrefix))
+
+	for name, tc := range map[string]struct {
+		m     *Manager
+		write func(w http.ResponseWriter) error
+	}{
+		"signed": {m, func(w http.ResponseWriter) error {
+			return m.WriteSigned(w, "a", "chocolate fudge")
+		}},
+		"prefixed": {prefixed, func(w http.ResponseWriter) error {
+			return prefixed.WriteSigned(w, "a", "chocolate fudge")
+		}},
+		"expiring": {m, func(w http.ResponseWriter) error {
+			return m.WriteSignedWithExpiry(w, "a", "chocolate fudge", time.Now().Add(time.Hour))
+		}},
+		"unversioned": {m, func(w http.ResponseWriter) error {
+			return WriteSigned(w, http.Cookie{Name: "a", Value: "chocolate fudge"}, m.secretKeys[0])
+		}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			require.NoError(t, tc.write(w))
+			r := requestWith(w)
+			r.TLS = &tls.ConnectionState{}
+
+			dst := []byte("prefix:")
+			dst, err := tc.m.AppendSigned(dst, r, "a")
+			require.NoError(t, err)
+			require.Equal(t, "prefix:chocolate fudge", string(dst))
+
+			// the value of an insecure request fails as ReadSigned does
+			r.TLS = nil
+			_, err = tc.m.AppendSigned(nil, r, "a")
+			_, expected := tc.m.ReadSigned(r, "a")
+			require.Equal(t, expected, err)
+		})
+	}
+
+	t.Run("tampered", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		require.NoError(t, m.WriteSigned(w, "a", "chocolate fudge"))
+		raw, err := Read(requestWith(w), "a")
+		require.NoError(t, err)
+		tampered := httptest.NewRecorder()
+		require.NoError(t, Write(tampered, http.Cookie{Name: "a", Value: raw[:len(raw)-1] + "!"}))
+		dst, err := m.AppendSigned([]byte("keep"), requestWith(tampered), "a")
+		require.ErrorIs(t, err, ErrCookie)
+		require.Equal(t, "keep", string(dst))
+
+		_, err = newTestManager(t).AppendSigned(nil, requestWith(w), "a")
+		require.ErrorIs(t, err, ErrUnknownKey)
+		_, err = m.AppendSigned(nil, requestWith(w), "missing")
+		require.ErrorIs(t, err, ErrCookie)
+	})
+
+	t.Run("plain", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		require.NoError(t, m.Write(w, "a", "oatmeal raisin"))
+		dst, err := m.AppendRead(nil, requestWith(w), "a")
+		require.NoError(t, err)
+		require.Equal(t, "oatmeal raisin", string(dst))
+	})
+}
+
+func TestAppendSignedAllocs(t *testing.T) {
+	m := newTestManager(t, WithPrefix(HostPrefix))
+	w := httptest.NewRecorder()
+	require.NoError(t, m.WriteSigned(w, "a", "chocolate fudge"))
+	r := requestWith(w)
+	r.TLS = &tls.ConnectionState{}
+	buf := make([]byte, 0, 512)
+
+	allocs := testing.AllocsPerRun(100, func() {
+		if _, err := m.AppendSigned(buf[:0], r, "a"); err != nil {
+			t.Fatal(err)
+		}
+	})
+	require.Zero(t, allocs)
+}
+
+func TestAppendDecode(t *testing.T) {
+	for _, value := range []string{"", "a", "ab", "abc", "abcd", "\xff\xfe\x00binary"} {
+		for _, encoded := range []string{
+			base64URLPadded(value),
+			base64URLRaw(value),
+		} {
+			decoded, err := appendDecode(nil, encoded)
+			require.NoError(t, err)
+			require.Equal(t, value, string(decoded))
+		}
+	}
+	_, err := appendDecode(nil, "ab!d")
+	require.Error(t, err)
+	_, err = appendDecode(nil, "abcde")
+	require.Error(t, err)
+}
+
+func base64URLPadded(s string) string {
+	w := httptest.NewRecorder()
+	Write(w, http.Cookie{Name: "a", Value: s})
+	return w.Result().Cookies()[0].Value
+}
+
+func base64URLRaw(s string) string {
+	w := httptest.NewRecorder()
+	write(w, http.Cookie{Name: "a", Value: s}, maxCookieSize, base64.RawURLEncoding)
+	return w.Result().Cookies()[0].Value
+}
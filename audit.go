@@ -0,0 +1,111 @@
+package cookie
+
+import (
+	"log/slog"
+	"net/http"
+	"slices"
+)
+
+// AuditPolicy is the cookie security policy checked by Audit. Every cookie a
+// response sets must be Secure and HttpOnly unless its name is allowed.
+type AuditPolicy struct {
+	AllowInsecure []string // names exempt from Secure, such as cookies for plain-HTTP development
+	AllowScript   []string // names exempt from HttpOnly, such as a CSRF token read by JavaScript
+
+	// Reject removes violating cookies from the response, so they never reach
+	// the client, rather than only logging them.
+	Reject bool
+
+	Logger *slog.Logger // nil uses slog.Default
+}
+
+// Audit checks every Set-Cookie header of the responses of the handler it
+// wraps against policy, whether written by a Manager or not, logging each
+// violation with the cookie name, missing attributes, and request path.
+// Cookies being deleted are not checked.
+func Audit(policy AuditPolicy) func(http.Handler) http.Handler {
+	if policy.Logger == nil {
+		policy.Logger = slog.Default()
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			aw := &auditWriter{ResponseWriter: w, policy: &policy, r: r}
+			next.ServeHTTP(aw, r)
+			aw.audit()
+		})
+	}
+}
+
+// auditWriter audits Set-Cookie headers before the response headers are sent.
+type auditWriter struct {
+	http.ResponseWriter
+	policy *AuditPolicy
+	r      *http.Request
+	done   bool
+}
+
+func (w *auditWriter) WriteHeader(code int) {
+	w.audit()
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *auditWriter) Write(b []byte) (int, error) {
+	w.audit()
+	return w.ResponseWriter.Write(b)
+}
+
+// Flush audits the headers before flushing buffered data to the client.
+func (w *auditWriter) Flush() {
+	w.audit()
+	http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (w *auditWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// audit checks each Set-Cookie header, once.
+func (w *auditWriter) audit() {
+	if w.done {
+		return
+	}
+	w.done = true
+	header := w.Header()
+	var kept []string
+	for _, line := range header["Set-Cookie"] {
+		c, err := http.ParseSetCookie(line)
+		if err != nil || c.MaxAge < 0 {
+			kept = append(kept, line)
+			continue
+		}
+		var missing []string
+		if !c.Secure && !slices.Contains(w.policy.AllowInsecure, c.Name) {
+			missing = append(missing, "Secure")
+		}
+		if !c.HttpOnly && !slices.Contains(w.policy.AllowScript, c.Name) {
+			missing = append(missing, "HttpOnly")
+		}
+		if len(missing) == 0 {
+			kept = append(kept, line)
+			continue
+		}
+		level, msg := slog.LevelWarn, "insecure cookie set"
+		if w.policy.Reject {
+			level, msg = slog.LevelError, "insecure cookie rejected"
+		} else {
+			kept = append(kept, line)
+		}
+		w.policy.Logger.LogAttrs(w.r.Context(), level, msg,
+			slog.String("cookie", c.Name),
+			slog.Any("missing", missing),
+			slog.String("method", w.r.Method),
+			slog.String("path", w.r.URL.Path),
+		)
+	}
+	if len(kept) == 0 {
+		header.Del("Set-Cookie")
+	} else {
+		header["Set-Cookie"] = kept
+	}
+}
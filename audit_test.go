@@ -0,0 +1,61 @@
+package cookie
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAudit(t *testing.T) {
+	m := newTestManager(t)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, m.WriteSigned(w, "user", "alice"))
+		http.SetCookie(w, &http.Cookie{Name: "theme", Value: "dark"})
+		http.SetCookie(w, &http.Cookie{Name: "csrf", Value: "token", Secure: true})
+		m.Delete(w, "old")
+		w.Write([]byte("ok"))
+	})
+	policy := AuditPolicy{AllowScript: []string{"csrf"}}
+
+	var buf bytes.Buffer
+	policy.Logger = slog.New(slog.NewJSONHandler(&buf, nil))
+	w := httptest.NewRecorder()
+	Audit(policy)(handler).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/page", nil))
+	require.Len(t, w.Result().Cookies(), 4)
+
+	var entry map[string]any
+	require.NoError(t, json.Unmarshal(bytes.TrimSpace(buf.Bytes()), &entry))
+	require.Equal(t, "WARN", entry["level"])
+	require.Equal(t, "insecure cookie set", entry["msg"])
+	require.Equal(t, "theme", entry["cookie"])
+	require.Equal(t, []any{"Secure", "HttpOnly"}, entry["missing"])
+	require.Equal(t, "/page", entry["path"])
+
+	buf.Reset()
+	policy.Reject = true
+	w = httptest.NewRecorder()
+	Audit(policy)(handler).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/page", nil))
+	var names []string
+	for _, c := range w.Result().Cookies() {
+		names = append(names, c.Name)
+	}
+	require.Equal(t, []string{"user", "csrf", "old"}, names)
+	require.Contains(t, buf.String(), "insecure cookie rejected")
+}
+
+func TestAuditWithoutWrite(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: "theme", Value: "dark"})
+	})
+	var buf bytes.Buffer
+	policy := AuditPolicy{Reject: true, Logger: slog.New(slog.NewJSONHandler(&buf, nil))}
+	w := httptest.NewRecorder()
+	Audit(policy)(handler).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	require.Empty(t, w.Result().Cookies())
+	require.Contains(t, buf.String(), "theme")
+}
@@ -0,0 +1,82 @@
+package cookie
+
+import (
+	"errors"
+	"net/http"
+)
+
+// Bag holds several small values in a single cookie, so related state
+// costs one Set-Cookie header instead of one per value. Changes are kept
+// in memory until Save. A Bag is not safe for concurrent use.
+type Bag struct {
+	m       *Manager
+	name    string
+	mode    Mode
+	values  map[string]string
+	changed bool
+}
+
+// Bag reads the named bag cookie written with mode, encoded with the Manager's Codec.
+// A request without the cookie returns an empty Bag. If the cookie cannot be read,
+// the error is returned along with an empty Bag, which Save will overwrite it with.
+func (m *Manager) Bag(r *http.Request, name string, mode Mode) (*Bag, error) {
+	bag := &Bag{m: m, name: name, mode: mode}
+	values, err := ReadValue[map[string]string](m, r, name, mode)
+	if err != nil {
+		bag.values = map[string]string{}
+		if errors.Is(err, http.ErrNoCookie) {
+			return bag, nil
+		}
+		bag.changed = true
+		return bag, err
+	}
+	if values == nil {
+		values = map[string]string{}
+	}
+	bag.values = values
+	return bag, nil
+}
+
+// Get returns the value stored under key.
+func (b *Bag) Get(key string) (string, bool) {
+	value, ok := b.values[key]
+	return value, ok
+}
+
+// Set stores value under key.
+func (b *Bag) Set(key, value string) {
+	if current, ok := b.values[key]; ok && current == value {
+		return
+	}
+	b.values[key] = value
+	b.changed = true
+}
+
+// Delete removes key from the bag.
+func (b *Bag) Delete(key string) {
+	if _, ok := b.values[key]; !ok {
+		return
+	}
+	delete(b.values, key)
+	b.changed = true
+}
+
+// Len returns the number of values in the bag.
+func (b *Bag) Len() int {
+	return len(b.values)
+}
+
+// Save writes the bag if it has changed since it was read,
+// deleting the cookie once the bag is empty.
+func (b *Bag) Save(w http.ResponseWriter) error {
+	if !b.changed {
+		return nil
+	}
+	if len(b.values) == 0 {
+		b.m.Delete(w, b.name)
+	} else if err := WriteValue(b.m, w, b.name, b.values, b.mode); err != nil {
+		return err
+	}
+	b.changed = false
+	return nil
+}
@@ -0,0 +1,65 @@
+package cookie
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBag(t *testing.T) {
+	m := newTestManager(t)
+	for _, mode := range []Mode{Signed, Encrypted} {
+		t.Run(mode.String(), func(t *testing.T) {
+			bag, err := m.Bag(httptest.NewRequest("GET", "/", nil), "prefs", mode)
+			require.NoError(t, err)
+			require.Zero(t, bag.Len())
+			bag.Set("theme", "dark")
+			bag.Set("lang", "en")
+
+			w := httptest.NewRecorder()
+			require.NoError(t, bag.Save(w))
+			require.Len(t, w.Result().Cookies(), 1)
+
+			bag, err = m.Bag(requestWith(w), "prefs", mode)
+			require.NoError(t, err)
+			require.Equal(t, 2, bag.Len())
+			theme, ok := bag.Get("theme")
+			require.True(t, ok)
+			require.Equal(t, "dark", theme)
+
+			// unchanged bags are not rewritten
+			w = httptest.NewRecorder()
+			bag.Set("theme", "dark")
+			require.NoError(t, bag.Save(w))
+			require.Empty(t, w.Result().Cookies())
+
+			bag.Delete("theme")
+			bag.Delete("lang")
+			w = httptest.NewRecorder()
+			require.NoError(t, bag.Save(w))
+			cookies := w.Result().Cookies()
+			require.Len(t, cookies, 1)
+			require.Negative(t, cookies[0].MaxAge)
+		})
+	}
+}
+
+func TestBagTampered(t *testing.T) {
+	m := newTestManager(t)
+	r := httptest.NewRequest("GET", "/", nil)
+	r.AddCookie(&http.Cookie{Name: "prefs", Value: "tampered"})
+
+	bag, err := m.Bag(r, "prefs", Signed)
+	require.ErrorIs(t, err, ErrCookie)
+	require.Zero(t, bag.Len())
+
+	bag.Set("theme", "light")
+	w := httptest.NewRecorder()
+	require.NoError(t, bag.Save(w))
+	bag, err = m.Bag(requestWith(w), "prefs", Signed)
+	require.NoError(t, err)
+	theme, _ := bag.Get("theme")
+	require.Equal(t, "light", theme)
+}
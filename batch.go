@@ -0,0 +1,48 @@
+package cookie
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// WriteAll seals the value of each cookie according to mode and writes it.
+// Cookies are written as given, so build them with Cookie or NewCookie to apply
+// the Manager's defaults and name prefix. Every cookie is attempted; the
+// returned error joins the failures, each naming its cookie.
+func (m *Manager) WriteAll(w http.ResponseWriter, cookies []http.Cookie, mode Mode) error {
+	var errs []error
+	for _, cookie := range cookies {
+		if err := m.writeSealed(w, cookie, mode); err != nil {
+			errs = append(errs, fmt.Errorf("'%s': %w", cookie.Name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// ReadAll reads each named cookie written with mode, returning the values
+// that could be read by name. The returned error joins the failures,
+// each naming its cookie, so one missing cookie does not hide the rest.
+func (m *Manager) ReadAll(r *http.Request, mode Mode, names ...string) (map[string]string, error) {
+	values := make(map[string]string, len(names))
+	var errs []error
+	for _, name := range names {
+		value, err := m.readMode(r, name, mode)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("'%s': %w", name, err))
+			continue
+		}
+		values[name] = value
+	}
+	return values, errors.Join(errs...)
+}
+
+// writeSealed seals the value of cookie according to mode and writes it.
+func (m *Manager) writeSealed(w http.ResponseWriter, cookie http.Cookie, mode Mode) error {
+	sealed, err := m.seal(mode, cookie.Name, cookie.Value, sealOptions{})
+	if err != nil {
+		return err
+	}
+	cookie.Value = sealed
+	return m.write(w, cookie)
+}
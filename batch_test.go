@@ -0,0 +1,47 @@
+package cookie
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteAllReadAll(t *testing.T) {
+	m := newTestManager(t, WithPrefix(SecurePrefix), WithTrustedProxies("192.0.2.0/24")) // httptest requests come from 192.0.2.1
+	for _, mode := range []Mode{Plain, Signed, Encrypted} {
+		t.Run(mode.String(), func(t *testing.T) {
+			w := httptest.NewRecorder()
+			err := m.WriteAll(w, []http.Cookie{
+				m.Cookie("a", "chocolate fudge"),
+				m.Cookie("b", "oatmeal raisin"),
+			}, mode)
+			require.NoError(t, err)
+
+			r := requestWith(w)
+			r.Header.Set("X-Forwarded-Proto", "https")
+			values, err := m.ReadAll(r, mode, "a", "b")
+			require.NoError(t, err)
+			require.Equal(t, map[string]string{"a": "chocolate fudge", "b": "oatmeal raisin"}, values)
+		})
+	}
+}
+
+func TestWriteAllReadAllErrors(t *testing.T) {
+	m := newTestManager(t)
+	w := httptest.NewRecorder()
+	err := m.WriteAll(w, []http.Cookie{
+		m.Cookie("a", "chocolate fudge"),
+		m.Cookie("bad name", "x"),
+		m.Cookie("c", "snickerdoodle"),
+	}, Signed)
+	require.ErrorIs(t, err, ErrInvalidName)
+	require.ErrorContains(t, err, "'bad name'")
+	require.Len(t, w.Result().Cookies(), 2)
+
+	values, err := m.ReadAll(requestWith(w), Signed, "a", "missing", "c")
+	require.ErrorIs(t, err, ErrCookie)
+	require.ErrorContains(t, err, "'missing'")
+	require.Equal(t, map[string]string{"a": "chocolate fudge", "c": "snickerdoodle"}, values)
+}
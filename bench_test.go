@@ -0,0 +1,88 @@
+package cookie
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+// BenchmarkSealOpen measures the cryptographic round trip of a Manager value,
+// without the cost of writing and parsing HTTP headers.
+func BenchmarkSealOpen(b *testing.B) {
+	secretKey, err := NewCookieSecret()
+	if err != nil {
+		b.Fatal(err)
+	}
+	r := httptest.NewRequest("GET", "/", nil)
+	for _, mode := range []Mode{Signed, Encrypted} {
+		for name, opt := range map[string]Option{
+			"secret":  WithSecret(secretKey),
+			"derived": WithMasterSecret(secretKey),
+		} {
+			m, err := New(opt)
+			if err != nil {
+				b.Fatal(err)
+			}
+			b.Run(mode.String()+"/"+name, func(b *testing.B) {
+				b.ReportAllocs()
+				for i := 0; i < b.N; i++ {
+					sealed, err := m.seal(mode, testCookie.Name, testCookie.Value, sealOptions{})
+					if err != nil {
+						b.Fatal(err)
+					}
+					if _, err := m.open(r, mode, testCookie.Name, sealed); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	}
+}
+
+// BenchmarkManager measures a full write and read through HTTP headers.
+func BenchmarkManager(b *testing.B) {
+	m, err := New(WithSecret(make([]byte, secretLength)))
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		w := httptest.NewRecorder()
+		if err := m.WriteEncryptedValue(w, testCookie.Name, testCookie.Value); err != nil {
+			b.Fatal(err)
+		}
+		if _, err := m.ReadEncryptedValue(requestWith(w), testCookie.Name); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkRead compares ReadSigned with AppendSigned into a reused buffer.
+func BenchmarkRead(b *testing.B) {
+	m, err := New(WithSecret(make([]byte, secretLength)))
+	if err != nil {
+		b.Fatal(err)
+	}
+	w := httptest.NewRecorder()
+	if err := m.WriteSigned(w, testCookie.Name, testCookie.Value); err != nil {
+		b.Fatal(err)
+	}
+	r := requestWith(w)
+
+	b.Run("ReadSigned", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := m.ReadSigned(r, testCookie.Name); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("AppendSigned", func(b *testing.B) {
+		buf := make([]byte, 0, 512)
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := m.AppendSigned(buf[:0], r, testCookie.Name); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
@@ -0,0 +1,65 @@
+package cookie
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteSignedBound(t *testing.T) {
+	m := newTestManager(t, WithBinding(Binding{UserAgent: true, IPv4Prefix: 24, IPv6Prefix: 64, TLS: true}))
+
+	client := func(userAgent, remoteAddr string) *http.Request {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("User-Agent", userAgent)
+		r.RemoteAddr = remoteAddr
+		r.TLS = &tls.ConnectionState{Version: tls.VersionTLS13, CipherSuite: tls.TLS_AES_128_GCM_SHA256}
+		return r
+	}
+	// replay copies the cookies written to w onto r
+	replay := func(w *httptest.ResponseRecorder, r *http.Request) *http.Request {
+		for _, c := range w.Result().Cookies() {
+			r.AddCookie(c)
+		}
+		return r
+	}
+
+	w := httptest.NewRecorder()
+	require.NoError(t, m.WriteSignedBound(w, client("firefox", "203.0.113.7:5000"), "a", "b"))
+
+	// same client, new port and host within the /24
+	value, err := m.ReadSigned(replay(w, client("firefox", "203.0.113.99:6000")), "a")
+	require.NoError(t, err)
+	require.Equal(t, "b", value)
+
+	for name, r := range map[string]*http.Request{
+		"user agent": client("curl", "203.0.113.7:5000"),
+		"address":    client("firefox", "198.51.100.7:5000"),
+		"tls":        httptest.NewRequest(http.MethodGet, "/", nil),
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := m.ReadSigned(replay(w, r), "a")
+			require.ErrorIs(t, err, ErrCookie)
+		})
+	}
+
+	t.Run("ipv6", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		require.NoError(t, m.WriteSignedBound(w, client("firefox", "[2001:db8::1]:5000"), "a", "b"))
+		_, err := m.ReadSigned(replay(w, client("firefox", "[2001:db8::2]:5000")), "a")
+		require.NoError(t, err)
+		_, err = m.ReadSigned(replay(w, client("firefox", "[2001:db8:1::1]:5000")), "a")
+		require.ErrorIs(t, err, ErrCookie)
+	})
+
+	t.Run("no binding", func(t *testing.T) {
+		plain := newTestManager(t)
+		require.Error(t, plain.WriteSignedBound(httptest.NewRecorder(), client("firefox", "203.0.113.7:5000"), "a", "b"))
+	})
+
+	_, err = New(WithBinding(Binding{IPv4Prefix: 33}))
+	require.ErrorIs(t, err, ErrInitiation)
+}
@@ -0,0 +1,113 @@
+package cookie
+
+import (
+	"crypto/cipher"
+	"crypto/hmac"
+	"hash"
+	"sync"
+)
+
+// maxCachedKeys bounds each keyCache map; when full, the map is reset.
+// Entries only accumulate through rotation and distinct cookie names.
+const maxCachedKeys = 1024
+
+// keyCache holds per-key state that is expensive to rebuild on every call:
+// AEAD instances, pools of keyed HMACs, and derived subkeys.
+// Keys are cached by content, so a cache may be shared between Managers.
+// A nil keyCache computes everything afresh.
+type keyCache struct {
+	mu      sync.RWMutex
+	aeads   map[string]cipher.AEAD
+	macs    map[string]*sync.Pool
+	derived map[string][]byte
+}
+
+func newKeyCache() *keyCache {
+	return &keyCache{
+		aeads:   map[string]cipher.AEAD{},
+		macs:    map[string]*sync.Pool{},
+		derived: map[string][]byte{},
+	}
+}
+
+// aead returns the AEAD for c keyed with secretKey.
+func (k *keyCache) aead(c Cipher, secretKey []byte) (cipher.AEAD, error) {
+	if k == nil {
+		return c.aead(secretKey)
+	}
+	id := string(byte(c)) + string(secretKey)
+	k.mu.RLock()
+	aead, ok := k.aeads[id]
+	k.mu.RUnlock()
+	if ok {
+		return aead, nil
+	}
+	aead, err := c.aead(secretKey)
+	if err != nil {
+		return nil, err
+	}
+	store(k, k.aeads, id, aead)
+	return aead, nil
+}
+
+// mac returns the HMAC of parts, written in order, reusing pooled hashers.
+func (k *keyCache) mac(h Hash, newHash func() hash.Hash, secretKey []byte, parts ...string) []byte {
+	if k == nil {
+		return macSum(newHash, secretKey, parts...)
+	}
+	pool := k.macPool(h, newHash, secretKey)
+	mac := pool.Get().(hash.Hash)
+	mac.Reset()
+	for _, part := range parts {
+		mac.Write([]byte(part))
+	}
+	sum := mac.Sum(nil)
+	pool.Put(mac)
+	return sum
+}
+
+// macPool returns the pool of HMACs for h keyed with secretKey.
+func (k *keyCache) macPool(h Hash, newHash func() hash.Hash, secretKey []byte) *sync.Pool {
+	// look up through a stack buffer so hits on typical keys do not allocate
+	var buf [1 + 64]byte
+	id := append(buf[:0], byte(h))
+	id = append(id, secretKey...)
+	k.mu.RLock()
+	pool, ok := k.macs[string(id)]
+	k.mu.RUnlock()
+	if !ok {
+		pool = &sync.Pool{New: func() any { return hmac.New(newHash, secretKey) }}
+		store(k, k.macs, string(id), pool)
+	}
+	return pool
+}
+
+// derive returns DeriveKey(master, purpose, name), computing it once.
+func (k *keyCache) derive(master []byte, purpose, name string) ([]byte, error) {
+	if k == nil {
+		return DeriveKey(master, purpose, name)
+	}
+	id := purpose + "\x00" + name + "\x00" + string(master)
+	k.mu.RLock()
+	key, ok := k.derived[id]
+	k.mu.RUnlock()
+	if ok {
+		return key, nil
+	}
+	key, err := DeriveKey(master, purpose, name)
+	if err != nil {
+		return nil, err
+	}
+	store(k, k.derived, id, key)
+	return key, nil
+}
+
+// store adds an entry to one of the cache's maps, resetting it when full.
+func store[V any](k *keyCache, entries map[string]V, id string, v V) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if len(entries) >= maxCachedKeys {
+		clear(entries)
+	}
+	entries[id] = v
+}
@@ -0,0 +1,40 @@
+package cookie
+
+import (
+	"crypto/sha256"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeyCache(t *testing.T) {
+	secretKey, err := NewCookieSecret()
+	require.NoError(t, err)
+
+	for _, k := range []*keyCache{nil, newKeyCache()} {
+		var wg sync.WaitGroup
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				require.Equal(t, macSum(sha256.New, secretKey, "a", "b"), k.mac(SHA256, sha256.New, secretKey, "a", "b"))
+				derived, err := k.derive(secretKey, purposeSign, "a")
+				require.NoError(t, err)
+				expected, err := DeriveKey(secretKey, purposeSign, "a")
+				require.NoError(t, err)
+				require.Equal(t, expected, derived)
+			}()
+		}
+		wg.Wait()
+	}
+
+	k := newKeyCache()
+	first, err := k.aead(AESGCM, secretKey)
+	require.NoError(t, err)
+	second, err := k.aead(AESGCM, secretKey)
+	require.NoError(t, err)
+	require.Same(t, first, second)
+	_, err = k.aead(AESGCM, []byte("short"))
+	require.Error(t, err)
+}
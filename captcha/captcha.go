@@ -0,0 +1,194 @@
+// package captcha marks visitors who solved a CAPTCHA with a short-lived
+// signed cookie, bound to their session and network prefix, so form handlers
+// can skip challenging them again. It does not talk to CAPTCHA providers:
+// verify the provider's response first, then call Mark.
+package captcha
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/grackleclub/cookie/v2"
+)
+
+const (
+	defaultName       = "captcha"
+	defaultTTL        = 10 * time.Minute
+	defaultIPv4Prefix = 24
+	defaultIPv6Prefix = 48
+)
+
+var ErrNotSolved = errors.New("captcha not solved")
+
+// stored marks a solved CAPTCHA, for the session whose ID hashes to Session,
+// until Expires in unix seconds.
+type stored struct {
+	Session string `json:"s"` // hash of the session ID
+	Expires int64  `json:"e"`
+}
+
+// Marker marks and checks visitors who solved a CAPTCHA.
+type Marker struct {
+	cookies    *cookie.Manager
+	name       string
+	ttl        time.Duration
+	ipv4Prefix int
+	ipv6Prefix int
+	sessionID  func(*http.Request) string
+}
+
+// Option configures a Marker.
+type Option func(*Marker) error
+
+// New creates a Marker. The cookie.Manager must hold a secret,
+// which is used to sign the marker cookie.
+func New(cookies *cookie.Manager, opts ...Option) (*Marker, error) {
+	if cookies == nil {
+		return nil, fmt.Errorf("%w: cookie manager is required", cookie.ErrInitiation)
+	}
+	m := &Marker{
+		name:       defaultName,
+		ttl:        defaultTTL,
+		ipv4Prefix: defaultIPv4Prefix,
+		ipv6Prefix: defaultIPv6Prefix,
+		sessionID:  func(*http.Request) string { return "" },
+	}
+	for _, opt := range opts {
+		if err := opt(m); err != nil {
+			return nil, fmt.Errorf("%w: %w", cookie.ErrInitiation, err)
+		}
+	}
+	copts := []cookie.Option{cookie.WithMaxAge(m.ttl)}
+	if m.bound() {
+		copts = append(copts, cookie.WithBinding(cookie.Binding{IPv4Prefix: m.ipv4Prefix, IPv6Prefix: m.ipv6Prefix}))
+	}
+	cookies, err := cookies.With(copts...)
+	if err != nil {
+		return nil, err
+	}
+	m.cookies = cookies
+	return m, nil
+}
+
+// WithName sets the name of the marker cookie.
+func WithName(name string) Option {
+	return func(m *Marker) error {
+		if name == "" {
+			return errors.New("empty captcha cookie name")
+		}
+		m.name = name
+		return nil
+	}
+}
+
+// WithTTL sets how long a solved CAPTCHA is honored. Defaults to 10 minutes.
+func WithTTL(ttl time.Duration) Option {
+	return func(m *Marker) error {
+		if ttl < time.Second {
+			return fmt.Errorf("captcha ttl too short: %s", ttl)
+		}
+		m.ttl = ttl
+		return nil
+	}
+}
+
+// WithIPPrefix binds markers to the leading bits of the client address, as
+// cookie.Binding does. Zero leaves that address family unbound. Defaults to
+// 24 and 48, so clients keep their marker while moving within a network.
+func WithIPPrefix(ipv4, ipv6 int) Option {
+	return func(m *Marker) error {
+		if ipv4 < 0 || ipv4 > 32 || ipv6 < 0 || ipv6 > 128 {
+			return fmt.Errorf("invalid captcha ip prefixes: %d, %d", ipv4, ipv6)
+		}
+		m.ipv4Prefix = ipv4
+		m.ipv6Prefix = ipv6
+		return nil
+	}
+}
+
+// WithSessionID binds markers to the session identified by fn, so a marker
+// earned in one session, or before logging in, is ignored in any other.
+func WithSessionID(fn func(*http.Request) string) Option {
+	return func(m *Marker) error {
+		if fn == nil {
+			return errors.New("session id func is nil")
+		}
+		m.sessionID = fn
+		return nil
+	}
+}
+
+// Mark records that the client of r solved a CAPTCHA.
+func (m *Marker) Mark(w http.ResponseWriter, r *http.Request) error {
+	value, err := json.Marshal(stored{
+		Session: sessionHash(m.sessionID(r)),
+		Expires: m.cookies.Now().Add(m.ttl).Unix(),
+	})
+	if err != nil {
+		return err
+	}
+	if m.bound() {
+		return m.cookies.WriteSignedBound(w, r, m.name, string(value))
+	}
+	return m.cookies.WriteSigned(w, m.name, string(value))
+}
+
+// Check returns nil if the client of r solved a CAPTCHA within the TTL, in
+// the same session and network prefix, or an error wrapping ErrNotSolved.
+func (m *Marker) Check(r *http.Request) error {
+	value, err := m.cookies.ReadSigned(r, m.name)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrNotSolved, err)
+	}
+	var payload stored
+	if err := json.Unmarshal([]byte(value), &payload); err != nil {
+		return fmt.Errorf("%w: %w", ErrNotSolved, err)
+	}
+	if !m.cookies.Now().Before(time.Unix(payload.Expires, 0)) {
+		return fmt.Errorf("%w: %w", ErrNotSolved, cookie.ErrExpired)
+	}
+	if subtle.ConstantTimeCompare([]byte(payload.Session), []byte(sessionHash(m.sessionID(r)))) != 1 {
+		return fmt.Errorf("%w: marked in another session", ErrNotSolved)
+	}
+	return nil
+}
+
+// Solved reports whether Check passes.
+func (m *Marker) Solved(r *http.Request) bool {
+	return m.Check(r) == nil
+}
+
+// Clear removes the marker, such as once a form protected by it is submitted.
+func (m *Marker) Clear(w http.ResponseWriter) {
+	m.cookies.Delete(w, m.name)
+}
+
+// Middleware calls next for clients that solved a CAPTCHA,
+// and challenge, such as a page showing one, for others.
+func (m *Marker) Middleware(challenge http.Handler) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !m.Solved(r) {
+				challenge.ServeHTTP(w, r)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func (m *Marker) bound() bool {
+	return m.ipv4Prefix > 0 || m.ipv6Prefix > 0
+}
+
+// sessionHash keeps session IDs out of the readable signed cookie.
+func sessionHash(sessionID string) string {
+	sum := sha256.Sum256([]byte(sessionID))
+	return base64.RawURLEncoding.EncodeToString(sum[:16])
+}
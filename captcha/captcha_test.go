@@ -0,0 +1,111 @@
+package captcha
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/grackleclub/cookie/v2"
+	"github.com/grackleclub/cookie/v2/cookietest"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestMarker(t *testing.T, opts ...Option) *Marker {
+	t.Helper()
+	secretKey, err := cookie.NewCookieSecret()
+	require.NoError(t, err)
+	cookies, err := cookie.New(cookie.WithSecret(secretKey))
+	require.NoError(t, err)
+	m, err := New(cookies, opts...)
+	require.NoError(t, err)
+	return m
+}
+
+// newRequest returns a request from remoteAddr.
+func newRequest(remoteAddr string) *http.Request {
+	r := httptest.NewRequest(http.MethodPost, "/", nil)
+	r.RemoteAddr = remoteAddr
+	return r
+}
+
+// requestFrom returns the client's next request, from remoteAddr.
+func requestFrom(t *testing.T, w *httptest.ResponseRecorder, remoteAddr string) *http.Request {
+	r := cookietest.RequestFrom(t, w)
+	r.RemoteAddr = remoteAddr
+	return r
+}
+
+func TestMark(t *testing.T) {
+	m := newTestMarker(t)
+	require.ErrorIs(t, m.Check(httptest.NewRequest(http.MethodPost, "/", nil)), ErrNotSolved)
+
+	w := httptest.NewRecorder()
+	require.NoError(t, m.Mark(w, newRequest("203.0.113.7:4000")))
+	require.Equal(t, 600, w.Result().Cookies()[0].MaxAge)
+
+	// the same network passes; another does not
+	require.True(t, m.Solved(requestFrom(t, w, "203.0.113.99:5000")))
+	require.ErrorIs(t, m.Check(requestFrom(t, w, "198.51.100.7:4000")), ErrNotSolved)
+
+	// markers signed with another secret are rejected
+	require.False(t, newTestMarker(t).Solved(requestFrom(t, w, "203.0.113.7:4000")))
+
+	w = httptest.NewRecorder()
+	m.Clear(w)
+	require.False(t, m.Solved(requestFrom(t, w, "203.0.113.7:4000")))
+}
+
+func TestSession(t *testing.T) {
+	sessionID := "session-1"
+	m := newTestMarker(t,
+		WithIPPrefix(0, 0),
+		WithSessionID(func(*http.Request) string { return sessionID }),
+	)
+
+	w := httptest.NewRecorder()
+	require.NoError(t, m.Mark(w, newRequest("203.0.113.7:4000")))
+	require.NotContains(t, w.Result().Cookies()[0].Value, "session-1")
+	require.True(t, m.Solved(requestFrom(t, w, "198.51.100.7:4000")))
+
+	sessionID = "session-2"
+	require.ErrorIs(t, m.Check(requestFrom(t, w, "203.0.113.7:4000")), ErrNotSolved)
+
+	cookies, err := cookie.New()
+	require.NoError(t, err)
+	_, err = New(cookies, WithIPPrefix(33, 48))
+	require.ErrorIs(t, err, cookie.ErrInitiation)
+}
+
+func TestMiddleware(t *testing.T) {
+	m := newTestMarker(t)
+	handler := m.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/", nil))
+	require.Equal(t, http.StatusForbidden, w.Code)
+
+	marked := httptest.NewRecorder()
+	require.NoError(t, m.Mark(marked, httptest.NewRequest(http.MethodPost, "/", nil)))
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, requestFrom(t, marked, "192.0.2.1:1234"))
+	require.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestExpiry(t *testing.T) {
+	secretKey, err := cookie.NewCookieSecret()
+	require.NoError(t, err)
+	clock := cookietest.NewClock(time.Unix(1_700_000_000, 0))
+	cookies, err := cookie.New(cookie.WithSecret(secretKey), cookie.WithClock(clock))
+	require.NoError(t, err)
+	m, err := New(cookies)
+	require.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	require.NoError(t, m.Mark(w, newRequest("203.0.113.7:4000")))
+	require.True(t, m.Solved(requestFrom(t, w, "203.0.113.7:4000")))
+	clock.Advance(defaultTTL)
+	require.ErrorIs(t, m.Check(requestFrom(t, w, "203.0.113.7:4000")), cookie.ErrExpired)
+}
@@ -0,0 +1,307 @@
+// package cart keeps a shopping cart in a signed cookie, in a compact binary
+// encoding, and moves it to a server-side store behind a pointer cookie once
+// it outgrows the cookie size limit, so small carts need no storage at all.
+package cart
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/grackleclub/cookie/v2"
+	"github.com/grackleclub/cookie/v2/internal/token"
+	"github.com/grackleclub/cookie/v2/session"
+)
+
+const (
+	defaultName   = "cart"
+	defaultTTL    = 30 * 24 * time.Hour
+	pointerLength = 24
+
+	encodingV1 = 1
+
+	kindInline  = 'c' // cookie value holds the encoded cart
+	kindPointer = 'p' // cookie value holds the store key of the encoded cart
+)
+
+var ErrCart = errors.New("cart invalid")
+
+// Item is a line of a cart.
+type Item struct {
+	ID      string
+	Qty     int
+	Variant string // such as a size or color; may be empty
+}
+
+// Encode returns the compact binary encoding of items.
+func Encode(items []Item) ([]byte, error) {
+	b := []byte{encodingV1}
+	b = binary.AppendUvarint(b, uint64(len(items)))
+	for _, item := range items {
+		if item.ID == "" || item.Qty <= 0 {
+			return nil, fmt.Errorf("%w: item '%s' needs an ID and a positive quantity", ErrCart, item.ID)
+		}
+		b = appendString(b, item.ID)
+		b = binary.AppendUvarint(b, uint64(item.Qty))
+		b = appendString(b, item.Variant)
+	}
+	return b, nil
+}
+
+// Decode returns the items encoded by Encode.
+func Decode(data []byte) ([]Item, error) {
+	if len(data) == 0 || data[0] != encodingV1 {
+		return nil, fmt.Errorf("%w: unknown encoding", ErrCart)
+	}
+	d := decoder{data: data[1:]}
+	n := d.uvarint()
+	// every item takes at least 3 bytes, so a bad count cannot allocate much
+	if n > uint64(len(d.data)/3) {
+		return nil, fmt.Errorf("%w: item count %d too large", ErrCart, n)
+	}
+	items := make([]Item, 0, n)
+	for range n {
+		item := Item{ID: d.string(), Qty: int(d.uvarint()), Variant: d.string()}
+		if d.err == nil && (item.ID == "" || item.Qty <= 0) {
+			d.err = fmt.Errorf("%w: empty item", ErrCart)
+		}
+		items = append(items, item)
+	}
+	if d.err == nil && len(d.data) > 0 {
+		d.err = fmt.Errorf("%w: trailing data", ErrCart)
+	}
+	if d.err != nil {
+		return nil, d.err
+	}
+	return items, nil
+}
+
+func appendString(b []byte, s string) []byte {
+	b = binary.AppendUvarint(b, uint64(len(s)))
+	return append(b, s...)
+}
+
+// decoder reads encoded items, keeping the first error.
+type decoder struct {
+	data []byte
+	err  error
+}
+
+func (d *decoder) uvarint() uint64 {
+	if d.err != nil {
+		return 0
+	}
+	v, n := binary.Uvarint(d.data)
+	if n <= 0 || v > 1<<31 {
+		d.err = fmt.Errorf("%w: malformed number", ErrCart)
+		return 0
+	}
+	d.data = d.data[n:]
+	return v
+}
+
+func (d *decoder) string() string {
+	n := d.uvarint()
+	if d.err != nil {
+		return ""
+	}
+	if n > uint64(len(d.data)) {
+		d.err = fmt.Errorf("%w: truncated", ErrCart)
+		return ""
+	}
+	s := string(d.data[:n])
+	d.data = d.data[n:]
+	return s
+}
+
+// Carts loads and saves carts.
+type Carts struct {
+	cookies   *cookie.Manager
+	store     session.Store // holds carts too large for a cookie; nil refuses them
+	name      string
+	ttl       time.Duration
+	maxInline int // largest cookie kept inline; zero for as large as the Manager allows
+}
+
+// Option configures Carts.
+type Option func(*Carts) error
+
+// New creates Carts that move carts too large for a cookie to store, any
+// session.Store, or refuse them if store is nil. The cookie.Manager must hold
+// a secret, which is used to sign the cart cookie.
+func New(cookies *cookie.Manager, store session.Store, opts ...Option) (*Carts, error) {
+	if cookies == nil {
+		return nil, fmt.Errorf("%w: cookie manager is required", cookie.ErrInitiation)
+	}
+	c := &Carts{
+		cookies: cookies,
+		store:   store,
+		name:    defaultName,
+		ttl:     defaultTTL,
+	}
+	for _, opt := range opts {
+		if err := opt(c); err != nil {
+			return nil, fmt.Errorf("%w: %w", cookie.ErrInitiation, err)
+		}
+	}
+	cookies, err := cookies.With(cookie.WithMaxAge(c.ttl))
+	if err != nil {
+		return nil, err
+	}
+	c.cookies = cookies
+	if setter, ok := store.(cookie.ClockSetter); ok {
+		setter.SetClock(cookies)
+	}
+	return c, nil
+}
+
+// WithName sets the name of the cart cookie.
+func WithName(name string) Option {
+	return func(c *Carts) error {
+		if name == "" {
+			return errors.New("empty cart cookie name")
+		}
+		c.name = name
+		return nil
+	}
+}
+
+// WithTTL sets how long a cart is kept after it is last saved. Defaults to 30 days.
+func WithTTL(ttl time.Duration) Option {
+	return func(c *Carts) error {
+		if ttl < time.Minute {
+			return fmt.Errorf("cart ttl too short: %s", ttl)
+		}
+		c.ttl = ttl
+		return nil
+	}
+}
+
+// WithMaxInline moves carts to the store once their cookie would exceed size
+// bytes, below the Manager's limit, leaving room for other cookies.
+func WithMaxInline(size int) Option {
+	return func(c *Carts) error {
+		if size <= 0 {
+			return fmt.Errorf("invalid max inline cart size: %d", size)
+		}
+		c.maxInline = size
+		return nil
+	}
+}
+
+// Load returns the request's cart, which is empty without a cart cookie
+// or once a stored cart has expired.
+func (c *Carts) Load(r *http.Request) ([]Item, error) {
+	kind, value, err := c.read(r)
+	if errors.Is(err, cookie.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if kind == kindInline {
+		return Decode([]byte(value))
+	}
+	data, err := c.get(r.Context(), value)
+	if errors.Is(err, session.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return Decode(data)
+}
+
+// Save replaces the request's cart with items, in the cookie if it fits,
+// otherwise in the store.
+func (c *Carts) Save(w http.ResponseWriter, r *http.Request, items []Item) error {
+	data, err := Encode(items)
+	if err != nil {
+		return err
+	}
+	kind, pointer, err := c.read(r)
+	if err != nil {
+		kind = 0
+	}
+
+	inline := string(kindInline) + string(data)
+	fits := true
+	if c.maxInline > 0 {
+		size, err := c.cookies.EstimateSize(c.name, inline, cookie.Signed)
+		if err != nil {
+			return err
+		}
+		fits = size <= c.maxInline
+	}
+	if fits {
+		err = c.cookies.WriteSigned(w, c.name, inline)
+		if err == nil {
+			if kind == kindPointer {
+				return c.destroy(r.Context(), pointer)
+			}
+			return nil
+		}
+		if !errors.Is(err, cookie.ErrTooLong) {
+			return err
+		}
+	}
+
+	if c.store == nil {
+		return fmt.Errorf("%w: %w: cart too large for a cookie", ErrCart, cookie.ErrTooLong)
+	}
+	if kind != kindPointer {
+		if pointer, err = token.Random(pointerLength); err != nil {
+			return fmt.Errorf("%w: %w", ErrCart, err)
+		}
+	}
+	if err := c.store.Save(r.Context(), pointer, data, c.cookies.Now().Add(c.ttl)); err != nil {
+		return fmt.Errorf("%w: unable to save cart: %w", ErrCart, err)
+	}
+	return c.cookies.WriteSigned(w, c.name, string(kindPointer)+pointer)
+}
+
+// Clear empties the request's cart, removing it from the store if it is there.
+func (c *Carts) Clear(w http.ResponseWriter, r *http.Request) error {
+	c.cookies.Delete(w, c.name)
+	kind, pointer, err := c.read(r)
+	if err != nil || kind != kindPointer {
+		return nil
+	}
+	return c.destroy(r.Context(), pointer)
+}
+
+// read returns the kind and value of the request's cart cookie.
+func (c *Carts) read(r *http.Request) (byte, string, error) {
+	value, err := c.cookies.ReadSigned(r, c.name)
+	if err != nil {
+		return 0, "", err
+	}
+	if value == "" || (value[0] != kindInline && value[0] != kindPointer) {
+		return 0, "", fmt.Errorf("%w: unknown cart cookie", ErrCart)
+	}
+	return value[0], value[1:], nil
+}
+
+func (c *Carts) get(ctx context.Context, pointer string) ([]byte, error) {
+	if c.store == nil {
+		return nil, session.ErrNotFound
+	}
+	data, err := c.store.Get(ctx, pointer)
+	if err != nil && !errors.Is(err, session.ErrNotFound) {
+		return nil, fmt.Errorf("%w: unable to get cart: %w", ErrCart, err)
+	}
+	return data, err
+}
+
+func (c *Carts) destroy(ctx context.Context, pointer string) error {
+	if c.store == nil {
+		return nil
+	}
+	if err := c.store.Destroy(ctx, pointer); err != nil {
+		return fmt.Errorf("%w: unable to destroy cart: %w", ErrCart, err)
+	}
+	return nil
+}
@@ -0,0 +1,167 @@
+package cart
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/grackleclub/cookie/v2"
+	"github.com/grackleclub/cookie/v2/cookietest"
+	"github.com/grackleclub/cookie/v2/session"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestCarts(t *testing.T, store session.Store, opts ...Option) *Carts {
+	t.Helper()
+	secretKey, err := cookie.NewCookieSecret()
+	require.NoError(t, err)
+	cookies, err := cookie.New(cookie.WithSecret(secretKey))
+	require.NoError(t, err)
+	c, err := New(cookies, store, opts...)
+	require.NoError(t, err)
+	return c
+}
+
+// manyItems returns n items with long IDs.
+func manyItems(n int) []Item {
+	items := make([]Item, n)
+	for i := range items {
+		items[i] = Item{ID: fmt.Sprintf("sku-%s-%d", strings.Repeat("x", 40), i), Qty: i + 1}
+	}
+	return items
+}
+
+func TestCodec(t *testing.T) {
+	items := []Item{{ID: "sku-1", Qty: 2}, {ID: "sku-2", Qty: 300, Variant: "blue/XL"}}
+	data, err := Encode(items)
+	require.NoError(t, err)
+	require.Len(t, data, 1+1+(1+5+1+1)+(1+5+2+1+7))
+	decoded, err := Decode(data)
+	require.NoError(t, err)
+	require.Equal(t, items, decoded)
+
+	empty, err := Encode(nil)
+	require.NoError(t, err)
+	decoded, err = Decode(empty)
+	require.NoError(t, err)
+	require.Empty(t, decoded)
+
+	_, err = Encode([]Item{{ID: "sku-1"}})
+	require.ErrorIs(t, err, ErrCart)
+	_, err = Encode([]Item{{Qty: 1}})
+	require.ErrorIs(t, err, ErrCart)
+
+	for _, bad := range [][]byte{nil, {2}, data[:len(data)-1], append(data, 0), {1, 100, 1, 'a', 1, 0}} {
+		_, err = Decode(bad)
+		require.ErrorIs(t, err, ErrCart, bad)
+	}
+}
+
+func TestInline(t *testing.T) {
+	store := session.NewMemoryStore(0)
+	c := newTestCarts(t, store)
+
+	items, err := c.Load(httptest.NewRequest(http.MethodGet, "/", nil))
+	require.NoError(t, err)
+	require.Empty(t, items)
+
+	w := httptest.NewRecorder()
+	want := []Item{{ID: "sku-1", Qty: 1, Variant: "red"}}
+	require.NoError(t, c.Save(w, httptest.NewRequest(http.MethodGet, "/", nil), want))
+	require.Equal(t, 0, store.Len())
+	items, err = c.Load(cookietest.RequestFrom(t, w))
+	require.NoError(t, err)
+	require.Equal(t, want, items)
+
+	// a tampered cookie is rejected
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.AddCookie(&http.Cookie{Name: defaultName, Value: "Y2FydA"})
+	_, err = c.Load(r)
+	require.Error(t, err)
+}
+
+func TestOverflow(t *testing.T) {
+	ctx := context.Background()
+	store := session.NewMemoryStore(0)
+	c := newTestCarts(t, store)
+
+	// too large for a cookie, so kept in the store
+	w := httptest.NewRecorder()
+	big := manyItems(100)
+	require.NoError(t, c.Save(w, httptest.NewRequest(http.MethodGet, "/", nil), big))
+	require.Equal(t, 1, store.Len())
+	r := cookietest.RequestFrom(t, w)
+	items, err := c.Load(r)
+	require.NoError(t, err)
+	require.Equal(t, big, items)
+
+	// growing the stored cart keeps its pointer
+	w = httptest.NewRecorder()
+	require.NoError(t, c.Save(w, r, manyItems(101)))
+	require.Equal(t, 1, store.Len())
+	r = cookietest.RequestFrom(t, w)
+	items, err = c.Load(r)
+	require.NoError(t, err)
+	require.Len(t, items, 101)
+
+	// shrinking it moves it back into the cookie
+	_, pointer, err := c.read(r)
+	require.NoError(t, err)
+	w = httptest.NewRecorder()
+	require.NoError(t, c.Save(w, r, manyItems(1)))
+	_, err = store.Get(ctx, pointer)
+	require.ErrorIs(t, err, session.ErrNotFound)
+	items, err = c.Load(cookietest.RequestFrom(t, w))
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+
+	// without a store, large carts are refused
+	noStore := newTestCarts(t, nil)
+	err = noStore.Save(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil), big)
+	require.ErrorIs(t, err, cookie.ErrTooLong)
+}
+
+func TestMaxInline(t *testing.T) {
+	store := session.NewMemoryStore(0)
+	c := newTestCarts(t, store, WithMaxInline(256))
+
+	w := httptest.NewRecorder()
+	require.NoError(t, c.Save(w, httptest.NewRequest(http.MethodGet, "/", nil), manyItems(1)))
+	require.Equal(t, 0, store.Len())
+	w = httptest.NewRecorder()
+	require.NoError(t, c.Save(w, httptest.NewRequest(http.MethodGet, "/", nil), manyItems(5)))
+	require.Equal(t, 1, store.Len())
+	require.Less(t, len(w.Result().Cookies()[0].Value), 256)
+
+	// an expired stored cart loads empty
+	r := cookietest.RequestFrom(t, w)
+	_, pointer, err := c.read(r)
+	require.NoError(t, err)
+	require.NoError(t, store.Destroy(context.Background(), pointer))
+	items, err := c.Load(r)
+	require.NoError(t, err)
+	require.Empty(t, items)
+
+	cookies, err := cookie.New()
+	require.NoError(t, err)
+	_, err = New(cookies, store, WithMaxInline(0))
+	require.ErrorIs(t, err, cookie.ErrInitiation)
+}
+
+func TestClear(t *testing.T) {
+	store := session.NewMemoryStore(0)
+	c := newTestCarts(t, store)
+
+	w := httptest.NewRecorder()
+	require.NoError(t, c.Save(w, httptest.NewRequest(http.MethodGet, "/", nil), manyItems(100)))
+	require.Equal(t, 1, store.Len())
+
+	r := cookietest.RequestFrom(t, w)
+	w = httptest.NewRecorder()
+	require.NoError(t, c.Clear(w, r))
+	require.Equal(t, 0, store.Len())
+	require.Equal(t, -1, w.Result().Cookies()[0].MaxAge)
+}
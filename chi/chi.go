@@ -0,0 +1,142 @@
+// package chi provides middleware for chi routers that loads cookies and
+// sessions, protects against CSRF, and enforces secure cookie attributes.
+// Every constructor returns a standard func(http.Handler) http.Handler,
+// so it composes in chi.Router.Use chains and any other net/http stack.
+package chi
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/grackleclub/cookie/v2"
+	"github.com/grackleclub/cookie/v2/csrf"
+	"github.com/grackleclub/cookie/v2/session"
+)
+
+type sessionKey struct{}
+
+// loadedSession is the session stored in the request context by Sessions.
+type loadedSession struct {
+	sessions *session.Manager
+	session  *session.Session
+}
+
+// Middleware loads and refreshes the Manager's managed cookies, as cookie.Middleware.
+func Middleware(m *cookie.Manager) func(http.Handler) http.Handler {
+	return cookie.Middleware(m)
+}
+
+// Sessions loads the request's session into the request context for Session
+// and SaveSession, responding 500 Internal Server Error if the store fails.
+// The session is not saved automatically, since headers may already be sent
+// when the handler returns.
+func Sessions(sessions *session.Manager) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			s, err := sessions.Load(r)
+			if err != nil {
+				http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+				return
+			}
+			ctx := context.WithValue(r.Context(), sessionKey{}, loadedSession{sessions: sessions, session: s})
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// Session returns the session loaded by Sessions, or nil outside of it.
+func Session(r *http.Request) *session.Session {
+	loaded, _ := r.Context().Value(sessionKey{}).(loadedSession)
+	return loaded.session
+}
+
+// SaveSession saves the session loaded by Sessions and writes its cookie.
+// Call it before writing the response body.
+func SaveSession(w http.ResponseWriter, r *http.Request) error {
+	loaded, ok := r.Context().Value(sessionKey{}).(loadedSession)
+	if !ok {
+		return fmt.Errorf("%w: no session loaded", session.ErrSession)
+	}
+	return loaded.sessions.Save(w, r, loaded.session)
+}
+
+// Protect rejects requests that fail CSRF checks, as csrf.Protect.
+// The request's token is available from csrf.Token.
+func Protect(protect *csrf.CSRF) func(http.Handler) http.Handler {
+	return protect.Protect
+}
+
+// SecureDefaults upgrades every cookie the handler sets to the attributes of
+// New with opts: Secure, HttpOnly, and SameSite=Lax unless opts say otherwise.
+// Cookies without a SameSite or Path attribute get the default. Cookies set
+// by handlers outside this package are covered too, so a forgotten attribute
+// cannot ship.
+func SecureDefaults(opts ...cookie.Option) (func(http.Handler) http.Handler, error) {
+	m, err := cookie.New(opts...)
+	if err != nil {
+		return nil, err
+	}
+	defaults := m.Cookie("", "")
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ew := &enforcer{ResponseWriter: w, defaults: defaults}
+			next.ServeHTTP(ew, r)
+			ew.enforce()
+		})
+	}, nil
+}
+
+// enforcer rewrites Set-Cookie headers before the response headers are sent.
+type enforcer struct {
+	http.ResponseWriter
+	defaults http.Cookie
+	done     bool
+}
+
+func (w *enforcer) WriteHeader(code int) {
+	w.enforce()
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *enforcer) Write(b []byte) (int, error) {
+	w.enforce()
+	return w.ResponseWriter.Write(b)
+}
+
+// Flush applies the defaults before flushing buffered data to the client.
+func (w *enforcer) Flush() {
+	w.enforce()
+	http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (w *enforcer) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// enforce applies the defaults to each Set-Cookie header, once.
+func (w *enforcer) enforce() {
+	if w.done {
+		return
+	}
+	w.done = true
+	header := w.Header()
+	for i, line := range header["Set-Cookie"] {
+		c, err := http.ParseSetCookie(line)
+		if err != nil {
+			continue
+		}
+		c.Secure = c.Secure || w.defaults.Secure
+		c.HttpOnly = c.HttpOnly || w.defaults.HttpOnly
+		if c.SameSite == 0 { // no SameSite attribute
+			c.SameSite = w.defaults.SameSite
+		}
+		if c.Path == "" {
+			c.Path = w.defaults.Path
+		}
+		if enforced := c.String(); enforced != "" {
+			header["Set-Cookie"][i] = enforced
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package chi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	gochi "github.com/go-chi/chi/v5"
+	"github.com/grackleclub/cookie/v2"
+	"github.com/grackleclub/cookie/v2/cookietest"
+	"github.com/grackleclub/cookie/v2/csrf"
+	"github.com/grackleclub/cookie/v2/session"
+	"github.com/stretchr/testify/require"
+)
+
+func serve(h http.Handler, method, target string, cookies ...*http.Cookie) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, target, nil)
+	for _, c := range cookies {
+		r.AddCookie(c)
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w
+}
+
+func TestMiddleware(t *testing.T) {
+	m := cookietest.NewManager(t, cookie.WithManaged("theme", cookie.Signed))
+	r := gochi.NewRouter()
+	r.Use(Middleware(m))
+	r.Get("/set", func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, m.WriteSigned(w, "theme", "dark"))
+	})
+	r.Get("/get", func(w http.ResponseWriter, r *http.Request) {
+		theme, err := cookie.FromContext(r.Context(), "theme")
+		require.NoError(t, err)
+		w.Write([]byte(theme))
+	})
+
+	w := serve(r, http.MethodGet, "/set")
+	w = serve(r, http.MethodGet, "/get", w.Result().Cookies()...)
+	require.Equal(t, "dark", w.Body.String())
+}
+
+func TestSessions(t *testing.T) {
+	sessions, err := session.New(cookietest.NewManager(t), session.NewMemoryStore(time.Minute))
+	require.NoError(t, err)
+
+	r := gochi.NewRouter()
+	r.Use(Sessions(sessions))
+	r.Get("/", func(w http.ResponseWriter, r *http.Request) {
+		s := Session(r)
+		visits, _ := session.Get[int](s, "visits")
+		require.NoError(t, s.Set("visits", visits+1))
+		require.NoError(t, SaveSession(w, r))
+		w.Write([]byte(strconv.Itoa(visits + 1)))
+	})
+
+	w := serve(r, http.MethodGet, "/")
+	require.Equal(t, "1", w.Body.String())
+	w = serve(r, http.MethodGet, "/", w.Result().Cookies()...)
+	require.Equal(t, "2", w.Body.String())
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	require.Nil(t, Session(req))
+	require.ErrorIs(t, SaveSession(httptest.NewRecorder(), req), session.ErrSession)
+}
+
+func TestProtect(t *testing.T) {
+	secretKey, err := cookie.NewCookieSecret()
+	require.NoError(t, err)
+	protect, err := csrf.New(cookietest.NewManager(t), secretKey)
+	require.NoError(t, err)
+
+	r := gochi.NewRouter()
+	r.Use(Protect(protect))
+	handler := func(w http.ResponseWriter, r *http.Request) { w.Write([]byte(csrf.Token(r))) }
+	r.Get("/", handler)
+	r.Post("/", handler)
+
+	w := serve(r, http.MethodGet, "/")
+	require.Equal(t, http.StatusOK, w.Code)
+	require.NotEmpty(t, w.Body.String())
+
+	w = serve(r, http.MethodPost, "/", w.Result().Cookies()...)
+	require.Equal(t, http.StatusForbidden, w.Code)
+}
+
+func TestSecureDefaults(t *testing.T) {
+	_, err := SecureDefaults(cookie.WithSecret(nil))
+	require.ErrorIs(t, err, cookie.ErrInitiation)
+
+	secure, err := SecureDefaults()
+	require.NoError(t, err)
+
+	r := gochi.NewRouter()
+	r.Use(secure)
+	r.Get("/", func(w http.ResponseWriter, r *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: "plain", Value: "a"})
+		http.SetCookie(w, &http.Cookie{Name: "strict", Value: "b", Path: "/app", SameSite: http.SameSiteStrictMode})
+		w.Write([]byte("ok"))
+	})
+	r.Get("/empty", func(w http.ResponseWriter, r *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: "late", Value: "c"})
+	})
+
+	cookies := serve(r, http.MethodGet, "/").Result().Cookies()
+	require.Len(t, cookies, 2)
+	require.True(t, cookies[0].Secure)
+	require.True(t, cookies[0].HttpOnly)
+	require.Equal(t, http.SameSiteLaxMode, cookies[0].SameSite)
+	require.Equal(t, "/", cookies[0].Path)
+	require.Equal(t, http.SameSiteStrictMode, cookies[1].SameSite)
+	require.Equal(t, "/app", cookies[1].Path)
+
+	cookies = serve(r, http.MethodGet, "/empty").Result().Cookies()
+	require.Len(t, cookies, 1)
+	require.True(t, cookies[0].Secure)
+}
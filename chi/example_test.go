@@ -0,0 +1,77 @@
+package chi_test
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	gochi "github.com/go-chi/chi/v5"
+	"github.com/grackleclub/cookie/v2"
+	cookiechi "github.com/grackleclub/cookie/v2/chi"
+	"github.com/grackleclub/cookie/v2/csrf"
+	"github.com/grackleclub/cookie/v2/session"
+)
+
+func Example() {
+	secretKey, err := cookie.NewCookieSecret()
+	if err != nil {
+		log.Fatal(err)
+	}
+	cookies, err := cookie.New(cookie.WithSecret(secretKey))
+	if err != nil {
+		log.Fatal(err)
+	}
+	sessions, err := session.New(cookies, session.NewMemoryStore(time.Minute))
+	if err != nil {
+		log.Fatal(err)
+	}
+	protect, err := csrf.New(cookies, secretKey)
+	if err != nil {
+		log.Fatal(err)
+	}
+	secure, err := cookiechi.SecureDefaults()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	r := gochi.NewRouter()
+	r.Use(secure, cookiechi.Middleware(cookies), cookiechi.Sessions(sessions), cookiechi.Protect(protect))
+	r.Get("/", func(w http.ResponseWriter, r *http.Request) {
+		s := cookiechi.Session(r)
+		visits, _ := session.Get[int](s, "visits")
+		if err := s.Set("visits", visits+1); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if err := cookiechi.SaveSession(w, r); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		fmt.Fprintf(w, "visits: %d", visits+1)
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	fmt.Println(w.Body.String())
+	// Output: visits: 1
+}
+
+func ExampleSecureDefaults() {
+	secure, err := cookiechi.SecureDefaults(cookie.WithSameSite(http.SameSiteStrictMode))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	r := gochi.NewRouter()
+	r.Use(secure)
+	r.Get("/", func(w http.ResponseWriter, r *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: "theme", Value: "dark"})
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	fmt.Println(w.Header().Get("Set-Cookie"))
+	// Output: theme=dark; Path=/; HttpOnly; Secure; SameSite=Strict
+}
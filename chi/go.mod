@@ -0,0 +1,22 @@
+module github.com/grackleclub/cookie/v2/chi
+
+go 1.23.0
+
+require (
+	github.com/go-chi/chi/v5 v5.1.0
+	github.com/grackleclub/cookie/v2 v2.0.0-00010101000000-000000000000
+	github.com/stretchr/testify v1.9.0
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/google/uuid v1.6.0 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	github.com/vmihailenco/msgpack/v5 v5.4.1 // indirect
+	github.com/vmihailenco/tagparser/v2 v2.0.0 // indirect
+	golang.org/x/crypto v0.38.0 // indirect
+	golang.org/x/sys v0.33.0 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
+
+replace github.com/grackleclub/cookie/v2 => ..
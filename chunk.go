@@ -0,0 +1,136 @@
+package cookie
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// maxChunks bounds how many cookies a single value may span;
+// browsers commonly cap a domain at around 50 cookies.
+const maxChunks = 32
+
+// WithChunking lets the Manager split values too large for a single cookie
+// across several cookies named 'name.0', 'name.1', and so on, reassembling them
+// on read. Values larger than limit bytes are refused.
+//
+// Signatures and encryption cover the whole value, so a missing, reordered,
+// or tampered chunk fails verification on read.
+func WithChunking(limit int) Option {
+	return func(m *Manager) error {
+		if limit <= 0 {
+			return fmt.Errorf("invalid chunk limit: %d", limit)
+		}
+		m.chunkLimit = limit
+		return nil
+	}
+}
+
+// write sets the cookie once it meets the Manager's Policy, splitting it
+// into chunks when it is too large for one cookie and chunking is enabled.
+// Values are base64 encoded once, without padding; Read accepts values
+// written with or without it. Within Track, a cookie the client already
+// holds unchanged is not sent again.
+func (m *Manager) write(w http.ResponseWriter, cookie http.Cookie) error {
+	if err := m.checkPolicy(cookie); err != nil {
+		return err
+	}
+	var err error
+	if m.chunkLimit == 0 || encodedLen(cookie) <= m.maxSize {
+		if unchanged(w, cookie) {
+			return nil
+		}
+		err = write(w, cookie, m.maxSize, base64.RawURLEncoding)
+	} else {
+		err = m.writeChunks(w, cookie)
+	}
+	if err == nil {
+		track(w, cookie)
+	}
+	return err
+}
+
+// read reads a cookie by its unprefixed name, reassembling it from chunks
+// when no single cookie by that name is present and chunking is enabled.
+func (m *Manager) read(r *http.Request, name string) (string, error) {
+	name = m.prefix + name
+	if err := m.validatePrefixRequest(r, name); err != nil {
+		return "", err
+	}
+	value, err := Read(r, name)
+	if err == nil || m.chunkLimit == 0 {
+		return value, err
+	}
+	count, countErr := Read(r, chunkName(name, 0))
+	if countErr != nil {
+		return "", err
+	}
+	n, countErr := strconv.Atoi(count)
+	if countErr != nil || n < 1 || n > maxChunks {
+		return "", fmt.Errorf("%w: invalid chunk count for '%s': %s", ErrCookie, name, redact(count))
+	}
+	var b strings.Builder
+	for i := 1; i <= n; i++ {
+		chunk, err := Read(r, chunkName(name, i))
+		if err != nil {
+			return "", fmt.Errorf("%w: chunk %d of %d: %w", ErrCookie, i, n, err)
+		}
+		b.WriteString(chunk)
+		if b.Len() > m.chunkLimit {
+			return "", fmt.Errorf("%w: '%s' exceeds chunk limit of %d bytes", ErrCookie, name, m.chunkLimit)
+		}
+	}
+	return b.String(), nil
+}
+
+// writeChunks writes the chunk count to 'name.0' and the value across 'name.1'
+// through 'name.N', expiring any unchunked cookie left by an earlier write.
+func (m *Manager) writeChunks(w http.ResponseWriter, cookie http.Cookie) error {
+	if len(cookie.Value) > m.chunkLimit {
+		return fmt.Errorf("%w: '%s' exceeds chunk limit of %d bytes", ErrCookie, cookie.Name, m.chunkLimit)
+	}
+	overhead := cookie
+	overhead.Name = chunkName(cookie.Name, maxChunks)
+	overhead.Value = ""
+	// base64 expands every 3 bytes to 4
+	size := (m.maxSize - len(overhead.String())) / 4 * 3
+	if size <= 0 {
+		return fmt.Errorf("%w: max size %d too small for chunks of '%s'", ErrCookie, m.maxSize, cookie.Name)
+	}
+	var chunks []string
+	for value := cookie.Value; len(value) > 0; {
+		n := min(size, len(value))
+		chunks = append(chunks, value[:n])
+		value = value[n:]
+	}
+	if len(chunks) > maxChunks {
+		return fmt.Errorf("%w: %w: '%s' needs %d chunks, more than %d", ErrCookie, ErrTooLong, cookie.Name, len(chunks), maxChunks)
+	}
+
+	count := cookie
+	count.Name = chunkName(cookie.Name, 0)
+	count.Value = strconv.Itoa(len(chunks))
+	errs := []error{write(w, count, m.maxSize, base64.RawURLEncoding)}
+	for i, value := range chunks {
+		chunk := cookie
+		chunk.Name = chunkName(cookie.Name, i+1)
+		chunk.Value = value
+		errs = append(errs, write(w, chunk, m.maxSize, base64.RawURLEncoding))
+	}
+	expire(w, cookie)
+	return errors.Join(errs...)
+}
+
+func chunkName(name string, i int) string {
+	return name + "." + strconv.Itoa(i)
+}
+
+// encodedLen returns the serialized length of cookie once its value is base64 encoded.
+func encodedLen(cookie http.Cookie) int {
+	n := base64.RawURLEncoding.EncodedLen(len(cookie.Value))
+	cookie.Value = ""
+	return len(cookie.String()) + n
+}
@@ -0,0 +1,59 @@
+package cookie
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestChunking(t *testing.T) {
+	m := newTestManager(t, WithChunking(32*1024))
+
+	large := make([]byte, 6000)
+	_, err := rand.Read(large)
+	require.NoError(t, err)
+	value := hex.EncodeToString(large)
+
+	for _, mode := range []Mode{Plain, Signed, Encrypted} {
+		t.Run(mode.String(), func(t *testing.T) {
+			w := httptest.NewRecorder()
+			require.NoError(t, m.writeMode(w, "big", value, mode))
+			for _, c := range w.Result().Cookies() {
+				require.LessOrEqual(t, len(c.String()), maxCookieSize)
+			}
+
+			got, err := m.readMode(requestWith(w), "big", mode)
+			require.NoError(t, err)
+			require.Equal(t, value, got)
+		})
+	}
+
+	t.Run("missing chunk", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		require.NoError(t, m.WriteSigned(w, "big", value))
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		for _, c := range w.Result().Cookies() {
+			if c.Name != "big.2" && c.MaxAge >= 0 {
+				r.AddCookie(c)
+			}
+		}
+		_, err := m.ReadSigned(r, "big")
+		require.ErrorIs(t, err, ErrCookie)
+	})
+
+	t.Run("over limit", func(t *testing.T) {
+		small := newTestManager(t, WithChunking(5000))
+		err := small.Write(httptest.NewRecorder(), "big", value)
+		require.ErrorIs(t, err, ErrCookie)
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		plain := newTestManager(t)
+		err := plain.Write(httptest.NewRecorder(), "big", value)
+		require.ErrorIs(t, err, ErrCookie)
+	})
+}
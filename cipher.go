@@ -0,0 +1,71 @@
+package cookie
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"fmt"
+
+	"golang.org/x/crypto/chacha20poly1305"
+)
+
+// Cipher identifies an AEAD used to encrypt cookie values.
+// It is recorded in each value's envelope, so cookies encrypted under any
+// supported Cipher can be read regardless of which one the Manager writes.
+type Cipher byte
+
+const (
+	AESGCM            Cipher = iota + 1 // AES-GCM, 12-byte random nonce
+	ChaCha20Poly1305                    // ChaCha20-Poly1305, 12-byte random nonce
+	XChaCha20Poly1305                   // XChaCha20-Poly1305, 24-byte random nonce safe for long-lived keys
+)
+
+func (c Cipher) String() string {
+	switch c {
+	case AESGCM:
+		return "AES-GCM"
+	case ChaCha20Poly1305:
+		return "ChaCha20-Poly1305"
+	case XChaCha20Poly1305:
+		return "XChaCha20-Poly1305"
+	default:
+		return fmt.Sprintf("Cipher(%d)", byte(c))
+	}
+}
+
+// aead returns the AEAD for c keyed with secretKey, failing with
+// ErrBadKeyLength if the key does not suit c.
+func (c Cipher) aead(secretKey []byte) (cipher.AEAD, error) {
+	switch c {
+	case AESGCM:
+		if err := ValidateSecret(secretKey); err != nil {
+			return nil, err
+		}
+		block, err := aes.NewCipher(secretKey)
+		if err != nil {
+			return nil, err
+		}
+		return cipher.NewGCM(block)
+	case ChaCha20Poly1305, XChaCha20Poly1305:
+		if len(secretKey) != chacha20poly1305.KeySize {
+			return nil, fmt.Errorf("%w: %s requires 32 bytes, got %d", ErrBadKeyLength, c, len(secretKey))
+		}
+		if c == ChaCha20Poly1305 {
+			return chacha20poly1305.New(secretKey)
+		}
+		return chacha20poly1305.NewX(secretKey)
+	default:
+		return nil, fmt.Errorf("unsupported cipher: %s", c)
+	}
+}
+
+// WithCipher selects the AEAD the Manager encrypts with; the default is AESGCM.
+// ChaCha20 ciphers require a 32-byte secret.
+func WithCipher(c Cipher) Option {
+	return func(m *Manager) error {
+		if _, err := c.aead(make([]byte, chacha20poly1305.KeySize)); err != nil {
+			return err
+		}
+		m.cipher = c
+		return nil
+	}
+}
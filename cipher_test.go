@@ -0,0 +1,50 @@
+package cookie
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCiphers(t *testing.T) {
+	secretKey, err := NewCookieSecret()
+	require.NoError(t, err)
+	defaults, err := New(WithSecret(secretKey))
+	require.NoError(t, err)
+
+	for _, c := range []Cipher{AESGCM, ChaCha20Poly1305, XChaCha20Poly1305} {
+		t.Run(c.String(), func(t *testing.T) {
+			m, err := New(WithSecret(secretKey), WithCipher(c))
+			require.NoError(t, err)
+
+			w := httptest.NewRecorder()
+			require.NoError(t, m.WriteEncryptedValue(w, "a", "chocolate fudge"))
+			raw, err := Read(requestWith(w), "a")
+			require.NoError(t, err)
+			h, _, _, err := parseHeader(raw)
+			require.NoError(t, err)
+			require.Equal(t, byte(c), h.algorithm)
+
+			// readable by any Manager holding the key, whatever it writes
+			for _, reader := range []*Manager{m, defaults} {
+				value, err := reader.ReadEncryptedValue(requestWith(w), "a")
+				require.NoError(t, err)
+				require.Equal(t, "chocolate fudge", value)
+			}
+
+			// and unversioned cookies remain readable
+			w = httptest.NewRecorder()
+			c := testCookie
+			c.Name = "a"
+			c.Value = "oatmeal raisin"
+			require.NoError(t, WriteEncryptedValue(w, c, secretKey))
+			value, err := m.ReadEncryptedValue(requestWith(w), "a")
+			require.NoError(t, err)
+			require.Equal(t, "oatmeal raisin", value)
+		})
+	}
+
+	_, err = New(WithCipher(Cipher(42)))
+	require.ErrorIs(t, err, ErrInitiation)
+}
@@ -0,0 +1,135 @@
+package cookie
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// ErrNotYetValid is returned for claims read before their NotBefore time.
+var ErrNotYetValid = errors.New("cookie not yet valid")
+
+// Claims is a standard encrypted cookie payload, identifying a subject
+// for a window of time. Zero times are omitted and not checked.
+type Claims struct {
+	Subject   string
+	Audience  string
+	IssuedAt  time.Time
+	ExpiresAt time.Time
+	NotBefore time.Time
+	Custom    map[string]any // values decode as their JSON types, so numbers are float64
+}
+
+// claimsJSON is the serialized form of Claims, with times as unix seconds.
+type claimsJSON struct {
+	Subject   string         `json:"sub,omitempty"`
+	Audience  string         `json:"aud,omitempty"`
+	IssuedAt  int64          `json:"iat,omitempty"`
+	ExpiresAt int64          `json:"exp,omitempty"`
+	NotBefore int64          `json:"nbf,omitempty"`
+	Custom    map[string]any `json:"ext,omitempty"`
+}
+
+// WithClockSkew tolerates clocks that differ by up to skew when ReadClaims
+// checks ExpiresAt and NotBefore.
+func WithClockSkew(skew time.Duration) Option {
+	return func(m *Manager) error {
+		if skew < 0 {
+			return fmt.Errorf("negative clock skew: %s", skew)
+		}
+		m.clockSkew = skew
+		return nil
+	}
+}
+
+// WriteClaims encrypts claims into a cookie with the Manager's defaults.
+// IssuedAt defaults to now, and a set ExpiresAt also sets the cookie's Max-Age.
+func (m *Manager) WriteClaims(w http.ResponseWriter, name string, claims Claims) error {
+	now := m.Now()
+	if claims.IssuedAt.IsZero() {
+		claims.IssuedAt = now
+	}
+	cookie := m.Cookie(name, "")
+	if !claims.ExpiresAt.IsZero() {
+		cookie.MaxAge = int(claims.ExpiresAt.Sub(now).Seconds())
+		if cookie.MaxAge <= 0 {
+			return fmt.Errorf("%w: expiry %s is not in the future", ErrCookie, claims.ExpiresAt.UTC().Format(time.RFC3339))
+		}
+	}
+	data, err := json.Marshal(claimsJSON{
+		Subject:   claims.Subject,
+		Audience:  claims.Audience,
+		IssuedAt:  unixOrZero(claims.IssuedAt),
+		ExpiresAt: unixOrZero(claims.ExpiresAt),
+		NotBefore: unixOrZero(claims.NotBefore),
+		Custom:    claims.Custom,
+	})
+	if err != nil {
+		return fmt.Errorf("%w: unable to encode claims: %w", ErrCookie, err)
+	}
+	sealed, err := m.seal(Encrypted, cookie.Name, string(data), sealOptions{})
+	if err != nil {
+		return err
+	}
+	cookie.Value = sealed
+	return m.write(w, cookie)
+}
+
+// ReadClaims decrypts claims written by WriteClaims, failing with ErrExpired
+// after ExpiresAt and ErrNotYetValid before NotBefore, give or take the
+// Manager's clock skew. If audience is not empty, the claims must match it.
+func (m *Manager) ReadClaims(r *http.Request, name, audience string) (Claims, error) {
+	value, err := m.readMode(r, name, Encrypted)
+	if err != nil {
+		return Claims{}, err
+	}
+	var wire claimsJSON
+	if err := json.Unmarshal([]byte(value), &wire); err != nil {
+		return Claims{}, fmt.Errorf("%w: unable to decode claims: %w", ErrCookie, err)
+	}
+	claims := Claims{
+		Subject:   wire.Subject,
+		Audience:  wire.Audience,
+		IssuedAt:  timeOrZero(wire.IssuedAt),
+		ExpiresAt: timeOrZero(wire.ExpiresAt),
+		NotBefore: timeOrZero(wire.NotBefore),
+		Custom:    wire.Custom,
+	}
+
+	if err := m.checkClaims(claims, audience); err != nil {
+		m.logRead(r, name, Encrypted, err)
+		return Claims{}, err
+	}
+	return claims, nil
+}
+
+// checkClaims checks the validity window and audience of claims.
+func (m *Manager) checkClaims(claims Claims, audience string) error {
+	now := m.Now()
+	if !claims.ExpiresAt.IsZero() && !now.Before(claims.ExpiresAt.Add(m.clockSkew)) {
+		return fmt.Errorf("%w: %w at %s", ErrCookie, ErrExpired, claims.ExpiresAt.UTC().Format(time.RFC3339))
+	}
+	if !claims.NotBefore.IsZero() && now.Before(claims.NotBefore.Add(-m.clockSkew)) {
+		return fmt.Errorf("%w: %w until %s", ErrCookie, ErrNotYetValid, claims.NotBefore.UTC().Format(time.RFC3339))
+	}
+	if audience != "" && claims.Audience != audience {
+		return fmt.Errorf("%w: audience '%s' does not match '%s'", ErrCookie, claims.Audience, audience)
+	}
+	return nil
+}
+
+func unixOrZero(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.Unix()
+}
+
+func timeOrZero(unix int64) time.Time {
+	if unix == 0 {
+		return time.Time{}
+	}
+	return time.Unix(unix, 0)
+}
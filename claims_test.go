@@ -0,0 +1,81 @@
+package cookie
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestClaims(t *testing.T) {
+	m := newTestManager(t)
+
+	w := httptest.NewRecorder()
+	require.NoError(t, m.WriteClaims(w, "auth", Claims{
+		Subject:   "1312",
+		Audience:  "api",
+		ExpiresAt: time.Now().Add(time.Hour),
+		Custom:    map[string]any{"role": "admin", "level": 3},
+	}))
+	require.InDelta(t, time.Hour.Seconds(), w.Result().Cookies()[0].MaxAge, 1)
+
+	claims, err := m.ReadClaims(requestWith(w), "auth", "api")
+	require.NoError(t, err)
+	require.Equal(t, "1312", claims.Subject)
+	require.WithinDuration(t, time.Now(), claims.IssuedAt, time.Second)
+	require.Equal(t, "admin", claims.Custom["role"])
+	require.Equal(t, 3.0, claims.Custom["level"])
+
+	_, err = m.ReadClaims(requestWith(w), "auth", "billing")
+	require.ErrorIs(t, err, ErrCookie)
+
+	t.Run("not before", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		require.NoError(t, m.WriteClaims(w, "auth", Claims{NotBefore: time.Now().Add(time.Minute)}))
+		_, err := m.ReadClaims(requestWith(w), "auth", "")
+		require.ErrorIs(t, err, ErrNotYetValid)
+
+		skewed, err := m.With(WithClockSkew(2 * time.Minute))
+		require.NoError(t, err)
+		_, err = skewed.ReadClaims(requestWith(w), "auth", "")
+		require.NoError(t, err)
+	})
+
+	t.Run("logged", func(t *testing.T) {
+		var buf bytes.Buffer
+		logged, err := m.With(WithLogger(slog.New(slog.NewJSONHandler(&buf, nil))))
+		require.NoError(t, err)
+		w := httptest.NewRecorder()
+		require.NoError(t, logged.WriteClaims(w, "auth", Claims{NotBefore: time.Now().Add(time.Minute)}))
+		_, err = logged.ReadClaims(requestWith(w), "auth", "")
+		require.ErrorIs(t, err, ErrNotYetValid)
+
+		var entry map[string]any
+		require.NoError(t, json.Unmarshal(bytes.TrimSpace(buf.Bytes()), &entry))
+		require.Equal(t, "not yet valid", entry["reason"])
+		require.Equal(t, "encrypted", entry["mode"])
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		// expiry is checked on read, independently of the cookie's Max-Age
+		w := httptest.NewRecorder()
+		require.NoError(t, m.WriteClaims(w, "auth", Claims{ExpiresAt: time.Now().Add(1500 * time.Millisecond)}))
+		r := requestWith(w)
+		time.Sleep(1500 * time.Millisecond)
+
+		_, err := m.ReadClaims(r, "auth", "")
+		require.ErrorIs(t, err, ErrExpired)
+
+		skewed, err := m.With(WithClockSkew(time.Minute))
+		require.NoError(t, err)
+		_, err = skewed.ReadClaims(r, "auth", "")
+		require.NoError(t, err)
+	})
+
+	err = m.WriteClaims(httptest.NewRecorder(), "auth", Claims{ExpiresAt: time.Now().Add(-time.Minute)})
+	require.ErrorIs(t, err, ErrCookie)
+}
@@ -0,0 +1,83 @@
+package cookie
+
+import (
+	"crypto/rand"
+	"errors"
+	"io"
+	"time"
+)
+
+// Clock tells the time used for expiry, creation stamps, and claims.
+type Clock interface {
+	Now() time.Time
+}
+
+// ClockSetter is implemented by stores that compare the expiry times they
+// are given against the current time. A Manager, or a package built on one,
+// calls SetClock with its clock before using such a store, so one WithClock
+// controls expiry in the store too.
+type ClockSetter interface {
+	SetClock(Clock)
+}
+
+// WithClock replaces the system clock, so expiry can be tested
+// without waiting, and values reproduced for test vectors.
+func WithClock(clock Clock) Option {
+	return func(m *Manager) error {
+		if clock == nil {
+			return errors.New("clock is nil")
+		}
+		m.clock = clock
+		m.shareClock = true
+		return nil
+	}
+}
+
+// WithRandom replaces crypto/rand as the source of nonces and one-time token IDs.
+// A predictable source makes encrypted values reproducible; use it only in tests.
+func WithRandom(random io.Reader) Option {
+	return func(m *Manager) error {
+		if random == nil {
+			return errors.New("random source is nil")
+		}
+		m.rand = random
+		return nil
+	}
+}
+
+// Now returns the Manager's current time, from WithClock or the system clock.
+// Packages keeping expiry times in cookie payloads use it, so one WithClock
+// controls all expiry.
+func (m *Manager) Now() time.Time {
+	return clockNow(m.clock)
+}
+
+// clockNow returns the time from clock, or the system clock if it is nil.
+func clockNow(clock Clock) time.Time {
+	if clock == nil {
+		return time.Now()
+	}
+	return clock.Now()
+}
+
+// setStoreClocks passes the Manager's clock to its stores that keep one,
+// once WithClock or a store option has been applied.
+func (m *Manager) setStoreClocks() {
+	if !m.shareClock || m.clock == nil {
+		return
+	}
+	m.shareClock = false
+	for _, store := range []any{m.replay, m.oneTime, m.revocation} {
+		if setter, ok := store.(ClockSetter); ok {
+			setter.SetClock(m.clock)
+		}
+	}
+}
+
+// random returns the Manager's source of randomness.
+func (m *Manager) random() io.Reader {
+	if m.rand == nil {
+		return rand.Reader
+	}
+	return m.rand
+}
@@ -0,0 +1,51 @@
+package cookie
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type testClock struct{ t time.Time }
+
+func (c *testClock) Now() time.Time { return c.t }
+
+func TestClock(t *testing.T) {
+	clock := &testClock{t: time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)}
+	m := newTestManager(t, WithClock(clock), WithSignedMaxAge(time.Hour))
+
+	w := httptest.NewRecorder()
+	require.NoError(t, m.WriteSigned(w, "a", "chocolate fudge"))
+	require.NoError(t, m.WriteSignedWithExpiry(w, "b", "oatmeal raisin", clock.t.Add(time.Minute)))
+	r := requestWith(w)
+
+	clock.t = clock.t.Add(59 * time.Minute)
+	_, err := m.ReadSigned(r, "a")
+	require.NoError(t, err)
+	_, err = m.ReadSigned(r, "b")
+	require.ErrorIs(t, err, ErrExpired)
+
+	clock.t = clock.t.Add(time.Minute)
+	_, err = m.ReadSigned(r, "a")
+	require.ErrorIs(t, err, ErrExpired)
+}
+
+func TestRandom(t *testing.T) {
+	secretKey := bytes.Repeat([]byte{1}, secretLength)
+	seal := func() string {
+		m, err := New(WithSecret(secretKey), WithRandom(bytes.NewReader(make([]byte, 64))))
+		require.NoError(t, err)
+		sealed, err := m.seal(Encrypted, "a", "chocolate fudge", sealOptions{})
+		require.NoError(t, err)
+		return sealed
+	}
+	require.Equal(t, seal(), seal())
+
+	_, err := New(WithRandom(nil))
+	require.ErrorIs(t, err, ErrInitiation)
+	_, err = New(WithClock(nil))
+	require.ErrorIs(t, err, ErrInitiation)
+}
@@ -0,0 +1,253 @@
+// Command cookie inspects and mints cookies written by package cookie,
+// for debugging why a server accepts or rejects a client's cookies.
+//
+// Usage:
+//
+//	cookie decode  [flags] value    base64-decode a plain cookie
+//	cookie verify  [flags] value    verify a signed cookie
+//	cookie decrypt [flags] value    decrypt an encrypted cookie
+//	cookie mint    [flags] value    write a cookie, printing its Set-Cookie headers
+//	cookie secret                   generate a new random secret
+//
+// A value is either a raw cookie value, named with -name, or a Cookie header
+// such as 'session=...; theme=...' copied from the browser, which may also
+// carry the chunks of a large cookie. A value of '-' is read from standard input.
+//
+// Secrets are read, newest first, from the environment variables named by
+// -secret-env, or from the file named by -secret-file with one base64 secret
+// per line, so they do not end up in shell history. Errors quote the values
+// they describe rather than redacting them.
+package main
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/grackleclub/cookie/v2"
+)
+
+const usage = `usage: cookie <decode|verify|decrypt|mint|secret> [flags] [value]`
+
+func main() {
+	if err := run(os.Args[1:], os.Stdin, os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, "cookie:", err)
+		os.Exit(1)
+	}
+}
+
+// run executes the command in args, reading a '-' value from stdin.
+func run(args []string, stdin io.Reader, stdout io.Writer) error {
+	if len(args) == 0 {
+		return errors.New(usage)
+	}
+	command, args := args[0], args[1:]
+	if command == "secret" {
+		secret, err := cookie.NewCookieSecret()
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(stdout, cookie.EncodeSecret(secret, cookie.SecretBase64))
+		return nil
+	}
+	commands := map[string]cookie.Mode{"decode": cookie.Plain, "verify": cookie.Signed, "decrypt": cookie.Encrypted, "mint": cookie.Signed}
+	mode, ok := commands[command]
+	if !ok {
+		return fmt.Errorf("unknown command '%s'\n%s", command, usage)
+	}
+
+	flags := flag.NewFlagSet(command, flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	cookieName := flags.String("name", "", "cookie name, without the Manager's prefix")
+	prefix := flags.String("prefix", "", "the Manager's name prefix, __Host- or __Secure-, as WithPrefix")
+	secretEnv := flags.String("secret-env", "COOKIE_SECRET", "comma-separated environment variables holding base64 secrets, newest first")
+	secretFile := flags.String("secret-file", "", "file of base64 secrets, one per line, newest first")
+	associatedData := flags.String("associated-data", "", "context bound into every value, as WithAssociatedData")
+	chunking := flags.Int("chunking", 0, "chunk limit in bytes, as WithChunking")
+	mintMode := flags.String("mode", "signed", "mint: plain, signed, or encrypted")
+	maxAge := flags.Duration("max-age", 0, "mint: cookie Max-Age")
+	if err := flags.Parse(args); err != nil {
+		return fmt.Errorf("%w\n%s", err, usage)
+	}
+	if command == "mint" {
+		modes := map[string]cookie.Mode{"plain": cookie.Plain, "signed": cookie.Signed, "encrypted": cookie.Encrypted}
+		if mode, ok = modes[*mintMode]; !ok {
+			return fmt.Errorf("unknown mode '%s'", *mintMode)
+		}
+	}
+	if flags.NArg() != 1 {
+		return errors.New(usage)
+	}
+	value := flags.Arg(0)
+	if value == "-" {
+		data, err := io.ReadAll(stdin)
+		if err != nil {
+			return err
+		}
+		value = strings.TrimSpace(string(data))
+	}
+
+	var opts []cookie.Option
+	if *prefix != "" {
+		opts = append(opts, cookie.WithPrefix(*prefix))
+	}
+	if mode != cookie.Plain {
+		secrets, err := loadSecrets(*secretEnv, *secretFile)
+		if err != nil {
+			return err
+		}
+		opts = append(opts, cookie.WithSecretSource(secrets))
+	}
+	if *associatedData != "" {
+		opts = append(opts, cookie.WithAssociatedData(*associatedData))
+	}
+	if *chunking > 0 {
+		opts = append(opts, cookie.WithChunking(*chunking))
+	}
+	if *maxAge > 0 {
+		opts = append(opts, cookie.WithMaxAge(*maxAge))
+	}
+	m, err := cookie.New(opts...)
+	if err != nil {
+		return err
+	}
+	cookie.SetVerboseErrors(true)
+
+	if command == "mint" {
+		return mint(stdout, m, *cookieName, value, mode)
+	}
+	r, name, err := request(m, *cookieName, value)
+	if err != nil {
+		return err
+	}
+	return inspect(stdout, m, r, name, mode)
+}
+
+// loadSecrets reads secrets from file if given, or from the environment.
+func loadSecrets(env, file string) (cookie.Secrets, error) {
+	if file != "" {
+		return cookie.SecretsFromFile(file)
+	}
+	return cookie.SecretsFromEnv(strings.Split(env, ",")...)
+}
+
+// request returns a request carrying value, and the unprefixed name to read.
+// A value containing '=' is a Cookie header; name may then be omitted if it
+// holds a single cookie.
+func request(m *cookie.Manager, name, value string) (*http.Request, string, error) {
+	r, err := http.NewRequest(http.MethodGet, "https://localhost/", nil)
+	if err != nil {
+		return nil, "", err
+	}
+	// cookies with a __Host- or __Secure- prefix only arrive over TLS
+	r.TLS = &tls.ConnectionState{}
+	if !strings.Contains(value, "=") {
+		if name == "" {
+			return nil, "", errors.New("-name is required for a raw value")
+		}
+		r.AddCookie(&http.Cookie{Name: m.Cookie(name, "").Name, Value: value})
+		return r, name, nil
+	}
+	r.Header.Set("Cookie", strings.TrimSpace(strings.TrimPrefix(value, "Cookie:")))
+	if name != "" {
+		return r, name, nil
+	}
+	names := map[string]bool{}
+	for _, c := range r.Cookies() {
+		// chunks of a large cookie are named 'name.0', 'name.1', and so on
+		base := c.Name
+		if i := strings.LastIndexByte(base, '.'); i >= 0 {
+			if _, err := strconv.Atoi(base[i+1:]); err == nil {
+				base = base[:i]
+			}
+		}
+		names[base] = true
+	}
+	if len(names) != 1 {
+		return nil, "", fmt.Errorf("-name is required for a header of %d cookies", len(names))
+	}
+	for full := range names {
+		name = strings.TrimPrefix(full, m.Cookie("", "").Name)
+	}
+	return r, name, nil
+}
+
+// inspect reads the named cookie in mode and prints a report.
+func inspect(stdout io.Writer, m *cookie.Manager, r *http.Request, name string, mode cookie.Mode) error {
+	var value string
+	var issued time.Time
+	var err error
+	switch mode {
+	case cookie.Plain:
+		value, err = m.Read(r, name)
+	case cookie.Signed:
+		value, err = m.ReadSigned(r, name)
+	case cookie.Encrypted:
+		value, issued, err = m.ReadEncryptedValueIssued(r, name)
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(stdout, "cookie:  %s\n", m.Cookie(name, "").Name)
+	fmt.Fprintf(stdout, "mode:    %s\n", mode)
+	if !issued.IsZero() {
+		fmt.Fprintf(stdout, "issued:  %s (%s ago)\n", issued.UTC().Format(time.RFC3339), time.Since(issued).Round(time.Second))
+	}
+	fmt.Fprintf(stdout, "value:   %s\n", pretty(value))
+	return nil
+}
+
+// pretty indents value if it is a JSON object or array.
+func pretty(value string) string {
+	trimmed := strings.TrimSpace(value)
+	if !strings.HasPrefix(trimmed, "{") && !strings.HasPrefix(trimmed, "[") {
+		return value
+	}
+	var b bytes.Buffer
+	if err := json.Indent(&b, []byte(trimmed), "", "  "); err != nil {
+		return value
+	}
+	return b.String()
+}
+
+// mint writes value as the named cookie in mode and prints its Set-Cookie headers.
+func mint(stdout io.Writer, m *cookie.Manager, name, value string, mode cookie.Mode) error {
+	if name == "" {
+		return errors.New("-name is required to mint")
+	}
+	w := &headerWriter{header: http.Header{}}
+	var err error
+	switch mode {
+	case cookie.Plain:
+		err = m.Write(w, name, value)
+	case cookie.Signed:
+		err = m.WriteSigned(w, name, value)
+	case cookie.Encrypted:
+		err = m.WriteEncryptedValue(w, name, value)
+	}
+	if err != nil {
+		return err
+	}
+	for _, line := range w.header.Values("Set-Cookie") {
+		fmt.Fprintf(stdout, "Set-Cookie: %s\n", line)
+	}
+	return nil
+}
+
+// headerWriter collects the headers written by the Manager; bodies are discarded.
+type headerWriter struct {
+	header http.Header
+}
+
+func (w *headerWriter) Header() http.Header         { return w.header }
+func (w *headerWriter) Write(b []byte) (int, error) { return len(b), nil }
+func (w *headerWriter) WriteHeader(int)             {}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/grackleclub/cookie/v2"
+	"github.com/stretchr/testify/require"
+)
+
+func setSecret(t *testing.T) {
+	t.Helper()
+	secret, err := cookie.NewCookieSecret()
+	require.NoError(t, err)
+	t.Setenv("COOKIE_SECRET", base64.StdEncoding.EncodeToString(secret))
+}
+
+// exec runs the command, returning its output.
+func exec(t *testing.T, stdin string, args ...string) (string, error) {
+	t.Helper()
+	var stdout bytes.Buffer
+	err := run(args, strings.NewReader(stdin), &stdout)
+	return stdout.String(), err
+}
+
+// header returns the Cookie header for the Set-Cookie lines minted by mint.
+func header(t *testing.T, minted string) string {
+	t.Helper()
+	var pairs []string
+	for _, line := range strings.Split(strings.TrimSpace(minted), "\n") {
+		pair, _, _ := strings.Cut(strings.TrimPrefix(line, "Set-Cookie: "), ";")
+		if !strings.HasSuffix(pair, "=") {
+			pairs = append(pairs, pair)
+		}
+	}
+	return "Cookie: " + strings.Join(pairs, "; ")
+}
+
+func TestMintVerify(t *testing.T) {
+	setSecret(t)
+	minted, err := exec(t, "", "mint", "-name", "user", "-prefix", "__Host-", "alice")
+	require.NoError(t, err)
+	require.Contains(t, minted, "Set-Cookie: __Host-user=")
+
+	out, err := exec(t, "", "verify", "-prefix", "__Host-", header(t, minted))
+	require.NoError(t, err)
+	require.Contains(t, out, "cookie:  __Host-user\n")
+	require.Contains(t, out, "mode:    signed\n")
+	require.Contains(t, out, "value:   alice\n")
+
+	// a raw value read from stdin
+	value := strings.TrimPrefix(header(t, minted), "Cookie: __Host-user=")
+	out, err = exec(t, value, "verify", "-prefix", "__Host-", "-name", "user", "-")
+	require.NoError(t, err)
+	require.Contains(t, out, "value:   alice\n")
+
+	_, err = exec(t, "", "decrypt", "-prefix", "__Host-", header(t, minted))
+	require.ErrorIs(t, err, cookie.ErrCookie)
+
+	setSecret(t)
+	_, err = exec(t, "", "verify", "-prefix", "__Host-", header(t, minted))
+	require.ErrorIs(t, err, cookie.ErrUnknownKey)
+}
+
+func TestMintDecrypt(t *testing.T) {
+	setSecret(t)
+	minted, err := exec(t, "", "mint", "-name", "cart", "-mode", "encrypted", "-chunking", "16384", `{"items":["`+strings.Repeat("x", 6000)+`"]}`)
+	require.NoError(t, err)
+	require.Contains(t, minted, "Set-Cookie: cart.1=")
+
+	out, err := exec(t, "", "decrypt", "-chunking", "16384", header(t, minted))
+	require.NoError(t, err)
+	require.Contains(t, out, "value:   {\n  \"items\": [\n    \"xxx")
+}
+
+func TestDecode(t *testing.T) {
+	minted, err := exec(t, "", "mint", "-name", "theme", "-mode", "plain", "dark")
+	require.NoError(t, err)
+	out, err := exec(t, "", "decode", header(t, minted))
+	require.NoError(t, err)
+	require.Contains(t, out, "value:   dark\n")
+}
+
+func TestUsage(t *testing.T) {
+	out, err := exec(t, "", "secret")
+	require.NoError(t, err)
+	secret, err := base64.StdEncoding.DecodeString(strings.TrimSpace(out))
+	require.NoError(t, err)
+	require.NotEmpty(t, secret)
+
+	_, err = exec(t, "")
+	require.Error(t, err)
+	_, err = exec(t, "", "unknown", "value")
+	require.Error(t, err)
+	_, err = exec(t, "", "decode", "raw")
+	require.ErrorContains(t, err, "-name is required")
+	_, err = exec(t, "", "decode", "a=1; b=2")
+	require.ErrorContains(t, err, "-name is required")
+	_, err = exec(t, "", "mint", "-mode", "sealed", "-name", "a", "value")
+	require.ErrorContains(t, err, "unknown mode")
+}
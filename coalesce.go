@@ -0,0 +1,79 @@
+package cookie
+
+import (
+	"net/http"
+	"strings"
+)
+
+// Coalesce removes duplicate Set-Cookie headers from the responses of the
+// handler it wraps, so a cookie written more than once during a request,
+// such as by a Manager and again by a handler, reaches the client once.
+// Cookies are duplicates when they share a name, domain, and path; the last
+// write wins. Headers are coalesced once, just before they are sent.
+func Coalesce(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cw := &coalesceWriter{ResponseWriter: w}
+		next.ServeHTTP(cw, r)
+		cw.coalesce()
+	})
+}
+
+// coalesceWriter coalesces Set-Cookie headers before the response headers are sent.
+type coalesceWriter struct {
+	http.ResponseWriter
+	done bool
+}
+
+func (w *coalesceWriter) WriteHeader(code int) {
+	w.coalesce()
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *coalesceWriter) Write(b []byte) (int, error) {
+	w.coalesce()
+	return w.ResponseWriter.Write(b)
+}
+
+// Flush coalesces the headers before flushing buffered data to the client.
+func (w *coalesceWriter) Flush() {
+	w.coalesce()
+	http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (w *coalesceWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// coalesce keeps the last Set-Cookie header for each cookie, once.
+func (w *coalesceWriter) coalesce() {
+	if w.done {
+		return
+	}
+	w.done = true
+	header := w.Header()
+	lines := header["Set-Cookie"]
+	if len(lines) < 2 {
+		return
+	}
+	type key struct{ name, domain, path string }
+	last := make(map[key]int, len(lines))
+	keys := make([]key, len(lines))
+	for i, line := range lines {
+		c, err := http.ParseSetCookie(line)
+		if err != nil {
+			keys[i] = key{name: line} // unparsable lines are kept as written
+			continue
+		}
+		keys[i] = key{c.Name, strings.ToLower(strings.TrimPrefix(c.Domain, ".")), c.Path}
+		last[keys[i]] = i
+	}
+	kept := lines[:0:0]
+	for i, line := range lines {
+		if j, ok := last[keys[i]]; ok && j != i {
+			continue
+		}
+		kept = append(kept, line)
+	}
+	header["Set-Cookie"] = kept
+}
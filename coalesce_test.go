@@ -0,0 +1,43 @@
+package cookie
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCoalesce(t *testing.T) {
+	m := newTestManager(t)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, m.WriteSigned(w, "theme", "light"))
+		require.NoError(t, m.Write(w, "user", "alice"))
+		require.NoError(t, m.WriteSigned(w, "theme", "dark"))
+		http.SetCookie(w, &http.Cookie{Name: "user", Value: "bob", Path: "/admin"})
+		w.Write([]byte("ok"))
+		http.SetCookie(w, &http.Cookie{Name: "late", Value: "x"}) // after the headers are sent
+	})
+
+	w := httptest.NewRecorder()
+	Coalesce(handler).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	var names []string
+	for _, c := range w.Result().Cookies() {
+		names = append(names, c.Name+" "+c.Path)
+	}
+	require.Equal(t, []string{"user /", "theme /", "user /admin"}, names)
+
+	theme, err := m.ReadSigned(requestWith(w), "theme")
+	require.NoError(t, err)
+	require.Equal(t, "dark", theme)
+}
+
+func TestCoalesceWithoutWrite(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: "theme", Value: "light"})
+		http.SetCookie(w, &http.Cookie{Name: "theme", Value: "dark"})
+	})
+	w := httptest.NewRecorder()
+	Coalesce(handler).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	require.Equal(t, []string{"theme=dark"}, w.Header().Values("Set-Cookie"))
+}
@@ -0,0 +1,28 @@
+package cookie
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCodecs(t *testing.T) {
+	prefs := testPrefs{Theme: "light", FontSize: 12, Pinned: []string{"sent"}}
+
+	for name, codec := range map[string]Codec{"json": JSON, "gob": Gob, "msgpack": MsgPack} {
+		t.Run(name, func(t *testing.T) {
+			m := newTestManager(t, WithCodec(codec))
+
+			w := httptest.NewRecorder()
+			require.NoError(t, WriteValue(m, w, "prefs", prefs, Encrypted))
+
+			got, err := ReadValue[testPrefs](m, requestWith(w), "prefs", Encrypted)
+			require.NoError(t, err)
+			require.Equal(t, prefs, got)
+		})
+	}
+
+	_, err := New(WithCodec(nil))
+	require.ErrorIs(t, err, ErrInitiation)
+}
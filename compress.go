@@ -0,0 +1,67 @@
+package cookie
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// maxDecompressedSize bounds gzip output on read, guarding against decompression bombs.
+const maxDecompressedSize = 1 << 20
+
+// WithCompression gzips values of at least threshold bytes before they are
+// signed or encrypted, and decompresses them on read. Compression is recorded
+// in each value's envelope, so it can be toggled without invalidating cookies.
+//
+// Compressing secrets alongside attacker-controlled data before encryption
+// can leak information through the ciphertext length; leave compression off
+// for such payloads.
+func WithCompression(threshold int) Option {
+	return func(m *Manager) error {
+		if threshold < 0 {
+			return fmt.Errorf("invalid compression threshold: %d", threshold)
+		}
+		m.compression = true
+		m.compressThreshold = threshold
+		return nil
+	}
+}
+
+// compress gzips value when compression is enabled and the value is large enough,
+// reporting whether the returned value is compressed. The raw value is kept
+// whenever gzip would not make it smaller.
+func (m *Manager) compress(value string) (string, bool, error) {
+	if !m.compression || len(value) < m.compressThreshold {
+		return value, false, nil
+	}
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write([]byte(value)); err != nil {
+		return "", false, fmt.Errorf("%w: unable to compress: %w", ErrCookie, err)
+	}
+	if err := zw.Close(); err != nil {
+		return "", false, fmt.Errorf("%w: unable to compress: %w", ErrCookie, err)
+	}
+	if buf.Len() >= len(value) {
+		return value, false, nil
+	}
+	return buf.String(), true, nil
+}
+
+// decompress reverses compress.
+func decompress(value string) (string, error) {
+	zr, err := gzip.NewReader(strings.NewReader(value))
+	if err != nil {
+		return "", fmt.Errorf("%w: unable to decompress: %w", ErrCookie, err)
+	}
+	data, err := io.ReadAll(io.LimitReader(zr, maxDecompressedSize+1))
+	if err != nil {
+		return "", fmt.Errorf("%w: unable to decompress: %w", ErrCookie, err)
+	}
+	if len(data) > maxDecompressedSize {
+		return "", fmt.Errorf("%w: decompressed value exceeds %d bytes", ErrCookie, maxDecompressedSize)
+	}
+	return string(data), nil
+}
@@ -0,0 +1,46 @@
+package cookie
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompression(t *testing.T) {
+	m := newTestManager(t, WithCompression(64))
+	large := strings.Repeat("chocolate fudge ", 512) // ~8KB, compresses well
+	small := "chocolate fudge"
+
+	for _, mode := range []Mode{Plain, Signed, Encrypted} {
+		t.Run(mode.String(), func(t *testing.T) {
+			for _, value := range []string{large, small} {
+				w := httptest.NewRecorder()
+				require.NoError(t, m.writeMode(w, "c", value, mode))
+				require.Len(t, w.Result().Cookies(), 1)
+
+				got, err := m.readMode(requestWith(w), "c", mode)
+				require.NoError(t, err)
+				require.Equal(t, value, got)
+			}
+		})
+	}
+
+	_, compressed, err := m.compress(large)
+	require.NoError(t, err)
+	require.True(t, compressed)
+
+	_, compressed, err = m.compress(small)
+	require.NoError(t, err)
+	require.False(t, compressed)
+
+	// compression can be turned off without invalidating compressed cookies
+	w := httptest.NewRecorder()
+	require.NoError(t, m.WriteSigned(w, "c", large))
+	off, err := m.With(func(m *Manager) error { m.compression = false; return nil })
+	require.NoError(t, err)
+	value, err := off.ReadSigned(requestWith(w), "c")
+	require.NoError(t, err)
+	require.Equal(t, large, value)
+}
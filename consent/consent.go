@@ -0,0 +1,251 @@
+// package consent records a visitor's consent to categories of cookies in a
+// signed cookie, lets handlers check it before setting non-essential cookies,
+// and keeps cookies of categories without consent from being set at all.
+package consent
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/grackleclub/cookie/v2"
+)
+
+const (
+	defaultName = "consent"
+	defaultTTL  = 180 * 24 * time.Hour
+)
+
+// Category is a purpose for which cookies are set.
+type Category string
+
+const (
+	Necessary  Category = "necessary" // always allowed
+	Functional Category = "functional"
+	Analytics  Category = "analytics"
+	Ads        Category = "ads"
+)
+
+// Choices is a visitor's consent.
+type Choices struct {
+	Version int       // the policy version consented to; zero for defaults
+	Updated time.Time // when the choices were saved
+	Granted []Category
+}
+
+// Allows reports whether the choices consent to category.
+// Necessary cookies are always allowed.
+func (c Choices) Allows(category Category) bool {
+	return category == Necessary || slices.Contains(c.Granted, category)
+}
+
+// stored is a visitor's Choices, with Updated in unix seconds.
+type stored struct {
+	Version int        `json:"v"`
+	Updated int64      `json:"t"`
+	Granted []Category `json:"g,omitempty"`
+}
+
+// Consent saves and checks visitors' consent.
+type Consent struct {
+	cookies    *cookie.Manager
+	name       string
+	ttl        time.Duration
+	version    int
+	categories map[string]Category // cookie names Enforce checks
+
+	region         func(*http.Request) string
+	defaults       []Category            // allowed without choices
+	regionDefaults map[string][]Category // replace defaults by region
+}
+
+// Option configures a Consent.
+type Option func(*Consent) error
+
+// New creates a Consent for the given version of the site's cookie policy,
+// starting at 1. Raising the version discards earlier choices, so visitors
+// are asked again. The cookie.Manager must hold a secret, which is used to
+// sign the consent cookie.
+func New(cookies *cookie.Manager, version int, opts ...Option) (*Consent, error) {
+	if cookies == nil {
+		return nil, fmt.Errorf("%w: cookie manager is required", cookie.ErrInitiation)
+	}
+	if version < 1 {
+		return nil, fmt.Errorf("%w: invalid consent version: %d", cookie.ErrInitiation, version)
+	}
+	c := &Consent{
+		cookies:    cookies,
+		name:       defaultName,
+		ttl:        defaultTTL,
+		version:    version,
+		categories: map[string]Category{},
+
+		regionDefaults: map[string][]Category{},
+	}
+	for _, opt := range opts {
+		if err := opt(c); err != nil {
+			return nil, fmt.Errorf("%w: %w", cookie.ErrInitiation, err)
+		}
+	}
+	cookies, err := cookies.With(cookie.WithMaxAge(c.ttl))
+	if err != nil {
+		return nil, err
+	}
+	c.cookies = cookies
+	return c, nil
+}
+
+// WithName sets the name of the consent cookie.
+func WithName(name string) Option {
+	return func(c *Consent) error {
+		if name == "" {
+			return errors.New("empty consent cookie name")
+		}
+		c.name = name
+		return nil
+	}
+}
+
+// WithTTL sets how long choices are kept before visitors are asked again. Defaults to 180 days.
+func WithTTL(ttl time.Duration) Option {
+	return func(c *Consent) error {
+		if ttl < time.Hour {
+			return fmt.Errorf("consent ttl too short: %s", ttl)
+		}
+		c.ttl = ttl
+		return nil
+	}
+}
+
+// WithCookies assigns the cookies with the given names, as sent in Set-Cookie
+// headers, to category, for Enforce to remove when category is not allowed.
+func WithCookies(category Category, names ...string) Option {
+	return func(c *Consent) error {
+		for _, name := range names {
+			if prev, ok := c.categories[name]; ok && prev != category {
+				return fmt.Errorf("cookie '%s' assigned to both %s and %s", name, prev, category)
+			}
+			c.categories[name] = category
+		}
+		return nil
+	}
+}
+
+// Save records consent to the granted categories for the current version,
+// replacing any earlier choices. Within Enforce, the new choices apply to
+// the rest of the response.
+func (c *Consent) Save(w http.ResponseWriter, granted ...Category) (Choices, error) {
+	granted = slices.Clone(granted)
+	slices.Sort(granted)
+	granted = slices.Compact(granted)
+	choices := Choices{Version: c.version, Updated: c.cookies.Now().Truncate(time.Second), Granted: granted}
+	payload := stored{Version: choices.Version, Updated: choices.Updated.Unix(), Granted: choices.Granted}
+	if err := cookie.WriteJSON(c.cookies, w, c.name, payload, cookie.Signed); err != nil {
+		return Choices{}, err
+	}
+	if e := enforcer(w, c); e != nil {
+		e.choices = choices
+	}
+	return choices, nil
+}
+
+// Load returns the visitor's choices, reporting false if they have made
+// none for the current version and should be asked.
+func (c *Consent) Load(r *http.Request) (Choices, bool) {
+	payload, err := cookie.ReadJSON[stored](c.cookies, r, c.name, cookie.Signed)
+	if err != nil || payload.Version != c.version {
+		return Choices{}, false
+	}
+	return Choices{Version: payload.Version, Updated: time.Unix(payload.Updated, 0), Granted: payload.Granted}, true
+}
+
+// Allowed reports whether the visitor has consented to category. Without
+// choices for the current version, only Necessary and the defaults for the
+// request's region are allowed.
+func (c *Consent) Allowed(r *http.Request, category Category) bool {
+	return c.Effective(r).Allows(category)
+}
+
+// Enforce removes Set-Cookie headers, other than deletions, for cookies assigned
+// by WithCookies to a category the visitor has not consented to, from the
+// responses of the handler it wraps. Unassigned cookies are left alone.
+func (c *Consent) Enforce(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ew := &enforceWriter{ResponseWriter: w, consent: c, choices: c.Effective(r)}
+		next.ServeHTTP(ew, r)
+		ew.enforce()
+	})
+}
+
+// enforceWriter removes Set-Cookie headers before the response headers are sent.
+type enforceWriter struct {
+	http.ResponseWriter
+	consent *Consent
+	choices Choices
+	done    bool
+}
+
+func (w *enforceWriter) WriteHeader(code int) {
+	w.enforce()
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *enforceWriter) Write(b []byte) (int, error) {
+	w.enforce()
+	return w.ResponseWriter.Write(b)
+}
+
+// Flush enforces consent before flushing buffered data to the client.
+func (w *enforceWriter) Flush() {
+	w.enforce()
+	http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (w *enforceWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// enforce removes the Set-Cookie headers of cookies without consent, once.
+func (w *enforceWriter) enforce() {
+	if w.done {
+		return
+	}
+	w.done = true
+	header := w.Header()
+	lines := header["Set-Cookie"]
+	kept := lines[:0:0]
+	for _, line := range lines {
+		sc, err := http.ParseSetCookie(line)
+		if err == nil && sc.MaxAge >= 0 {
+			if category, ok := w.consent.categories[sc.Name]; ok && !w.choices.Allows(category) {
+				continue
+			}
+		}
+		kept = append(kept, line)
+	}
+	if len(kept) == 0 {
+		header.Del("Set-Cookie")
+	} else {
+		header["Set-Cookie"] = kept
+	}
+}
+
+// enforcer returns the enforceWriter of c that w is or wraps, if any.
+func enforcer(w http.ResponseWriter, c *Consent) *enforceWriter {
+	for {
+		switch v := w.(type) {
+		case *enforceWriter:
+			if v.consent == c {
+				return v
+			}
+			w = v.ResponseWriter
+		case interface{ Unwrap() http.ResponseWriter }:
+			w = v.Unwrap()
+		default:
+			return nil
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package consent
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/grackleclub/cookie/v2"
+	"github.com/grackleclub/cookie/v2/cookietest"
+	"github.com/stretchr/testify/require"
+)
+
+// names returns the names of the cookies set on the recorder.
+func names(w *httptest.ResponseRecorder) []string {
+	var names []string
+	for _, c := range w.Result().Cookies() {
+		names = append(names, c.Name)
+	}
+	return names
+}
+
+func TestConsent(t *testing.T) {
+	cookies := cookietest.NewManager(t)
+	c, err := New(cookies, 1)
+	require.NoError(t, err)
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	_, ok := c.Load(r)
+	require.False(t, ok)
+	require.True(t, c.Allowed(r, Necessary))
+	require.False(t, c.Allowed(r, Analytics))
+
+	w := httptest.NewRecorder()
+	saved, err := c.Save(w, Analytics, Functional, Analytics)
+	require.NoError(t, err)
+	require.Equal(t, []Category{Analytics, Functional}, saved.Granted)
+
+	r = cookietest.RequestFrom(t, w)
+	choices, ok := c.Load(r)
+	require.True(t, ok)
+	require.Equal(t, saved, choices)
+	require.True(t, c.Allowed(r, Analytics))
+	require.False(t, c.Allowed(r, Ads))
+
+	// a new policy version asks again
+	c2, err := New(cookies, 2)
+	require.NoError(t, err)
+	_, ok = c2.Load(r)
+	require.False(t, ok)
+	require.False(t, c2.Allowed(r, Analytics))
+
+	_, err = New(cookies, 0)
+	require.ErrorIs(t, err, cookie.ErrInitiation)
+	_, err = New(cookies, 1, WithCookies(Ads, "_ga"), WithCookies(Analytics, "_ga"))
+	require.ErrorIs(t, err, cookie.ErrInitiation)
+}
+
+func TestEnforce(t *testing.T) {
+	c, err := New(cookietest.NewManager(t), 1, WithCookies(Analytics, "_ga"), WithCookies(Ads, "_fbp"))
+	require.NoError(t, err)
+	handler := c.Enforce(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Has("accept") {
+			_, err := c.Save(w, Analytics)
+			require.NoError(t, err)
+		}
+		http.SetCookie(w, &http.Cookie{Name: "_ga", Value: "1"})
+		http.SetCookie(w, &http.Cookie{Name: "_fbp", Value: "1"})
+		http.SetCookie(w, &http.Cookie{Name: "lang", Value: "en"})
+		http.SetCookie(w, &http.Cookie{Name: "_fbp", MaxAge: -1})
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	require.Equal(t, []string{"lang", "_fbp"}, names(w))
+
+	// consent given during the request applies to the rest of the response
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/?accept", nil))
+	require.Equal(t, []string{"consent", "_ga", "lang", "_fbp"}, names(w))
+
+	r := cookietest.RequestFrom(t, w)
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	require.Equal(t, []string{"_ga", "lang", "_fbp"}, names(w))
+}
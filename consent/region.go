@@ -0,0 +1,74 @@
+package consent
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+)
+
+// WithRegion sets how the region of a request is detected, such as from a
+// country header added by a CDN, for WithRegionDefaults. It returns "" when
+// the region is unknown.
+func WithRegion(region func(*http.Request) string) Option {
+	return func(c *Consent) error {
+		if region == nil {
+			return errors.New("region func is nil")
+		}
+		c.region = region
+		return nil
+	}
+}
+
+// WithDefaults allows the granted categories for visitors who have not made
+// choices, in regions without their own defaults. Without it, only Necessary is.
+func WithDefaults(granted ...Category) Option {
+	return func(c *Consent) error {
+		c.defaults = slices.Clone(granted)
+		return nil
+	}
+}
+
+// WithRegionDefaults replaces the defaults for visitors from region, as detected
+// by WithRegion, who have not made choices. Call it with no categories to allow
+// only Necessary cookies there, as in the EU:
+//
+//	consent.New(mgr, 1,
+//		consent.WithRegion(region),
+//		consent.WithDefaults(consent.Functional, consent.Analytics),
+//		consent.WithRegionDefaults("EU"),
+//	)
+func WithRegionDefaults(region string, granted ...Category) Option {
+	return func(c *Consent) error {
+		if region == "" {
+			return errors.New("empty consent region")
+		}
+		if _, ok := c.regionDefaults[region]; ok {
+			return fmt.Errorf("consent defaults for region '%s' set twice", region)
+		}
+		c.regionDefaults[region] = slices.Clone(granted)
+		return nil
+	}
+}
+
+// Region returns the region of r as detected by WithRegion, or "" without it.
+func (c *Consent) Region(r *http.Request) string {
+	if c.region == nil {
+		return ""
+	}
+	return c.region(r)
+}
+
+// Effective returns the visitor's choices for the current version, or the
+// defaults for the request's region, with a zero Version, if they have made none.
+func (c *Consent) Effective(r *http.Request) Choices {
+	if choices, ok := c.Load(r); ok {
+		return choices
+	}
+	if len(c.regionDefaults) > 0 {
+		if granted, ok := c.regionDefaults[c.Region(r)]; ok {
+			return Choices{Granted: granted}
+		}
+	}
+	return Choices{Granted: c.defaults}
+}
@@ -0,0 +1,67 @@
+package consent
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/grackleclub/cookie/v2"
+	"github.com/grackleclub/cookie/v2/cookietest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegionDefaults(t *testing.T) {
+	eu := map[string]bool{"DE": true, "FR": true}
+	region := func(r *http.Request) string {
+		if eu[r.Header.Get("CF-IPCountry")] {
+			return "EU"
+		}
+		return r.Header.Get("CF-IPCountry")
+	}
+	c, err := New(cookietest.NewManager(t), 1,
+		WithRegion(region),
+		WithDefaults(Functional, Analytics),
+		WithRegionDefaults("EU"),
+		WithRegionDefaults("BR", Functional),
+		WithCookies(Analytics, "_ga"),
+	)
+	require.NoError(t, err)
+
+	from := func(country string) *http.Request {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("CF-IPCountry", country)
+		return r
+	}
+	require.Equal(t, "EU", c.Region(from("DE")))
+	require.True(t, c.Allowed(from("US"), Analytics))
+	require.True(t, c.Allowed(from(""), Analytics))
+	require.False(t, c.Allowed(from("DE"), Analytics))
+	require.False(t, c.Allowed(from("DE"), Functional))
+	require.True(t, c.Allowed(from("BR"), Functional))
+	require.False(t, c.Allowed(from("BR"), Analytics))
+	require.Zero(t, c.Effective(from("US")).Version)
+
+	handler := c.Enforce(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: "_ga", Value: "1"})
+	}))
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, from("US"))
+	require.Equal(t, []string{"_ga"}, names(w))
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, from("FR"))
+	require.Empty(t, names(w))
+
+	// explicit choices win over any default
+	w = httptest.NewRecorder()
+	_, err = c.Save(w, Analytics)
+	require.NoError(t, err)
+	r := cookietest.RequestFrom(t, w)
+	r.Header.Set("CF-IPCountry", "DE")
+	require.True(t, c.Allowed(r, Analytics))
+	require.Equal(t, 1, c.Effective(r).Version)
+
+	_, err = New(cookietest.NewManager(t), 1, WithRegionDefaults("EU"), WithRegionDefaults("EU", Ads))
+	require.ErrorIs(t, err, cookie.ErrInitiation)
+	_, err = New(cookietest.NewManager(t), 1, WithRegion(nil))
+	require.ErrorIs(t, err, cookie.ErrInitiation)
+}
@@ -19,13 +19,29 @@ import (
 	"time"
 )
 
-const secretLength = 32
+const (
+	secretLength    = 32
+	minSecretLength = 16
+	maxCookieSize   = 4096 // conservative limit honored by all major browsers
+)
 
 var (
 	ErrInitiation    = errors.New("initialization failure")
 	ErrEncryption    = errors.New("encryption failure")
 	ErrCookie        = errors.New("cookie failure")
 	ErrSecretMissing = errors.New("secret key is missing")
+	ErrBadKeyLength  = errors.New("secret key must be 16, 24, or 32 bytes")
+	ErrExpired       = errors.New("cookie expired")
+)
+
+// Read and write failures, for telling a missing cookie from a tampered one
+// with errors.Is. Each failure other than ErrNotFound also matches ErrCookie.
+var (
+	ErrNotFound          = errors.New("cookie not found") // also matches http.ErrNoCookie
+	ErrTooShort          = errors.New("value too short")
+	ErrTooLong           = errors.New("cookie value too long")
+	ErrSignatureMismatch = errors.New("signature mismatch")
+	ErrDecryptFailed     = errors.New("unable to decrypt cookie")
 )
 
 // Cookie defines an HTTP cookie. For more information see:
@@ -49,6 +65,10 @@ type Cookie struct {
 	// SameSite allows a server to define a cookie attribute making it impossible for the browser to send this cookie along with cross-site requests.
 	SameSite http.SameSite
 
+	// Partitioned stores the cookie separately per top-level site (CHIPS),
+	// keeping embedded and third-party contexts working. Requires Secure.
+	Partitioned bool
+
 	Raw      string
 	Unparsed []string
 }
@@ -56,8 +76,17 @@ type Cookie struct {
 // NewCookieSecret generates a random secret key for use with signed or encrypted cookies.
 // Assumes secretLength is 32.
 func NewCookieSecret() ([]byte, error) {
-	length := secretLength
-	secret := make([]byte, length)
+	return NewCookieSecretN(secretLength)
+}
+
+// NewCookieSecretN generates a random secret key of n bytes, at least 16.
+// Encryption with AES-GCM needs 16, 24, or 32 bytes, and ChaCha20 ciphers
+// need 32; longer secrets suit signing and WithMasterSecret.
+func NewCookieSecretN(n int) ([]byte, error) {
+	if n < minSecretLength {
+		return nil, fmt.Errorf("secret length %d is less than %d bytes", n, minSecretLength)
+	}
+	secret := make([]byte, n)
 	_, err := rand.Read(secret)
 	if err != nil {
 		return nil, fmt.Errorf("unable to generate random secret: %w", err)
@@ -65,30 +94,67 @@ func NewCookieSecret() ([]byte, error) {
 	return secret, nil
 }
 
-// Write a cookie to the response without any additional modifications
-// and basic length validation
+// ValidateSecret checks that secretKey can encrypt with AES-GCM, failing with
+// ErrSecretMissing if it is empty, or ErrBadKeyLength unless it is 16, 24,
+// or 32 bytes. Call it at startup to catch a misconfigured secret before the
+// first encrypted cookie is written.
+func ValidateSecret(secretKey []byte) error {
+	switch len(secretKey) {
+	case 0:
+		return ErrSecretMissing
+	case 16, 24, 32:
+		return nil
+	default:
+		return fmt.Errorf("%w: got %d bytes", ErrBadKeyLength, len(secretKey))
+	}
+}
+
+// Write a cookie to the response without any additional modifications,
+// with basic length validation and enforcement of __Host- and __Secure- prefixes.
+// Names that are not RFC 6265 tokens fail with ErrInvalidName.
 func Write(w http.ResponseWriter, cookie http.Cookie) error {
+	return write(w, cookie, maxCookieSize, base64.URLEncoding)
+}
+
+// write base64 encodes the cookie value with encoding and sets the cookie,
+// refusing any cookie whose serialized length exceeds maxSize.
+func write(w http.ResponseWriter, cookie http.Cookie, maxSize int, encoding *base64.Encoding) error {
+	if err := validateName(cookie.Name); err != nil {
+		return err
+	}
+	if err := validatePrefix(cookie); err != nil {
+		return err
+	}
+	if cookie.Partitioned && !cookie.Secure {
+		return fmt.Errorf("%w: partitioned cookie '%s' requires Secure", ErrCookie, cookie.Name)
+	}
+	// browsers silently drop SameSite=None cookies without Secure
+	if cookie.SameSite == http.SameSiteNoneMode && !cookie.Secure {
+		return fmt.Errorf("%w: SameSite=None cookie '%s' requires Secure", ErrCookie, cookie.Name)
+	}
+
 	// only a small subset of US ASCII is supported, so we base64 encode
-	cookie.Value = base64.URLEncoding.EncodeToString([]byte(cookie.Value))
+	cookie.Value = encoding.EncodeToString([]byte(cookie.Value))
 
 	// not all browsers will prohibit long cookies, so we set a conservative limit
-	if len(cookie.String()) > 4096 {
-		return fmt.Errorf("%w: cookie value too long", ErrCookie)
+	if len(cookie.String()) > maxSize {
+		return fmt.Errorf("%w: %w", ErrCookie, ErrTooLong)
 	}
 
 	http.SetCookie(w, &cookie)
 	return nil
 }
 
-// Read a basic base64 encoded cookie from the request, returning the decoded string
+// Read a basic base64 encoded cookie from the request, returning the decoded string.
+// Values are accepted with or without base64 padding.
 func Read(r *http.Request, name string) (string, error) {
 	cookie, err := r.Cookie(name)
 	if err != nil {
-		return "", fmt.Errorf("'%s' not found: %w", name, err)
+		return "", fmt.Errorf("%w: '%s': %w", ErrNotFound, name, err)
 	}
-	value, err := base64.URLEncoding.DecodeString(cookie.Value)
+	value, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(cookie.Value, "="))
 	if err != nil {
-		return "", fmt.Errorf("cannot decode (%s=%v): %w", name, cookie.Value, err)
+		return "", fmt.Errorf("cannot decode '%s' value %s: %w", name, redact(cookie.Value), err)
 	}
 	return string(value), nil
 }
@@ -99,11 +165,7 @@ func WriteSigned(w http.ResponseWriter, cookie http.Cookie, secretKey []byte) er
 	if len(secretKey) == 0 {
 		return ErrSecretMissing
 	}
-	mac := hmac.New(sha256.New, secretKey)
-	mac.Write([]byte(cookie.Name))
-	mac.Write([]byte(cookie.Value))
-	signature := mac.Sum(nil)
-	cookie.Value = fmt.Sprintf("%s%s", string(signature), cookie.Value)
+	cookie.Value = sign(cookie.Name, cookie.Value, secretKey)
 	return Write(w, cookie)
 }
 
@@ -117,82 +179,157 @@ func ReadSigned(r *http.Request, name string, secretKey []byte) (string, error)
 	if err != nil {
 		return "", fmt.Errorf("%w: %w", ErrCookie, err)
 	}
+	return verify(name, signedValue, secretKey)
+}
+
+// sign prefixes value with a sha256 HMAC of the cookie name and value.
+func sign(name, value string, secretKey []byte) string {
+	signature := macSum(sha256.New, secretKey, name, value)
+	return fmt.Sprintf("%s%s", string(signature), value)
+}
+
+// verify checks the signature prefix of signedValue, returning the bare value.
+// Values written with an embedded expiry are accepted until that expiry passes.
+func verify(name, signedValue string, secretKey []byte) (string, error) {
+	value, err := verifyMAC(name, signedValue, secretKey)
+	if err == nil {
+		return value, nil
+	}
+	if value, ok, expiryErr := verifyExpiring(name, signedValue, secretKey); ok {
+		return value, expiryErr
+	}
+	return "", err
+}
+
+// verifyMAC checks a value produced by sign.
+func verifyMAC(name, signedValue string, secretKey []byte) (string, error) {
 	if len(signedValue) < sha256.Size {
-		return "", fmt.Errorf("%w: %w", ErrCookie, errors.New("signature wrong length"))
+		return "", fmt.Errorf("%w: %w: signature wrong length", ErrCookie, ErrTooShort)
 	}
 	signature := signedValue[:sha256.Size]
 	value := signedValue[sha256.Size:]
-	mac := hmac.New(sha256.New, secretKey)
-	mac.Write([]byte(name))
-	mac.Write([]byte(value))
-	expectedSignature := mac.Sum(nil)
+	expectedSignature := macSum(sha256.New, secretKey, name, value)
 
 	if !hmac.Equal([]byte(signature), expectedSignature) {
-		return "", fmt.Errorf("%w: %w", ErrCookie, errors.New("signature mismatch"))
+		return "", fmt.Errorf("%w: %w", ErrCookie, ErrSignatureMismatch)
 	}
 	return value, nil
 }
 
+// WriteEncryptedValue writes a cookie to the response with its value AES-GCM encrypted.
+// The value may be any string or byte payload; it cannot be read by the client.
+func WriteEncryptedValue(w http.ResponseWriter, cookie http.Cookie, secretKey []byte) error {
+	if err := ValidateSecret(secretKey); err != nil {
+		return err
+	}
+	encryptedValue, err := encrypt(cookie.Name, cookie.Value, secretKey)
+	if err != nil {
+		return err
+	}
+	cookie.Value = encryptedValue
+	return Write(w, cookie)
+}
+
+// ReadEncryptedValue reads a cookie from the request and decrypts the AES-GCM encrypted value
+// written by WriteEncryptedValue.
+func ReadEncryptedValue(r *http.Request, name string, secretKey []byte) (string, error) {
+	if err := ValidateSecret(secretKey); err != nil {
+		return "", err
+	}
+	return readEncrypted(r, name, [][]byte{secretKey})
+}
+
 // WriteEcrypted writes a cookie to the response with an AES-GCM encrypted value
-// An encrypted cookie cannot be read by the client.
+// in the format 'id:value'. An encrypted cookie cannot be read by the client.
 func WriteEncrypted(w http.ResponseWriter, userID int, cookie http.Cookie, secretKey []byte) error {
+	cookie.Value = fmt.Sprintf("%d:%s", userID, cookie.Value)
+	return WriteEncryptedValue(w, cookie, secretKey)
+}
+
+// ReadEncrypted reads a cookie from the request and decrypts the AES-GCM encrypted value,
+// splitting the 'id:value' plaintext written by WriteEncrypted.
+// An encrypted cookie cannot be read by the client.
+func ReadEncrypted(r *http.Request, name string, secretKey []byte) (int, string, error) {
+	plaintext, err := ReadEncryptedValue(r, name, secretKey)
+	if err != nil {
+		return 0, "", err
+	}
+	return splitUserID(plaintext)
+}
+
+// readEncrypted reads an encrypted cookie, decrypting it with the first matching secret.
+func readEncrypted(r *http.Request, name string, secretKeys [][]byte) (string, error) {
+	encryptedValue, err := Read(r, name)
+	if err != nil {
+		return "", fmt.Errorf("unable to read encrypted cookie: %w", err)
+	}
+	return decryptKeys(name, encryptedValue, secretKeys)
+}
+
+// encrypt seals plaintext with AES-GCM, returning the nonce and ciphertext.
+// The cookie name is authenticated as associated data, so the ciphertext
+// cannot be moved into a cookie with a different name.
+func encrypt(name, plaintext string, secretKey []byte) (string, error) {
 	block, err := aes.NewCipher(secretKey)
 	if err != nil {
-		return fmt.Errorf("unable to create new cypher block for write: %w", err)
+		return "", fmt.Errorf("unable to create new cypher block for write: %w", err)
 	}
 	aesGCM, err := cipher.NewGCM(block)
 	if err != nil {
-		return fmt.Errorf("unable to create new GCM for write: %w", err)
+		return "", fmt.Errorf("unable to create new GCM for write: %w", err)
 	}
 	nonce := make([]byte, aesGCM.NonceSize())
 	_, err = io.ReadFull(rand.Reader, nonce)
 	if err != nil {
-		return fmt.Errorf("unable to read random bytes into nonce: %w", err)
+		return "", fmt.Errorf("unable to read random bytes into nonce: %w", err)
 	}
-	plaintext := fmt.Sprintf("%d:%s", userID, cookie.Value)
-	encryptedValue := aesGCM.Seal(nonce, nonce, []byte(plaintext), nil)
-	cookie.Value = string(encryptedValue)
-	return Write(w, cookie)
+	encryptedValue := aesGCM.Seal(nonce, nonce, []byte(plaintext), []byte(name))
+	return string(encryptedValue), nil
 }
 
-// ReadEncrypted reads a cookie from the request and decrypts the AES-GCM encrypted value
-// An encrypted cookie cannot be read by the client.
-func ReadEncrypted(r *http.Request, name string, secretKey []byte) (int, string, error) {
-	encryptedValue, err := Read(r, name)
-	if err != nil {
-		return 0, "", fmt.Errorf("unable to read encrypted cookie: %w", err)
-	}
+// decrypt opens a value produced by encrypt for the named cookie. Values
+// encrypted before names were bound fail, and must be written again.
+func decrypt(name, encryptedValue string, secretKey []byte) (string, error) {
 	block, err := aes.NewCipher(secretKey)
 	if err != nil {
-		return 0, "", fmt.Errorf("unable to create new cypher block for read: %w", err)
+		return "", fmt.Errorf("unable to create new cypher block for read: %w", err)
 	}
 	aesGCM, err := cipher.NewGCM(block)
 	if err != nil {
-		return 0, "", fmt.Errorf("unable to create new GCM for read: %w", err)
+		return "", fmt.Errorf("unable to create new GCM for read: %w", err)
 	}
 	nonceSize := aesGCM.NonceSize()
 	if len(encryptedValue) < nonceSize {
-		err := errors.New("encrypted value too short")
-		return 0, "", fmt.Errorf("%w: %w", ErrCookie, err)
+		return "", fmt.Errorf("%w: %w: encrypted value", ErrCookie, ErrTooShort)
 	}
 	nonce := encryptedValue[:nonceSize]
 	ciphertext := encryptedValue[nonceSize:]
-	plaintext, err := aesGCM.Open(nil, []byte(nonce), []byte(ciphertext), nil)
+	plaintext, err := aesGCM.Open(nil, []byte(nonce), []byte(ciphertext), []byte(name))
 	if err != nil {
-		return 0, "", fmt.Errorf("unable to decrypt cookie: %w", err)
+		return "", fmt.Errorf("%w: %w: %w", ErrCookie, ErrDecryptFailed, err)
 	}
-	userID, sessionKey, ok := strings.Cut(string(plaintext), ":")
+	return string(plaintext), nil
+}
+
+// splitUserID parses the 'id:sessionKey' plaintext of an encrypted cookie.
+func splitUserID(plaintext string) (int, string, error) {
+	userID, sessionKey, ok := strings.Cut(plaintext, ":")
 	if !ok {
 		err := errors.New("unable to split plaintext")
 		return 0, "", fmt.Errorf("%w: %w", ErrCookie, err)
 	}
 	id, err := strconv.Atoi(userID)
 	if err != nil {
+		// the strconv error quotes its input, so only its cause is kept
+		var numErr *strconv.NumError
+		if errors.As(err, &numErr) {
+			err = numErr.Err
+		}
 		return 0, sessionKey, fmt.Errorf(
-			"%w: invalid id '%v' for user '%s': %w",
+			"%w: invalid id %s for user %s: %w",
 			ErrCookie,
-			userID,
-			sessionKey,
+			redact(userID),
+			redact(sessionKey),
 			err,
 		)
 	}
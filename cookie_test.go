@@ -3,6 +3,7 @@ package cookie
 import (
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -79,3 +80,182 @@ func TestWriteReadEncrypted(t *testing.T) {
 	require.Equal(t, testCookie.Value, sessionKey)
 	t.Logf("wrote and read encrypted cookie for id:%d: %s=%s\n", id, testCookie.Name, sessionKey)
 }
+
+// requestWith returns a request carrying every cookie set on the recorder,
+// omitting any the recorder asked the client to delete.
+func requestWith(w *httptest.ResponseRecorder) *http.Request {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	for _, c := range w.Result().Cookies() {
+		if c.MaxAge < 0 {
+			continue
+		}
+		r.AddCookie(c)
+	}
+	return r
+}
+
+func TestWriteReadEncryptedValue(t *testing.T) {
+	secretKey, err := NewCookieSecret()
+	require.NoError(t, err)
+
+	payload := "no:user:id\x00\xffbinary"
+	c := testCookie
+	c.Value = payload
+
+	w := httptest.NewRecorder()
+	require.NoError(t, WriteEncryptedValue(w, c, secretKey))
+
+	value, err := ReadEncryptedValue(requestWith(w), c.Name, secretKey)
+	require.NoError(t, err)
+	require.Equal(t, payload, value)
+
+	_, err = ReadEncryptedValue(requestWith(w), c.Name, nil)
+	require.ErrorIs(t, err, ErrSecretMissing)
+}
+
+func TestEncryptedNameBinding(t *testing.T) {
+	secretKey, err := NewCookieSecret()
+	require.NoError(t, err)
+
+	// moving a ciphertext into another cookie fails to decrypt
+	encrypted, err := encrypt("a", "admin", secretKey)
+	require.NoError(t, err)
+	_, err = decrypt("b", encrypted, secretKey)
+	require.Error(t, err)
+
+	// values encrypted before names were bound cannot be moved either
+	legacy, err := encrypt("", "admin", secretKey)
+	require.NoError(t, err)
+	_, err = decrypt("b", legacy, secretKey)
+	require.ErrorIs(t, err, ErrDecryptFailed)
+
+	value, err := decrypt("a", encrypted, secretKey)
+	require.NoError(t, err)
+	require.Equal(t, "admin", value)
+}
+
+func TestReadPadding(t *testing.T) {
+	// Write pads for older readers; Managers write without padding
+	padded := httptest.NewRecorder()
+	require.NoError(t, Write(padded, http.Cookie{Name: "a", Value: "ab"}))
+	require.Equal(t, "YWI=", padded.Result().Cookies()[0].Value)
+	value, err := Read(requestWith(padded), "a")
+	require.NoError(t, err)
+	require.Equal(t, "ab", value)
+
+	m := newTestManager(t)
+	w := httptest.NewRecorder()
+	require.NoError(t, m.WriteEncryptedValue(w, "b", "ab"))
+	require.NotContains(t, w.Result().Cookies()[0].Value, "=")
+	value, err = m.ReadEncryptedValue(requestWith(w), "b")
+	require.NoError(t, err)
+	require.Equal(t, "ab", value)
+}
+
+func TestReadErrors(t *testing.T) {
+	secretKey, err := NewCookieSecret()
+	require.NoError(t, err)
+	otherKey, err := NewCookieSecret()
+	require.NoError(t, err)
+
+	// raw returns a request carrying a cookie whose decoded value is value
+	raw := func(value string) *http.Request {
+		w := httptest.NewRecorder()
+		require.NoError(t, Write(w, http.Cookie{Name: testCookie.Name, Value: value}))
+		return requestWith(w)
+	}
+	signed := httptest.NewRecorder()
+	require.NoError(t, WriteSigned(signed, testCookie, secretKey))
+	encrypted := httptest.NewRecorder()
+	require.NoError(t, WriteEncryptedValue(encrypted, testCookie, secretKey))
+
+	_, err = Read(httptest.NewRequest("GET", "/", nil), testCookie.Name)
+	require.ErrorIs(t, err, ErrNotFound)
+	require.ErrorIs(t, err, http.ErrNoCookie)
+	require.NotErrorIs(t, err, ErrCookie)
+
+	for name, tc := range map[string]struct {
+		read   func() error
+		target error
+	}{
+		"short signature": {func() error {
+			_, err := ReadSigned(raw("short"), testCookie.Name, secretKey)
+			return err
+		}, ErrTooShort},
+		"tampered": {func() error {
+			_, err := ReadSigned(requestWith(signed), testCookie.Name, otherKey)
+			return err
+		}, ErrSignatureMismatch},
+		"short ciphertext": {func() error {
+			_, err := ReadEncryptedValue(raw("short"), testCookie.Name, secretKey)
+			return err
+		}, ErrTooShort},
+		"wrong key": {func() error {
+			_, err := ReadEncryptedValue(requestWith(encrypted), testCookie.Name, otherKey)
+			return err
+		}, ErrDecryptFailed},
+		"too long": {func() error {
+			return Write(httptest.NewRecorder(), http.Cookie{Name: "a", Value: strings.Repeat("x", maxCookieSize)})
+		}, ErrTooLong},
+	} {
+		t.Run(name, func(t *testing.T) {
+			err := tc.read()
+			require.ErrorIs(t, err, tc.target)
+			require.ErrorIs(t, err, ErrCookie)
+		})
+	}
+}
+
+func TestManagerReadErrors(t *testing.T) {
+	m := newTestManager(t)
+
+	w := httptest.NewRecorder()
+	require.NoError(t, m.WriteSigned(w, "signed", "chocolate fudge"))
+	require.NoError(t, m.WriteEncryptedValue(w, "encrypted", "oatmeal raisin"))
+	r := requestWith(w)
+
+	_, err := m.ReadSigned(httptest.NewRequest("GET", "/", nil), "signed")
+	require.ErrorIs(t, err, ErrNotFound)
+	_, err = m.ReadSigned(r, "encrypted")
+	require.ErrorIs(t, err, ErrCookie)
+
+	// a value under a known key ID with a corrupted body
+	raw, err := Read(r, "signed")
+	require.NoError(t, err)
+	tampered := httptest.NewRecorder()
+	require.NoError(t, Write(tampered, http.Cookie{Name: "signed", Value: raw[:len(raw)-1] + "!"}))
+	_, err = m.ReadSigned(requestWith(tampered), "signed")
+	require.ErrorIs(t, err, ErrSignatureMismatch)
+
+	raw, err = Read(r, "encrypted")
+	require.NoError(t, err)
+	tampered = httptest.NewRecorder()
+	require.NoError(t, Write(tampered, http.Cookie{Name: "encrypted", Value: raw[:len(raw)-1] + "!"}))
+	_, err = m.ReadEncryptedValue(requestWith(tampered), "encrypted")
+	require.ErrorIs(t, err, ErrDecryptFailed)
+}
+
+func TestValidateSecret(t *testing.T) {
+	for _, n := range []int{16, 24, 32} {
+		require.NoError(t, ValidateSecret(make([]byte, n)))
+	}
+	require.ErrorIs(t, ValidateSecret(nil), ErrSecretMissing)
+	err := ValidateSecret([]byte("short"))
+	require.ErrorIs(t, err, ErrBadKeyLength)
+	require.ErrorContains(t, err, "got 5 bytes")
+
+	testCookie := http.Cookie{Name: "test", Value: "x"}
+	err = WriteEncryptedValue(httptest.NewRecorder(), testCookie, []byte("short"))
+	require.ErrorIs(t, err, ErrBadKeyLength)
+	_, err = ReadEncryptedValue(httptest.NewRequest(http.MethodGet, "/", nil), "test", make([]byte, 20))
+	require.ErrorIs(t, err, ErrBadKeyLength)
+	_, _, err = ReadEncryptedKeys(httptest.NewRequest(http.MethodGet, "/", nil), "test", [][]byte{make([]byte, 32), make([]byte, 20)})
+	require.ErrorIs(t, err, ErrBadKeyLength)
+
+	m, err := New(WithSecret([]byte("short")))
+	require.NoError(t, err)
+	require.ErrorIs(t, m.WriteEncryptedValue(httptest.NewRecorder(), "test", "x"), ErrBadKeyLength)
+	m, err = New(WithSecret(make([]byte, 16)), WithCipher(XChaCha20Poly1305))
+	require.NoError(t, err)
+	require.ErrorIs(t, m.WriteEncryptedValue(httptest.NewRecorder(), "test", "x"), ErrBadKeyLength)
+}
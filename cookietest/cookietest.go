@@ -0,0 +1,193 @@
+// package cookietest provides helpers for testing handlers that use package cookie:
+// forging valid cookies from a secret or Manager, attaching them to requests,
+// and reading back and checking the cookies set on a ResponseRecorder.
+package cookietest
+
+import (
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/grackleclub/cookie/v2"
+)
+
+// NewManager returns a Manager holding a new random secret, with opts applied.
+func NewManager(tb testing.TB, opts ...cookie.Option) *cookie.Manager {
+	tb.Helper()
+	secretKey, err := cookie.NewCookieSecret()
+	if err != nil {
+		tb.Fatalf("cookietest: unable to create secret: %v", err)
+	}
+	m, err := cookie.New(append([]cookie.Option{cookie.WithSecret(secretKey)}, opts...)...)
+	if err != nil {
+		tb.Fatalf("cookietest: unable to create manager: %v", err)
+	}
+	return m
+}
+
+// Signed returns a cookie as written by cookie.WriteSigned with secretKey.
+func Signed(tb testing.TB, name, value string, secretKey []byte) *http.Cookie {
+	tb.Helper()
+	rec := httptest.NewRecorder()
+	if err := cookie.WriteSigned(rec, http.Cookie{Name: name, Value: value}, secretKey); err != nil {
+		tb.Fatalf("cookietest: unable to sign '%s': %v", name, err)
+	}
+	return Find(tb, rec, name)
+}
+
+// Encrypted returns a cookie as written by cookie.WriteEncryptedValue with secretKey.
+func Encrypted(tb testing.TB, name, value string, secretKey []byte) *http.Cookie {
+	tb.Helper()
+	rec := httptest.NewRecorder()
+	if err := cookie.WriteEncryptedValue(rec, http.Cookie{Name: name, Value: value}, secretKey); err != nil {
+		tb.Fatalf("cookietest: unable to encrypt '%s': %v", name, err)
+	}
+	return Find(tb, rec, name)
+}
+
+// Forge returns the cookie m writes for name and value in mode, including
+// the Manager's name prefix and default attributes.
+func Forge(tb testing.TB, m *cookie.Manager, name, value string, mode cookie.Mode) *http.Cookie {
+	tb.Helper()
+	rec := httptest.NewRecorder()
+	var err error
+	switch mode {
+	case cookie.Plain:
+		err = m.Write(rec, name, value)
+	case cookie.Signed:
+		err = m.WriteSigned(rec, name, value)
+	case cookie.Encrypted:
+		err = m.WriteEncryptedValue(rec, name, value)
+	default:
+		tb.Fatalf("cookietest: unsupported mode %s", mode)
+	}
+	if err != nil {
+		tb.Fatalf("cookietest: unable to write '%s': %v", name, err)
+	}
+	cookies := SetCookies(tb, rec)
+	if len(cookies) != 1 {
+		tb.Fatalf("cookietest: '%s' was written as %d cookies; use RequestFrom for chunked values", name, len(cookies))
+	}
+	return cookies[0]
+}
+
+// NewRequest returns a GET request for target carrying cookies. Targets starting
+// with https:// arrive over TLS, as cookies with a __Host- or __Secure- prefix require.
+func NewRequest(target string, cookies ...*http.Cookie) *http.Request {
+	r := httptest.NewRequest(http.MethodGet, target, nil)
+	for _, c := range cookies {
+		r.AddCookie(c)
+	}
+	return r
+}
+
+// RequestFrom returns a GET request over HTTPS carrying every cookie set on rec
+// that the browser would keep, as the client's next request would.
+func RequestFrom(tb testing.TB, rec *httptest.ResponseRecorder) *http.Request {
+	tb.Helper()
+	var live []*http.Cookie
+	for _, c := range SetCookies(tb, rec) {
+		if c.MaxAge >= 0 {
+			live = append(live, c)
+		}
+	}
+	return NewRequest("https://example.com/", live...)
+}
+
+// SetCookies parses every Set-Cookie header on rec. Unlike rec.Result().Cookies(),
+// it sees headers set after an earlier call.
+func SetCookies(tb testing.TB, rec *httptest.ResponseRecorder) []*http.Cookie {
+	tb.Helper()
+	var cookies []*http.Cookie
+	for _, line := range rec.Header().Values("Set-Cookie") {
+		c, err := http.ParseSetCookie(line)
+		if err != nil {
+			tb.Fatalf("cookietest: invalid Set-Cookie %q: %v", line, err)
+		}
+		cookies = append(cookies, c)
+	}
+	return cookies
+}
+
+// Find returns the last cookie named name set on rec, failing the test if there is none.
+func Find(tb testing.TB, rec *httptest.ResponseRecorder, name string) *http.Cookie {
+	tb.Helper()
+	cookies := SetCookies(tb, rec)
+	for i := len(cookies) - 1; i >= 0; i-- {
+		if cookies[i].Name == name {
+			return cookies[i]
+		}
+	}
+	tb.Fatalf("cookietest: no cookie '%s' set", name)
+	return nil
+}
+
+// Decode returns the base64-decoded value of c, as written by cookie.Write.
+// Values written by a Manager carry a binary header; read those back through
+// the Manager with RequestFrom instead.
+func Decode(tb testing.TB, c *http.Cookie) string {
+	tb.Helper()
+	value, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(c.Value, "="))
+	if err != nil {
+		tb.Fatalf("cookietest: unable to decode '%s': %v", c.Name, err)
+	}
+	return string(value)
+}
+
+// Clock is a cookie.Clock moved by hand, for testing expiry without waiting.
+// It is safe for concurrent use.
+type Clock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+// NewClock returns a Clock stopped at now.
+func NewClock(now time.Time) *Clock {
+	return &Clock{now: now}
+}
+
+// Now returns the time the clock is stopped at.
+func (c *Clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// Advance moves the clock by d, which may be negative.
+func (c *Clock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// Attributes are the attributes of a Set-Cookie header checked by RequireAttributes.
+type Attributes struct {
+	Path        string
+	Domain      string
+	MaxAge      int
+	Secure      bool
+	HttpOnly    bool
+	SameSite    http.SameSite
+	Partitioned bool
+}
+
+// RequireAttributes fails the test unless every attribute of c equals want.
+func RequireAttributes(tb testing.TB, c *http.Cookie, want Attributes) {
+	tb.Helper()
+	got := Attributes{
+		Path:        c.Path,
+		Domain:      c.Domain,
+		MaxAge:      c.MaxAge,
+		Secure:      c.Secure,
+		HttpOnly:    c.HttpOnly,
+		SameSite:    c.SameSite,
+		Partitioned: c.Partitioned,
+	}
+	if got != want {
+		tb.Fatalf("cookietest: '%s' attributes\n got: %+v\nwant: %+v", c.Name, got, want)
+	}
+}
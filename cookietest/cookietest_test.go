@@ -0,0 +1,93 @@
+package cookietest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/grackleclub/cookie/v2"
+	"github.com/stretchr/testify/require"
+)
+
+func TestForgedCookies(t *testing.T) {
+	secretKey, err := cookie.NewCookieSecret()
+	require.NoError(t, err)
+
+	r := NewRequest("/",
+		Signed(t, "signed", "chocolate fudge", secretKey),
+		Encrypted(t, "encrypted", "oatmeal raisin", secretKey),
+	)
+	value, err := cookie.ReadSigned(r, "signed", secretKey)
+	require.NoError(t, err)
+	require.Equal(t, "chocolate fudge", value)
+	value, err = cookie.ReadEncryptedValue(r, "encrypted", secretKey)
+	require.NoError(t, err)
+	require.Equal(t, "oatmeal raisin", value)
+}
+
+func TestForge(t *testing.T) {
+	secretKey, err := cookie.NewCookieSecret()
+	require.NoError(t, err)
+	m, err := cookie.New(cookie.WithSecret(secretKey), cookie.WithPrefix(cookie.HostPrefix))
+	require.NoError(t, err)
+
+	for _, mode := range []cookie.Mode{cookie.Plain, cookie.Signed, cookie.Encrypted} {
+		c := Forge(t, m, "user", "alice", mode)
+		require.Equal(t, "__Host-user", c.Name)
+		r := NewRequest("https://example.com/", c)
+		var value string
+		switch mode {
+		case cookie.Plain:
+			value, err = m.Read(r, "user")
+		case cookie.Signed:
+			value, err = m.ReadSigned(r, "user")
+		case cookie.Encrypted:
+			value, err = m.ReadEncryptedValue(r, "user")
+		}
+		require.NoError(t, err)
+		require.Equal(t, "alice", value)
+	}
+}
+
+func TestRecorder(t *testing.T) {
+	m, err := cookie.New(cookie.WithMaxAge(time.Hour))
+	require.NoError(t, err)
+
+	rec := httptest.NewRecorder()
+	require.NoError(t, m.Write(rec, "theme", "dark"))
+	require.Len(t, rec.Result().Cookies(), 1)
+	require.NoError(t, m.Write(rec, "lang", "en"))
+	m.Delete(rec, "gone")
+
+	// headers set after rec.Result are still seen
+	require.Len(t, SetCookies(t, rec), 3)
+	c := Find(t, rec, "lang")
+	RequireAttributes(t, c, Attributes{
+		Path:     "/",
+		MaxAge:   3600,
+		Secure:   true,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	r := RequestFrom(t, rec)
+	require.Len(t, r.Cookies(), 2)
+	require.NotNil(t, r.TLS)
+	value, err := m.Read(r, "theme")
+	require.NoError(t, err)
+	require.Equal(t, "dark", value)
+
+	rec = httptest.NewRecorder()
+	require.NoError(t, cookie.Write(rec, http.Cookie{Name: "plain", Value: "chocolate fudge"}))
+	require.Equal(t, "chocolate fudge", Decode(t, Find(t, rec, "plain")))
+}
+
+func TestClock(t *testing.T) {
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := NewClock(start)
+	m := NewManager(t, cookie.WithClock(clock))
+	require.Equal(t, start, m.Now())
+	clock.Advance(time.Hour)
+	require.Equal(t, start.Add(time.Hour), m.Now())
+}
@@ -0,0 +1,220 @@
+// package csrf implements the signed double-submit cookie pattern:
+// a random token, HMAC-bound to the session, is set in a cookie and must be
+// echoed back in a header or form field on every state-changing request.
+package csrf
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"html/template"
+	"net/http"
+	"strings"
+
+	"github.com/grackleclub/cookie/v2"
+)
+
+const (
+	HeaderName  = "X-CSRF-Token" // request header checked for the token
+	FieldName   = "csrf_token"   // form field checked for the token
+	defaultName = "csrf"
+	nonceLength = 32
+)
+
+var (
+	ErrTokenMissing = errors.New("csrf token missing")
+	ErrTokenInvalid = errors.New("csrf token invalid")
+)
+
+type contextKey struct{}
+
+// CSRF issues and verifies tokens.
+type CSRF struct {
+	cookies      *cookie.Manager
+	secretKey    []byte
+	name         string
+	sessionID    func(*http.Request) string
+	errorHandler http.Handler
+}
+
+// Option configures a CSRF.
+type Option func(*CSRF) error
+
+// New creates a CSRF that signs tokens with secretKey and writes the token
+// cookie with the cookie.Manager's defaults.
+func New(cookies *cookie.Manager, secretKey []byte, opts ...Option) (*CSRF, error) {
+	if cookies == nil {
+		return nil, fmt.Errorf("%w: cookie manager is required", cookie.ErrInitiation)
+	}
+	if len(secretKey) == 0 {
+		return nil, fmt.Errorf("%w: %w", cookie.ErrInitiation, cookie.ErrSecretMissing)
+	}
+	c := &CSRF{
+		cookies:      cookies,
+		secretKey:    secretKey,
+		name:         defaultName,
+		sessionID:    func(*http.Request) string { return "" },
+		errorHandler: http.HandlerFunc(forbidden),
+	}
+	for _, opt := range opts {
+		if err := opt(c); err != nil {
+			return nil, fmt.Errorf("%w: %w", cookie.ErrInitiation, err)
+		}
+	}
+	return c, nil
+}
+
+// WithName sets the name of the token cookie.
+func WithName(name string) Option {
+	return func(c *CSRF) error {
+		if name == "" {
+			return errors.New("empty csrf cookie name")
+		}
+		c.name = name
+		return nil
+	}
+}
+
+// WithSessionID binds tokens to the session identified by fn, so a token
+// issued to one session is rejected in any other.
+func WithSessionID(fn func(*http.Request) string) Option {
+	return func(c *CSRF) error {
+		if fn == nil {
+			return errors.New("session id func is nil")
+		}
+		c.sessionID = fn
+		return nil
+	}
+}
+
+// WithErrorHandler sets the handler called when a request fails verification.
+// The failure is available from Failure. The default responds 403 Forbidden.
+func WithErrorHandler(h http.Handler) Option {
+	return func(c *CSRF) error {
+		if h == nil {
+			return errors.New("error handler is nil")
+		}
+		c.errorHandler = h
+		return nil
+	}
+}
+
+// Protect ensures every request carries a valid token cookie, and rejects
+// state-changing requests whose header or form token does not match it.
+func (c *CSRF) Protect(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sessionID := c.sessionID(r)
+		token, err := c.cookies.Read(r, c.name)
+		if err != nil || !c.valid(token, sessionID) {
+			token, err = c.newToken(sessionID)
+			if err != nil {
+				http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+				return
+			}
+			if err := c.cookies.Write(w, c.name, token); err != nil {
+				http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+				return
+			}
+			// a freshly issued token cannot have been submitted with this request
+			if !safeMethod(r.Method) {
+				c.fail(w, r, token, ErrTokenMissing)
+				return
+			}
+		}
+		if !safeMethod(r.Method) {
+			if err := c.check(r, token); err != nil {
+				c.fail(w, r, token, err)
+				return
+			}
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), contextKey{}, token)))
+	})
+}
+
+// Token returns the token for the request, for embedding in pages or
+// JavaScript headers. It is empty outside of Protect.
+func Token(r *http.Request) string {
+	token, _ := r.Context().Value(contextKey{}).(string)
+	return token
+}
+
+// TemplateField returns a hidden form input carrying the request's token.
+func TemplateField(r *http.Request) template.HTML {
+	return template.HTML(fmt.Sprintf(
+		`<input type="hidden" name="%s" value="%s">`,
+		FieldName,
+		template.HTMLEscapeString(Token(r)),
+	))
+}
+
+type failureKey struct{}
+
+// Failure returns why Protect rejected the request, for use in error handlers.
+func Failure(r *http.Request) error {
+	err, _ := r.Context().Value(failureKey{}).(error)
+	return err
+}
+
+// check compares the submitted token against the cookie token.
+func (c *CSRF) check(r *http.Request, token string) error {
+	submitted := r.Header.Get(HeaderName)
+	if submitted == "" {
+		submitted = r.PostFormValue(FieldName)
+	}
+	if submitted == "" {
+		return ErrTokenMissing
+	}
+	if !hmac.Equal([]byte(submitted), []byte(token)) {
+		return ErrTokenInvalid
+	}
+	return nil
+}
+
+func (c *CSRF) fail(w http.ResponseWriter, r *http.Request, token string, err error) {
+	ctx := context.WithValue(r.Context(), contextKey{}, token)
+	ctx = context.WithValue(ctx, failureKey{}, err)
+	c.errorHandler.ServeHTTP(w, r.WithContext(ctx))
+}
+
+// newToken returns 'nonce.mac', where mac binds the nonce to the session.
+func (c *CSRF) newToken(sessionID string) (string, error) {
+	nonce := make([]byte, nonceLength)
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("unable to generate csrf nonce: %w", err)
+	}
+	encoded := base64.RawURLEncoding.EncodeToString(nonce)
+	return encoded + "." + c.mac(sessionID, encoded), nil
+}
+
+// valid reports whether token was issued by this CSRF for the session.
+func (c *CSRF) valid(token, sessionID string) bool {
+	nonce, mac, ok := strings.Cut(token, ".")
+	if !ok {
+		return false
+	}
+	return hmac.Equal([]byte(mac), []byte(c.mac(sessionID, nonce)))
+}
+
+func (c *CSRF) mac(sessionID, nonce string) string {
+	mac := hmac.New(sha256.New, c.secretKey)
+	mac.Write([]byte(sessionID))
+	mac.Write([]byte{0})
+	mac.Write([]byte(nonce))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func safeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return true
+	}
+	return false
+}
+
+func forbidden(w http.ResponseWriter, r *http.Request) {
+	http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+}
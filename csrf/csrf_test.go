@@ -0,0 +1,92 @@
+package csrf
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/grackleclub/cookie/v2"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestCSRF(t *testing.T, opts ...Option) *CSRF {
+	t.Helper()
+	secretKey, err := cookie.NewCookieSecret()
+	require.NoError(t, err)
+	cookies, err := cookie.New()
+	require.NoError(t, err)
+	c, err := New(cookies, secretKey, opts...)
+	require.NoError(t, err)
+	return c
+}
+
+func TestProtect(t *testing.T) {
+	session := "alice"
+	c := newTestCSRF(t, WithSessionID(func(*http.Request) string { return session }))
+
+	var seen string
+	h := c.Protect(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = Token(r)
+	}))
+
+	// safe request issues a token
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	require.NotEmpty(t, seen)
+	tokenCookie := w.Result().Cookies()[0]
+
+	post := func(header, field string) *httptest.ResponseRecorder {
+		form := url.Values{}
+		if field != "" {
+			form.Set(FieldName, field)
+		}
+		r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(form.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		if header != "" {
+			r.Header.Set(HeaderName, header)
+		}
+		r.AddCookie(tokenCookie)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w
+	}
+
+	require.Equal(t, http.StatusOK, post(seen, "").Code)
+	require.Equal(t, http.StatusOK, post("", seen).Code)
+	require.Equal(t, http.StatusForbidden, post("", "").Code)
+	require.Equal(t, http.StatusForbidden, post("forged", "").Code)
+
+	// a token issued to one session is useless in another
+	session = "mallory"
+	require.Equal(t, http.StatusForbidden, post(seen, "").Code)
+
+	// a post without any cookie is rejected
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/", nil))
+	require.Equal(t, http.StatusForbidden, w.Code)
+}
+
+func TestTemplateField(t *testing.T) {
+	c := newTestCSRF(t)
+	var field string
+	h := c.Protect(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		field = string(TemplateField(r))
+	}))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	require.True(t, strings.HasPrefix(field, `<input type="hidden" name="csrf_token" value="`))
+}
+
+func TestErrorHandler(t *testing.T) {
+	var failure error
+	c := newTestCSRF(t, WithErrorHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		failure = Failure(r)
+		w.WriteHeader(http.StatusTeapot)
+	})))
+	w := httptest.NewRecorder()
+	c.Protect(http.NotFoundHandler()).ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/", nil))
+	require.Equal(t, http.StatusTeapot, w.Code)
+	require.ErrorIs(t, failure, ErrTokenMissing)
+}
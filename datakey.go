@@ -0,0 +1,74 @@
+package cookie
+
+import (
+	"crypto/cipher"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+)
+
+// dataKeyLength is the size of a per-value data key, suiting every Cipher.
+const dataKeyLength = 32
+
+// WithDataKeys encrypts the named cookies, or every encrypted cookie when no
+// names are given, under a fresh random data key per value. The data key is
+// wrapped by the secret and carried in the envelope, so each secret encrypts
+// only keys, and a nonce repeated under one data key exposes a single value.
+// Values cost 60 more bytes with AES-GCM. Reading needs no option: any
+// Manager holding the secret reads values with or without a data key.
+// Values encrypted by a custom Encrypter are unaffected.
+func WithDataKeys(names ...string) Option {
+	return func(m *Manager) error {
+		if slices.Contains(names, "") {
+			return fmt.Errorf("empty data key cookie name")
+		}
+		m.dataKeys = append([]string{}, names...)
+		return nil
+	}
+}
+
+// usesDataKey reports whether the cookie with the full name is encrypted under a data key.
+func (m *Manager) usesDataKey(name string) bool {
+	if m.dataKeys == nil {
+		return false
+	}
+	return len(m.dataKeys) == 0 || slices.Contains(m.dataKeys, strings.TrimPrefix(name, m.prefix))
+}
+
+// newDataKey returns a fresh data key for c, wrapped by master with
+// associated data ad, and an AEAD keyed with it.
+func (m *Manager) newDataKey(c Cipher, master cipher.AEAD, ad []byte) ([]byte, cipher.AEAD, error) {
+	key := make([]byte, dataKeyLength)
+	nonce := make([]byte, master.NonceSize())
+	if _, err := io.ReadFull(m.random(), key); err != nil {
+		return nil, nil, fmt.Errorf("unable to read random bytes into data key: %w", err)
+	}
+	if _, err := io.ReadFull(m.random(), nonce); err != nil {
+		return nil, nil, fmt.Errorf("unable to read random bytes into nonce: %w", err)
+	}
+	aead, err := c.aead(key)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: unable to create %s for data key: %w", ErrEncryption, c, err)
+	}
+	return master.Seal(nonce, nonce, key, ad), aead, nil
+}
+
+// openDataKey unwraps the data key at the start of body with master, returning
+// an AEAD keyed with it and the rest of body.
+func openDataKey(c Cipher, master cipher.AEAD, body string, ad []byte) (cipher.AEAD, string, error) {
+	size := master.NonceSize() + dataKeyLength + master.Overhead()
+	if len(body) < size {
+		return nil, "", fmt.Errorf("%w: %w: wrapped data key", ErrCookie, ErrTooShort)
+	}
+	nonce, wrapped := body[:master.NonceSize()], body[master.NonceSize():size]
+	key, err := master.Open(nil, []byte(nonce), []byte(wrapped), ad)
+	if err != nil {
+		return nil, "", fmt.Errorf("%w: %w: data key: %w", ErrCookie, ErrDecryptFailed, err)
+	}
+	aead, err := c.aead(key)
+	if err != nil {
+		return nil, "", fmt.Errorf("%w: unsupported cipher %s: %w", ErrCookie, c, err)
+	}
+	return aead, body[size:], nil
+}
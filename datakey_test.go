@@ -0,0 +1,81 @@
+package cookie
+
+import (
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDataKeys(t *testing.T) {
+	secretKey, err := NewCookieSecret()
+	require.NoError(t, err)
+	plain, err := New(WithSecret(secretKey))
+	require.NoError(t, err)
+
+	for c, overhead := range map[Cipher]int{AESGCM: 60, ChaCha20Poly1305: 60, XChaCha20Poly1305: 72} {
+		t.Run(c.String(), func(t *testing.T) {
+			m, err := New(WithSecret(secretKey), WithCipher(c), WithDataKeys())
+			require.NoError(t, err)
+			w := httptest.NewRecorder()
+			require.NoError(t, m.WriteEncryptedValue(w, "user", "alice"))
+			without, err := New(WithSecret(secretKey), WithCipher(c))
+			require.NoError(t, err)
+			w2 := httptest.NewRecorder()
+			require.NoError(t, without.WriteEncryptedValue(w2, "user", "alice"))
+			require.Equal(t, overhead, decodedLen(t, w)-decodedLen(t, w2))
+
+			// reading needs only the secret
+			value, err := plain.ReadEncryptedValue(requestWith(w), "user")
+			require.NoError(t, err)
+			require.Equal(t, "alice", value)
+		})
+	}
+}
+
+func TestDataKeysNamed(t *testing.T) {
+	m := newTestManager(t, WithPrefix(SecurePrefix), WithDataKeys("session"))
+	require.True(t, m.usesDataKey("__Secure-session"))
+	require.False(t, m.usesDataKey("__Secure-theme"))
+	require.False(t, newTestManager(t).usesDataKey("session"))
+
+	_, err := New(WithSecret(make([]byte, 32)), WithDataKeys(""))
+	require.ErrorIs(t, err, ErrInitiation)
+}
+
+func TestDataKeysTampered(t *testing.T) {
+	m := newTestManager(t, WithDataKeys())
+	w := httptest.NewRecorder()
+	require.NoError(t, m.WriteEncryptedValue(w, "user", "alice"))
+	value := w.Result().Cookies()[0].Value
+
+	for name, forged := range map[string]string{
+		"truncated": value[:20],
+		"wrapped":   value[:16] + strings.Map(flip, value[16:20]) + value[20:],
+	} {
+		t.Run(name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.AddCookie(&http.Cookie{Name: "user", Value: forged})
+			_, err := m.ReadEncryptedValue(r, "user")
+			require.ErrorIs(t, err, ErrCookie)
+		})
+	}
+}
+
+func decodedLen(t *testing.T, w *httptest.ResponseRecorder) int {
+	t.Helper()
+	value, err := base64.RawURLEncoding.DecodeString(w.Result().Cookies()[0].Value)
+	require.NoError(t, err)
+	return len(value)
+}
+
+// flip changes a base64 character to another.
+func flip(r rune) rune {
+	if r == 'A' {
+		return 'B'
+	}
+	return 'A'
+}
@@ -0,0 +1,44 @@
+package cookie
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Delete tells the client to delete the named cookie. Browsers only delete a
+// cookie whose Path, Domain, and Secure attributes match those it was set with,
+// so pass the same options used to write it; unset options take the defaults of New.
+func Delete(w http.ResponseWriter, name string, opts ...Option) error {
+	m, err := New(opts...)
+	if err != nil {
+		return fmt.Errorf("unable to delete '%s': %w", name, err)
+	}
+	m.Delete(w, name)
+	return nil
+}
+
+// Delete tells the client to delete the named cookie, mirroring the Manager's
+// default scope so it matches the cookie as written. Within Track, a cookie
+// written earlier, in this request or another, is deleted with the scope it
+// was last written with.
+// If chunking is enabled, the chunked form of the cookie is deleted too.
+func (m *Manager) Delete(w http.ResponseWriter, name string) {
+	cookie, ok := written(w, m.prefix+name)
+	if !ok {
+		cookie = m.Cookie(name, "")
+	}
+	expire(w, cookie)
+	if m.chunkLimit > 0 {
+		cookie.Name = chunkName(cookie.Name, 0)
+		expire(w, cookie)
+	}
+}
+
+// expire sets cookie with attributes that delete it in every browser.
+func expire(w http.ResponseWriter, cookie http.Cookie) {
+	cookie.Value = ""
+	cookie.MaxAge = -1
+	cookie.Expires = time.Unix(0, 0)
+	http.SetCookie(w, &cookie)
+}
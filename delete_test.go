@@ -0,0 +1,34 @@
+package cookie
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDelete(t *testing.T) {
+	w := httptest.NewRecorder()
+	require.NoError(t, Delete(w, "a", WithPath("/admin"), WithDomain("example.com")))
+
+	deleted := w.Result().Cookies()[0]
+	require.Equal(t, "a", deleted.Name)
+	require.Equal(t, "/admin", deleted.Path)
+	require.Equal(t, "example.com", deleted.Domain)
+	require.True(t, deleted.Secure)
+	require.Equal(t, -1, deleted.MaxAge)
+	require.Contains(t, w.Header().Get("Set-Cookie"), "Expires=Thu, 01 Jan 1970")
+
+	require.ErrorIs(t, Delete(httptest.NewRecorder(), "a", WithMaxSize(-1)), ErrInitiation)
+}
+
+func TestManagerDelete(t *testing.T) {
+	m := newTestManager(t, WithPrefix(HostPrefix), WithChunking(16*1024))
+	w := httptest.NewRecorder()
+	m.Delete(w, "a")
+
+	cookies := w.Result().Cookies()
+	require.Len(t, cookies, 2)
+	require.Equal(t, "__Host-a", cookies[0].Name)
+	require.Equal(t, "__Host-a.0", cookies[1].Name)
+}
@@ -0,0 +1,112 @@
+package cookie
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io"
+
+	"golang.org/x/crypto/hkdf"
+)
+
+// key purposes, used as HKDF info labels so derived keys never overlap
+const (
+	purposeSign    = "sign"
+	purposeEncrypt = "encrypt"
+)
+
+// deriveInfo namespaces HKDF info strings to this package.
+const deriveInfo = "github.com/grackleclub/cookie/v2 "
+
+// DeriveKey derives a 32-byte subkey from a master secret with HKDF-SHA256.
+// Distinct purpose and name labels yield independent keys, so one master secret
+// can safely serve signing, encryption, and individual cookies.
+// An empty name derives a key shared by all cookies for the purpose.
+func DeriveKey(master []byte, purpose, name string) ([]byte, error) {
+	if len(master) == 0 {
+		return nil, ErrSecretMissing
+	}
+	info := deriveInfo + purpose
+	if name != "" {
+		info += " " + name
+	}
+	key := make([]byte, secretLength)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, master, nil, []byte(info)), key); err != nil {
+		return nil, fmt.Errorf("unable to derive %s key: %w", purpose, err)
+	}
+	return key, nil
+}
+
+// WithMasterSecret configures the Manager from master secrets, newest first,
+// deriving separate signing and encryption keys for each cookie name with DeriveKey.
+// Rotation works as with WithSecrets.
+func WithMasterSecret(masters ...[]byte) Option {
+	return func(m *Manager) error {
+		if err := WithSecrets(masters...)(m); err != nil {
+			return err
+		}
+		m.derive = true
+		return nil
+	}
+}
+
+// writeKey returns the newest key for purpose and the named cookie,
+// with the ID of the secret it came from.
+func (m *Manager) writeKey(purpose, name string) ([]byte, []byte, error) {
+	id, secretKey, err := m.currentKey()
+	if err != nil {
+		return nil, nil, err
+	}
+	if !m.derive {
+		return secretKey, id, nil
+	}
+	key, err := m.cache.derive(secretKey, purpose, name)
+	return key, id, err
+}
+
+// readKeys returns every key for purpose and the named cookie, newest first.
+func (m *Manager) readKeys(purpose, name string) ([][]byte, error) {
+	secretKeys, err := m.secrets()
+	if err != nil {
+		return nil, err
+	}
+	if !m.derive {
+		return secretKeys, nil
+	}
+	keys := make([][]byte, len(secretKeys))
+	for i, master := range secretKeys {
+		key, err := m.cache.derive(master, purpose, name)
+		if err != nil {
+			return nil, err
+		}
+		keys[i] = key
+	}
+	return keys, nil
+}
+
+// keyFor returns the key for purpose and the named cookie whose secret matches id.
+func (m *Manager) keyFor(purpose, name string, id []byte) ([]byte, error) {
+	if ring, ok := m.source.(*KeyRing); ok {
+		secretKey, ok := ring.lookup(id)
+		if !ok {
+			return nil, fmt.Errorf("%w: %w %q", ErrCookie, ErrUnknownKey, id)
+		}
+		if !m.derive {
+			return secretKey, nil
+		}
+		return m.cache.derive(secretKey, purpose, name)
+	}
+	secretKeys, err := m.secrets()
+	if err != nil {
+		return nil, err
+	}
+	for _, secretKey := range secretKeys {
+		if !hasKeyID(secretKey, id) {
+			continue
+		}
+		if !m.derive {
+			return secretKey, nil
+		}
+		return m.cache.derive(secretKey, purpose, name)
+	}
+	return nil, fmt.Errorf("%w: %w %x", ErrCookie, ErrUnknownKey, id)
+}
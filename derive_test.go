@@ -0,0 +1,65 @@
+package cookie
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeriveKey(t *testing.T) {
+	master, err := NewCookieSecret()
+	require.NoError(t, err)
+
+	sign, err := DeriveKey(master, purposeSign, "")
+	require.NoError(t, err)
+	require.Len(t, sign, secretLength)
+	again, err := DeriveKey(master, purposeSign, "")
+	require.NoError(t, err)
+	require.Equal(t, sign, again)
+
+	encrypt, err := DeriveKey(master, purposeEncrypt, "")
+	require.NoError(t, err)
+	named, err := DeriveKey(master, purposeSign, "session")
+	require.NoError(t, err)
+	require.NotEqual(t, sign, encrypt)
+	require.NotEqual(t, sign, named)
+	require.NotEqual(t, master, sign)
+
+	_, err = DeriveKey(nil, purposeSign, "")
+	require.ErrorIs(t, err, ErrSecretMissing)
+}
+
+func TestManagerMasterSecret(t *testing.T) {
+	master, err := NewCookieSecret()
+	require.NoError(t, err)
+	m, err := New(WithMasterSecret(master))
+	require.NoError(t, err)
+	raw, err := New(WithSecret(master))
+	require.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	require.NoError(t, m.WriteSigned(w, "a", "chocolate fudge"))
+	require.NoError(t, m.WriteEncryptedValue(w, "b", "oatmeal raisin"))
+	r := requestWith(w)
+
+	value, err := m.ReadSigned(r, "a")
+	require.NoError(t, err)
+	require.Equal(t, "chocolate fudge", value)
+	value, err = m.ReadEncryptedValue(r, "b")
+	require.NoError(t, err)
+	require.Equal(t, "oatmeal raisin", value)
+
+	// the master secret itself never signs or encrypts
+	_, err = raw.ReadSigned(r, "a")
+	require.ErrorIs(t, err, ErrCookie)
+	_, err = raw.ReadEncryptedValue(r, "b")
+	require.Error(t, err)
+
+	// keys are bound to the cookie name
+	swapped := httptest.NewRequest(http.MethodGet, "/", nil)
+	swapped.AddCookie(&http.Cookie{Name: "c", Value: w.Result().Cookies()[1].Value})
+	_, err = m.ReadEncryptedValue(swapped, "c")
+	require.Error(t, err)
+}
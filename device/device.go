@@ -0,0 +1,238 @@
+// package device remembers devices on which a user completed multi-factor
+// authentication, so later logins from them can skip the second factor. A
+// long-lived signed cookie carries a device record ID and a secret, of which
+// the Store keeps only a hash. The secret is rotated on every use, and a
+// secret mismatch, a sign of a copied cookie, forgets every device of the user.
+package device
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/grackleclub/cookie/v2"
+	"github.com/grackleclub/cookie/v2/internal/token"
+)
+
+const (
+	defaultName  = "device"
+	defaultTTL   = 30 * 24 * time.Hour
+	idLength     = 12
+	secretLength = 32
+)
+
+var (
+	ErrNotFound = errors.New("device not found")
+	ErrDevice   = errors.New("device not remembered")
+)
+
+// Device is a remembered device as kept by a Store.
+type Device struct {
+	ID         string
+	UserID     string
+	SecretHash []byte // sha256 of the secret held by the client
+	Label      string // shown to the user when listing their devices, such as a browser name
+	Created    time.Time
+	LastUsed   time.Time
+	Expires    time.Time
+}
+
+// Store persists remembered devices by ID.
+type Store interface {
+	// Get returns the device with id, or ErrNotFound
+	// if there is none or it has expired.
+	Get(ctx context.Context, id string) (Device, error)
+	// Save stores device, replacing any device with the same ID.
+	Save(ctx context.Context, device Device) error
+	// Delete removes the device with id. Deleting a missing device is not an error.
+	Delete(ctx context.Context, id string) error
+	// DeleteUser removes every device of userID.
+	DeleteUser(ctx context.Context, userID string) error
+}
+
+// Devices remembers, verifies, and forgets devices.
+type Devices struct {
+	cookies *cookie.Manager
+	store   Store
+	name    string
+	ttl     time.Duration
+}
+
+// Option configures Devices.
+type Option func(*Devices) error
+
+// New creates Devices backed by store. The cookie.Manager
+// must hold a secret, which is used to sign the device cookie.
+func New(cookies *cookie.Manager, store Store, opts ...Option) (*Devices, error) {
+	if cookies == nil || store == nil {
+		return nil, fmt.Errorf("%w: cookie manager and store are required", cookie.ErrInitiation)
+	}
+	d := &Devices{
+		cookies: cookies,
+		store:   store,
+		name:    defaultName,
+		ttl:     defaultTTL,
+	}
+	for _, opt := range opts {
+		if err := opt(d); err != nil {
+			return nil, fmt.Errorf("%w: %w", cookie.ErrInitiation, err)
+		}
+	}
+	cookies, err := cookies.With(cookie.WithMaxAge(d.ttl))
+	if err != nil {
+		return nil, err
+	}
+	d.cookies = cookies
+	if setter, ok := store.(cookie.ClockSetter); ok {
+		setter.SetClock(cookies)
+	}
+	return d, nil
+}
+
+// WithName sets the name of the device cookie.
+func WithName(name string) Option {
+	return func(d *Devices) error {
+		if name == "" {
+			return errors.New("empty device cookie name")
+		}
+		d.name = name
+		return nil
+	}
+}
+
+// WithTTL sets how long a device is remembered after multi-factor
+// authentication on it. Using the device does not extend it. Defaults to 30 days.
+func WithTTL(ttl time.Duration) Option {
+	return func(d *Devices) error {
+		if ttl < time.Minute {
+			return fmt.Errorf("device ttl too short: %s", ttl)
+		}
+		d.ttl = ttl
+		return nil
+	}
+}
+
+// Remember stores a new device for userID and writes its cookie.
+// Call it once userID has completed multi-factor authentication.
+func (d *Devices) Remember(w http.ResponseWriter, r *http.Request, userID, label string) (Device, error) {
+	id, err := token.Random(idLength)
+	if err != nil {
+		return Device{}, fmt.Errorf("%w: %w", ErrDevice, err)
+	}
+	now := d.cookies.Now()
+	device := Device{
+		ID:       id,
+		UserID:   userID,
+		Label:    label,
+		Created:  now,
+		LastUsed: now,
+		Expires:  now.Add(d.ttl),
+	}
+	return d.issue(w, r, device)
+}
+
+// Verify reports whether the request comes from a device remembered for userID,
+// the user who just passed the first factor, returning the device. The secret
+// is used up: a replacement is issued in its place, so a copied cookie stops
+// working once either copy is used. A valid device ID with the wrong secret
+// forgets every device of its user. Failures delete the cookie, unless it
+// belongs to another user of the same browser.
+func (d *Devices) Verify(w http.ResponseWriter, r *http.Request, userID string) (Device, error) {
+	device, err := d.verify(r, userID)
+	if errors.Is(err, errOtherUser) {
+		return Device{}, fmt.Errorf("%w: %w", ErrDevice, err)
+	}
+	if err != nil {
+		d.cookies.Delete(w, d.name)
+		return Device{}, err
+	}
+	device.LastUsed = d.cookies.Now()
+	return d.issue(w, r, device)
+}
+
+// Forget deletes the request's device from the store and expires its cookie.
+func (d *Devices) Forget(w http.ResponseWriter, r *http.Request) error {
+	d.cookies.Delete(w, d.name)
+	split, err := d.read(r)
+	if err != nil {
+		return nil
+	}
+	return d.Revoke(r.Context(), split.Selector)
+}
+
+// Revoke forgets the device with id, such as from a list of the user's devices.
+func (d *Devices) Revoke(ctx context.Context, id string) error {
+	if err := d.store.Delete(ctx, id); err != nil {
+		return fmt.Errorf("%w: unable to delete device: %w", ErrDevice, err)
+	}
+	return nil
+}
+
+// RevokeAll forgets every device of userID, such as after a change
+// of password or second factor.
+func (d *Devices) RevokeAll(ctx context.Context, userID string) error {
+	if err := d.store.DeleteUser(ctx, userID); err != nil {
+		return fmt.Errorf("%w: unable to delete devices: %w", ErrDevice, err)
+	}
+	return nil
+}
+
+// errOtherUser marks a device cookie left by another user of the browser.
+var errOtherUser = errors.New("device remembered for another user")
+
+// issue gives device a new secret, saving it and writing its cookie.
+func (d *Devices) issue(w http.ResponseWriter, r *http.Request, device Device) (Device, error) {
+	secret, err := token.Random(secretLength)
+	if err != nil {
+		return Device{}, fmt.Errorf("%w: %w", ErrDevice, err)
+	}
+	split := token.Split{Selector: device.ID, Validator: secret}
+	device.SecretHash = split.Hash()
+	if err := d.store.Save(r.Context(), device); err != nil {
+		return Device{}, fmt.Errorf("%w: unable to save device: %w", ErrDevice, err)
+	}
+	if err := d.cookies.WriteSigned(w, d.name, split.String()); err != nil {
+		return Device{}, err
+	}
+	return device, nil
+}
+
+// verify checks the request's device cookie against the store.
+func (d *Devices) verify(r *http.Request, userID string) (Device, error) {
+	split, err := d.read(r)
+	if err != nil {
+		return Device{}, err
+	}
+	ctx := r.Context()
+	device, err := d.store.Get(ctx, split.Selector)
+	if err != nil {
+		return Device{}, fmt.Errorf("%w: %w", ErrDevice, err)
+	}
+	if device.UserID != userID {
+		return Device{}, errOtherUser
+	}
+	revoke := func() error { return d.store.DeleteUser(ctx, device.UserID) }
+	if err := split.Verify(device.SecretHash, revoke); err != nil {
+		return Device{}, fmt.Errorf("%w: %w", ErrDevice, err)
+	}
+	if !d.cookies.Now().Before(device.Expires) {
+		return Device{}, fmt.Errorf("%w: %w", ErrDevice, cookie.ErrExpired)
+	}
+	return device, nil
+}
+
+// read returns the device ID, as the selector, and the secret, as the
+// validator, of the request's device cookie.
+func (d *Devices) read(r *http.Request) (token.Split, error) {
+	value, err := d.cookies.ReadSigned(r, d.name)
+	if err != nil {
+		return token.Split{}, fmt.Errorf("%w: %w", ErrDevice, err)
+	}
+	split, ok := token.Parse(value)
+	if !ok {
+		return token.Split{}, fmt.Errorf("%w: malformed device cookie", ErrDevice)
+	}
+	return split, nil
+}
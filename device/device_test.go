@@ -0,0 +1,133 @@
+package device
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/grackleclub/cookie/v2"
+	"github.com/grackleclub/cookie/v2/cookietest"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestDevices(t *testing.T, store Store, opts ...Option) *Devices {
+	t.Helper()
+	secretKey, err := cookie.NewCookieSecret()
+	require.NoError(t, err)
+	cookies, err := cookie.New(cookie.WithSecret(secretKey))
+	require.NoError(t, err)
+	d, err := New(cookies, store, opts...)
+	require.NoError(t, err)
+	return d
+}
+
+func TestDevices(t *testing.T) {
+	store := NewMemoryStore()
+	d := newTestDevices(t, store, WithName("mfa"))
+
+	w := httptest.NewRecorder()
+	remembered, err := d.Remember(w, httptest.NewRequest(http.MethodGet, "/", nil), "1312", "Firefox on Linux")
+	require.NoError(t, err)
+	setCookie := w.Result().Cookies()[0]
+	require.Equal(t, "mfa", setCookie.Name)
+	require.Equal(t, int(defaultTTL.Seconds()), setCookie.MaxAge)
+	require.Equal(t, 1, store.Len())
+
+	// verifying rotates the secret but keeps the device
+	first := cookietest.RequestFrom(t, w)
+	w = httptest.NewRecorder()
+	device, err := d.Verify(w, first, "1312")
+	require.NoError(t, err)
+	require.Equal(t, remembered.ID, device.ID)
+	require.Equal(t, "Firefox on Linux", device.Label)
+	require.NotEqual(t, remembered.SecretHash, device.SecretHash)
+	require.Equal(t, 1, store.Len())
+
+	second := cookietest.RequestFrom(t, w)
+	_, err = d.Verify(httptest.NewRecorder(), second, "42")
+	require.ErrorIs(t, err, ErrDevice)
+	require.Equal(t, 1, store.Len(), "another user's cookie is left alone")
+
+	w = httptest.NewRecorder()
+	_, err = d.Verify(w, second, "1312")
+	require.NoError(t, err)
+
+	require.NoError(t, d.Forget(httptest.NewRecorder(), cookietest.RequestFrom(t, w)))
+	require.Zero(t, store.Len())
+	_, err = d.Verify(httptest.NewRecorder(), cookietest.RequestFrom(t, w), "1312")
+	require.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestDevicesCopiedCookie(t *testing.T) {
+	store := NewMemoryStore()
+	d := newTestDevices(t, store)
+	ctx := context.Background()
+
+	w := httptest.NewRecorder()
+	_, err := d.Remember(w, httptest.NewRequest(http.MethodGet, "/", nil), "1312", "")
+	require.NoError(t, err)
+	_, err = d.Remember(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil), "1312", "")
+	require.NoError(t, err)
+	other, err := d.Remember(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil), "42", "")
+	require.NoError(t, err)
+
+	// the copy is used after the original rotated the secret
+	copied := cookietest.RequestFrom(t, w)
+	_, err = d.Verify(httptest.NewRecorder(), copied, "1312")
+	require.NoError(t, err)
+	w = httptest.NewRecorder()
+	_, err = d.Verify(w, copied, "1312")
+	require.ErrorIs(t, err, ErrDevice)
+	require.Equal(t, -1, w.Result().Cookies()[0].MaxAge)
+
+	_, err = store.Get(ctx, other.ID)
+	require.NoError(t, err)
+	require.Equal(t, 1, store.Len(), "every device of the user is forgotten")
+
+	require.NoError(t, d.RevokeAll(ctx, "42"))
+	require.Zero(t, store.Len())
+}
+
+func TestDevicesExpired(t *testing.T) {
+	store := NewMemoryStore()
+	d := newTestDevices(t, store)
+
+	w := httptest.NewRecorder()
+	device, err := d.Remember(w, httptest.NewRequest(http.MethodGet, "/", nil), "1312", "")
+	require.NoError(t, err)
+	device.Expires = time.Now().Add(-time.Second)
+	require.NoError(t, store.Save(context.Background(), device))
+
+	_, err = d.Verify(httptest.NewRecorder(), cookietest.RequestFrom(t, w), "1312")
+	require.ErrorIs(t, err, ErrNotFound)
+
+	_, err = New(nil, store)
+	require.ErrorIs(t, err, cookie.ErrInitiation)
+	_, err = New(d.cookies, store, WithTTL(time.Second))
+	require.ErrorIs(t, err, cookie.ErrInitiation)
+}
+
+func TestDevicesClock(t *testing.T) {
+	secretKey, err := cookie.NewCookieSecret()
+	require.NoError(t, err)
+	clock := cookietest.NewClock(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC))
+	cookies, err := cookie.New(cookie.WithSecret(secretKey), cookie.WithClock(clock))
+	require.NoError(t, err)
+	d, err := New(cookies, NewMemoryStore())
+	require.NoError(t, err)
+
+	// the store expires devices by the Manager's clock, not the system clock
+	w := httptest.NewRecorder()
+	_, err = d.Remember(w, httptest.NewRequest(http.MethodGet, "/", nil), "1312", "")
+	require.NoError(t, err)
+	r := cookietest.RequestFrom(t, w)
+	w = httptest.NewRecorder()
+	_, err = d.Verify(w, r, "1312")
+	require.NoError(t, err)
+
+	clock.Advance(defaultTTL)
+	_, err = d.Verify(httptest.NewRecorder(), cookietest.RequestFrom(t, w), "1312")
+	require.Error(t, err)
+}
@@ -0,0 +1,90 @@
+package device
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/grackleclub/cookie/v2"
+)
+
+// MemoryStore keeps trusted devices in a map by device ID, with each
+// device's user, label, and secret hash. An expired device is dropped when
+// it is read, or when DeleteUser runs for any user. Restarting the process
+// forgets every device, so each user completes multi-factor authentication again.
+type MemoryStore struct {
+	mu      sync.Mutex
+	devices map[string]Device
+	clock   cookie.Clock
+}
+
+// NewMemoryStore creates an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{devices: map[string]Device{}}
+}
+
+// Get returns the device with id, or ErrNotFound if there is none or it has expired.
+func (s *MemoryStore) Get(_ context.Context, id string) (Device, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	device, ok := s.devices[id]
+	if !ok {
+		return Device{}, ErrNotFound
+	}
+	if !s.now().Before(device.Expires) {
+		delete(s.devices, id)
+		return Device{}, ErrNotFound
+	}
+	return device, nil
+}
+
+// Save stores device.
+func (s *MemoryStore) Save(_ context.Context, device Device) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.devices[device.ID] = device
+	return nil
+}
+
+// Delete removes the device with id.
+func (s *MemoryStore) Delete(_ context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.devices, id)
+	return nil
+}
+
+// DeleteUser removes every device of userID, along with any expired devices.
+func (s *MemoryStore) DeleteUser(_ context.Context, userID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	for id, device := range s.devices {
+		if device.UserID == userID || !now.Before(device.Expires) {
+			delete(s.devices, id)
+		}
+	}
+	return nil
+}
+
+// SetClock implements cookie.ClockSetter.
+func (s *MemoryStore) SetClock(clock cookie.Clock) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.clock = clock
+}
+
+// now returns the time from the store's clock, or the system clock. s.mu must be held.
+func (s *MemoryStore) now() time.Time {
+	if s.clock == nil {
+		return time.Now()
+	}
+	return s.clock.Now()
+}
+
+// Len returns the number of devices held, including any expired but not yet removed.
+func (s *MemoryStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.devices)
+}
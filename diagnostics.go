@@ -0,0 +1,124 @@
+package cookie
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// maxHeaderSize is the Cookie header size above which common servers and
+// proxies start rejecting requests.
+const maxHeaderSize = 8 << 10
+
+// Diagnostics describes the cookies of a request as the Manager sees them.
+type Diagnostics struct {
+	HeaderSize int                `json:"header_size"`        // bytes in the Cookie header
+	Cookies    []CookieDiagnostic `json:"cookies"`            // in the order received, chunks grouped
+	Problems   []string           `json:"problems,omitempty"` // with the Manager's attributes or the request
+}
+
+// CookieDiagnostic describes one cookie, or one chunked value, of a request.
+type CookieDiagnostic struct {
+	Name    string `json:"name"`
+	Size    int    `json:"size"`             // bytes, including the name and all chunks
+	Chunks  int    `json:"chunks,omitempty"` // cookies the value spans, if chunked
+	Managed bool   `json:"managed"`          // registered with WithManaged
+	Mode    string `json:"mode,omitempty"`
+	Value   string `json:"value,omitempty"` // decoded, verified, or decrypted
+	Error   string `json:"error,omitempty"` // why the value could not be read
+}
+
+// Diagnose reads every cookie of r, reporting which are managed and whether
+// they verify, along with problems in the Manager's default attributes that
+// would keep browsers from storing or sending its cookies.
+func (m *Manager) Diagnose(r *http.Request) Diagnostics {
+	d := Diagnostics{HeaderSize: len(strings.Join(r.Header.Values("Cookie"), "; "))}
+	modes := make(map[string]Mode, len(m.managed))
+	for _, c := range m.managed {
+		modes[m.prefix+c.name] = c.mode
+	}
+	index := map[string]int{}
+	for _, c := range r.Cookies() {
+		name, chunk := c.Name, false
+		if i := strings.LastIndexByte(name, '.'); i >= 0 {
+			if _, err := strconv.Atoi(name[i+1:]); err == nil && m.chunkLimit > 0 {
+				name, chunk = name[:i], true
+			}
+		}
+		i, seen := index[name]
+		if !seen {
+			i = len(d.Cookies)
+			index[name] = i
+			d.Cookies = append(d.Cookies, CookieDiagnostic{Name: name})
+		}
+		d.Cookies[i].Size += len(c.Name) + 1 + len(c.Value)
+		if chunk {
+			d.Cookies[i].Chunks++
+		}
+	}
+	for i := range d.Cookies {
+		cd := &d.Cookies[i]
+		if cd.Chunks == 0 && cd.Size > m.maxSize {
+			d.Problems = append(d.Problems, fmt.Sprintf("'%s' is %d bytes, above the %d byte limit", cd.Name, cd.Size, m.maxSize))
+		}
+		mode, ok := modes[cd.Name]
+		if !ok {
+			continue
+		}
+		cd.Managed, cd.Mode = true, mode.String()
+		o, err := m.readOpened(r, strings.TrimPrefix(cd.Name, m.prefix), mode)
+		if err != nil {
+			cd.Error = err.Error()
+			continue
+		}
+		cd.Value = o.value
+	}
+	if d.HeaderSize > maxHeaderSize {
+		d.Problems = append(d.Problems, fmt.Sprintf("Cookie header is %d bytes, above the %d bytes many servers accept", d.HeaderSize, maxHeaderSize))
+	}
+	d.Problems = append(d.Problems, m.attributeProblems(r)...)
+	return d
+}
+
+// attributeProblems reports default attributes that are insecure, or that
+// browsers reject, for cookies written by m and sent over r's connection.
+func (m *Manager) attributeProblems(r *http.Request) []string {
+	var problems []string
+	c := m.defaults
+	if !c.Secure {
+		problems = append(problems, "cookies are written without Secure")
+	}
+	if !c.HttpOnly {
+		problems = append(problems, "cookies are written without HttpOnly")
+	}
+	if c.SameSite == http.SameSiteNoneMode && !c.Secure {
+		problems = append(problems, "SameSite=None without Secure is rejected by browsers")
+	}
+	if c.Partitioned && !c.Secure {
+		problems = append(problems, "Partitioned without Secure is rejected by browsers")
+	}
+	if m.prefix == HostPrefix && (c.Domain != "" || c.Path != "/") {
+		problems = append(problems, "__Host- cookies must have Path=/ and no Domain")
+	}
+	if m.prefix != "" && !c.Secure {
+		problems = append(problems, m.prefix+" cookies must be Secure")
+	}
+	if c.Secure && !m.secureRequest(r) {
+		problems = append(problems, "Secure cookies are not sent over this insecure connection, except to localhost")
+	}
+	return problems
+}
+
+// DiagnosticsHandler serves Manager.Diagnose for the request as JSON.
+// Managed values are shown decrypted, so mount it only in development.
+func DiagnosticsHandler(m *Manager) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		enc.Encode(m.Diagnose(r))
+	})
+}
@@ -0,0 +1,67 @@
+package cookie
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiagnose(t *testing.T) {
+	m := newTestManager(t,
+		WithChunking(16<<10),
+		WithManaged("user", Signed),
+		WithManaged("cart", Encrypted),
+		WithManaged("absent", Signed),
+	)
+	w := httptest.NewRecorder()
+	require.NoError(t, m.WriteSigned(w, "user", "alice"))
+	require.NoError(t, m.WriteEncryptedValue(w, "cart", strings.Repeat("x", 6000)))
+	r := requestWith(w)
+	r.AddCookie(&http.Cookie{Name: "theme", Value: "dark"})
+	r.AddCookie(&http.Cookie{Name: "forged", Value: "x"})
+
+	d := m.Diagnose(r)
+	require.Len(t, d.Cookies, 4)
+	user := d.Cookies[0]
+	require.Equal(t, "user", user.Name)
+	require.True(t, user.Managed)
+	require.Equal(t, "signed", user.Mode)
+	require.Equal(t, "alice", user.Value)
+	require.Empty(t, user.Error)
+
+	cart := d.Cookies[1]
+	require.Equal(t, "cart", cart.Name)
+	require.Equal(t, 3, cart.Chunks)
+	require.Greater(t, cart.Size, 6000)
+	require.Equal(t, strings.Repeat("x", 6000), cart.Value)
+
+	require.Equal(t, CookieDiagnostic{Name: "theme", Size: len("theme=dark")}, d.Cookies[2])
+	require.Equal(t, []string{"Secure cookies are not sent over this insecure connection, except to localhost"}, d.Problems)
+
+	m = newTestManager(t, WithManaged("user", Signed), WithSecure(false), WithSameSite(http.SameSiteNoneMode))
+	r = httptest.NewRequest(http.MethodGet, "/", nil)
+	r.AddCookie(&http.Cookie{Name: "user", Value: "tampered"})
+	d = m.Diagnose(r)
+	require.NotEmpty(t, d.Cookies[0].Error)
+	require.Empty(t, d.Cookies[0].Value)
+	require.Contains(t, d.Problems, "cookies are written without Secure")
+	require.Contains(t, d.Problems, "SameSite=None without Secure is rejected by browsers")
+}
+
+func TestDiagnosticsHandler(t *testing.T) {
+	m := newTestManager(t, WithManaged("user", Signed))
+	w := httptest.NewRecorder()
+	require.NoError(t, m.WriteSigned(w, "user", "alice"))
+
+	rec := httptest.NewRecorder()
+	DiagnosticsHandler(m).ServeHTTP(rec, requestWith(w))
+	require.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	var d Diagnostics
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &d))
+	require.Len(t, d.Cookies, 1)
+	require.Equal(t, "alice", d.Cookies[0].Value)
+}
@@ -0,0 +1,130 @@
+// package echo adapts the cookie, session, and csrf packages to Echo,
+// so handlers can read and write cookies from an echo.Context.
+//
+// Import it under another name, such as cookieecho, alongside Echo itself.
+package echo
+
+import (
+	"net/http"
+
+	"github.com/grackleclub/cookie/v2"
+	"github.com/grackleclub/cookie/v2/csrf"
+	"github.com/grackleclub/cookie/v2/session"
+	labstack "github.com/labstack/echo/v4"
+)
+
+// Keys under which the middleware stores values in the echo.Context.
+const (
+	ManagerKey  = "github.com/grackleclub/cookie/v2/echo.manager"
+	SessionsKey = "github.com/grackleclub/cookie/v2/echo.sessions"
+	SessionKey  = "github.com/grackleclub/cookie/v2/echo.session"
+)
+
+// Middleware stores m in the echo.Context for the helpers of this package,
+// and loads and refreshes the Manager's managed cookies as cookie.Middleware.
+func Middleware(m *cookie.Manager) labstack.MiddlewareFunc {
+	wrapped := labstack.WrapMiddleware(cookie.Middleware(m))
+	return func(next labstack.HandlerFunc) labstack.HandlerFunc {
+		h := wrapped(next)
+		return func(c labstack.Context) error {
+			c.Set(ManagerKey, m)
+			return h(c)
+		}
+	}
+}
+
+// Manager returns the cookie.Manager stored by Middleware.
+// It panics if Middleware is not in the chain.
+func Manager(c labstack.Context) *cookie.Manager {
+	m, ok := c.Get(ManagerKey).(*cookie.Manager)
+	if !ok {
+		panic("cookie manager not found: add echo.Middleware to the chain")
+	}
+	return m
+}
+
+// Managed returns the value of a managed cookie loaded by Middleware,
+// as cookie.FromContext.
+func Managed(c labstack.Context, name string) (string, error) {
+	return cookie.FromContext(c.Request().Context(), name)
+}
+
+// Get reads a base64 encoded cookie, as Manager.Read.
+func Get(c labstack.Context, name string) (string, error) {
+	return Manager(c).Read(c.Request(), name)
+}
+
+// Set writes a base64 encoded cookie, as Manager.Write.
+func Set(c labstack.Context, name, value string) error {
+	return Manager(c).Write(c.Response(), name, value)
+}
+
+// GetSigned reads and verifies a signed cookie, as Manager.ReadSigned.
+func GetSigned(c labstack.Context, name string) (string, error) {
+	return Manager(c).ReadSigned(c.Request(), name)
+}
+
+// SetSigned writes a signed cookie, as Manager.WriteSigned.
+func SetSigned(c labstack.Context, name, value string) error {
+	return Manager(c).WriteSigned(c.Response(), name, value)
+}
+
+// GetEncrypted reads and decrypts an encrypted cookie, as Manager.ReadEncryptedValue.
+func GetEncrypted(c labstack.Context, name string) (string, error) {
+	return Manager(c).ReadEncryptedValue(c.Request(), name)
+}
+
+// SetEncrypted writes an encrypted cookie, as Manager.WriteEncryptedValue.
+func SetEncrypted(c labstack.Context, name, value string) error {
+	return Manager(c).WriteEncryptedValue(c.Response(), name, value)
+}
+
+// Delete expires a cookie, as Manager.Delete.
+func Delete(c labstack.Context, name string) {
+	Manager(c).Delete(c.Response(), name)
+}
+
+// Sessions loads the request's session into the echo.Context for Session and
+// SaveSession, failing the request with 500 Internal Server Error if the store fails.
+// The session is not saved automatically, since headers may already be sent
+// when the handler returns.
+func Sessions(sessions *session.Manager) labstack.MiddlewareFunc {
+	return func(next labstack.HandlerFunc) labstack.HandlerFunc {
+		return func(c labstack.Context) error {
+			s, err := sessions.Load(c.Request())
+			if err != nil {
+				return labstack.NewHTTPError(http.StatusInternalServerError).SetInternal(err)
+			}
+			c.Set(SessionsKey, sessions)
+			c.Set(SessionKey, s)
+			return next(c)
+		}
+	}
+}
+
+// Session returns the session loaded by Sessions.
+// It panics if Sessions is not in the chain.
+func Session(c labstack.Context) *session.Session {
+	s, ok := c.Get(SessionKey).(*session.Session)
+	if !ok {
+		panic("session not found: add echo.Sessions to the chain")
+	}
+	return s
+}
+
+// SaveSession saves the session loaded by Sessions and writes its cookie.
+// Call it before writing the response body.
+func SaveSession(c labstack.Context) error {
+	return c.Get(SessionsKey).(*session.Manager).Save(c.Response(), c.Request(), Session(c))
+}
+
+// Protect rejects requests that fail CSRF checks, as csrf.Protect.
+// The request's token is available from CSRFToken.
+func Protect(protect *csrf.CSRF) labstack.MiddlewareFunc {
+	return labstack.WrapMiddleware(protect.Protect)
+}
+
+// CSRFToken returns the request's CSRF token, for embedding in pages.
+func CSRFToken(c labstack.Context) string {
+	return csrf.Token(c.Request())
+}
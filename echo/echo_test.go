@@ -0,0 +1,109 @@
+package echo
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/grackleclub/cookie/v2"
+	"github.com/grackleclub/cookie/v2/cookietest"
+	"github.com/grackleclub/cookie/v2/csrf"
+	"github.com/grackleclub/cookie/v2/session"
+	labstack "github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/require"
+)
+
+func serve(e *labstack.Echo, method, target string, cookies ...*http.Cookie) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, target, nil)
+	for _, c := range cookies {
+		r.AddCookie(c)
+	}
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, r)
+	return w
+}
+
+func TestHelpers(t *testing.T) {
+	e := labstack.New()
+	e.Use(Middleware(cookietest.NewManager(t, cookie.WithManaged("theme", cookie.Signed))))
+	e.GET("/set", func(c labstack.Context) error {
+		if err := SetSigned(c, "theme", "dark"); err != nil {
+			return err
+		}
+		if err := SetEncrypted(c, "user", "alice"); err != nil {
+			return err
+		}
+		return c.NoContent(http.StatusNoContent)
+	})
+	e.GET("/get", func(c labstack.Context) error {
+		theme, err := Managed(c, "theme")
+		if err != nil {
+			return err
+		}
+		signed, err := GetSigned(c, "theme")
+		if err != nil {
+			return err
+		}
+		user, err := GetEncrypted(c, "user")
+		if err != nil {
+			return err
+		}
+		return c.String(http.StatusOK, theme+" "+signed+" "+user)
+	})
+
+	w := serve(e, http.MethodGet, "/set")
+	require.Equal(t, http.StatusNoContent, w.Code)
+	w = serve(e, http.MethodGet, "/get", w.Result().Cookies()...)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, "dark dark alice", w.Body.String())
+}
+
+func TestManagerPanics(t *testing.T) {
+	c := labstack.New().NewContext(httptest.NewRequest(http.MethodGet, "/", nil), httptest.NewRecorder())
+	require.Panics(t, func() { Manager(c) })
+}
+
+func TestSessions(t *testing.T) {
+	sessions, err := session.New(cookietest.NewManager(t), session.NewMemoryStore(time.Minute))
+	require.NoError(t, err)
+
+	e := labstack.New()
+	e.Use(Sessions(sessions))
+	e.GET("/", func(c labstack.Context) error {
+		s := Session(c)
+		visits, _ := session.Get[int](s, "visits")
+		if err := s.Set("visits", visits+1); err != nil {
+			return err
+		}
+		if err := SaveSession(c); err != nil {
+			return err
+		}
+		return c.JSON(http.StatusOK, visits+1)
+	})
+
+	w := serve(e, http.MethodGet, "/")
+	require.Equal(t, "1\n", w.Body.String())
+	w = serve(e, http.MethodGet, "/", w.Result().Cookies()...)
+	require.Equal(t, "2\n", w.Body.String())
+}
+
+func TestProtect(t *testing.T) {
+	secretKey, err := cookie.NewCookieSecret()
+	require.NoError(t, err)
+	protect, err := csrf.New(cookietest.NewManager(t), secretKey)
+	require.NoError(t, err)
+
+	e := labstack.New()
+	e.Use(Protect(protect))
+	handler := func(c labstack.Context) error { return c.String(http.StatusOK, CSRFToken(c)) }
+	e.GET("/", handler)
+	e.POST("/", handler)
+
+	w := serve(e, http.MethodGet, "/")
+	require.Equal(t, http.StatusOK, w.Code)
+	require.NotEmpty(t, w.Body.String())
+
+	w = serve(e, http.MethodPost, "/", w.Result().Cookies()...)
+	require.Equal(t, http.StatusForbidden, w.Code)
+}
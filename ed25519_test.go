@@ -0,0 +1,43 @@
+package cookie
+
+import (
+	"crypto/ed25519"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEd25519(t *testing.T) {
+	publicKey, privateKey, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	require.NoError(t, WriteSignedEd25519(w, testCookie, privateKey))
+	value, err := ReadVerifyEd25519(requestWith(w), testCookie.Name, publicKey)
+	require.NoError(t, err)
+	require.Equal(t, testCookie.Value, value)
+
+	t.Run("wrong key", func(t *testing.T) {
+		otherKey, _, err := ed25519.GenerateKey(nil)
+		require.NoError(t, err)
+		_, err = ReadVerifyEd25519(requestWith(w), testCookie.Name, otherKey)
+		require.ErrorIs(t, err, ErrCookie)
+	})
+
+	t.Run("tampered", func(t *testing.T) {
+		signed, err := Read(requestWith(w), testCookie.Name)
+		require.NoError(t, err)
+		tampered := httptest.NewRecorder()
+		require.NoError(t, Write(tampered, http.Cookie{Name: testCookie.Name, Value: signed + "!"}))
+		_, err = ReadVerifyEd25519(requestWith(tampered), testCookie.Name, publicKey)
+		require.ErrorIs(t, err, ErrCookie)
+	})
+
+	t.Run("missing key", func(t *testing.T) {
+		require.ErrorIs(t, WriteSignedEd25519(httptest.NewRecorder(), testCookie, nil), ErrSecretMissing)
+		_, err := ReadVerifyEd25519(requestWith(w), testCookie.Name, nil)
+		require.ErrorIs(t, err, ErrSecretMissing)
+	})
+}
@@ -0,0 +1,418 @@
+package cookie
+
+import (
+	"bytes"
+	"cmp"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"time"
+)
+
+// Values written by a Manager are wrapped in an envelope:
+//
+//	magic (2) | version (1) | mode (1) | algorithm (1) | flags (1) | key ID length (1) | key ID | body
+//
+// For signed values the body is an HMAC followed by the value, and the HMAC
+// covers the header, NUL-terminated cookie name, and value, so a value cannot
+// be moved to a cookie whose name is a prefix of its own. For encrypted values
+// the body is a nonce and ciphertext, with the header, cookie name, and any
+// context set by WithAssociatedData as associated data. With WithDataKeys, the
+// body begins with a nonce and a fresh data key encrypted by the secret, and
+// the value is encrypted by the data key instead. The magic bytes begin with
+// 0xC0, which never occurs in UTF-8 text, so enveloped values are told apart
+// from unversioned ones written by the package-level functions.
+const (
+	envelopeMagic      = "\xC0\x0C"
+	envelopeV1    byte = 1
+	headerLength       = len(envelopeMagic) + 5 // fixed fields, excluding the key ID
+)
+
+// algorithmCustom marks values signed by a Signer or encrypted by an Encrypter.
+const algorithmCustom byte = 0xFF
+
+// envelope flags
+const (
+	flagCompressed byte = 1 << iota // value is gzipped
+	flagExpires                     // value is prefixed with a big-endian unix expiry
+	flagIssued                      // value is prefixed with a big-endian unix creation time
+	flagOnce                        // value is prefixed with a random token ID, accepted once
+	flagBound                       // signature covers a fingerprint of the client
+	flagDataKey                     // body begins with a data key wrapped by the secret
+)
+
+var (
+	ErrUnsupportedVersion = errors.New("unsupported cookie format version")
+	ErrUnknownKey         = errors.New("unknown key id")
+)
+
+// header describes an enveloped value.
+type header struct {
+	version   byte
+	mode      Mode
+	algorithm byte // Hash for signed values, Cipher for encrypted values
+	flags     byte
+	keyID     []byte
+}
+
+// isEnvelope reports whether value begins with the envelope magic bytes.
+func isEnvelope(value string) bool {
+	return len(value) >= len(envelopeMagic) && value[:len(envelopeMagic)] == envelopeMagic
+}
+
+func (h header) encode() string {
+	var b bytes.Buffer
+	b.WriteString(envelopeMagic)
+	b.WriteByte(h.version)
+	b.WriteByte(byte(h.mode))
+	b.WriteByte(h.algorithm)
+	b.WriteByte(h.flags)
+	b.WriteByte(byte(len(h.keyID)))
+	b.Write(h.keyID)
+	return b.String()
+}
+
+// parseHeader splits an enveloped value into its header, encoded header, and body.
+func parseHeader(value string) (header, string, string, error) {
+	if !isEnvelope(value) || len(value) < headerLength {
+		return header{}, "", "", fmt.Errorf("%w: %w: envelope", ErrCookie, ErrTooShort)
+	}
+	h := header{
+		version:   value[2],
+		mode:      Mode(value[3]),
+		algorithm: value[4],
+		flags:     value[5],
+	}
+	if h.version != envelopeV1 {
+		return header{}, "", "", fmt.Errorf("%w: %w %d", ErrCookie, ErrUnsupportedVersion, h.version)
+	}
+	end := headerLength + int(value[6])
+	if len(value) < end {
+		return header{}, "", "", fmt.Errorf("%w: envelope key id truncated", ErrCookie)
+	}
+	h.keyID = []byte(value[headerLength:end])
+	return h, value[:end], value[end:], nil
+}
+
+// KeyID returns a short, stable fingerprint of a secret: the first 4 bytes
+// of its SHA-256 hash, in hex. It is the key ID embedded in the envelopes of
+// values written with the secret and logged with read failures, so operators
+// can tell which key a failing cookie was issued under without the key itself.
+func KeyID(secretKey []byte) string {
+	return hex.EncodeToString(keyID(secretKey))
+}
+
+// keyID returns a short fingerprint identifying a secret within envelopes.
+func keyID(secretKey []byte) []byte {
+	sum := sha256.Sum256(secretKey)
+	return sum[:4]
+}
+
+// keyName returns the envelope key ID id as text: a KeyRing ID, or the hex
+// fingerprint of a secret as returned by KeyID.
+func (m *Manager) keyName(id []byte) string {
+	if _, ok := m.source.(*KeyRing); ok {
+		return string(id)
+	}
+	return hex.EncodeToString(id)
+}
+
+// hasKeyID reports whether id is the keyID of secretKey, without allocating.
+func hasKeyID(secretKey, id []byte) bool {
+	sum := sha256.Sum256(secretKey)
+	return bytes.Equal(sum[:4], id)
+}
+
+// sealOptions are the per-value inputs to seal.
+type sealOptions struct {
+	expires     time.Time // bound into the envelope when non-zero
+	fingerprint string    // client fingerprint covered by the signature when non-empty
+	once        bool      // prefix a token ID, accepted once, whatever the mode
+}
+
+// seal wraps value in an envelope for mode, signing or encrypting it with the
+// newest key for the named cookie.
+func (m *Manager) seal(mode Mode, name, value string, opts sealOptions) (string, error) {
+	h := header{version: envelopeV1, mode: mode}
+	expires := opts.expires
+	if opts.once || (mode == Signed && m.replay != nil) {
+		tokenID := make([]byte, tokenIDLength)
+		if _, err := io.ReadFull(m.random(), tokenID); err != nil {
+			return "", fmt.Errorf("unable to read random bytes into token id: %w", err)
+		}
+		value = string(tokenID) + value
+		h.flags |= flagOnce
+		if expires.IsZero() {
+			expires = m.Now().Add(m.replayTTL)
+		}
+	}
+	if (mode == Signed && m.signedMaxAge > 0) || (mode != Plain && m.refresh > 0) ||
+		(mode == Encrypted && m.revocation != nil) {
+		value = unixPrefix(m.Now()) + value
+		h.flags |= flagIssued
+	}
+	if !expires.IsZero() {
+		value = unixPrefix(expires) + value
+		h.flags |= flagExpires
+	}
+	value, compressed, err := m.compress(value)
+	if err != nil {
+		return "", err
+	}
+	if compressed {
+		h.flags |= flagCompressed
+	}
+
+	if opts.fingerprint != "" {
+		h.flags |= flagBound
+	}
+
+	switch mode {
+	case Plain:
+		return h.encode() + value, nil
+	case Signed:
+		if m.signer != nil {
+			h.algorithm = algorithmCustom
+			encoded := h.encode()
+			signature, err := m.signer.Sign([]byte(encoded + name + "\x00" + opts.fingerprint + value))
+			if err != nil {
+				return "", fmt.Errorf("%w: unable to sign: %w", ErrCookie, err)
+			}
+			if len(signature) > math.MaxUint16 {
+				return "", fmt.Errorf("%w: signature too long", ErrCookie)
+			}
+			return encoded + string(binary.BigEndian.AppendUint16(nil, uint16(len(signature)))) + string(signature) + value, nil
+		}
+		secretKey, id, err := m.writeKey(purposeSign, name)
+		if err != nil {
+			return "", err
+		}
+		h.algorithm = byte(cmp.Or(m.hash, SHA256))
+		h.keyID = id
+		newHash, _ := Hash(h.algorithm).new()
+		encoded := h.encode()
+		return encoded + string(m.cache.mac(Hash(h.algorithm), newHash, secretKey, encoded, name, "\x00", opts.fingerprint, value)) + value, nil
+	case Encrypted:
+		if m.encrypter != nil {
+			h.algorithm = algorithmCustom
+			encoded := h.encode()
+			ciphertext, err := m.encrypter.Encrypt([]byte(value), m.associatedData(encoded, name))
+			if err != nil {
+				return "", fmt.Errorf("%w: %w", ErrEncryption, err)
+			}
+			return encoded + string(ciphertext), nil
+		}
+		secretKey, id, err := m.writeKey(purposeEncrypt, name)
+		if err != nil {
+			return "", err
+		}
+		h.algorithm = byte(cmp.Or(m.cipher, AESGCM))
+		h.keyID = id
+		aead, err := m.cache.aead(Cipher(h.algorithm), secretKey)
+		if err != nil {
+			return "", fmt.Errorf("%w: unable to create %s for write: %w", ErrEncryption, Cipher(h.algorithm), err)
+		}
+		if m.usesDataKey(name) {
+			h.flags |= flagDataKey
+		}
+		encoded := h.encode()
+		ad := m.associatedData(encoded, name)
+		var wrapped []byte
+		if h.flags&flagDataKey != 0 {
+			if wrapped, aead, err = m.newDataKey(Cipher(h.algorithm), aead, ad); err != nil {
+				return "", err
+			}
+		}
+		// header, wrapped data key, nonce, and ciphertext share one buffer
+		prefix := len(encoded) + len(wrapped)
+		out := make([]byte, prefix+aead.NonceSize(), prefix+aead.NonceSize()+len(value)+aead.Overhead())
+		copy(out[copy(out, encoded):], wrapped)
+		nonce := out[prefix:]
+		if _, err := io.ReadFull(m.random(), nonce); err != nil {
+			return "", fmt.Errorf("unable to read random bytes into nonce: %w", err)
+		}
+		return string(aead.Seal(out, nonce, []byte(value), ad)), nil
+	default:
+		return "", fmt.Errorf("%w: unsupported mode %s", ErrCookie, mode)
+	}
+}
+
+// open unwraps a value read from the named cookie, verifying or decrypting it
+// according to mode. Values without an envelope are read in the unversioned
+// formats of the package-level functions.
+func (m *Manager) open(r *http.Request, mode Mode, name, value string) (string, error) {
+	o, err := m.openEnvelope(r, mode, name, value)
+	return o.value, err
+}
+
+// opened is a value unwrapped by openEnvelope, with the envelope details
+// needed to seal it again.
+type opened struct {
+	value  string
+	flags  byte
+	issued time.Time // zero unless flagIssued is set
+}
+
+// openEnvelope implements open, also returning the envelope's flags and creation stamp.
+func (m *Manager) openEnvelope(r *http.Request, mode Mode, name, value string) (opened, error) {
+	stamped := mode == Signed && m.signedMaxAge > 0
+	if !isEnvelope(value) {
+		if stamped {
+			return opened{}, fmt.Errorf("%w: %w: creation time missing", ErrCookie, ErrExpired)
+		}
+		value, err := m.openUnversioned(mode, name, value)
+		return opened{value: value}, err
+	}
+	h, encoded, body, err := parseHeader(value)
+	if err != nil {
+		return opened{}, err
+	}
+	if h.mode != mode {
+		return opened{}, fmt.Errorf("%w: value written as %s, read as %s", ErrCookie, h.mode, mode)
+	}
+	if stamped && h.flags&flagIssued == 0 {
+		return opened{}, fmt.Errorf("%w: %w: creation time missing", ErrCookie, ErrExpired)
+	}
+
+	var fingerprint string
+	if h.flags&flagBound != 0 {
+		if m.binding == (Binding{}) {
+			return opened{}, fmt.Errorf("%w: bound value read without a Binding", ErrCookie)
+		}
+		fingerprint = m.binding.fingerprint(r)
+	}
+
+	switch {
+	case mode == Plain:
+		value = body
+	case mode == Signed && h.algorithm == algorithmCustom:
+		if m.signer == nil {
+			return opened{}, fmt.Errorf("%w: value signed by a custom Signer", ErrCookie)
+		}
+		var size int
+		if len(body) >= 2 {
+			size = 2 + int(binary.BigEndian.Uint16([]byte(body[:2])))
+		}
+		if size == 0 || len(body) < size {
+			return opened{}, fmt.Errorf("%w: %w: signature wrong length", ErrCookie, ErrTooShort)
+		}
+		signature, signed := body[2:size], body[size:]
+		if err := m.verify(r, []byte(encoded+name+"\x00"+fingerprint+signed), []byte(signature)); err != nil {
+			return opened{}, fmt.Errorf("%w: %w: %w", ErrCookie, ErrSignatureMismatch, err)
+		}
+		value = signed
+	case mode == Signed:
+		newHash, ok := Hash(h.algorithm).new()
+		if !ok {
+			return opened{}, fmt.Errorf("%w: unsupported signature algorithm %s", ErrCookie, Hash(h.algorithm))
+		}
+		secretKey, err := m.keyFor(purposeSign, name, h.keyID)
+		if err != nil {
+			return opened{}, err
+		}
+		size := newHash().Size()
+		if len(body) < size {
+			return opened{}, fmt.Errorf("%w: %w: signature wrong length", ErrCookie, ErrTooShort)
+		}
+		signature, signed := body[:size], body[size:]
+		if !hmac.Equal([]byte(signature), m.cache.mac(Hash(h.algorithm), newHash, secretKey, encoded, name, "\x00", fingerprint, signed)) {
+			return opened{}, fmt.Errorf("%w: %w", ErrCookie, ErrSignatureMismatch)
+		}
+		value = signed
+	case mode == Encrypted && h.algorithm == algorithmCustom:
+		if m.encrypter == nil {
+			return opened{}, fmt.Errorf("%w: value encrypted by a custom Encrypter", ErrCookie)
+		}
+		plaintext, err := m.decrypt(r, []byte(body), m.associatedData(encoded, name))
+		if err != nil {
+			return opened{}, fmt.Errorf("%w: %w: %w", ErrCookie, ErrDecryptFailed, err)
+		}
+		value = string(plaintext)
+	case mode == Encrypted:
+		secretKey, err := m.keyFor(purposeEncrypt, name, h.keyID)
+		if err != nil {
+			return opened{}, err
+		}
+		aead, err := m.cache.aead(Cipher(h.algorithm), secretKey)
+		if err != nil {
+			return opened{}, fmt.Errorf("%w: unsupported cipher %s: %w", ErrCookie, Cipher(h.algorithm), err)
+		}
+		if h.flags&flagDataKey != 0 {
+			if aead, body, err = openDataKey(Cipher(h.algorithm), aead, body, m.associatedData(encoded, name)); err != nil {
+				return opened{}, err
+			}
+		}
+		if len(body) < aead.NonceSize() {
+			return opened{}, fmt.Errorf("%w: %w: encrypted value", ErrCookie, ErrTooShort)
+		}
+		nonce, ciphertext := body[:aead.NonceSize()], body[aead.NonceSize():]
+		plaintext, err := aead.Open(nil, []byte(nonce), []byte(ciphertext), m.associatedData(encoded, name))
+		if err != nil {
+			return opened{}, fmt.Errorf("%w: %w: %w", ErrCookie, ErrDecryptFailed, err)
+		}
+		value = string(plaintext)
+	default:
+		return opened{}, fmt.Errorf("%w: unsupported mode %s", ErrCookie, mode)
+	}
+
+	if h.flags&flagCompressed != 0 {
+		if value, err = decompress(value); err != nil {
+			return opened{}, err
+		}
+	}
+	var expires time.Time
+	if h.flags&flagExpires != 0 {
+		expires, _, _ = cutUnix(value)
+		if value, err = checkExpiry(value, m.Now()); err != nil {
+			return opened{}, err
+		}
+	}
+	o := opened{flags: h.flags}
+	if h.flags&flagIssued != 0 {
+		o.issued, _, _ = cutUnix(value)
+		if value, err = m.checkIssued(mode, value); err != nil {
+			return opened{}, err
+		}
+	}
+	if h.flags&flagOnce != 0 {
+		value, err = m.claim(r.Context(), name, value, expires)
+		if err != nil {
+			return opened{}, err
+		}
+	}
+	o.value = value
+	return o, nil
+}
+
+// associatedData binds an encrypted value to its header, cookie name, and context.
+func (m *Manager) associatedData(encoded, name string) []byte {
+	return []byte(encoded + name + "\x00" + m.context)
+}
+
+// openUnversioned reads values written without an envelope.
+func (m *Manager) openUnversioned(mode Mode, name, value string) (string, error) {
+	switch mode {
+	case Plain:
+		return value, nil
+	case Signed:
+		secretKeys, err := m.readKeys(purposeSign, name)
+		if err != nil {
+			return "", err
+		}
+		return verifyKeys(name, value, secretKeys)
+	case Encrypted:
+		secretKeys, err := m.readKeys(purposeEncrypt, name)
+		if err != nil {
+			return "", err
+		}
+		return decryptKeys(name, value, secretKeys)
+	default:
+		return "", fmt.Errorf("%w: unsupported mode %s", ErrCookie, mode)
+	}
+}
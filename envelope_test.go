@@ -0,0 +1,120 @@
+package cookie
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// rewrite returns a request carrying cookie name with its decoded value replaced.
+func rewrite(t *testing.T, w *httptest.ResponseRecorder, name string, edit func(string) string) *http.Request {
+	t.Helper()
+	value, err := Read(requestWith(w), name)
+	require.NoError(t, err)
+	out := httptest.NewRecorder()
+	require.NoError(t, Write(out, http.Cookie{Name: name, Value: edit(value)}))
+	return requestWith(out)
+}
+
+// renamed returns a request carrying cookie from's value as cookie to, with
+// the part of from's name not in to inserted into the body at offset(body).
+func renamed(t *testing.T, w *httptest.ResponseRecorder, from, to string, offset func(body string) int) *http.Request {
+	t.Helper()
+	value, err := Read(requestWith(w), from)
+	require.NoError(t, err)
+	_, encoded, body, err := parseHeader(value)
+	require.NoError(t, err)
+	i := offset(body)
+	moved := encoded + body[:i] + from[len(to):] + body[i:]
+	out := httptest.NewRecorder()
+	require.NoError(t, Write(out, http.Cookie{Name: to, Value: moved}))
+	return requestWith(out)
+}
+
+func TestEnvelope(t *testing.T) {
+	m := newTestManager(t)
+	w := httptest.NewRecorder()
+	require.NoError(t, m.WriteSigned(w, "s", "chocolate fudge"))
+	require.NoError(t, m.WriteEncryptedValue(w, "e", "oatmeal raisin"))
+
+	raw, err := Read(requestWith(w), "s")
+	require.NoError(t, err)
+	require.True(t, isEnvelope(raw))
+
+	t.Run("unsupported version", func(t *testing.T) {
+		r := rewrite(t, w, "s", func(v string) string { return v[:2] + "\x09" + v[3:] })
+		_, err := m.ReadSigned(r, "s")
+		require.ErrorIs(t, err, ErrUnsupportedVersion)
+	})
+
+	t.Run("mode mismatch", func(t *testing.T) {
+		_, err := m.ReadEncryptedValue(requestWith(w), "s")
+		require.ErrorIs(t, err, ErrCookie)
+	})
+
+	t.Run("header tamper", func(t *testing.T) {
+		// flipping a flag bit is caught by the signature and the AEAD
+		flip := func(v string) string { return v[:5] + string(v[5]^flagCompressed) + v[6:] }
+		_, err := m.ReadSigned(rewrite(t, w, "s", flip), "s")
+		require.ErrorIs(t, err, ErrCookie)
+		_, err = m.ReadEncryptedValue(rewrite(t, w, "e", flip), "e")
+		require.Error(t, err)
+	})
+
+	t.Run("swapped name", func(t *testing.T) {
+		value, err := Read(requestWith(w), "e")
+		require.NoError(t, err)
+		swapped := httptest.NewRecorder()
+		require.NoError(t, Write(swapped, http.Cookie{Name: "other", Value: value}))
+		_, err = m.ReadEncryptedValue(requestWith(swapped), "other")
+		require.Error(t, err)
+	})
+
+	t.Run("name prefix", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		require.NoError(t, m.WriteSigned(w, "ab", "cX"))
+		// move the last byte of the name to the front of the value
+		r := renamed(t, w, "ab", "a", func(string) int { return 32 }) // after the SHA-256 HMAC
+		_, err := m.ReadSigned(r, "a")
+		require.ErrorIs(t, err, ErrSignatureMismatch)
+	})
+
+	t.Run("associated data", func(t *testing.T) {
+		tenant, err := m.With(WithAssociatedData("tenant-a"))
+		require.NoError(t, err)
+		w := httptest.NewRecorder()
+		require.NoError(t, tenant.WriteEncryptedValue(w, "e", "oatmeal raisin"))
+		value, err := tenant.ReadEncryptedValue(requestWith(w), "e")
+		require.NoError(t, err)
+		require.Equal(t, "oatmeal raisin", value)
+		_, err = m.ReadEncryptedValue(requestWith(w), "e")
+		require.Error(t, err)
+	})
+
+	t.Run("unknown key", func(t *testing.T) {
+		other := newTestManager(t)
+		_, err := other.ReadSigned(requestWith(w), "s")
+		require.ErrorIs(t, err, ErrUnknownKey)
+	})
+
+	t.Run("unversioned", func(t *testing.T) {
+		secretKey, err := NewCookieSecret()
+		require.NoError(t, err)
+		legacy, err := New(WithSecret(secretKey))
+		require.NoError(t, err)
+
+		w := httptest.NewRecorder()
+		require.NoError(t, WriteSigned(w, testCookie, secretKey))
+		value, err := legacy.ReadSigned(requestWith(w), testCookie.Name)
+		require.NoError(t, err)
+		require.Equal(t, testCookie.Value, value)
+
+		w = httptest.NewRecorder()
+		require.NoError(t, Write(w, testCookie))
+		value, err = legacy.Read(requestWith(w), testCookie.Name)
+		require.NoError(t, err)
+		require.Equal(t, testCookie.Value, value)
+	})
+}
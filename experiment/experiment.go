@@ -0,0 +1,230 @@
+// package experiment assigns visitors to A/B experiment variants. Each variant
+// is chosen deterministically from a hash of a random visitor ID and the
+// experiment name, and kept with the visitor ID in a signed cookie, so the
+// assignment is stable across requests and cannot be picked by the client.
+package experiment
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/grackleclub/cookie/v2"
+	"github.com/grackleclub/cookie/v2/internal/token"
+)
+
+const (
+	defaultName     = "experiments"
+	defaultTTL      = 90 * 24 * time.Hour
+	visitorIDLength = 16
+)
+
+var ErrExperiment = errors.New("experiment failure")
+
+// Experiment is a test between variants.
+type Experiment struct {
+	Name     string
+	Variants []string
+	Weights  []int // relative share of visitors per variant; nil splits them evenly
+}
+
+// Bucket returns the variant of e for visitorID. It depends only on its
+// arguments, so every server assigns a visitor the same variant.
+func (e Experiment) Bucket(visitorID string) string {
+	sum := sha256.Sum256([]byte(visitorID + "\x00" + e.Name))
+	n := binary.BigEndian.Uint64(sum[:8])
+	if e.Weights == nil {
+		return e.Variants[n%uint64(len(e.Variants))]
+	}
+	total := 0
+	for _, weight := range e.Weights {
+		total += weight
+	}
+	point := int(n % uint64(total))
+	for i, weight := range e.Weights {
+		if point < weight {
+			return e.Variants[i]
+		}
+		point -= weight
+	}
+	return e.Variants[len(e.Variants)-1]
+}
+
+func (e Experiment) validate() error {
+	if e.Name == "" {
+		return errors.New("experiment has no name")
+	}
+	if len(e.Variants) == 0 {
+		return fmt.Errorf("experiment '%s' has no variants", e.Name)
+	}
+	seen := map[string]bool{}
+	for _, variant := range e.Variants {
+		if seen[variant] {
+			return fmt.Errorf("experiment '%s' has variant '%s' twice", e.Name, variant)
+		}
+		seen[variant] = true
+	}
+	if e.Weights == nil {
+		return nil
+	}
+	if len(e.Weights) != len(e.Variants) {
+		return fmt.Errorf("experiment '%s' has %d weights for %d variants", e.Name, len(e.Weights), len(e.Variants))
+	}
+	total := 0
+	for _, weight := range e.Weights {
+		if weight < 0 {
+			return fmt.Errorf("experiment '%s' has a negative weight", e.Name)
+		}
+		total += weight
+	}
+	if total == 0 {
+		return fmt.Errorf("experiment '%s' has no weight", e.Name)
+	}
+	return nil
+}
+
+// running reports whether variant is a variant of e still given visitors.
+func (e Experiment) running(variant string) bool {
+	i := slices.Index(e.Variants, variant)
+	return i >= 0 && (e.Weights == nil || e.Weights[i] > 0)
+}
+
+// Assignments maps experiment names to the variants a visitor is assigned.
+type Assignments map[string]string
+
+// Variant returns the variant assigned for experiment, or "" if there is none.
+func (a Assignments) Variant(experiment string) string {
+	return a[experiment]
+}
+
+// stored is a visitor's ID, from which variants are hashed, and the variants
+// they were assigned, kept so changing an experiment's weights does not move them.
+type stored struct {
+	VisitorID string      `json:"id"`
+	Variants  Assignments `json:"a,omitempty"`
+}
+
+// Experiments assigns visitors to the variants of running experiments.
+type Experiments struct {
+	cookies     *cookie.Manager
+	name        string
+	ttl         time.Duration
+	experiments map[string]Experiment
+}
+
+// Option configures Experiments.
+type Option func(*Experiments) error
+
+// New creates Experiments running the given experiments. The cookie.Manager
+// must hold a secret, which is used to sign the assignment cookie.
+func New(cookies *cookie.Manager, experiments []Experiment, opts ...Option) (*Experiments, error) {
+	if cookies == nil {
+		return nil, fmt.Errorf("%w: cookie manager is required", cookie.ErrInitiation)
+	}
+	x := &Experiments{
+		cookies:     cookies,
+		name:        defaultName,
+		ttl:         defaultTTL,
+		experiments: map[string]Experiment{},
+	}
+	for _, e := range experiments {
+		if err := e.validate(); err != nil {
+			return nil, fmt.Errorf("%w: %w", cookie.ErrInitiation, err)
+		}
+		if _, ok := x.experiments[e.Name]; ok {
+			return nil, fmt.Errorf("%w: experiment '%s' defined twice", cookie.ErrInitiation, e.Name)
+		}
+		x.experiments[e.Name] = e
+	}
+	for _, opt := range opts {
+		if err := opt(x); err != nil {
+			return nil, fmt.Errorf("%w: %w", cookie.ErrInitiation, err)
+		}
+	}
+	cookies, err := cookies.With(cookie.WithMaxAge(x.ttl))
+	if err != nil {
+		return nil, err
+	}
+	x.cookies = cookies
+	return x, nil
+}
+
+// WithName sets the name of the assignment cookie.
+func WithName(name string) Option {
+	return func(x *Experiments) error {
+		if name == "" {
+			return errors.New("empty experiment cookie name")
+		}
+		x.name = name
+		return nil
+	}
+}
+
+// WithTTL sets how long a visitor keeps their ID and assignments. Defaults to 90 days.
+func WithTTL(ttl time.Duration) Option {
+	return func(x *Experiments) error {
+		if ttl < time.Hour {
+			return fmt.Errorf("experiment ttl too short: %s", ttl)
+		}
+		x.ttl = ttl
+		return nil
+	}
+}
+
+// Assign returns the visitor's variant of every running experiment, writing
+// the assignment cookie when it is new or changed. Variants already assigned
+// are kept while their experiment still gives them visitors; assignments to
+// experiments no longer running are dropped. A missing or tampered cookie
+// gives the visitor a new ID.
+func (x *Experiments) Assign(w http.ResponseWriter, r *http.Request) (Assignments, error) {
+	payload, err := cookie.ReadJSON[stored](x.cookies, r, x.name, cookie.Signed)
+	fresh := err != nil || payload.VisitorID == ""
+	if fresh {
+		id, err := token.Random(visitorIDLength)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrExperiment, err)
+		}
+		payload = stored{VisitorID: id}
+	}
+	assigned := Assignments{}
+	for name, e := range x.experiments {
+		variant, ok := payload.Variants[name]
+		if !ok || !e.running(variant) {
+			variant = e.Bucket(payload.VisitorID)
+		}
+		assigned[name] = variant
+	}
+	if fresh || !maps.Equal(assigned, payload.Variants) {
+		payload.Variants = assigned
+		if err := cookie.WriteJSON(x.cookies, w, x.name, payload, cookie.Signed); err != nil {
+			return nil, err
+		}
+	}
+	return maps.Clone(assigned), nil
+}
+
+type assignmentsKey struct{}
+
+// Middleware assigns each request's visitor, adding the assignments to the
+// request context, where FromContext returns them for handlers and templates.
+// Requests are served without assignments if the cookie cannot be written.
+func (x *Experiments) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if assigned, err := x.Assign(w, r); err == nil {
+			r = r.WithContext(context.WithValue(r.Context(), assignmentsKey{}, assigned))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// FromContext returns the assignments added by Middleware, or nil.
+func FromContext(ctx context.Context) Assignments {
+	assigned, _ := ctx.Value(assignmentsKey{}).(Assignments)
+	return assigned
+}
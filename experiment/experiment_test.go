@@ -0,0 +1,93 @@
+package experiment
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/grackleclub/cookie/v2"
+	"github.com/grackleclub/cookie/v2/cookietest"
+	"github.com/stretchr/testify/require"
+)
+
+var checkout = Experiment{Name: "checkout", Variants: []string{"control", "one-page"}}
+
+func TestBucket(t *testing.T) {
+	require.Equal(t, checkout.Bucket("visitor"), checkout.Bucket("visitor"))
+
+	counts := map[string]int{}
+	weighted := Experiment{Name: "banner", Variants: []string{"a", "b", "c"}, Weights: []int{90, 10, 0}}
+	for i := range 10000 {
+		counts[weighted.Bucket(fmt.Sprint(i))]++
+	}
+	require.InDelta(t, 9000, counts["a"], 300)
+	require.InDelta(t, 1000, counts["b"], 300)
+	require.Zero(t, counts["c"])
+}
+
+func TestExperiments(t *testing.T) {
+	cookies := cookietest.NewManager(t)
+	x, err := New(cookies, []Experiment{checkout})
+	require.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	assigned, err := x.Assign(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	require.NoError(t, err)
+	require.Contains(t, checkout.Variants, assigned.Variant("checkout"))
+	require.Len(t, w.Result().Cookies(), 1)
+
+	// stable, without rewriting the cookie
+	r := cookietest.RequestFrom(t, w)
+	w = httptest.NewRecorder()
+	again, err := x.Assign(w, r)
+	require.NoError(t, err)
+	require.Equal(t, assigned, again)
+	require.Empty(t, w.Result().Cookies())
+
+	// a variant that no longer gets visitors is reassigned
+	shifted := checkout
+	shifted.Weights = []int{0, 1}
+	if assigned.Variant("checkout") == "one-page" {
+		shifted.Weights = []int{1, 0}
+	}
+	x2, err := New(cookies, []Experiment{shifted, {Name: "color", Variants: []string{"red"}}})
+	require.NoError(t, err)
+	w = httptest.NewRecorder()
+	moved, err := x2.Assign(w, r)
+	require.NoError(t, err)
+	require.NotEqual(t, assigned.Variant("checkout"), moved.Variant("checkout"))
+	require.Equal(t, "red", moved.Variant("color"))
+	require.Len(t, w.Result().Cookies(), 1)
+
+	// a tampered cookie gives a new visitor
+	c := r.Cookies()[0]
+	c.Value = c.Value[:len(c.Value)-2] + "AA"
+	tampered := httptest.NewRequest(http.MethodGet, "/", nil)
+	tampered.AddCookie(c)
+	w = httptest.NewRecorder()
+	_, err = x.Assign(w, tampered)
+	require.NoError(t, err)
+	require.NotEqual(t, c.Value, w.Result().Cookies()[0].Value)
+
+	_, err = New(cookies, []Experiment{{Name: "empty"}})
+	require.ErrorIs(t, err, cookie.ErrInitiation)
+	_, err = New(cookies, []Experiment{checkout, checkout})
+	require.ErrorIs(t, err, cookie.ErrInitiation)
+	_, err = New(cookies, []Experiment{{Name: "w", Variants: []string{"a"}, Weights: []int{0}}})
+	require.ErrorIs(t, err, cookie.ErrInitiation)
+}
+
+func TestMiddleware(t *testing.T) {
+	x, err := New(cookietest.NewManager(t), []Experiment{checkout})
+	require.NoError(t, err)
+	var variant string
+	handler := x.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		variant = FromContext(r.Context()).Variant("checkout")
+	}))
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	require.Contains(t, checkout.Variants, variant)
+
+	require.Nil(t, FromContext(httptest.NewRequest(http.MethodGet, "/", nil).Context()))
+}
@@ -0,0 +1,131 @@
+package cookie
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"hash"
+	"net/http"
+	"time"
+)
+
+// expiryLabel separates expiring signatures from plain signatures,
+// so neither can be replayed as the other.
+const expiryLabel = "cookie/expires\x00"
+
+// WriteSignedWithExpiry writes a signed cookie whose expiry is bound into the signature.
+// Unlike Expires and Max-Age, the embedded expiry cannot be stripped by the client:
+// ReadSigned rejects the cookie with ErrExpired once it has passed.
+// If the cookie sets neither Expires nor MaxAge, Expires is set to match.
+func WriteSignedWithExpiry(w http.ResponseWriter, cookie http.Cookie, expires time.Time, secretKey []byte) error {
+	if len(secretKey) == 0 {
+		return ErrSecretMissing
+	}
+	if cookie.Expires.IsZero() && cookie.MaxAge == 0 {
+		cookie.Expires = expires
+	}
+	cookie.Value = signExpiring(cookie.Name, cookie.Value, expires, secretKey)
+	return Write(w, cookie)
+}
+
+// WriteSignedWithExpiry writes a signed cookie whose expiry is bound into the signature
+// using the Manager's newest secret. The cookie's Max-Age is set to match.
+func (m *Manager) WriteSignedWithExpiry(w http.ResponseWriter, name, value string, expires time.Time) error {
+	maxAge := int(expires.Sub(m.Now()).Seconds())
+	if maxAge <= 0 {
+		return fmt.Errorf("%w: expiry %s is not in the future", ErrCookie, expires.UTC().Format(time.RFC3339))
+	}
+	cookie := m.Cookie(name, "")
+	sealed, err := m.seal(Signed, cookie.Name, value, sealOptions{expires: expires})
+	if err != nil {
+		return err
+	}
+	cookie.Value = sealed
+	cookie.MaxAge = maxAge
+	return m.write(w, cookie)
+}
+
+// WithSignedMaxAge stamps every signed value with its creation time, and makes
+// ReadSigned reject values older than maxAge, or without a stamp, with ErrExpired.
+// Unlike Max-Age, the stamp is covered by the signature, so the client cannot extend it.
+func WithSignedMaxAge(maxAge time.Duration) Option {
+	return func(m *Manager) error {
+		if maxAge < 0 {
+			return fmt.Errorf("negative signed max age: %s", maxAge)
+		}
+		m.signedMaxAge = maxAge
+		return nil
+	}
+}
+
+// signExpiring prefixes value with its expiry as big-endian unix seconds,
+// then signs the name, expiry, and value together.
+func signExpiring(name, value string, expires time.Time, secretKey []byte) string {
+	payload := unixPrefix(expires) + value
+	return string(macSum(sha256.New, secretKey, expiryLabel, name, payload)) + payload
+}
+
+// verifyExpiring checks a value produced by signExpiring.
+// ok reports whether the signature matched, regardless of expiry.
+func verifyExpiring(name, signedValue string, secretKey []byte) (value string, ok bool, err error) {
+	if len(signedValue) < sha256.Size+8 {
+		return "", false, nil
+	}
+	signature := signedValue[:sha256.Size]
+	payload := signedValue[sha256.Size:]
+	if !hmac.Equal([]byte(signature), macSum(sha256.New, secretKey, expiryLabel, name, payload)) {
+		return "", false, nil
+	}
+	value, err = checkExpiry(payload, time.Now())
+	return value, true, err
+}
+
+// checkExpiry strips the expiry prefix from payload, failing with ErrExpired once it has passed at now.
+func checkExpiry(payload string, now time.Time) (string, error) {
+	expires, value, ok := cutUnix(payload)
+	if !ok {
+		return "", fmt.Errorf("%w: expiry missing", ErrCookie)
+	}
+	if !now.Before(expires) {
+		return "", fmt.Errorf("%w: %w at %s", ErrCookie, ErrExpired, expires.UTC().Format(time.RFC3339))
+	}
+	return value, nil
+}
+
+// checkIssued strips the creation stamp from payload, failing signed values
+// with ErrExpired once they are older than the Manager's signed max age.
+func (m *Manager) checkIssued(mode Mode, payload string) (string, error) {
+	issued, value, ok := cutUnix(payload)
+	if !ok {
+		return "", fmt.Errorf("%w: creation time missing", ErrCookie)
+	}
+	if mode == Signed && m.signedMaxAge > 0 && !m.Now().Before(issued.Add(m.signedMaxAge)) {
+		return "", fmt.Errorf("%w: %w: issued at %s", ErrCookie, ErrExpired, issued.UTC().Format(time.RFC3339))
+	}
+	return value, nil
+}
+
+// unixPrefix encodes t as big-endian unix seconds.
+func unixPrefix(t time.Time) string {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, uint64(t.Unix()))
+	return string(b)
+}
+
+// cutUnix splits a unixPrefix from the front of payload.
+func cutUnix(payload string) (time.Time, string, bool) {
+	if len(payload) < 8 {
+		return time.Time{}, "", false
+	}
+	return time.Unix(int64(binary.BigEndian.Uint64([]byte(payload[:8]))), 0), payload[8:], true
+}
+
+// macSum returns the HMAC of parts, written in order.
+func macSum(newHash func() hash.Hash, secretKey []byte, parts ...string) []byte {
+	mac := hmac.New(newHash, secretKey)
+	for _, part := range parts {
+		mac.Write([]byte(part))
+	}
+	return mac.Sum(nil)
+}
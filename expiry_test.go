@@ -0,0 +1,80 @@
+package cookie
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteSignedWithExpiry(t *testing.T) {
+	secretKey, err := NewCookieSecret()
+	require.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	require.NoError(t, WriteSignedWithExpiry(w, testCookie, time.Now().Add(time.Hour), secretKey))
+	value, err := ReadSigned(requestWith(w), testCookie.Name, secretKey)
+	require.NoError(t, err)
+	require.Equal(t, testCookie.Value, value)
+
+	// the client ignoring Max-Age does not extend the embedded expiry
+	w = httptest.NewRecorder()
+	require.NoError(t, WriteSignedWithExpiry(w, testCookie, time.Now().Add(-time.Second), secretKey))
+	_, err = ReadSigned(requestWith(w), testCookie.Name, secretKey)
+	require.ErrorIs(t, err, ErrExpired)
+	require.ErrorIs(t, err, ErrCookie)
+
+	otherKey, err := NewCookieSecret()
+	require.NoError(t, err)
+	_, err = ReadSigned(requestWith(w), testCookie.Name, otherKey)
+	require.ErrorIs(t, err, ErrCookie)
+	require.NotErrorIs(t, err, ErrExpired)
+}
+
+func TestManagerWriteSignedWithExpiry(t *testing.T) {
+	m := newTestManager(t)
+
+	w := httptest.NewRecorder()
+	require.NoError(t, m.WriteSignedWithExpiry(w, "a", "b", time.Now().Add(time.Minute)))
+	require.InDelta(t, 60, w.Result().Cookies()[0].MaxAge, 1)
+
+	value, err := m.ReadSigned(requestWith(w), "a")
+	require.NoError(t, err)
+	require.Equal(t, "b", value)
+
+	err = m.WriteSignedWithExpiry(httptest.NewRecorder(), "a", "b", time.Now().Add(-time.Minute))
+	require.ErrorIs(t, err, ErrCookie)
+}
+
+func TestWithSignedMaxAge(t *testing.T) {
+	plain := newTestManager(t)
+	m, err := plain.With(WithSignedMaxAge(time.Hour))
+	require.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	require.NoError(t, m.WriteSigned(w, "a", "b"))
+	value, err := m.ReadSigned(requestWith(w), "a")
+	require.NoError(t, err)
+	require.Equal(t, "b", value)
+
+	// a Manager without the option still reads stamped values
+	value, err = plain.ReadSigned(requestWith(w), "a")
+	require.NoError(t, err)
+	require.Equal(t, "b", value)
+
+	// stamps have second resolution, so any value is older than a nanosecond
+	strict, err := plain.With(WithSignedMaxAge(time.Nanosecond))
+	require.NoError(t, err)
+	_, err = strict.ReadSigned(requestWith(w), "a")
+	require.ErrorIs(t, err, ErrExpired)
+
+	// unstamped values are rejected once the option is set
+	w = httptest.NewRecorder()
+	require.NoError(t, plain.WriteSigned(w, "a", "b"))
+	_, err = m.ReadSigned(requestWith(w), "a")
+	require.ErrorIs(t, err, ErrExpired)
+
+	_, err = New(WithSignedMaxAge(-time.Second))
+	require.ErrorIs(t, err, ErrInitiation)
+}
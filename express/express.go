@@ -0,0 +1,123 @@
+// package express reads and writes cookies signed in the format of the
+// Node.js cookie-signature module, used by cookie-parser and express-session,
+// so Go and Express services can verify the same cookies during a migration.
+//
+// A signed value is "s:" + value + "." + the unpadded standard base64 of its
+// HMAC-SHA256, and is URI-encoded in the cookie as by encodeURIComponent.
+package express
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/grackleclub/cookie/v2"
+)
+
+const (
+	SessionCookie = "connect.sid" // default cookie name of express-session
+
+	signedPrefix  = "s:"
+	maxCookieSize = 4096
+)
+
+// Signer signs and verifies cookie-signature values.
+type Signer struct {
+	cookies *cookie.Manager
+	secrets [][]byte // newest first; only the newest is used to sign
+}
+
+// New creates a Signer from the secrets given to cookie-parser or
+// express-session, in the same order. Values are signed with the first
+// secret and verified against each in turn, as Express does.
+// Cookies are written with the cookie.Manager's default attributes and name prefix.
+func New(cookies *cookie.Manager, secrets ...[]byte) (*Signer, error) {
+	if cookies == nil {
+		return nil, fmt.Errorf("%w: cookie manager is required", cookie.ErrInitiation)
+	}
+	if len(secrets) == 0 {
+		return nil, fmt.Errorf("%w: %w", cookie.ErrInitiation, cookie.ErrSecretMissing)
+	}
+	for i, secret := range secrets {
+		if len(secret) == 0 {
+			return nil, fmt.Errorf("%w: secret %d: %w", cookie.ErrInitiation, i, cookie.ErrSecretMissing)
+		}
+	}
+	return &Signer{cookies: cookies, secrets: secrets}, nil
+}
+
+// Sign returns value with its signature appended, as cookie-signature's sign.
+func (s *Signer) Sign(value string) string {
+	return value + "." + signature(value, s.secrets[0])
+}
+
+// Unsign verifies a value produced by Sign, returning it without its signature,
+// as cookie-signature's unsign.
+func (s *Signer) Unsign(signed string) (string, error) {
+	i := strings.LastIndexByte(signed, '.')
+	if i < 0 {
+		return "", fmt.Errorf("%w: %w: no signature", cookie.ErrCookie, cookie.ErrTooShort)
+	}
+	value, mac := signed[:i], signed[i+1:]
+	for _, secret := range s.secrets {
+		if hmac.Equal([]byte(mac), []byte(signature(value, secret))) {
+			return value, nil
+		}
+	}
+	return "", fmt.Errorf("%w: %w", cookie.ErrCookie, cookie.ErrSignatureMismatch)
+}
+
+// Write signs value and sets it as the named cookie with the Manager's defaults,
+// as res.cookie(name, value, { signed: true }) does.
+func (s *Signer) Write(w http.ResponseWriter, name, value string) error {
+	c := s.cookies.Cookie(name, encodeURIComponent(signedPrefix+s.Sign(value)))
+	if len(c.String()) > maxCookieSize {
+		return fmt.Errorf("%w: %w", cookie.ErrCookie, cookie.ErrTooLong)
+	}
+	http.SetCookie(w, &c)
+	return nil
+}
+
+// Read verifies the named cookie and returns its value, as req.signedCookies does.
+// For express-session, the value is the session ID.
+func (s *Signer) Read(r *http.Request, name string) (string, error) {
+	fullName := s.cookies.Cookie(name, "").Name
+	c, err := r.Cookie(fullName)
+	if err != nil {
+		return "", fmt.Errorf("%w: '%s': %w", cookie.ErrNotFound, fullName, err)
+	}
+	raw, err := url.PathUnescape(c.Value)
+	if err != nil {
+		return "", fmt.Errorf("%w: cannot decode '%s': %w", cookie.ErrCookie, fullName, err)
+	}
+	signed, ok := strings.CutPrefix(raw, signedPrefix)
+	if !ok {
+		return "", fmt.Errorf("%w: '%s' is not signed", cookie.ErrCookie, fullName)
+	}
+	return s.Unsign(signed)
+}
+
+// signature returns the unpadded base64 HMAC-SHA256 of value.
+func signature(value string, secret []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(value))
+	return base64.RawStdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// encodeURIComponent escapes s as JavaScript's encodeURIComponent.
+func encodeURIComponent(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || strings.IndexByte("-_.!~*'()", c) >= 0 {
+			b.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(&b, "%%%02X", c)
+	}
+	return b.String()
+}
@@ -0,0 +1,99 @@
+package express
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/grackleclub/cookie/v2"
+	"github.com/stretchr/testify/require"
+)
+
+// signed by cookie-signature: sign('hello', 'tobiiscool')
+const vector = "hello.DGDUkGlIkCzPz+C0B064FNgHdEjox7ch8tOBGslZ5QI"
+
+func newSigner(t *testing.T, secrets ...string) *Signer {
+	t.Helper()
+	m, err := cookie.New()
+	require.NoError(t, err)
+	keys := make([][]byte, len(secrets))
+	for i, secret := range secrets {
+		keys[i] = []byte(secret)
+	}
+	s, err := New(m, keys...)
+	require.NoError(t, err)
+	return s
+}
+
+func TestSign(t *testing.T) {
+	s := newSigner(t, "tobiiscool")
+	require.Equal(t, vector, s.Sign("hello"))
+
+	value, err := s.Unsign(vector)
+	require.NoError(t, err)
+	require.Equal(t, "hello", value)
+
+	_, err = s.Unsign("hello.bogus")
+	require.ErrorIs(t, err, cookie.ErrSignatureMismatch)
+	_, err = s.Unsign("hello")
+	require.ErrorIs(t, err, cookie.ErrCookie)
+
+	_, err = newSigner(t, "luna").Unsign(vector)
+	require.ErrorIs(t, err, cookie.ErrSignatureMismatch)
+}
+
+func TestRotation(t *testing.T) {
+	value, err := newSigner(t, "new", "tobiiscool").Unsign(vector)
+	require.NoError(t, err)
+	require.Equal(t, "hello", value)
+}
+
+func TestRead(t *testing.T) {
+	s := newSigner(t, "tobiiscool")
+
+	// as set by express: res.cookie('connect.sid', 'hello', { signed: true })
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Cookie", SessionCookie+"=s%3AHello.DGDUkGlIkCzPz%2BC0B064FNgHdEjox7ch8tOBGslZ5QI")
+	_, err := s.Read(r, SessionCookie)
+	require.ErrorIs(t, err, cookie.ErrSignatureMismatch)
+
+	r.Header.Set("Cookie", SessionCookie+"=s%3Ahello.DGDUkGlIkCzPz%2BC0B064FNgHdEjox7ch8tOBGslZ5QI")
+	value, err := s.Read(r, SessionCookie)
+	require.NoError(t, err)
+	require.Equal(t, "hello", value)
+
+	r.Header.Set("Cookie", SessionCookie+"=hello")
+	_, err = s.Read(r, SessionCookie)
+	require.ErrorIs(t, err, cookie.ErrCookie)
+
+	_, err = s.Read(httptest.NewRequest(http.MethodGet, "/", nil), SessionCookie)
+	require.ErrorIs(t, err, cookie.ErrNotFound)
+}
+
+func TestWrite(t *testing.T) {
+	s := newSigner(t, "tobiiscool")
+	rec := httptest.NewRecorder()
+	require.NoError(t, s.Write(rec, SessionCookie, "hello"))
+
+	set := rec.Result().Cookies()
+	require.Len(t, set, 1)
+	require.Equal(t, "s%3Ahello.DGDUkGlIkCzPz%2BC0B064FNgHdEjox7ch8tOBGslZ5QI", set[0].Value)
+	require.True(t, set[0].HttpOnly)
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.AddCookie(set[0])
+	value, err := s.Read(r, SessionCookie)
+	require.NoError(t, err)
+	require.Equal(t, "hello", value)
+}
+
+func TestNew(t *testing.T) {
+	m, err := cookie.New()
+	require.NoError(t, err)
+	_, err = New(nil, []byte("secret"))
+	require.ErrorIs(t, err, cookie.ErrInitiation)
+	_, err = New(m)
+	require.ErrorIs(t, err, cookie.ErrSecretMissing)
+	_, err = New(m, []byte("secret"), nil)
+	require.ErrorIs(t, err, cookie.ErrSecretMissing)
+}
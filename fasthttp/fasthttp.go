@@ -0,0 +1,99 @@
+// package fasthttp adapts the cookie package to fasthttp, and so to Fiber,
+// whose handlers expose the *fasthttp.RequestCtx as c.Context().
+//
+// Each call builds a net/http view of the request from its headers, runs the
+// Manager as usual, and copies any cookies it sets onto the fasthttp response,
+// so signing, encryption, chunking, and binding behave exactly as with net/http.
+//
+// Import it under another name, such as cookiefast, alongside fasthttp itself.
+package fasthttp
+
+import (
+	"net/http"
+
+	"github.com/grackleclub/cookie/v2"
+	valyala "github.com/valyala/fasthttp"
+)
+
+// Do runs fn with a net/http request and response writer adapted from ctx,
+// then sets the cookies fn wrote on ctx's response. It lets any function of
+// this module that takes an http.ResponseWriter and *http.Request, such as
+// session.Manager.Save, be used from a fasthttp handler.
+func Do(ctx *valyala.RequestCtx, fn func(w http.ResponseWriter, r *http.Request) error) error {
+	w := &responseWriter{header: http.Header{}}
+	err := fn(w, Request(ctx))
+	for _, setCookie := range w.header.Values("Set-Cookie") {
+		ctx.Response.Header.Add("Set-Cookie", setCookie)
+	}
+	return err
+}
+
+// Request returns a net/http request carrying the method, URI, headers,
+// client address, and TLS state of ctx, with ctx as its context.
+func Request(ctx *valyala.RequestCtx) *http.Request {
+	r, err := http.NewRequestWithContext(ctx, string(ctx.Method()), string(ctx.RequestURI()), nil)
+	if err != nil {
+		// an unparseable URI does not affect cookies
+		r, _ = http.NewRequestWithContext(ctx, string(ctx.Method()), "/", nil)
+	}
+	r.Host = string(ctx.Host())
+	r.RemoteAddr = ctx.RemoteAddr().String()
+	r.TLS = ctx.TLSConnectionState()
+	ctx.Request.Header.VisitAll(func(key, value []byte) {
+		r.Header.Add(string(key), string(value))
+	})
+	return r
+}
+
+// Read reads a base64 encoded cookie, as Manager.Read.
+func Read(m *cookie.Manager, ctx *valyala.RequestCtx, name string) (string, error) {
+	return m.Read(Request(ctx), name)
+}
+
+// Write writes a base64 encoded cookie, as Manager.Write.
+func Write(m *cookie.Manager, ctx *valyala.RequestCtx, name, value string) error {
+	return Do(ctx, func(w http.ResponseWriter, _ *http.Request) error {
+		return m.Write(w, name, value)
+	})
+}
+
+// ReadSigned reads and verifies a signed cookie, as Manager.ReadSigned.
+func ReadSigned(m *cookie.Manager, ctx *valyala.RequestCtx, name string) (string, error) {
+	return m.ReadSigned(Request(ctx), name)
+}
+
+// WriteSigned writes a signed cookie, as Manager.WriteSigned.
+func WriteSigned(m *cookie.Manager, ctx *valyala.RequestCtx, name, value string) error {
+	return Do(ctx, func(w http.ResponseWriter, _ *http.Request) error {
+		return m.WriteSigned(w, name, value)
+	})
+}
+
+// ReadEncrypted reads and decrypts an encrypted cookie, as Manager.ReadEncryptedValue.
+func ReadEncrypted(m *cookie.Manager, ctx *valyala.RequestCtx, name string) (string, error) {
+	return m.ReadEncryptedValue(Request(ctx), name)
+}
+
+// WriteEncrypted writes an encrypted cookie, as Manager.WriteEncryptedValue.
+func WriteEncrypted(m *cookie.Manager, ctx *valyala.RequestCtx, name, value string) error {
+	return Do(ctx, func(w http.ResponseWriter, _ *http.Request) error {
+		return m.WriteEncryptedValue(w, name, value)
+	})
+}
+
+// Delete expires a cookie, as Manager.Delete.
+func Delete(m *cookie.Manager, ctx *valyala.RequestCtx, name string) {
+	Do(ctx, func(w http.ResponseWriter, _ *http.Request) error {
+		m.Delete(w, name)
+		return nil
+	})
+}
+
+// responseWriter collects the headers written by the Manager; bodies are discarded.
+type responseWriter struct {
+	header http.Header
+}
+
+func (w *responseWriter) Header() http.Header         { return w.header }
+func (w *responseWriter) Write(b []byte) (int, error) { return len(b), nil }
+func (w *responseWriter) WriteHeader(int)             {}
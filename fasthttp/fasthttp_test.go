@@ -0,0 +1,107 @@
+package fasthttp
+
+import (
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/grackleclub/cookie/v2"
+	"github.com/grackleclub/cookie/v2/cookietest"
+	"github.com/grackleclub/cookie/v2/session"
+	"github.com/stretchr/testify/require"
+	valyala "github.com/valyala/fasthttp"
+)
+
+// newCtx returns a request context carrying the cookies set on prev's response.
+func newCtx(prev *valyala.RequestCtx) *valyala.RequestCtx {
+	var req valyala.Request
+	req.SetRequestURI("https://example.com/")
+	req.Header.Set("User-Agent", "test")
+	if prev != nil {
+		prev.Response.Header.VisitAllCookie(func(key, value []byte) {
+			c := valyala.AcquireCookie()
+			defer valyala.ReleaseCookie(c)
+			c.ParseBytes(value)
+			if c.MaxAge() >= 0 && len(c.Value()) > 0 {
+				req.Header.SetCookieBytesKV(key, c.Value())
+			}
+		})
+	}
+	ctx := &valyala.RequestCtx{}
+	ctx.Init(&req, &net.TCPAddr{IP: net.IPv4(192, 0, 2, 1), Port: 1234}, nil)
+	return ctx
+}
+
+func TestReadWrite(t *testing.T) {
+	m := cookietest.NewManager(t)
+	ctx := newCtx(nil)
+	require.NoError(t, Write(m, ctx, "theme", "dark"))
+	require.NoError(t, WriteSigned(m, ctx, "user", "alice"))
+	require.NoError(t, WriteEncrypted(m, ctx, "secret", "s3cr3t"))
+
+	next := newCtx(ctx)
+	value, err := Read(m, next, "theme")
+	require.NoError(t, err)
+	require.Equal(t, "dark", value)
+	value, err = ReadSigned(m, next, "user")
+	require.NoError(t, err)
+	require.Equal(t, "alice", value)
+	value, err = ReadEncrypted(m, next, "secret")
+	require.NoError(t, err)
+	require.Equal(t, "s3cr3t", value)
+
+	_, err = ReadSigned(m, newCtx(nil), "user")
+	require.ErrorIs(t, err, cookie.ErrNotFound)
+
+	Delete(m, next, "user")
+	require.Contains(t, string(next.Response.Header.PeekCookie("user")), "Max-Age=0")
+}
+
+func TestChunked(t *testing.T) {
+	m := cookietest.NewManager(t, cookie.WithChunking(16<<10))
+	ctx := newCtx(nil)
+	large := strings.Repeat("x", 6000)
+	require.NoError(t, WriteSigned(m, ctx, "large", large))
+
+	value, err := ReadSigned(m, newCtx(ctx), "large")
+	require.NoError(t, err)
+	require.Equal(t, large, value)
+}
+
+func TestBinding(t *testing.T) {
+	m := cookietest.NewManager(t, cookie.WithBinding(cookie.Binding{UserAgent: true}))
+	ctx := newCtx(nil)
+	require.NoError(t, Do(ctx, func(w http.ResponseWriter, r *http.Request) error {
+		return m.WriteSignedBound(w, r, "bound", "alice")
+	}))
+
+	next := newCtx(ctx)
+	value, err := ReadSigned(m, next, "bound")
+	require.NoError(t, err)
+	require.Equal(t, "alice", value)
+
+	next = newCtx(ctx)
+	next.Request.Header.Set("User-Agent", "other")
+	_, err = ReadSigned(m, next, "bound")
+	require.ErrorIs(t, err, cookie.ErrSignatureMismatch)
+}
+
+func TestSession(t *testing.T) {
+	sessions, err := session.New(cookietest.NewManager(t), session.NewMemoryStore(0))
+	require.NoError(t, err)
+
+	ctx := newCtx(nil)
+	s, err := sessions.Load(Request(ctx))
+	require.NoError(t, err)
+	require.NoError(t, s.Set("user", "alice"))
+	require.NoError(t, Do(ctx, func(w http.ResponseWriter, r *http.Request) error {
+		return sessions.Save(w, r, s)
+	}))
+
+	s, err = sessions.Load(Request(newCtx(ctx)))
+	require.NoError(t, err)
+	user, ok := session.Get[string](s, "user")
+	require.True(t, ok)
+	require.Equal(t, "alice", user)
+}
@@ -0,0 +1,170 @@
+// package flags keeps per-user feature flag overrides, flag names mapped to
+// variants, in a signed cookie. Only code holding the signing secret, such as
+// staff tooling, can set them, so developers can safely try features on
+// themselves in production while every other visitor gets the usual variant.
+package flags
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"time"
+
+	"github.com/grackleclub/cookie/v2"
+)
+
+const (
+	defaultName = "flags"
+	defaultTTL  = 24 * time.Hour
+)
+
+var ErrFlags = errors.New("feature flag overrides invalid")
+
+// Overrides maps flag names to the variants that replace their usual evaluation.
+type Overrides map[string]string
+
+// Variant returns the override for flag, reporting false if there is none.
+func (o Overrides) Variant(flag string) (string, bool) {
+	variant, ok := o[flag]
+	return variant, ok
+}
+
+// stored is a client's feature flag overrides and when, in unix seconds, they lapse.
+type stored struct {
+	Flags   Overrides `json:"f"`
+	Expires int64     `json:"e"`
+}
+
+// Flags sets and reads feature flag overrides.
+type Flags struct {
+	cookies *cookie.Manager
+	name    string
+	ttl     time.Duration
+	known   map[string]bool // flags that may be overridden; nil allows any
+}
+
+// Option configures Flags.
+type Option func(*Flags) error
+
+// New creates Flags. The cookie.Manager must hold a secret,
+// which is used to sign the overrides cookie.
+func New(cookies *cookie.Manager, opts ...Option) (*Flags, error) {
+	if cookies == nil {
+		return nil, fmt.Errorf("%w: cookie manager is required", cookie.ErrInitiation)
+	}
+	f := &Flags{
+		cookies: cookies,
+		name:    defaultName,
+		ttl:     defaultTTL,
+	}
+	for _, opt := range opts {
+		if err := opt(f); err != nil {
+			return nil, fmt.Errorf("%w: %w", cookie.ErrInitiation, err)
+		}
+	}
+	cookies, err := cookies.With(cookie.WithMaxAge(f.ttl))
+	if err != nil {
+		return nil, err
+	}
+	f.cookies = cookies
+	return f, nil
+}
+
+// WithName sets the name of the overrides cookie.
+func WithName(name string) Option {
+	return func(f *Flags) error {
+		if name == "" {
+			return errors.New("empty flags cookie name")
+		}
+		f.name = name
+		return nil
+	}
+}
+
+// WithTTL sets how long overrides last once set. Defaults to a day.
+func WithTTL(ttl time.Duration) Option {
+	return func(f *Flags) error {
+		if ttl < time.Minute {
+			return fmt.Errorf("flags ttl too short: %s", ttl)
+		}
+		f.ttl = ttl
+		return nil
+	}
+}
+
+// WithKnown limits overrides to the named flags. Set rejects others,
+// and Read drops them.
+func WithKnown(flags ...string) Option {
+	return func(f *Flags) error {
+		if f.known == nil {
+			f.known = map[string]bool{}
+		}
+		for _, flag := range flags {
+			f.known[flag] = true
+		}
+		return nil
+	}
+}
+
+// Set replaces the client's overrides. Serve it only to authenticated staff.
+func (f *Flags) Set(w http.ResponseWriter, overrides Overrides) error {
+	for flag, variant := range overrides {
+		if flag == "" || variant == "" {
+			return fmt.Errorf("%w: empty flag or variant", ErrFlags)
+		}
+		if f.known != nil && !f.known[flag] {
+			return fmt.Errorf("%w: unknown flag '%s'", ErrFlags, flag)
+		}
+	}
+	payload := stored{Flags: overrides, Expires: f.cookies.Now().Add(f.ttl).Unix()}
+	return cookie.WriteJSON(f.cookies, w, f.name, payload, cookie.Signed)
+}
+
+// Clear removes the client's overrides.
+func (f *Flags) Clear(w http.ResponseWriter) {
+	f.cookies.Delete(w, f.name)
+}
+
+// Read returns the request's overrides, or nil if it has none. A tampered
+// or expired cookie fails with ErrFlags; evaluate flags as usual then.
+func (f *Flags) Read(r *http.Request) (Overrides, error) {
+	payload, err := cookie.ReadJSON[stored](f.cookies, r, f.name, cookie.Signed)
+	if errors.Is(err, cookie.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrFlags, err)
+	}
+	if !f.cookies.Now().Before(time.Unix(payload.Expires, 0)) {
+		return nil, fmt.Errorf("%w: %w", ErrFlags, cookie.ErrExpired)
+	}
+	if f.known != nil {
+		maps.DeleteFunc(payload.Flags, func(flag, _ string) bool { return !f.known[flag] })
+	}
+	return payload.Flags, nil
+}
+
+type overridesKey struct{}
+
+// Middleware adds each request's overrides to its context, where FromContext
+// returns them. Invalid overrides cookies are deleted.
+func (f *Flags) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		overrides, err := f.Read(r)
+		if err != nil {
+			f.Clear(w)
+		}
+		if len(overrides) > 0 {
+			r = r.WithContext(context.WithValue(r.Context(), overridesKey{}, overrides))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// FromContext returns the overrides added by Middleware, or nil.
+func FromContext(ctx context.Context) Overrides {
+	overrides, _ := ctx.Value(overridesKey{}).(Overrides)
+	return overrides
+}
@@ -0,0 +1,76 @@
+package flags
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/grackleclub/cookie/v2/cookietest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFlags(t *testing.T) {
+	cookies := cookietest.NewManager(t)
+	f, err := New(cookies, WithKnown("search", "checkout"))
+	require.NoError(t, err)
+
+	overrides, err := f.Read(httptest.NewRequest(http.MethodGet, "/", nil))
+	require.NoError(t, err)
+	require.Nil(t, overrides)
+
+	w := httptest.NewRecorder()
+	require.NoError(t, f.Set(w, Overrides{"search": "v2"}))
+	require.Equal(t, 86400, w.Result().Cookies()[0].MaxAge)
+	overrides, err = f.Read(cookietest.RequestFrom(t, w))
+	require.NoError(t, err)
+	variant, ok := overrides.Variant("search")
+	require.True(t, ok)
+	require.Equal(t, "v2", variant)
+
+	require.ErrorIs(t, f.Set(httptest.NewRecorder(), Overrides{"payments": "on"}), ErrFlags)
+	require.ErrorIs(t, f.Set(httptest.NewRecorder(), Overrides{"search": ""}), ErrFlags)
+
+	// overrides signed with another secret are rejected
+	other, err := New(cookietest.NewManager(t))
+	require.NoError(t, err)
+	forged := httptest.NewRecorder()
+	require.NoError(t, other.Set(forged, Overrides{"checkout": "free"}))
+	_, err = f.Read(cookietest.RequestFrom(t, forged))
+	require.ErrorIs(t, err, ErrFlags)
+
+	// flags no longer known are dropped
+	unlimited, err := New(cookies)
+	require.NoError(t, err)
+	w = httptest.NewRecorder()
+	require.NoError(t, unlimited.Set(w, Overrides{"search": "v2", "retired": "on"}))
+	overrides, err = f.Read(cookietest.RequestFrom(t, w))
+	require.NoError(t, err)
+	require.Equal(t, Overrides{"search": "v2"}, overrides)
+
+	w = httptest.NewRecorder()
+	f.Clear(w)
+	overrides, err = f.Read(cookietest.RequestFrom(t, w))
+	require.NoError(t, err)
+	require.Nil(t, overrides)
+}
+
+func TestMiddleware(t *testing.T) {
+	f, err := New(cookietest.NewManager(t))
+	require.NoError(t, err)
+	var got Overrides
+	handler := f.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = FromContext(r.Context())
+	}))
+
+	w := httptest.NewRecorder()
+	require.NoError(t, f.Set(w, Overrides{"search": "v2"}))
+	handler.ServeHTTP(httptest.NewRecorder(), cookietest.RequestFrom(t, w))
+	require.Equal(t, Overrides{"search": "v2"}, got)
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.AddCookie(&http.Cookie{Name: defaultName, Value: "forged"})
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	require.Nil(t, got)
+	require.Equal(t, -1, w.Result().Cookies()[0].MaxAge)
+}
@@ -0,0 +1,67 @@
+package cookie
+
+import (
+	"crypto/tls"
+	"errors"
+	"net/http"
+	"slices"
+)
+
+// flashName is the unprefixed name of the cookie holding queued flash messages.
+const flashName = "flash"
+
+// FlashMessage is a one-time notification shown after a redirect.
+type FlashMessage struct {
+	Level   string `json:"level"`
+	Message string `json:"message"`
+}
+
+// Flash queues a message in a signed cookie, to be shown on the next request
+// that calls Flashes. Messages already queued by the request, or earlier in
+// this response, are kept.
+func (m *Manager) Flash(w http.ResponseWriter, r *http.Request, level, message string) error {
+	source := r
+	if pending, ok := takePending(w, m.prefix+flashName); ok {
+		source = pending
+	}
+	flashes, err := ReadJSON[[]FlashMessage](m, source, flashName, Signed)
+	if err != nil && !errors.Is(err, http.ErrNoCookie) {
+		// a tampered or stale queue is dropped rather than blocking new messages
+		flashes = nil
+	}
+	flashes = append(flashes, FlashMessage{Level: level, Message: message})
+	return WriteJSON(m, w, flashName, flashes, Signed)
+}
+
+// Flashes returns the queued flash messages and clears the queue.
+// A request without messages returns an empty slice and no error.
+func (m *Manager) Flashes(w http.ResponseWriter, r *http.Request) ([]FlashMessage, error) {
+	flashes, err := ReadJSON[[]FlashMessage](m, r, flashName, Signed)
+	if errors.Is(err, http.ErrNoCookie) {
+		return nil, nil
+	}
+	m.Delete(w, flashName)
+	if err != nil {
+		return nil, err
+	}
+	return flashes, nil
+}
+
+// takePending removes a cookie named name that was already set on the response,
+// returning a request that carries it so it can be read back.
+func takePending(w http.ResponseWriter, name string) (*http.Request, bool) {
+	header := w.Header()
+	values := header.Values("Set-Cookie")
+	for i, line := range values {
+		cookie, err := http.ParseSetCookie(line)
+		if err != nil || cookie.Name != name {
+			continue
+		}
+		header["Set-Cookie"] = slices.Delete(slices.Clone(values), i, i+1)
+		// the cookie never left the server, so it passes prefix transport checks
+		r := &http.Request{Header: http.Header{}, TLS: &tls.ConnectionState{}}
+		r.AddCookie(&http.Cookie{Name: cookie.Name, Value: cookie.Value})
+		return r, true
+	}
+	return nil, false
+}
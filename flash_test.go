@@ -0,0 +1,44 @@
+package cookie
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFlash(t *testing.T) {
+	m := newTestManager(t)
+
+	// nothing queued
+	w := httptest.NewRecorder()
+	flashes, err := m.Flashes(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	require.NoError(t, err)
+	require.Empty(t, flashes)
+	require.Empty(t, w.Result().Cookies())
+
+	// post: queue two messages in one response
+	w = httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/", nil)
+	require.NoError(t, m.Flash(w, r, "info", "saved"))
+	require.NoError(t, m.Flash(w, r, "warn", "quota nearly full"))
+	require.Len(t, w.Result().Cookies(), 1)
+
+	// redirect: queue another on top of the request's messages
+	r = requestWith(w)
+	w = httptest.NewRecorder()
+	require.NoError(t, m.Flash(w, r, "info", "redirected"))
+
+	// get: read and clear
+	r = requestWith(w)
+	w = httptest.NewRecorder()
+	flashes, err = m.Flashes(w, r)
+	require.NoError(t, err)
+	require.Equal(t, []FlashMessage{
+		{Level: "info", Message: "saved"},
+		{Level: "warn", Message: "quota nearly full"},
+		{Level: "info", Message: "redirected"},
+	}, flashes)
+	require.Equal(t, -1, w.Result().Cookies()[0].MaxAge)
+}
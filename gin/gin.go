@@ -0,0 +1,135 @@
+// package gin adapts the cookie, session, and csrf packages to Gin,
+// so handlers can read and write cookies from a *gin.Context.
+//
+// Import it under another name, such as cookiegin, alongside Gin itself.
+package gin
+
+import (
+	"net/http"
+
+	gingonic "github.com/gin-gonic/gin"
+	"github.com/grackleclub/cookie/v2"
+	"github.com/grackleclub/cookie/v2/csrf"
+	"github.com/grackleclub/cookie/v2/session"
+)
+
+// Keys under which the middleware stores values in the gin.Context.
+const (
+	ManagerKey  = "github.com/grackleclub/cookie/v2/gin.manager"
+	SessionsKey = "github.com/grackleclub/cookie/v2/gin.sessions"
+	SessionKey  = "github.com/grackleclub/cookie/v2/gin.session"
+)
+
+// Middleware stores m in the gin.Context for the helpers of this package,
+// and loads and refreshes the Manager's managed cookies as cookie.Middleware.
+func Middleware(m *cookie.Manager) gingonic.HandlerFunc {
+	wrapped := wrap(cookie.Middleware(m))
+	return func(c *gingonic.Context) {
+		c.Set(ManagerKey, m)
+		wrapped(c)
+	}
+}
+
+// Manager returns the cookie.Manager stored by Middleware.
+// Like c.MustGet, it panics if Middleware is not in the chain.
+func Manager(c *gingonic.Context) *cookie.Manager {
+	return c.MustGet(ManagerKey).(*cookie.Manager)
+}
+
+// Managed returns the value of a managed cookie loaded by Middleware,
+// as cookie.FromContext.
+func Managed(c *gingonic.Context, name string) (string, error) {
+	return cookie.FromContext(c.Request.Context(), name)
+}
+
+// Read reads a base64 encoded cookie with the Manager stored by Middleware.
+func Read(c *gingonic.Context, name string) (string, error) {
+	return Manager(c).Read(c.Request, name)
+}
+
+// Write writes a base64 encoded cookie with the Manager stored by Middleware.
+func Write(c *gingonic.Context, name, value string) error {
+	return Manager(c).Write(c.Writer, name, value)
+}
+
+// ReadSigned reads and verifies a signed cookie with the Manager stored by Middleware.
+func ReadSigned(c *gingonic.Context, name string) (string, error) {
+	return Manager(c).ReadSigned(c.Request, name)
+}
+
+// WriteSigned writes a signed cookie with the Manager stored by Middleware.
+func WriteSigned(c *gingonic.Context, name, value string) error {
+	return Manager(c).WriteSigned(c.Writer, name, value)
+}
+
+// ReadEncrypted reads and decrypts an encrypted cookie with the Manager stored by Middleware.
+func ReadEncrypted(c *gingonic.Context, name string) (string, error) {
+	return Manager(c).ReadEncryptedValue(c.Request, name)
+}
+
+// WriteEncrypted writes an encrypted cookie with the Manager stored by Middleware.
+func WriteEncrypted(c *gingonic.Context, name, value string) error {
+	return Manager(c).WriteEncryptedValue(c.Writer, name, value)
+}
+
+// Delete expires a cookie with the Manager stored by Middleware.
+func Delete(c *gingonic.Context, name string) {
+	Manager(c).Delete(c.Writer, name)
+}
+
+// Sessions loads the request's session into the gin.Context for Session and
+// SaveSession, aborting with 500 Internal Server Error if the store fails.
+// The session is not saved automatically, since headers may already be sent
+// when the handler chain returns.
+func Sessions(sessions *session.Manager) gingonic.HandlerFunc {
+	return func(c *gingonic.Context) {
+		s, err := sessions.Load(c.Request)
+		if err != nil {
+			c.AbortWithError(http.StatusInternalServerError, err)
+			return
+		}
+		c.Set(SessionsKey, sessions)
+		c.Set(SessionKey, s)
+		c.Next()
+	}
+}
+
+// Session returns the session loaded by Sessions.
+// Like c.MustGet, it panics if Sessions is not in the chain.
+func Session(c *gingonic.Context) *session.Session {
+	return c.MustGet(SessionKey).(*session.Session)
+}
+
+// SaveSession saves the session loaded by Sessions and writes its cookie.
+// Call it before writing the response body.
+func SaveSession(c *gingonic.Context) error {
+	return c.MustGet(SessionsKey).(*session.Manager).Save(c.Writer, c.Request, Session(c))
+}
+
+// Protect rejects requests that fail c's CSRF checks, as csrf.Protect.
+// The request's token is available from CSRFToken.
+func Protect(protect *csrf.CSRF) gingonic.HandlerFunc {
+	return wrap(protect.Protect)
+}
+
+// CSRFToken returns the request's CSRF token, for embedding in pages.
+func CSRFToken(c *gingonic.Context) string {
+	return csrf.Token(c.Request)
+}
+
+// wrap adapts net/http middleware to Gin, continuing the chain with the
+// request the middleware passes on and aborting it if the middleware does not call next.
+func wrap(middleware func(http.Handler) http.Handler) gingonic.HandlerFunc {
+	return func(c *gingonic.Context) {
+		called := false
+		next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			called = true
+			c.Request = r
+			c.Next()
+		})
+		middleware(next).ServeHTTP(c.Writer, c.Request)
+		if !called {
+			c.Abort()
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package gin
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	gingonic "github.com/gin-gonic/gin"
+	"github.com/grackleclub/cookie/v2"
+	"github.com/grackleclub/cookie/v2/cookietest"
+	"github.com/grackleclub/cookie/v2/csrf"
+	"github.com/grackleclub/cookie/v2/session"
+	"github.com/stretchr/testify/require"
+)
+
+func init() {
+	gingonic.SetMode(gingonic.TestMode)
+}
+
+func serve(router *gingonic.Engine, method, target string, cookies ...*http.Cookie) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, target, nil)
+	for _, c := range cookies {
+		r.AddCookie(c)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, r)
+	return w
+}
+
+func TestHelpers(t *testing.T) {
+	router := gingonic.New()
+	router.Use(Middleware(cookietest.NewManager(t, cookie.WithManaged("theme", cookie.Signed))))
+	router.GET("/set", func(c *gingonic.Context) {
+		require.NoError(t, WriteSigned(c, "theme", "dark"))
+		require.NoError(t, WriteEncrypted(c, "user", "alice"))
+		c.Status(http.StatusNoContent)
+	})
+	router.GET("/get", func(c *gingonic.Context) {
+		theme, err := Managed(c, "theme")
+		require.NoError(t, err)
+		user, err := ReadEncrypted(c, "user")
+		require.NoError(t, err)
+		c.String(http.StatusOK, theme+" "+user)
+	})
+
+	w := serve(router, http.MethodGet, "/set")
+	require.Equal(t, http.StatusNoContent, w.Code)
+	w = serve(router, http.MethodGet, "/get", w.Result().Cookies()...)
+	require.Equal(t, "dark alice", w.Body.String())
+}
+
+func TestManagerPanics(t *testing.T) {
+	c, _ := gingonic.CreateTestContext(httptest.NewRecorder())
+	require.Panics(t, func() { Manager(c) })
+}
+
+func TestSessions(t *testing.T) {
+	store := session.NewMemoryStore(time.Minute)
+	sessions, err := session.New(cookietest.NewManager(t), store)
+	require.NoError(t, err)
+
+	router := gingonic.New()
+	router.Use(Sessions(sessions))
+	router.GET("/", func(c *gingonic.Context) {
+		s := Session(c)
+		visits, _ := session.Get[int](s, "visits")
+		require.NoError(t, s.Set("visits", visits+1))
+		require.NoError(t, SaveSession(c))
+		c.JSON(http.StatusOK, visits+1)
+	})
+
+	w := serve(router, http.MethodGet, "/")
+	require.Equal(t, "1", w.Body.String())
+	w = serve(router, http.MethodGet, "/", w.Result().Cookies()...)
+	require.Equal(t, "2", w.Body.String())
+}
+
+func TestProtect(t *testing.T) {
+	secretKey, err := cookie.NewCookieSecret()
+	require.NoError(t, err)
+	protect, err := csrf.New(cookietest.NewManager(t), secretKey)
+	require.NoError(t, err)
+
+	router := gingonic.New()
+	router.Use(Protect(protect))
+	handler := func(c *gingonic.Context) { c.String(http.StatusOK, CSRFToken(c)) }
+	router.GET("/", handler)
+	router.POST("/", handler)
+
+	w := serve(router, http.MethodGet, "/")
+	require.Equal(t, http.StatusOK, w.Code)
+	require.NotEmpty(t, w.Body.String())
+
+	w = serve(router, http.MethodPost, "/", w.Result().Cookies()...)
+	require.Equal(t, http.StatusForbidden, w.Code)
+}
@@ -0,0 +1,222 @@
+// package grpc carries cookies over gRPC metadata, so a browser session
+// established over HTTP also authenticates gRPC-web and grpc-gateway calls,
+// including streams.
+//
+// Each call builds a net/http view of the call from its incoming metadata and
+// peer, runs the Manager as usual, and sends any cookies it sets as set-cookie
+// response metadata, so signing, encryption, chunking, and binding behave
+// exactly as with net/http.
+//
+// Import it under another name, such as cookiegrpc, alongside gRPC itself.
+package grpc
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/grackleclub/cookie/v2"
+	google "google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+// Metadata keys carrying cookies.
+const (
+	CookieKey        = "cookie"             // request cookies, as forwarded by gRPC-web proxies
+	GatewayCookieKey = "grpcgateway-cookie" // request cookies, as forwarded by grpc-gateway
+	SetCookieKey     = "set-cookie"         // response cookies
+)
+
+// Do runs fn with a net/http request and response writer adapted from ctx,
+// then sends the cookies fn wrote as response header metadata. It lets any
+// function of this module that takes an http.ResponseWriter and *http.Request,
+// such as session.Manager.Save, be used from a gRPC handler. Cookies must be
+// written before the handler sends its first response or stream message.
+func Do(ctx context.Context, fn func(w http.ResponseWriter, r *http.Request) error) error {
+	w := &responseWriter{header: http.Header{}}
+	if err := fn(w, Request(ctx)); err != nil {
+		return err
+	}
+	setCookies := w.header.Values("Set-Cookie")
+	if len(setCookies) == 0 {
+		return nil
+	}
+	if err := google.SetHeader(ctx, metadata.MD{SetCookieKey: setCookies}); err != nil {
+		return fmt.Errorf("%w: %w", cookie.ErrCookie, err)
+	}
+	return nil
+}
+
+// Request returns a net/http request carrying the cookies, user agent, and
+// authority of ctx's incoming metadata, the method name as its path, the
+// peer's address and TLS state, and ctx as its context.
+// Behind a proxy, the peer is the proxy, not the browser.
+func Request(ctx context.Context) *http.Request {
+	path, ok := google.Method(ctx)
+	if !ok {
+		path = "/"
+	}
+	r, err := http.NewRequestWithContext(ctx, http.MethodPost, path, nil)
+	if err != nil {
+		// an unparseable method name does not affect cookies
+		r, _ = http.NewRequestWithContext(ctx, http.MethodPost, "/", nil)
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+	for _, key := range []string{CookieKey, GatewayCookieKey} {
+		for _, value := range md.Get(key) {
+			r.Header.Add("Cookie", value)
+		}
+	}
+	// grpc-gateway forwards the browser's user agent under its own key
+	for _, key := range []string{"grpcgateway-user-agent", "user-agent"} {
+		if values := md.Get(key); len(values) > 0 {
+			r.Header.Set("User-Agent", values[0])
+			break
+		}
+	}
+	if values := md.Get(":authority"); len(values) > 0 {
+		r.Host = values[0]
+	}
+	if p, ok := peer.FromContext(ctx); ok {
+		if p.Addr != nil {
+			r.RemoteAddr = p.Addr.String()
+		}
+		if info, ok := p.AuthInfo.(credentials.TLSInfo); ok {
+			r.TLS = &info.State
+		}
+	}
+	return r
+}
+
+// Read reads a base64 encoded cookie, as Manager.Read.
+func Read(ctx context.Context, m *cookie.Manager, name string) (string, error) {
+	return m.Read(Request(ctx), name)
+}
+
+// Write writes a base64 encoded cookie, as Manager.Write.
+func Write(ctx context.Context, m *cookie.Manager, name, value string) error {
+	return Do(ctx, func(w http.ResponseWriter, _ *http.Request) error {
+		return m.Write(w, name, value)
+	})
+}
+
+// ReadSigned reads and verifies a signed cookie, as Manager.ReadSigned.
+func ReadSigned(ctx context.Context, m *cookie.Manager, name string) (string, error) {
+	return m.ReadSigned(Request(ctx), name)
+}
+
+// WriteSigned writes a signed cookie, as Manager.WriteSigned.
+func WriteSigned(ctx context.Context, m *cookie.Manager, name, value string) error {
+	return Do(ctx, func(w http.ResponseWriter, _ *http.Request) error {
+		return m.WriteSigned(w, name, value)
+	})
+}
+
+// ReadEncrypted reads and decrypts an encrypted cookie, as Manager.ReadEncryptedValue.
+func ReadEncrypted(ctx context.Context, m *cookie.Manager, name string) (string, error) {
+	return m.ReadEncryptedValue(Request(ctx), name)
+}
+
+// WriteEncrypted writes an encrypted cookie, as Manager.WriteEncryptedValue.
+func WriteEncrypted(ctx context.Context, m *cookie.Manager, name, value string) error {
+	return Do(ctx, func(w http.ResponseWriter, _ *http.Request) error {
+		return m.WriteEncryptedValue(w, name, value)
+	})
+}
+
+// Delete expires a cookie, as Manager.Delete.
+func Delete(ctx context.Context, m *cookie.Manager, name string) error {
+	return Do(ctx, func(w http.ResponseWriter, _ *http.Request) error {
+		m.Delete(w, name)
+		return nil
+	})
+}
+
+// UnaryServerInterceptor reads and verifies the Manager's managed cookies on
+// every call, as cookie.Middleware, so handlers can use cookie.FromContext.
+func UnaryServerInterceptor(m *cookie.Manager) google.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, _ *google.UnaryServerInfo, handler google.UnaryHandler) (any, error) {
+		ctx, err := load(ctx, m)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor reads and verifies the Manager's managed cookies on
+// every stream, as cookie.Middleware, so handlers can use cookie.FromContext
+// on the stream's context.
+func StreamServerInterceptor(m *cookie.Manager) google.StreamServerInterceptor {
+	return func(srv any, ss google.ServerStream, _ *google.StreamServerInfo, handler google.StreamHandler) error {
+		ctx, err := load(ss.Context(), m)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &serverStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+// load runs cookie.Middleware on ctx, returning the context it loads.
+func load(ctx context.Context, m *cookie.Manager) (context.Context, error) {
+	loaded := ctx
+	err := Do(ctx, func(w http.ResponseWriter, r *http.Request) error {
+		cookie.Middleware(m)(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+			loaded = r.Context()
+		})).ServeHTTP(w, r)
+		return nil
+	})
+	return loaded, err
+}
+
+// Forward returns ctx with r's cookies appended to its outgoing metadata,
+// for calling gRPC services on behalf of a browser request.
+func Forward(ctx context.Context, r *http.Request) context.Context {
+	for _, value := range r.Header.Values("Cookie") {
+		ctx = metadata.AppendToOutgoingContext(ctx, CookieKey, value)
+	}
+	return ctx
+}
+
+// SetCookies returns the cookies set in response header metadata,
+// such as that received with the grpc.Header call option.
+func SetCookies(md metadata.MD) []*http.Cookie {
+	var cookies []*http.Cookie
+	for _, line := range md.Get(SetCookieKey) {
+		if c, err := http.ParseSetCookie(line); err == nil {
+			cookies = append(cookies, c)
+		}
+	}
+	return cookies
+}
+
+// GatewayHeaderMatcher maps set-cookie response metadata to the Set-Cookie
+// header, and other keys to grpc-gateway's default Grpc-Metadata- headers.
+// Pass it to runtime.WithOutgoingHeaderMatcher.
+func GatewayHeaderMatcher(key string) (string, bool) {
+	if key == SetCookieKey {
+		return "Set-Cookie", true
+	}
+	return "Grpc-Metadata-" + key, true
+}
+
+// serverStream replaces the context of a stream.
+type serverStream struct {
+	google.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
+
+// responseWriter collects the headers written by the Manager; bodies are discarded.
+type responseWriter struct {
+	header http.Header
+}
+
+func (w *responseWriter) Header() http.Header         { return w.header }
+func (w *responseWriter) Write(b []byte) (int, error) { return len(b), nil }
+func (w *responseWriter) WriteHeader(int)             {}
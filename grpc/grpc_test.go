@@ -0,0 +1,223 @@
+package grpc
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/grackleclub/cookie/v2"
+	"github.com/grackleclub/cookie/v2/cookietest"
+	"github.com/grackleclub/cookie/v2/session"
+	"github.com/stretchr/testify/require"
+	google "google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// server implements the health service with the test's handlers.
+type server struct {
+	healthpb.UnimplementedHealthServer
+	check func(ctx context.Context) error
+	watch func(ctx context.Context) error
+}
+
+func (s *server) Check(ctx context.Context, _ *healthpb.HealthCheckRequest) (*healthpb.HealthCheckResponse, error) {
+	if err := s.check(ctx); err != nil {
+		return nil, err
+	}
+	return &healthpb.HealthCheckResponse{Status: healthpb.HealthCheckResponse_SERVING}, nil
+}
+
+func (s *server) Watch(_ *healthpb.HealthCheckRequest, stream healthpb.Health_WatchServer) error {
+	if err := s.watch(stream.Context()); err != nil {
+		return err
+	}
+	return stream.Send(&healthpb.HealthCheckResponse{Status: healthpb.HealthCheckResponse_SERVING})
+}
+
+// dial serves srv over an in-memory listener and returns a client for it.
+func dial(t *testing.T, srv *server, opts ...google.ServerOption) healthpb.HealthClient {
+	t.Helper()
+	lis := bufconn.Listen(1 << 20)
+	s := google.NewServer(opts...)
+	healthpb.RegisterHealthServer(s, srv)
+	go s.Serve(lis)
+	t.Cleanup(s.Stop)
+
+	conn, err := google.NewClient("passthrough:///bufconn",
+		google.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		google.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return healthpb.NewHealthClient(conn)
+}
+
+// withCookies returns a context sending cookies as a gRPC-web proxy would.
+func withCookies(cookies []*http.Cookie) context.Context {
+	return Forward(context.Background(), cookietest.NewRequest("/", cookies...))
+}
+
+func TestReadWrite(t *testing.T) {
+	m := cookietest.NewManager(t)
+	var theme, user string
+	var readErr error
+	srv := &server{}
+	client := dial(t, srv)
+
+	srv.check = func(ctx context.Context) error {
+		if err := WriteSigned(ctx, m, "theme", "dark"); err != nil {
+			return err
+		}
+		return WriteEncrypted(ctx, m, "user", "alice")
+	}
+	var header metadata.MD
+	_, err := client.Check(context.Background(), &healthpb.HealthCheckRequest{}, google.Header(&header))
+	require.NoError(t, err)
+	cookies := SetCookies(header)
+	require.Len(t, cookies, 2)
+
+	srv.check = func(ctx context.Context) error {
+		theme, readErr = ReadSigned(ctx, m, "theme")
+		if readErr == nil {
+			user, readErr = ReadEncrypted(ctx, m, "user")
+		}
+		return nil
+	}
+	_, err = client.Check(withCookies(cookies), &healthpb.HealthCheckRequest{})
+	require.NoError(t, err)
+	require.NoError(t, readErr)
+	require.Equal(t, "dark", theme)
+	require.Equal(t, "alice", user)
+
+	// grpc-gateway forwards the Cookie header under its own key
+	md, _ := metadata.FromOutgoingContext(withCookies(cookies))
+	ctx := metadata.AppendToOutgoingContext(context.Background(), GatewayCookieKey, md.Get(CookieKey)[0])
+	theme, user = "", ""
+	_, err = client.Check(ctx, &healthpb.HealthCheckRequest{})
+	require.NoError(t, err)
+	require.NoError(t, readErr)
+	require.Equal(t, "dark", theme)
+	require.Equal(t, "alice", user)
+
+	_, err = client.Check(context.Background(), &healthpb.HealthCheckRequest{})
+	require.NoError(t, err)
+	require.ErrorIs(t, readErr, cookie.ErrNotFound)
+
+	srv.check = func(ctx context.Context) error { return Delete(ctx, m, "theme") }
+	header = nil
+	_, err = client.Check(context.Background(), &healthpb.HealthCheckRequest{}, google.Header(&header))
+	require.NoError(t, err)
+	deleted := SetCookies(header)
+	require.Len(t, deleted, 1)
+	require.Negative(t, deleted[0].MaxAge)
+}
+
+func TestBinding(t *testing.T) {
+	m := cookietest.NewManager(t, cookie.WithBinding(cookie.Binding{UserAgent: true}))
+	var readErr error
+	srv := &server{}
+	client := dial(t, srv)
+
+	srv.check = func(ctx context.Context) error {
+		return Do(ctx, func(w http.ResponseWriter, r *http.Request) error {
+			return m.WriteSignedBound(w, r, "bound", "alice")
+		})
+	}
+	var header metadata.MD
+	_, err := client.Check(context.Background(), &healthpb.HealthCheckRequest{}, google.Header(&header))
+	require.NoError(t, err)
+
+	srv.check = func(ctx context.Context) error {
+		_, readErr = ReadSigned(ctx, m, "bound")
+		return nil
+	}
+	ctx := withCookies(SetCookies(header))
+	_, err = client.Check(ctx, &healthpb.HealthCheckRequest{})
+	require.NoError(t, err)
+	require.NoError(t, readErr)
+
+	ctx = metadata.AppendToOutgoingContext(ctx, "grpcgateway-user-agent", "other")
+	_, err = client.Check(ctx, &healthpb.HealthCheckRequest{})
+	require.NoError(t, err)
+	require.ErrorIs(t, readErr, cookie.ErrSignatureMismatch)
+}
+
+func TestInterceptors(t *testing.T) {
+	m := cookietest.NewManager(t, cookie.WithManaged("user", cookie.Encrypted))
+	var unary, stream string
+	srv := &server{
+		check: func(ctx context.Context) error {
+			var err error
+			unary, err = cookie.FromContext(ctx, "user")
+			return err
+		},
+		watch: func(ctx context.Context) error {
+			var err error
+			stream, err = cookie.FromContext(ctx, "user")
+			return err
+		},
+	}
+	client := dial(t, srv,
+		google.UnaryInterceptor(UnaryServerInterceptor(m)),
+		google.StreamInterceptor(StreamServerInterceptor(m)),
+	)
+
+	w := httptest.NewRecorder()
+	require.NoError(t, m.WriteEncryptedValue(w, "user", "alice"))
+	ctx := withCookies(w.Result().Cookies())
+
+	_, err := client.Check(ctx, &healthpb.HealthCheckRequest{})
+	require.NoError(t, err)
+	require.Equal(t, "alice", unary)
+
+	watch, err := client.Watch(ctx, &healthpb.HealthCheckRequest{})
+	require.NoError(t, err)
+	_, err = watch.Recv()
+	require.NoError(t, err)
+	require.Equal(t, "alice", stream)
+}
+
+func TestSession(t *testing.T) {
+	sessions, err := session.New(cookietest.NewManager(t), session.NewMemoryStore(0))
+	require.NoError(t, err)
+	var user string
+	srv := &server{
+		check: func(ctx context.Context) error {
+			s, err := sessions.Load(Request(ctx))
+			if err != nil {
+				return err
+			}
+			user, _ = session.Get[string](s, "user")
+			if err := s.Set("user", "alice"); err != nil {
+				return err
+			}
+			return Do(ctx, func(w http.ResponseWriter, r *http.Request) error {
+				return sessions.Save(w, r, s)
+			})
+		},
+	}
+	client := dial(t, srv)
+
+	var header metadata.MD
+	_, err = client.Check(context.Background(), &healthpb.HealthCheckRequest{}, google.Header(&header))
+	require.NoError(t, err)
+	require.Empty(t, user)
+
+	_, err = client.Check(withCookies(SetCookies(header)), &healthpb.HealthCheckRequest{})
+	require.NoError(t, err)
+	require.Equal(t, "alice", user)
+}
+
+func TestGatewayHeaderMatcher(t *testing.T) {
+	key, ok := GatewayHeaderMatcher(SetCookieKey)
+	require.True(t, ok)
+	require.Equal(t, "Set-Cookie", key)
+	key, ok = GatewayHeaderMatcher("trace-id")
+	require.True(t, ok)
+	require.Equal(t, "Grpc-Metadata-trace-id", key)
+}
@@ -0,0 +1,62 @@
+package cookie
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"fmt"
+	"hash"
+
+	"golang.org/x/crypto/blake2b"
+)
+
+// Hash identifies the HMAC hash used to sign cookie values.
+// It is recorded in each value's envelope, so cookies signed under any
+// supported Hash can be verified regardless of which one the Manager writes.
+type Hash byte
+
+const (
+	SHA256     Hash = iota + 1 // HMAC-SHA256
+	SHA512_256                 // HMAC-SHA512/256, faster than SHA256 on 64-bit CPUs
+	BLAKE2b256                 // HMAC-BLAKE2b-256
+)
+
+func (h Hash) String() string {
+	switch h {
+	case SHA256:
+		return "SHA256"
+	case SHA512_256:
+		return "SHA512/256"
+	case BLAKE2b256:
+		return "BLAKE2b-256"
+	default:
+		return fmt.Sprintf("Hash(%d)", byte(h))
+	}
+}
+
+// new returns the constructor for h, and whether h is supported.
+func (h Hash) new() (func() hash.Hash, bool) {
+	switch h {
+	case SHA256:
+		return sha256.New, true
+	case SHA512_256:
+		return sha512.New512_256, true
+	case BLAKE2b256:
+		return func() hash.Hash {
+			b, _ := blake2b.New256(nil) // only fails for keys longer than 64 bytes
+			return b
+		}, true
+	default:
+		return nil, false
+	}
+}
+
+// WithHash selects the HMAC hash the Manager signs with; the default is SHA256.
+func WithHash(h Hash) Option {
+	return func(m *Manager) error {
+		if _, ok := h.new(); !ok {
+			return fmt.Errorf("unsupported hash: %s", h)
+		}
+		m.hash = h
+		return nil
+	}
+}
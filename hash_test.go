@@ -0,0 +1,46 @@
+package cookie
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHashes(t *testing.T) {
+	secretKey, err := NewCookieSecret()
+	require.NoError(t, err)
+	defaults, err := New(WithSecret(secretKey))
+	require.NoError(t, err)
+
+	for _, h := range []Hash{SHA256, SHA512_256, BLAKE2b256} {
+		t.Run(h.String(), func(t *testing.T) {
+			m, err := New(WithSecret(secretKey), WithHash(h))
+			require.NoError(t, err)
+
+			w := httptest.NewRecorder()
+			require.NoError(t, m.WriteSigned(w, "a", "chocolate fudge"))
+			raw, err := Read(requestWith(w), "a")
+			require.NoError(t, err)
+			header, _, _, err := parseHeader(raw)
+			require.NoError(t, err)
+			require.Equal(t, byte(h), header.algorithm)
+
+			for _, reader := range []*Manager{m, defaults} {
+				value, err := reader.ReadSigned(requestWith(w), "a")
+				require.NoError(t, err)
+				require.Equal(t, "chocolate fudge", value)
+			}
+
+			w = httptest.NewRecorder()
+			require.NoError(t, m.WriteSignedWithExpiry(w, "a", "oatmeal raisin", time.Now().Add(time.Hour)))
+			value, err := defaults.ReadSigned(requestWith(w), "a")
+			require.NoError(t, err)
+			require.Equal(t, "oatmeal raisin", value)
+		})
+	}
+
+	_, err = New(WithHash(Hash(42)))
+	require.ErrorIs(t, err, ErrInitiation)
+}
@@ -0,0 +1,26 @@
+package cookie
+
+import (
+	"crypto/tls"
+	"net/http"
+)
+
+// WriteHeader seals the value of cookie according to mode and adds it to h
+// as a Set-Cookie header, for code that has headers but no ResponseWriter.
+// Build the cookie with Cookie or NewCookie to apply the Manager's defaults
+// and name prefix.
+func (m *Manager) WriteHeader(h http.Header, cookie http.Cookie, mode Mode) error {
+	return m.writeSealed(&headerWriter{header: h}, cookie, mode)
+}
+
+// ReadHeader reads the named cookie, written with mode, from the Cookie
+// headers in h. Without a connection, cookie name prefixes are not checked
+// against it; the caller is responsible for h arriving over HTTPS.
+func (m *Manager) ReadHeader(h http.Header, name string, mode Mode) (string, error) {
+	return m.readMode(headerRequest(h), name, mode)
+}
+
+// headerRequest returns a request carrying h, treated as received over HTTPS.
+func headerRequest(h http.Header) *http.Request {
+	return &http.Request{Method: http.MethodGet, Header: h, TLS: &tls.ConnectionState{}}
+}
@@ -0,0 +1,33 @@
+package cookie
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteHeaderReadHeader(t *testing.T) {
+	m := newTestManager(t, WithPrefix(HostPrefix))
+	for _, mode := range []Mode{Plain, Signed, Encrypted} {
+		t.Run(mode.String(), func(t *testing.T) {
+			h := http.Header{}
+			require.NoError(t, m.WriteHeader(h, m.Cookie("user", "alice"), mode))
+			require.Len(t, h.Values("Set-Cookie"), 1)
+
+			c, err := http.ParseSetCookie(h.Get("Set-Cookie"))
+			require.NoError(t, err)
+			require.Equal(t, "__Host-user", c.Name)
+
+			in := http.Header{}
+			in.Set("Cookie", c.Name+"="+c.Value)
+			value, err := m.ReadHeader(in, "user", mode)
+			require.NoError(t, err)
+			require.Equal(t, "alice", value)
+		})
+	}
+
+	_, err := m.ReadHeader(http.Header{}, "user", Signed)
+	require.ErrorIs(t, err, ErrNotFound)
+	require.ErrorIs(t, m.WriteHeader(http.Header{}, m.Cookie("bad name", "x"), Signed), ErrInvalidName)
+}
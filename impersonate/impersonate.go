@@ -0,0 +1,184 @@
+// package impersonate lets an administrator act as another user, keeping both
+// identities in a short-lived encrypted cookie, so handlers serve the
+// impersonated user while audit logs still record who is truly acting.
+package impersonate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/grackleclub/cookie/v2"
+)
+
+const (
+	defaultName = "impersonate"
+	defaultTTL  = time.Hour
+)
+
+var (
+	ErrNotImpersonating = errors.New("not impersonating")
+	ErrImpersonation    = errors.New("impersonation invalid")
+)
+
+// Identity is an impersonation in progress.
+type Identity struct {
+	Admin   string    // the administrator truly acting
+	User    string    // the user being impersonated
+	Started time.Time // when Start was called
+	Expires time.Time // when the impersonation ends on its own
+}
+
+// LogValue logs both identities, so audit records name the real actor.
+func (id Identity) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("admin", id.Admin),
+		slog.String("user", id.User),
+		slog.Time("started", id.Started),
+	)
+}
+
+// stored is an impersonation in progress: who is impersonating whom, in unix
+// seconds since when and until when.
+type stored struct {
+	Admin   string `json:"a"`
+	User    string `json:"u"`
+	Started int64  `json:"s"`
+	Expires int64  `json:"e"`
+}
+
+// Impersonator starts, checks, and stops impersonations.
+type Impersonator struct {
+	cookies *cookie.Manager
+	name    string
+	ttl     time.Duration
+}
+
+// Option configures an Impersonator.
+type Option func(*Impersonator) error
+
+// New creates an Impersonator. The cookie.Manager must hold a secret,
+// which is used to encrypt the impersonation cookie.
+func New(cookies *cookie.Manager, opts ...Option) (*Impersonator, error) {
+	if cookies == nil {
+		return nil, fmt.Errorf("%w: cookie manager is required", cookie.ErrInitiation)
+	}
+	imp := &Impersonator{
+		cookies: cookies,
+		name:    defaultName,
+		ttl:     defaultTTL,
+	}
+	for _, opt := range opts {
+		if err := opt(imp); err != nil {
+			return nil, fmt.Errorf("%w: %w", cookie.ErrInitiation, err)
+		}
+	}
+	cookies, err := cookies.With(cookie.WithMaxAge(imp.ttl))
+	if err != nil {
+		return nil, err
+	}
+	imp.cookies = cookies
+	return imp, nil
+}
+
+// WithName sets the name of the impersonation cookie.
+func WithName(name string) Option {
+	return func(imp *Impersonator) error {
+		if name == "" {
+			return errors.New("empty impersonation cookie name")
+		}
+		imp.name = name
+		return nil
+	}
+}
+
+// WithTTL sets how long an impersonation lasts before it stops on its own. Defaults to an hour.
+func WithTTL(ttl time.Duration) Option {
+	return func(imp *Impersonator) error {
+		if ttl < time.Second {
+			return fmt.Errorf("impersonation ttl too short: %s", ttl)
+		}
+		imp.ttl = ttl
+		return nil
+	}
+}
+
+// Start begins impersonating user on behalf of admin, replacing any impersonation
+// in progress. Only call it once admin is authenticated and allowed to impersonate.
+func (imp *Impersonator) Start(w http.ResponseWriter, admin, user string) (Identity, error) {
+	if admin == "" || user == "" {
+		return Identity{}, fmt.Errorf("%w: admin and user are required", ErrImpersonation)
+	}
+	if admin == user {
+		return Identity{}, fmt.Errorf("%w: '%s' cannot impersonate themself", ErrImpersonation, admin)
+	}
+	now := imp.cookies.Now()
+	id := Identity{Admin: admin, User: user, Started: now.Truncate(time.Second), Expires: now.Add(imp.ttl).Truncate(time.Second)}
+	payload := stored{Admin: admin, User: user, Started: id.Started.Unix(), Expires: id.Expires.Unix()}
+	if err := cookie.WriteJSON(imp.cookies, w, imp.name, payload, cookie.Encrypted); err != nil {
+		return Identity{}, err
+	}
+	return id, nil
+}
+
+// Stop ends any impersonation in progress.
+func (imp *Impersonator) Stop(w http.ResponseWriter) {
+	imp.cookies.Delete(w, imp.name)
+}
+
+// Current returns the impersonation in progress for admin, the user the request
+// is authenticated as, failing with ErrNotImpersonating if there is none. An
+// impersonation started by anyone else fails with ErrImpersonation, so the
+// cookie is worthless once its admin logs out.
+func (imp *Impersonator) Current(r *http.Request, admin string) (Identity, error) {
+	payload, err := cookie.ReadJSON[stored](imp.cookies, r, imp.name, cookie.Encrypted)
+	if errors.Is(err, cookie.ErrNotFound) {
+		return Identity{}, ErrNotImpersonating
+	}
+	if err != nil {
+		return Identity{}, fmt.Errorf("%w: %w", ErrImpersonation, err)
+	}
+	id := Identity{
+		Admin:   payload.Admin,
+		User:    payload.User,
+		Started: time.Unix(payload.Started, 0),
+		Expires: time.Unix(payload.Expires, 0),
+	}
+	if !imp.cookies.Now().Before(id.Expires) {
+		return Identity{}, fmt.Errorf("%w: %w", ErrImpersonation, cookie.ErrExpired)
+	}
+	if admin == "" || admin != id.Admin {
+		return Identity{}, fmt.Errorf("%w: started by '%s', not '%s'", ErrImpersonation, id.Admin, admin)
+	}
+	return id, nil
+}
+
+type identityKey struct{}
+
+// Middleware adds the impersonation in progress, if any, to the context of each
+// request, where FromContext returns it. authenticated returns the user the
+// request is authenticated as, or "" for none. Invalid impersonation cookies
+// are deleted.
+func (imp *Impersonator) Middleware(authenticated func(*http.Request) string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			id, err := imp.Current(r, authenticated(r))
+			switch {
+			case err == nil:
+				r = r.WithContext(context.WithValue(r.Context(), identityKey{}, id))
+			case !errors.Is(err, ErrNotImpersonating):
+				imp.Stop(w)
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// FromContext returns the impersonation added by Middleware, if any.
+func FromContext(ctx context.Context) (Identity, bool) {
+	id, ok := ctx.Value(identityKey{}).(Identity)
+	return id, ok
+}
@@ -0,0 +1,101 @@
+package impersonate
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/grackleclub/cookie/v2"
+	"github.com/grackleclub/cookie/v2/cookietest"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestImpersonator(t *testing.T, opts ...Option) *Impersonator {
+	t.Helper()
+	secretKey, err := cookie.NewCookieSecret()
+	require.NoError(t, err)
+	cookies, err := cookie.New(cookie.WithSecret(secretKey))
+	require.NoError(t, err)
+	imp, err := New(cookies, opts...)
+	require.NoError(t, err)
+	return imp
+}
+
+func TestImpersonator(t *testing.T) {
+	imp := newTestImpersonator(t, WithName("sudo"), WithTTL(10*time.Minute))
+
+	w := httptest.NewRecorder()
+	started, err := imp.Start(w, "alice", "bob")
+	require.NoError(t, err)
+	setCookie := w.Result().Cookies()[0]
+	require.Equal(t, "sudo", setCookie.Name)
+	require.Equal(t, 600, setCookie.MaxAge)
+	require.NotContains(t, setCookie.Value, "bob")
+
+	r := cookietest.RequestFrom(t, w)
+	id, err := imp.Current(r, "alice")
+	require.NoError(t, err)
+	require.Equal(t, started, id)
+
+	_, err = imp.Current(r, "mallory")
+	require.ErrorIs(t, err, ErrImpersonation)
+	_, err = imp.Current(r, "")
+	require.ErrorIs(t, err, ErrImpersonation)
+
+	w = httptest.NewRecorder()
+	imp.Stop(w)
+	_, err = imp.Current(cookietest.RequestFrom(t, w), "alice")
+	require.ErrorIs(t, err, ErrNotImpersonating)
+
+	_, err = imp.Start(httptest.NewRecorder(), "alice", "alice")
+	require.ErrorIs(t, err, ErrImpersonation)
+	_, err = imp.Start(httptest.NewRecorder(), "alice", "")
+	require.ErrorIs(t, err, ErrImpersonation)
+
+	_, err = New(nil)
+	require.ErrorIs(t, err, cookie.ErrInitiation)
+	_, err = New(imp.cookies, WithTTL(0))
+	require.ErrorIs(t, err, cookie.ErrInitiation)
+}
+
+func TestMiddleware(t *testing.T) {
+	imp := newTestImpersonator(t)
+	w := httptest.NewRecorder()
+	_, err := imp.Start(w, "alice", "bob")
+	require.NoError(t, err)
+	r := cookietest.RequestFrom(t, w)
+
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&logs, nil))
+	var got Identity
+	var ok bool
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got, ok = FromContext(r.Context())
+		if ok {
+			logger.Info("order cancelled", "actor", got)
+		}
+	})
+
+	user := "alice"
+	mw := imp.Middleware(func(*http.Request) string { return user })(handler)
+	mw.ServeHTTP(httptest.NewRecorder(), r)
+	require.True(t, ok)
+	require.Equal(t, "bob", got.User)
+	require.Contains(t, logs.String(), "actor.admin=alice actor.user=bob")
+
+	// a different user logged in on the same browser does not inherit it
+	user = "carol"
+	w = httptest.NewRecorder()
+	mw.ServeHTTP(w, r)
+	require.False(t, ok)
+	require.Equal(t, -1, w.Result().Cookies()[0].MaxAge)
+
+	// no impersonation cookie leaves the response alone
+	w = httptest.NewRecorder()
+	mw.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	require.False(t, ok)
+	require.Empty(t, w.Result().Cookies())
+}
@@ -0,0 +1,90 @@
+// package token generates the random tokens used across the module's
+// packages, and implements the split tokens of the remember, device, and
+// session packages: a selector, used to look a token up, and a validator, of
+// which only a sha256 hash is stored, so a leak of the store reveals no usable tokens.
+package token
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrMismatch is returned by Verify for a known selector with the wrong validator.
+var ErrMismatch = errors.New("validator mismatch")
+
+// Split is a token as carried by a client.
+type Split struct {
+	Selector  string
+	Validator string
+}
+
+// New returns a Split with a random selector and validator of the given byte lengths.
+func New(selectorLength, validatorLength int) (Split, error) {
+	selector, err := Random(selectorLength)
+	if err != nil {
+		return Split{}, err
+	}
+	validator, err := Random(validatorLength)
+	if err != nil {
+		return Split{}, err
+	}
+	return Split{Selector: selector, Validator: validator}, nil
+}
+
+// Parse splits a cookie value made by String.
+func Parse(value string) (Split, bool) {
+	selector, validator, ok := strings.Cut(value, ":")
+	if !ok || selector == "" || validator == "" {
+		return Split{}, false
+	}
+	return Split{Selector: selector, Validator: validator}, true
+}
+
+// Halves splits a single ID, such as a session ID, into selector and validator halves.
+func Halves(id string) Split {
+	return Split{Selector: id[:len(id)/2], Validator: id[len(id)/2:]}
+}
+
+// String joins the selector and validator as a cookie value, "selector:validator".
+func (s Split) String() string {
+	return s.Selector + ":" + s.Validator
+}
+
+// Hash returns the hash of the validator, stored in place of it.
+func (s Split) Hash() []byte {
+	sum := sha256.Sum256([]byte(s.Validator))
+	return sum[:]
+}
+
+// Matches reports whether hash is the Hash of the validator, in constant time.
+func (s Split) Matches(hash []byte) bool {
+	return subtle.ConstantTimeCompare(hash, s.Hash()) == 1
+}
+
+// Verify checks the validator against hash, the stored Hash of the token's
+// selector. A mismatch means someone guessed or copied the selector, so revoke
+// is called to revoke every token of its owner, and an error wrapping
+// ErrMismatch is returned.
+func (s Split) Verify(hash []byte, revoke func() error) error {
+	if s.Matches(hash) {
+		return nil
+	}
+	if err := revoke(); err != nil {
+		return fmt.Errorf("%w, unable to revoke tokens: %w", ErrMismatch, err)
+	}
+	return fmt.Errorf("%w, all tokens of its owner revoked", ErrMismatch)
+}
+
+// Random returns n random bytes, base64url encoded.
+func Random(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("unable to generate token: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,51 @@
+package token
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplit(t *testing.T) {
+	split, err := New(12, 32)
+	require.NoError(t, err)
+	require.Len(t, split.Selector, 16)
+	require.Len(t, split.Validator, 43)
+
+	parsed, ok := Parse(split.String())
+	require.True(t, ok)
+	require.Equal(t, split, parsed)
+	for _, malformed := range []string{"", "selector", ":validator", "selector:"} {
+		_, ok := Parse(malformed)
+		require.False(t, ok, malformed)
+	}
+
+	halves := Halves("0123456789abcdef")
+	require.Equal(t, Split{Selector: "01234567", Validator: "89abcdef"}, halves)
+}
+
+func TestVerify(t *testing.T) {
+	split, err := New(12, 32)
+	require.NoError(t, err)
+	hash := split.Hash()
+
+	revoked := 0
+	revoke := func() error {
+		revoked++
+		return nil
+	}
+	require.True(t, split.Matches(hash))
+	require.NoError(t, split.Verify(hash, revoke))
+	require.Zero(t, revoked)
+
+	forged := Split{Selector: split.Selector, Validator: "forged"}
+	require.False(t, forged.Matches(hash))
+	require.ErrorIs(t, forged.Verify(hash, revoke), ErrMismatch)
+	require.Equal(t, 1, revoked)
+
+	failed := errors.New("store down")
+	err = forged.Verify(hash, func() error { return failed })
+	require.ErrorIs(t, err, ErrMismatch)
+	require.ErrorIs(t, err, failed)
+}
@@ -0,0 +1,328 @@
+// package itsdangerous reads and writes values signed by the Python
+// itsdangerous URLSafeTimedSerializer, as used for Flask sessions,
+// so Flask and Go services can share a signed cookie.
+//
+// A value is payload.timestamp.signature, where the payload is unpadded
+// URL-safe base64 JSON, zlib compressed and prefixed with '.' when that is
+// shorter, and the signature is an HMAC under a key derived from the secret and salt.
+package itsdangerous
+
+import (
+	"bytes"
+	"compress/zlib"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/grackleclub/cookie/v2"
+)
+
+const (
+	FlaskSessionCookie = "session" // default cookie name of Flask sessions
+
+	defaultSalt     = "itsdangerous"
+	flaskSalt       = "cookie-session"
+	flaskLifetime   = 31 * 24 * time.Hour // Flask's PERMANENT_SESSION_LIFETIME
+	maxCookieSize   = 4096
+	maxDecompressed = 1 << 20 // guards against decompression bombs
+	separator       = '.'
+	compressedStart = '.'
+)
+
+// KeyDerivation selects how the signing key is derived from the secret and salt.
+type KeyDerivation int
+
+const (
+	DjangoConcat KeyDerivation = iota // digest(salt + "signer" + secret), the itsdangerous default
+	Concat                            // digest(salt + secret)
+	HMAC                              // HMAC(secret, salt), as Flask sessions use
+	None                              // the secret itself
+)
+
+// Serializer encodes and decodes values compatible with a URLSafeTimedSerializer
+// built from the same secrets and settings.
+type Serializer struct {
+	cookies    *cookie.Manager
+	secrets    [][]byte // newest first; only the newest is used to sign
+	salt       string
+	derivation KeyDerivation
+	digest     func() hash.Hash
+	maxAge     time.Duration
+	now        func() time.Time // replaced in tests
+}
+
+// Option configures a Serializer.
+type Option func(*Serializer) error
+
+// New creates a Serializer signing with secretKey and the itsdangerous defaults:
+// the salt "itsdangerous", DjangoConcat key derivation, and HMAC-SHA1.
+// Cookies are written with the cookie.Manager's default attributes and name prefix.
+func New(cookies *cookie.Manager, secretKey []byte, opts ...Option) (*Serializer, error) {
+	if cookies == nil {
+		return nil, fmt.Errorf("%w: cookie manager is required", cookie.ErrInitiation)
+	}
+	if len(secretKey) == 0 {
+		return nil, fmt.Errorf("%w: %w", cookie.ErrInitiation, cookie.ErrSecretMissing)
+	}
+	s := &Serializer{
+		cookies:    cookies,
+		secrets:    [][]byte{secretKey},
+		salt:       defaultSalt,
+		derivation: DjangoConcat,
+		digest:     sha1.New,
+		now:        cookies.Now,
+	}
+	for _, opt := range opts {
+		if err := opt(s); err != nil {
+			return nil, fmt.Errorf("%w: %w", cookie.ErrInitiation, err)
+		}
+	}
+	return s, nil
+}
+
+// WithFlaskSession matches the serializer of Flask's default session interface:
+// the salt "cookie-session", HMAC key derivation, and a 31 day max age.
+func WithFlaskSession() Option {
+	return func(s *Serializer) error {
+		s.salt = flaskSalt
+		s.derivation = HMAC
+		s.maxAge = flaskLifetime
+		return nil
+	}
+}
+
+// WithFallbackSecrets accepts values signed with retired secrets, newest first,
+// like Flask's SECRET_KEY_FALLBACKS.
+func WithFallbackSecrets(secretKeys ...[]byte) Option {
+	return func(s *Serializer) error {
+		for i, secretKey := range secretKeys {
+			if len(secretKey) == 0 {
+				return fmt.Errorf("fallback secret %d: %w", i, cookie.ErrSecretMissing)
+			}
+		}
+		s.secrets = append(s.secrets[:1:1], secretKeys...)
+		return nil
+	}
+}
+
+// WithSalt sets the salt, which namespaces signatures by purpose.
+func WithSalt(salt string) Option {
+	return func(s *Serializer) error {
+		s.salt = salt
+		return nil
+	}
+}
+
+// WithKeyDerivation sets how the signing key is derived.
+func WithKeyDerivation(derivation KeyDerivation) Option {
+	return func(s *Serializer) error {
+		if derivation < DjangoConcat || derivation > None {
+			return fmt.Errorf("unsupported key derivation: %d", derivation)
+		}
+		s.derivation = derivation
+		return nil
+	}
+}
+
+// WithDigest sets the hash for key derivation and signing; the default is SHA-1.
+func WithDigest(digest func() hash.Hash) Option {
+	return func(s *Serializer) error {
+		if digest == nil {
+			return errors.New("digest is nil")
+		}
+		s.digest = digest
+		return nil
+	}
+}
+
+// WithMaxAge sets the oldest value Decode accepts, as the max_age argument
+// of loads. Zero, the default, accepts values of any age.
+func WithMaxAge(maxAge time.Duration) Option {
+	return func(s *Serializer) error {
+		if maxAge < 0 {
+			return fmt.Errorf("negative max age: %s", maxAge)
+		}
+		s.maxAge = maxAge
+		return nil
+	}
+}
+
+// Encode serializes v to JSON and signs it with the current time, as dumps.
+func (s *Serializer) Encode(v any) (string, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", fmt.Errorf("%w: unable to encode value: %w", cookie.ErrCookie, err)
+	}
+	payload := base64.RawURLEncoding.EncodeToString(data)
+	if compressed, err := compress(data); err == nil && len(compressed) < len(data)-1 {
+		payload = string(compressedStart) + base64.RawURLEncoding.EncodeToString(compressed)
+	}
+	var stamp [8]byte
+	binary.BigEndian.PutUint64(stamp[:], uint64(s.now().Unix()))
+	value := payload + string(separator) + base64.RawURLEncoding.EncodeToString(bytes.TrimLeft(stamp[:], "\x00"))
+	return value + string(separator) + s.signature(value, s.secrets[0]), nil
+}
+
+// Decode verifies a value signed by Encode or dumps and decodes
+// its JSON into dst, as loads.
+func (s *Serializer) Decode(signed string, dst any) error {
+	value, mac, ok := cutLast(signed)
+	if !ok {
+		return fmt.Errorf("%w: %w: no signature", cookie.ErrCookie, cookie.ErrTooShort)
+	}
+	if !s.verify(value, mac) {
+		return fmt.Errorf("%w: %w", cookie.ErrCookie, cookie.ErrSignatureMismatch)
+	}
+	payload, stamp, ok := cutLast(value)
+	if !ok {
+		return fmt.Errorf("%w: %w: no timestamp", cookie.ErrCookie, cookie.ErrTooShort)
+	}
+	issued, err := decodeTimestamp(stamp)
+	if err != nil {
+		return err
+	}
+	if s.maxAge != 0 {
+		age := s.now().Sub(issued)
+		if age > s.maxAge || age < 0 {
+			return fmt.Errorf("%w: %w: issued %s", cookie.ErrCookie, cookie.ErrExpired, issued.UTC().Format(time.RFC3339))
+		}
+	}
+	data, err := decodePayload(payload)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, dst); err != nil {
+		return fmt.Errorf("%w: unable to decode value: %w", cookie.ErrCookie, err)
+	}
+	return nil
+}
+
+// Write encodes v and sets it as the named cookie with the Manager's defaults.
+func (s *Serializer) Write(w http.ResponseWriter, name string, v any) error {
+	encoded, err := s.Encode(v)
+	if err != nil {
+		return err
+	}
+	c := s.cookies.Cookie(name, encoded)
+	if len(c.String()) > maxCookieSize {
+		return fmt.Errorf("%w: %w", cookie.ErrCookie, cookie.ErrTooLong)
+	}
+	http.SetCookie(w, &c)
+	return nil
+}
+
+// Read verifies the named cookie and decodes its JSON into dst.
+func (s *Serializer) Read(r *http.Request, name string, dst any) error {
+	fullName := s.cookies.Cookie(name, "").Name
+	c, err := r.Cookie(fullName)
+	if err != nil {
+		return fmt.Errorf("%w: '%s': %w", cookie.ErrNotFound, fullName, err)
+	}
+	return s.Decode(c.Value, dst)
+}
+
+// verify checks mac against value under each secret in turn.
+func (s *Serializer) verify(value, mac string) bool {
+	for _, secret := range s.secrets {
+		if hmac.Equal([]byte(mac), []byte(s.signature(value, secret))) {
+			return true
+		}
+	}
+	return false
+}
+
+// signature returns the unpadded URL-safe base64 HMAC of value.
+func (s *Serializer) signature(value string, secret []byte) string {
+	mac := hmac.New(s.digest, s.deriveKey(secret))
+	mac.Write([]byte(value))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// deriveKey derives the signing key from secret and the salt.
+func (s *Serializer) deriveKey(secret []byte) []byte {
+	switch s.derivation {
+	case Concat:
+		h := s.digest()
+		h.Write([]byte(s.salt))
+		h.Write(secret)
+		return h.Sum(nil)
+	case HMAC:
+		mac := hmac.New(s.digest, secret)
+		mac.Write([]byte(s.salt))
+		return mac.Sum(nil)
+	case None:
+		return secret
+	default:
+		h := s.digest()
+		h.Write([]byte(s.salt + "signer"))
+		h.Write(secret)
+		return h.Sum(nil)
+	}
+}
+
+// cutLast splits s around its last separator.
+func cutLast(s string) (before, after string, ok bool) {
+	i := strings.LastIndexByte(s, separator)
+	if i < 0 {
+		return s, "", false
+	}
+	return s[:i], s[i+1:], true
+}
+
+// decodeTimestamp decodes the big-endian unix seconds of a signature.
+func decodeTimestamp(stamp string) (time.Time, error) {
+	b, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(stamp, "="))
+	if err != nil || len(b) > 8 {
+		return time.Time{}, fmt.Errorf("%w: invalid timestamp", cookie.ErrCookie)
+	}
+	var full [8]byte
+	copy(full[8-len(b):], b)
+	return time.Unix(int64(binary.BigEndian.Uint64(full[:])), 0), nil
+}
+
+// decodePayload decodes, and if marked decompresses, the JSON of a payload.
+func decodePayload(payload string) ([]byte, error) {
+	payload, compressed := strings.CutPrefix(payload, string(compressedStart))
+	data, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(payload, "="))
+	if err != nil {
+		return nil, fmt.Errorf("%w: cannot decode payload: %w", cookie.ErrCookie, err)
+	}
+	if !compressed {
+		return data, nil
+	}
+	zr, err := zlib.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: cannot decompress payload: %w", cookie.ErrCookie, err)
+	}
+	defer zr.Close()
+	data, err = io.ReadAll(io.LimitReader(zr, maxDecompressed+1))
+	if err != nil {
+		return nil, fmt.Errorf("%w: cannot decompress payload: %w", cookie.ErrCookie, err)
+	}
+	if len(data) > maxDecompressed {
+		return nil, fmt.Errorf("%w: decompressed payload exceeds %d bytes", cookie.ErrCookie, maxDecompressed)
+	}
+	return data, nil
+}
+
+// compress zlib compresses data, as Python's zlib.compress.
+func compress(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := zlib.NewWriter(&buf)
+	if _, err := zw.Write(data); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
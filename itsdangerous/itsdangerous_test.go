@@ -0,0 +1,126 @@
+package itsdangerous
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/grackleclub/cookie/v2"
+	"github.com/stretchr/testify/require"
+)
+
+var issued = time.Unix(1700000000, 0)
+
+// signed at issued with secret-key by Flask's session serializer: {"user": "alice"}
+const flaskVector = "eyJ1c2VyIjoiYWxpY2UifQ.ZVPxAA.uUhuIpOZ_RbBZ4ImWrqwftflJck"
+
+// signed at issued with secret-key by URLSafeTimedSerializer: ["a" * 100], compressed
+const compressedVector = ".eJyLVkqkA1CKBQD5Wibh.ZVPxAA.xKiM-86bUoWgSGcx0Ypz-XN29vM"
+
+func newSerializer(t *testing.T, secretKey string, opts ...Option) *Serializer {
+	t.Helper()
+	m, err := cookie.New()
+	require.NoError(t, err)
+	s, err := New(m, []byte(secretKey), opts...)
+	require.NoError(t, err)
+	s.now = func() time.Time { return issued.Add(time.Minute) }
+	return s
+}
+
+func TestDocsVector(t *testing.T) {
+	// URLSafeSerializer("secret-key").dumps([1, 2, 3, 4]), from the itsdangerous docs
+	s := newSerializer(t, "secret-key")
+	require.Equal(t, "wSPHqC0gR7VUqivlSukJ0IeTDgo", s.signature("WzEsMiwzLDRd", []byte("secret-key")))
+}
+
+func TestFlaskSession(t *testing.T) {
+	s := newSerializer(t, "secret-key", WithFlaskSession())
+
+	var got map[string]string
+	require.NoError(t, s.Decode(flaskVector, &got))
+	require.Equal(t, "alice", got["user"])
+
+	s.now = func() time.Time { return issued }
+	encoded, err := s.Encode(map[string]string{"user": "alice"})
+	require.NoError(t, err)
+	require.Equal(t, flaskVector, encoded)
+
+	s.now = func() time.Time { return issued.Add(32 * 24 * time.Hour) }
+	require.ErrorIs(t, s.Decode(flaskVector, &got), cookie.ErrExpired)
+
+	// the default salt and derivation produce a different signature
+	require.ErrorIs(t, newSerializer(t, "secret-key").Decode(flaskVector, &got), cookie.ErrSignatureMismatch)
+}
+
+func TestCompressed(t *testing.T) {
+	s := newSerializer(t, "secret-key")
+	var got []string
+	require.NoError(t, s.Decode(compressedVector, &got))
+	require.Equal(t, []string{strings.Repeat("a", 100)}, got)
+
+	encoded, err := s.Encode(got)
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(encoded, "."))
+	got = nil
+	require.NoError(t, s.Decode(encoded, &got))
+	require.Equal(t, []string{strings.Repeat("a", 100)}, got)
+}
+
+func TestDecodeFailures(t *testing.T) {
+	s := newSerializer(t, "secret-key", WithFlaskSession())
+	var got map[string]string
+
+	tampered := strings.Replace(flaskVector, "eyJ1", "eyJ2", 1)
+	require.ErrorIs(t, s.Decode(tampered, &got), cookie.ErrSignatureMismatch)
+	require.ErrorIs(t, s.Decode("nodots", &got), cookie.ErrTooShort)
+
+	s.now = func() time.Time { return issued.Add(-time.Minute) }
+	require.ErrorIs(t, s.Decode(flaskVector, &got), cookie.ErrExpired)
+}
+
+func TestFallbackSecrets(t *testing.T) {
+	s := newSerializer(t, "new-key", WithFlaskSession(), WithFallbackSecrets([]byte("secret-key")))
+	var got map[string]string
+	require.NoError(t, s.Decode(flaskVector, &got))
+
+	encoded, err := s.Encode(got)
+	require.NoError(t, err)
+	require.ErrorIs(t, newSerializer(t, "secret-key", WithFlaskSession()).Decode(encoded, &got), cookie.ErrSignatureMismatch)
+}
+
+func TestReadWrite(t *testing.T) {
+	s := newSerializer(t, "secret-key", WithFlaskSession())
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.AddCookie(&http.Cookie{Name: FlaskSessionCookie, Value: flaskVector})
+	var got map[string]string
+	require.NoError(t, s.Read(r, FlaskSessionCookie, &got))
+	require.Equal(t, "alice", got["user"])
+
+	rec := httptest.NewRecorder()
+	require.NoError(t, s.Write(rec, FlaskSessionCookie, map[string]string{"user": "bob"}))
+	set := rec.Result().Cookies()
+	require.Len(t, set, 1)
+	require.True(t, set[0].HttpOnly)
+
+	r = httptest.NewRequest(http.MethodGet, "/", nil)
+	r.AddCookie(set[0])
+	require.NoError(t, s.Read(r, FlaskSessionCookie, &got))
+	require.Equal(t, "bob", got["user"])
+
+	err := s.Read(httptest.NewRequest(http.MethodGet, "/", nil), FlaskSessionCookie, &got)
+	require.ErrorIs(t, err, cookie.ErrNotFound)
+}
+
+func TestNew(t *testing.T) {
+	m, err := cookie.New()
+	require.NoError(t, err)
+	_, err = New(nil, []byte("secret"))
+	require.ErrorIs(t, err, cookie.ErrInitiation)
+	_, err = New(m, nil)
+	require.ErrorIs(t, err, cookie.ErrSecretMissing)
+	_, err = New(m, []byte("secret"), WithKeyDerivation(KeyDerivation(9)))
+	require.ErrorIs(t, err, cookie.ErrInitiation)
+}
@@ -0,0 +1,37 @@
+package cookie
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type testPrefs struct {
+	Theme    string   `json:"theme"`
+	FontSize int      `json:"font_size"`
+	Pinned   []string `json:"pinned"`
+}
+
+func TestWriteReadJSON(t *testing.T) {
+	m := newTestManager(t)
+	prefs := testPrefs{Theme: "dark", FontSize: 14, Pinned: []string{"inbox", "drafts"}}
+
+	for _, mode := range []Mode{Plain, Signed, Encrypted} {
+		t.Run(mode.String(), func(t *testing.T) {
+			w := httptest.NewRecorder()
+			require.NoError(t, WriteJSON(m, w, "prefs", prefs, mode))
+
+			got, err := ReadJSON[testPrefs](m, requestWith(w), "prefs", mode)
+			require.NoError(t, err)
+			require.Equal(t, prefs, got)
+		})
+	}
+
+	w := httptest.NewRecorder()
+	require.NoError(t, WriteJSON(m, w, "prefs", prefs, Plain))
+	_, err := ReadJSON[testPrefs](m, requestWith(w), "prefs", Signed)
+	require.ErrorIs(t, err, ErrCookie)
+
+	require.ErrorIs(t, WriteJSON(m, w, "prefs", prefs, Mode(9)), ErrCookie)
+}
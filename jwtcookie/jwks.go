@@ -0,0 +1,308 @@
+package jwtcookie
+
+import (
+	"context"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/grackleclub/cookie/v2"
+)
+
+const (
+	defaultJWKSTimeout    = 5 * time.Second
+	defaultJWKSRefresh    = time.Hour
+	defaultJWKSMinRefresh = time.Minute
+	maxJWKSSize           = 1 << 20
+)
+
+// jwksMethods are the algorithms a JWKS can verify.
+var jwksMethods = []string{jwt.SigningMethodRS256.Alg(), jwt.SigningMethodEdDSA.Alg()}
+
+// JWKS verifies tokens with public keys fetched from a JSON Web Key Set URL,
+// chosen by the kid header, so verification keys need not be configured.
+// Keys are refetched periodically, and early when a token names an unknown
+// kid, so keys rotated by the issuer are picked up. If a fetch fails,
+// the keys fetched before remain in use. One fetch runs at a time, and
+// tokens whose keys are already known are verified while it does.
+type JWKS struct {
+	client     *http.Client
+	url        string
+	timeout    time.Duration
+	refresh    time.Duration
+	minRefresh time.Duration
+
+	mu        sync.Mutex
+	keys      map[string]jwk
+	fetched   time.Time  // last successful fetch
+	attempted time.Time  // last fetch, successful or not
+	fetching  *jwksFetch // fetch in progress, if any
+	clock     cookie.Clock
+}
+
+// jwksFetch is a fetch of the key set that other callers can wait for.
+type jwksFetch struct {
+	done chan struct{} // closed once err is set
+	err  error
+}
+
+// jwk is a verification key from the set.
+type jwk struct {
+	method jwt.SigningMethod
+	key    crypto.PublicKey
+}
+
+// JWKSOption configures a JWKS.
+type JWKSOption func(*JWKS) error
+
+// NewJWKS creates a JWKS fetching keys from url on first use.
+// RSA keys verify RS256 tokens and Ed25519 keys verify EdDSA tokens;
+// other keys in the set are ignored.
+func NewJWKS(url string, opts ...JWKSOption) (*JWKS, error) {
+	if url == "" {
+		return nil, fmt.Errorf("%w: jwks url is required", cookie.ErrInitiation)
+	}
+	s := &JWKS{
+		client:     http.DefaultClient,
+		url:        url,
+		timeout:    defaultJWKSTimeout,
+		refresh:    defaultJWKSRefresh,
+		minRefresh: defaultJWKSMinRefresh,
+	}
+	for _, opt := range opts {
+		if err := opt(s); err != nil {
+			return nil, fmt.Errorf("%w: %w", cookie.ErrInitiation, err)
+		}
+	}
+	return s, nil
+}
+
+// WithJWKSClient sets the client used to fetch the key set.
+func WithJWKSClient(client *http.Client) JWKSOption {
+	return func(s *JWKS) error {
+		if client == nil {
+			return errors.New("nil http client")
+		}
+		s.client = client
+		return nil
+	}
+}
+
+// WithJWKSTimeout bounds each fetch of the key set. The default is 5 seconds.
+func WithJWKSTimeout(timeout time.Duration) JWKSOption {
+	return func(s *JWKS) error {
+		if timeout <= 0 {
+			return fmt.Errorf("invalid timeout: %s", timeout)
+		}
+		s.timeout = timeout
+		return nil
+	}
+}
+
+// WithJWKSRefresh sets how often the key set is refetched, and the shortest
+// interval between fetches triggered by unknown kids. The defaults are
+// one hour and one minute.
+func WithJWKSRefresh(refresh, minRefresh time.Duration) JWKSOption {
+	return func(s *JWKS) error {
+		if refresh <= 0 || minRefresh < 0 || minRefresh > refresh {
+			return fmt.Errorf("invalid refresh intervals: %s, %s", refresh, minRefresh)
+		}
+		s.refresh = refresh
+		s.minRefresh = minRefresh
+		return nil
+	}
+}
+
+// JWKSKey verifies tokens with the keys of set. It cannot sign.
+func JWKSKey(set *JWKS) Key {
+	return Key{jwks: set}
+}
+
+// SetClock implements cookie.ClockSetter. New calls it with the Manager
+// of the JWT using the set, whose clock then times refetches.
+func (s *JWKS) SetClock(clock cookie.Clock) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.clock = clock
+}
+
+// Refresh fetches the key set now, or waits for a fetch already in progress.
+func (s *JWKS) Refresh(ctx context.Context) error {
+	s.mu.Lock()
+	f, start := s.startFetch()
+	s.mu.Unlock()
+	if start {
+		s.fetch(ctx, f)
+		return f.err
+	}
+	select {
+	case <-f.done:
+		return f.err
+	case <-ctx.Done():
+		return fmt.Errorf("%w: %w", ErrToken, ctx.Err())
+	}
+}
+
+// keyfunc returns the key named by the token's kid header, refetching the
+// set when it is stale or the kid is unknown. A token without a kid is
+// verified by the only key of a single-key set.
+func (s *JWKS) keyfunc(token *jwt.Token) (any, error) {
+	kid, _ := token.Header["kid"].(string)
+
+	s.mu.Lock()
+	now := s.now()
+	key, known := s.lookup(kid)
+	stale := now.Sub(s.fetched) >= s.refresh
+	var f *jwksFetch
+	start := false
+	if (stale || !known) && (s.fetching != nil || now.Sub(s.attempted) >= s.minRefresh) {
+		f, start = s.startFetch()
+	}
+	s.mu.Unlock()
+
+	// a known key is used without waiting for another caller's fetch
+	if f != nil && (start || !known) {
+		if start {
+			s.fetch(context.Background(), f)
+		} else {
+			<-f.done
+		}
+		s.mu.Lock()
+		key, known = s.lookup(kid)
+		empty := len(s.keys) == 0
+		s.mu.Unlock()
+		if f.err != nil && empty {
+			return nil, f.err
+		}
+	}
+	if !known {
+		return nil, fmt.Errorf("%w: unknown key id '%s'", ErrToken, kid)
+	}
+	if key.method.Alg() != token.Method.Alg() {
+		return nil, fmt.Errorf("%w: key '%s' does not verify %s", ErrToken, kid, token.Method.Alg())
+	}
+	return key.key, nil
+}
+
+// lookup finds the key with kid.
+func (s *JWKS) lookup(kid string) (jwk, bool) {
+	if kid == "" && len(s.keys) == 1 {
+		for _, key := range s.keys {
+			return key, true
+		}
+	}
+	key, ok := s.keys[kid]
+	return key, ok
+}
+
+// now returns the time from the set's clock, or the system clock. s.mu must be held.
+func (s *JWKS) now() time.Time {
+	if s.clock == nil {
+		return time.Now()
+	}
+	return s.clock.Now()
+}
+
+// startFetch returns the fetch in progress, or begins one, reporting
+// whether the caller must run it with fetch. s.mu must be held.
+func (s *JWKS) startFetch() (*jwksFetch, bool) {
+	if s.fetching != nil {
+		return s.fetching, false
+	}
+	s.fetching = &jwksFetch{done: make(chan struct{})}
+	s.attempted = s.now()
+	return s.fetching, true
+}
+
+// fetch runs f without holding s.mu, then swaps in the keys it fetched.
+func (s *JWKS) fetch(ctx context.Context, f *jwksFetch) {
+	keys, err := s.download(ctx)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err == nil {
+		s.keys = keys
+		s.fetched = s.attempted
+	}
+	s.fetching = nil
+	f.err = err
+	close(f.done)
+}
+
+// download returns the keys at the set's URL.
+func (s *JWKS) download(ctx context.Context) (map[string]jwk, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrToken, err)
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: unable to fetch jwks: %w", ErrToken, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%w: unable to fetch jwks: %s", ErrToken, resp.Status)
+	}
+	var set struct {
+		Keys []json.RawMessage `json:"keys"`
+	}
+	if err := json.NewDecoder(http.MaxBytesReader(nil, resp.Body, maxJWKSSize)).Decode(&set); err != nil {
+		return nil, fmt.Errorf("%w: unable to decode jwks: %w", ErrToken, err)
+	}
+	keys := make(map[string]jwk, len(set.Keys))
+	for _, raw := range set.Keys {
+		kid, key, ok := parseJWK(raw)
+		if ok {
+			keys[kid] = key
+		}
+	}
+	return keys, nil
+}
+
+// parseJWK parses an RSA or Ed25519 signing key, reporting false for
+// keys of other types or uses.
+func parseJWK(raw json.RawMessage) (string, jwk, bool) {
+	var k struct {
+		Kty string `json:"kty"`
+		Kid string `json:"kid"`
+		Use string `json:"use"`
+		Alg string `json:"alg"`
+		Crv string `json:"crv"`
+		N   string `json:"n"`
+		E   string `json:"e"`
+		X   string `json:"x"`
+	}
+	if err := json.Unmarshal(raw, &k); err != nil || (k.Use != "" && k.Use != "sig") {
+		return "", jwk{}, false
+	}
+	switch {
+	case k.Kty == "RSA" && (k.Alg == "" || k.Alg == jwt.SigningMethodRS256.Alg()):
+		n, errN := base64.RawURLEncoding.DecodeString(k.N)
+		e, errE := base64.RawURLEncoding.DecodeString(k.E)
+		if errN != nil || errE != nil || len(e) == 0 || len(e) > 4 {
+			return "", jwk{}, false
+		}
+		key := &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+		return k.Kid, jwk{method: jwt.SigningMethodRS256, key: key}, true
+	case k.Kty == "OKP" && k.Crv == "Ed25519" && (k.Alg == "" || k.Alg == jwt.SigningMethodEdDSA.Alg()):
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return "", jwk{}, false
+		}
+		return k.Kid, jwk{method: jwt.SigningMethodEdDSA, key: ed25519.PublicKey(x)}, true
+	default:
+		return "", jwk{}, false
+	}
+}
@@ -0,0 +1,219 @@
+package jwtcookie
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/grackleclub/cookie/v2"
+	"github.com/grackleclub/cookie/v2/cookietest"
+	"github.com/stretchr/testify/require"
+)
+
+// jwksServer serves a mutable key set, counting fetches.
+type jwksServer struct {
+	mu      sync.Mutex
+	keys    []map[string]string
+	hold    chan struct{} // when set, responses wait for it to close
+	fetches atomic.Int32
+}
+
+func (s *jwksServer) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	s.fetches.Add(1)
+	s.mu.Lock()
+	hold := s.hold
+	s.mu.Unlock()
+	if hold != nil {
+		<-hold
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	json.NewEncoder(w).Encode(map[string]any{"keys": s.keys})
+}
+
+func (s *jwksServer) set(keys ...map[string]string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys = keys
+}
+
+func rsaJWK(kid string, key *rsa.PublicKey) map[string]string {
+	return map[string]string{
+		"kty": "RSA",
+		"kid": kid,
+		"use": "sig",
+		"alg": "RS256",
+		"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+		"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+	}
+}
+
+func edJWK(kid string, key ed25519.PublicKey) map[string]string {
+	return map[string]string{
+		"kty": "OKP",
+		"crv": "Ed25519",
+		"kid": kid,
+		"x":   base64.RawURLEncoding.EncodeToString(key),
+	}
+}
+
+func TestJWKS(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	edPublic, edPrivate, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+
+	server := &jwksServer{}
+	server.set(rsaJWK("rsa-1", &rsaKey.PublicKey), edJWK("ed-1", edPublic), map[string]string{"kty": "EC", "kid": "ec-1"})
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	cookies, err := cookie.New()
+	require.NoError(t, err)
+	set, err := NewJWKS(ts.URL)
+	require.NoError(t, err)
+	verifier, err := New(cookies, JWKSKey(set))
+	require.NoError(t, err)
+
+	for _, key := range []Key{RS256(rsaKey).WithID("rsa-1"), EdDSA(edPrivate).WithID("ed-1")} {
+		signer, err := New(cookies, key)
+		require.NoError(t, err)
+		w := httptest.NewRecorder()
+		require.NoError(t, signer.Write(w, jwt.RegisteredClaims{Subject: "1312"}))
+		claims, err := verifier.Read(cookietest.RequestFrom(t, w))
+		require.NoError(t, err)
+		require.Equal(t, "1312", claims.Subject)
+	}
+	require.EqualValues(t, 1, server.fetches.Load())
+
+	t.Run("wrong algorithm for kid", func(t *testing.T) {
+		signer, err := New(cookies, EdDSA(edPrivate).WithID("rsa-1"))
+		require.NoError(t, err)
+		w := httptest.NewRecorder()
+		require.NoError(t, signer.Write(w, jwt.RegisteredClaims{}))
+		_, err = verifier.Read(cookietest.RequestFrom(t, w))
+		require.ErrorIs(t, err, ErrToken)
+	})
+
+	t.Run("cannot sign", func(t *testing.T) {
+		err := verifier.Write(httptest.NewRecorder(), jwt.RegisteredClaims{})
+		require.ErrorIs(t, err, ErrToken)
+	})
+}
+
+func TestJWKSRotation(t *testing.T) {
+	oldPublic, oldPrivate, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+	newPublic, newPrivate, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+
+	server := &jwksServer{}
+	server.set(edJWK("old", oldPublic))
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	cookies, err := cookie.New()
+	require.NoError(t, err)
+	set, err := NewJWKS(ts.URL, WithJWKSRefresh(time.Hour, time.Hour))
+	require.NoError(t, err)
+	verifier, err := New(cookies, JWKSKey(set))
+	require.NoError(t, err)
+
+	write := func(key Key) *httptest.ResponseRecorder {
+		signer, err := New(cookies, key)
+		require.NoError(t, err)
+		w := httptest.NewRecorder()
+		require.NoError(t, signer.Write(w, jwt.RegisteredClaims{}))
+		return w
+	}
+
+	// a single key verifies tokens without a kid
+	_, err = verifier.Read(cookietest.RequestFrom(t, write(EdDSA(oldPrivate))))
+	require.NoError(t, err)
+
+	// the issuer rotates, but unknown kids cannot trigger another fetch yet
+	server.set(edJWK("old", oldPublic), edJWK("new", newPublic))
+	_, err = verifier.Read(cookietest.RequestFrom(t, write(EdDSA(newPrivate).WithID("new"))))
+	require.ErrorIs(t, err, ErrToken)
+	require.EqualValues(t, 1, server.fetches.Load())
+
+	require.NoError(t, set.Refresh(context.Background()))
+	_, err = verifier.Read(cookietest.RequestFrom(t, write(EdDSA(newPrivate).WithID("new"))))
+	require.NoError(t, err)
+
+	// with no minimum interval, an unknown kid refetches at once
+	set.minRefresh = 0
+	server.set(edJWK("newer", oldPublic))
+	_, err = verifier.Read(cookietest.RequestFrom(t, write(EdDSA(oldPrivate).WithID("newer"))))
+	require.NoError(t, err)
+	require.EqualValues(t, 3, server.fetches.Load())
+
+	// failed fetches keep the keys fetched before
+	ts.Close()
+	require.Error(t, set.Refresh(context.Background()))
+	_, err = verifier.Read(cookietest.RequestFrom(t, write(EdDSA(oldPrivate).WithID("newer"))))
+	require.NoError(t, err)
+}
+
+func TestJWKSFetchInBackground(t *testing.T) {
+	public, private, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+	server := &jwksServer{}
+	server.set(edJWK("a", public))
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	clock := cookietest.NewClock(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC))
+	cookies, err := cookie.New(cookie.WithClock(clock))
+	require.NoError(t, err)
+	set, err := NewJWKS(ts.URL)
+	require.NoError(t, err)
+	verifier, err := New(cookies, JWKSKey(set))
+	require.NoError(t, err)
+	signer, err := New(cookies, EdDSA(private).WithID("a"))
+	require.NoError(t, err)
+	w := httptest.NewRecorder()
+	expires := jwt.NewNumericDate(clock.Now().Add(2 * defaultJWKSRefresh))
+	require.NoError(t, signer.Write(w, jwt.RegisteredClaims{ExpiresAt: expires}))
+	_, err = verifier.Read(cookietest.RequestFrom(t, w))
+	require.NoError(t, err)
+
+	// once the Manager's clock makes the set stale, one read refetches it
+	// while others verify with the keys already fetched
+	hold := make(chan struct{})
+	release := sync.OnceFunc(func() { close(hold) })
+	defer release()
+	server.mu.Lock()
+	server.hold = hold
+	server.mu.Unlock()
+	clock.Advance(defaultJWKSRefresh)
+	refetched := make(chan error)
+	go func() {
+		_, err := verifier.Read(cookietest.RequestFrom(t, w))
+		refetched <- err
+	}()
+	require.Eventually(t, func() bool { return server.fetches.Load() == 2 }, time.Second, time.Millisecond)
+	_, err = verifier.Read(cookietest.RequestFrom(t, w))
+	require.NoError(t, err)
+	release()
+	require.NoError(t, <-refetched)
+	require.EqualValues(t, 2, server.fetches.Load())
+}
+
+func TestNewJWKS(t *testing.T) {
+	_, err := NewJWKS("")
+	require.ErrorIs(t, err, cookie.ErrInitiation)
+	_, err = NewJWKS("https://example.com/jwks.json", WithJWKSRefresh(time.Minute, time.Hour))
+	require.ErrorIs(t, err, cookie.ErrInitiation)
+}
@@ -0,0 +1,254 @@
+// package jwtcookie stores JSON Web Tokens in cookies.
+// Tokens are written unmodified, so any JWT library can read them,
+// with the attributes of a cookie.Manager (Secure, HttpOnly, and so on).
+package jwtcookie
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/grackleclub/cookie/v2"
+)
+
+const (
+	defaultName    = "jwt"
+	maxCookieSize  = 4096
+	defaultExpires = time.Hour
+)
+
+var ErrToken = errors.New("jwt failure")
+
+// Key signs and verifies tokens with a single algorithm.
+// A Key built from a public key can only verify.
+type Key struct {
+	method    jwt.SigningMethod
+	signKey   any
+	verifyKey any
+	id        string // written as the kid header
+	jwks      *JWKS  // replaces verifyKey when set
+}
+
+// WithID returns a copy of k that names itself in the kid header of
+// tokens it signs, so verifiers using a JWKS can find the matching key.
+func (k Key) WithID(kid string) Key {
+	k.id = kid
+	return k
+}
+
+// HS256 signs and verifies with HMAC-SHA256 and a shared secret.
+func HS256(secretKey []byte) Key {
+	return Key{method: jwt.SigningMethodHS256, signKey: secretKey, verifyKey: secretKey}
+}
+
+// RS256 signs with an RSA private key and verifies with its public half.
+func RS256(privateKey *rsa.PrivateKey) Key {
+	return Key{method: jwt.SigningMethodRS256, signKey: privateKey, verifyKey: &privateKey.PublicKey}
+}
+
+// RS256Public verifies RS256 tokens signed elsewhere.
+func RS256Public(publicKey *rsa.PublicKey) Key {
+	return Key{method: jwt.SigningMethodRS256, verifyKey: publicKey}
+}
+
+// EdDSA signs with an Ed25519 private key and verifies with its public half.
+func EdDSA(privateKey ed25519.PrivateKey) Key {
+	return Key{method: jwt.SigningMethodEdDSA, signKey: privateKey, verifyKey: privateKey.Public()}
+}
+
+// EdDSAPublic verifies EdDSA tokens signed elsewhere.
+func EdDSAPublic(publicKey ed25519.PublicKey) Key {
+	return Key{method: jwt.SigningMethodEdDSA, verifyKey: publicKey}
+}
+
+// JWT writes and reads token cookies.
+type JWT struct {
+	cookies  *cookie.Manager
+	key      Key
+	name     string
+	issuer   string
+	audience string
+	leeway   time.Duration
+}
+
+// Option configures a JWT.
+type Option func(*JWT) error
+
+// New creates a JWT that signs and verifies with key, writing cookies
+// with the cookie.Manager's defaults.
+func New(cookies *cookie.Manager, key Key, opts ...Option) (*JWT, error) {
+	if cookies == nil {
+		return nil, fmt.Errorf("%w: cookie manager is required", cookie.ErrInitiation)
+	}
+	if key.jwks == nil && (key.method == nil || key.verifyKey == nil) {
+		return nil, fmt.Errorf("%w: %w", cookie.ErrInitiation, cookie.ErrSecretMissing)
+	}
+	if secretKey, ok := key.verifyKey.([]byte); ok && len(secretKey) == 0 {
+		return nil, fmt.Errorf("%w: %w", cookie.ErrInitiation, cookie.ErrSecretMissing)
+	}
+	j := &JWT{
+		cookies: cookies,
+		key:     key,
+		name:    defaultName,
+	}
+	for _, opt := range opts {
+		if err := opt(j); err != nil {
+			return nil, fmt.Errorf("%w: %w", cookie.ErrInitiation, err)
+		}
+	}
+	if key.jwks != nil {
+		key.jwks.SetClock(cookies)
+	}
+	return j, nil
+}
+
+// WithName sets the name of the token cookie.
+func WithName(name string) Option {
+	return func(j *JWT) error {
+		if name == "" {
+			return errors.New("empty jwt cookie name")
+		}
+		j.name = name
+		return nil
+	}
+}
+
+// WithIssuer sets the iss claim on written tokens and requires it on read.
+func WithIssuer(issuer string) Option {
+	return func(j *JWT) error {
+		j.issuer = issuer
+		return nil
+	}
+}
+
+// WithAudience sets the aud claim on written tokens and requires it on read.
+func WithAudience(audience string) Option {
+	return func(j *JWT) error {
+		j.audience = audience
+		return nil
+	}
+}
+
+// WithLeeway tolerates clock skew when checking exp, nbf, and iat.
+func WithLeeway(leeway time.Duration) Option {
+	return func(j *JWT) error {
+		if leeway < 0 {
+			return fmt.Errorf("negative leeway: %s", leeway)
+		}
+		j.leeway = leeway
+		return nil
+	}
+}
+
+// Write signs claims and sets them as the token cookie.
+// Unset iss, aud, iat, and exp claims are filled in; exp defaults to one hour
+// and the cookie's Max-Age is set to match.
+func (j *JWT) Write(w http.ResponseWriter, claims jwt.RegisteredClaims) error {
+	return j.WriteClaims(w, &claims)
+}
+
+// WriteClaims is Write for custom claims types, which are signed as given
+// and must set their own exp.
+func (j *JWT) WriteClaims(w http.ResponseWriter, claims jwt.Claims) error {
+	if j.key.signKey == nil {
+		return fmt.Errorf("%w: key can only verify", ErrToken)
+	}
+	if registered, ok := claims.(*jwt.RegisteredClaims); ok {
+		now := j.cookies.Now()
+		if registered.Issuer == "" {
+			registered.Issuer = j.issuer
+		}
+		if len(registered.Audience) == 0 && j.audience != "" {
+			registered.Audience = jwt.ClaimStrings{j.audience}
+		}
+		if registered.IssuedAt == nil {
+			registered.IssuedAt = jwt.NewNumericDate(now)
+		}
+		if registered.ExpiresAt == nil {
+			registered.ExpiresAt = jwt.NewNumericDate(now.Add(defaultExpires))
+		}
+	}
+	expires, err := claims.GetExpirationTime()
+	if err != nil || expires == nil {
+		return fmt.Errorf("%w: exp claim is required", ErrToken)
+	}
+	maxAge := int(expires.Time.Sub(j.cookies.Now()).Seconds())
+	if maxAge <= 0 {
+		return fmt.Errorf("%w: %w", ErrToken, jwt.ErrTokenExpired)
+	}
+
+	unsigned := jwt.NewWithClaims(j.key.method, claims)
+	if j.key.id != "" {
+		unsigned.Header["kid"] = j.key.id
+	}
+	token, err := unsigned.SignedString(j.key.signKey)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrToken, err)
+	}
+	// tokens are already URL-safe, so they are written without base64 encoding
+	c := j.cookies.Cookie(j.name, token)
+	c.MaxAge = maxAge
+	if len(c.String()) > maxCookieSize {
+		return fmt.Errorf("%w: %w: token too long", ErrToken, cookie.ErrCookie)
+	}
+	http.SetCookie(w, &c)
+	return nil
+}
+
+// Read verifies the token cookie and returns its registered claims.
+func (j *JWT) Read(r *http.Request) (*jwt.RegisteredClaims, error) {
+	var claims jwt.RegisteredClaims
+	if err := j.ReadClaims(r, &claims); err != nil {
+		return nil, err
+	}
+	return &claims, nil
+}
+
+// ReadClaims verifies the token cookie and decodes it into claims.
+// The signature, algorithm, exp, nbf, and any configured iss and aud are checked.
+func (j *JWT) ReadClaims(r *http.Request, claims jwt.Claims) error {
+	c, err := r.Cookie(j.cookies.Cookie(j.name, "").Name)
+	if err != nil {
+		return fmt.Errorf("%w: '%s' not found: %w", cookie.ErrCookie, j.name, err)
+	}
+	methods, keyfunc := j.key.verifier()
+	opts := []jwt.ParserOption{
+		jwt.WithValidMethods(methods),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(j.leeway),
+		jwt.WithTimeFunc(j.cookies.Now),
+	}
+	if j.issuer != "" {
+		opts = append(opts, jwt.WithIssuer(j.issuer))
+	}
+	if j.audience != "" {
+		opts = append(opts, jwt.WithAudience(j.audience))
+	}
+	_, err = jwt.ParseWithClaims(c.Value, claims, keyfunc, opts...)
+	if err != nil {
+		if errors.Is(err, jwt.ErrTokenExpired) {
+			return fmt.Errorf("%w: %w: %w", ErrToken, cookie.ErrExpired, err)
+		}
+		return fmt.Errorf("%w: %w", ErrToken, err)
+	}
+	return nil
+}
+
+// verifier returns the algorithms k accepts and the keyfunc that finds the key for a token.
+func (k Key) verifier() ([]string, jwt.Keyfunc) {
+	if k.jwks != nil {
+		return jwksMethods, k.jwks.keyfunc
+	}
+	return []string{k.method.Alg()}, func(*jwt.Token) (any, error) {
+		return k.verifyKey, nil
+	}
+}
+
+// Delete expires the token cookie.
+func (j *JWT) Delete(w http.ResponseWriter) {
+	j.cookies.Delete(w, j.name)
+}
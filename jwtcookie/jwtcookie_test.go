@@ -0,0 +1,140 @@
+package jwtcookie
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/grackleclub/cookie/v2"
+	"github.com/grackleclub/cookie/v2/cookietest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeys(t *testing.T) {
+	secretKey, err := cookie.NewCookieSecret()
+	require.NoError(t, err)
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	edPublic, edPrivate, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+
+	cookies, err := cookie.New()
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		alg            string
+		signer, verify Key
+	}{
+		{"HS256", HS256(secretKey), HS256(secretKey)},
+		{"RS256", RS256(rsaKey), RS256Public(&rsaKey.PublicKey)},
+		{"EdDSA", EdDSA(edPrivate), EdDSAPublic(edPublic)},
+	} {
+		t.Run(tc.alg, func(t *testing.T) {
+			signer, err := New(cookies, tc.signer)
+			require.NoError(t, err)
+			verifier, err := New(cookies, tc.verify)
+			require.NoError(t, err)
+
+			w := httptest.NewRecorder()
+			require.NoError(t, signer.Write(w, jwt.RegisteredClaims{Subject: "1312"}))
+			c := w.Result().Cookies()[0]
+			require.True(t, c.HttpOnly)
+			require.True(t, c.Secure)
+			require.InDelta(t, time.Hour.Seconds(), c.MaxAge, 2)
+
+			claims, err := verifier.Read(cookietest.RequestFrom(t, w))
+			require.NoError(t, err)
+			require.Equal(t, "1312", claims.Subject)
+
+			if tc.alg != "HS256" {
+				err := verifier.Write(httptest.NewRecorder(), jwt.RegisteredClaims{})
+				require.ErrorIs(t, err, ErrToken)
+			}
+		})
+	}
+
+	t.Run("algorithm mismatch", func(t *testing.T) {
+		signer, err := New(cookies, EdDSA(edPrivate))
+		require.NoError(t, err)
+		verifier, err := New(cookies, HS256(secretKey))
+		require.NoError(t, err)
+		w := httptest.NewRecorder()
+		require.NoError(t, signer.Write(w, jwt.RegisteredClaims{}))
+		_, err = verifier.Read(cookietest.RequestFrom(t, w))
+		require.ErrorIs(t, err, ErrToken)
+	})
+
+	_, err = New(cookies, HS256(nil))
+	require.ErrorIs(t, err, cookie.ErrInitiation)
+}
+
+func TestClaims(t *testing.T) {
+	secretKey, err := cookie.NewCookieSecret()
+	require.NoError(t, err)
+	cookies, err := cookie.New()
+	require.NoError(t, err)
+	j, err := New(cookies, HS256(secretKey), WithIssuer("auth"), WithAudience("api"))
+	require.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	require.NoError(t, j.Write(w, jwt.RegisteredClaims{}))
+	claims, err := j.Read(cookietest.RequestFrom(t, w))
+	require.NoError(t, err)
+	require.Equal(t, "auth", claims.Issuer)
+	require.Equal(t, jwt.ClaimStrings{"api"}, claims.Audience)
+
+	for name, tc := range map[string]struct {
+		claims jwt.RegisteredClaims
+		err    error
+	}{
+		"expired": {
+			claims: jwt.RegisteredClaims{ExpiresAt: jwt.NewNumericDate(time.Now().Add(-time.Minute))},
+			err:    cookie.ErrExpired,
+		},
+		"not before": {
+			claims: jwt.RegisteredClaims{NotBefore: jwt.NewNumericDate(time.Now().Add(time.Hour))},
+			err:    jwt.ErrTokenNotValidYet,
+		},
+		"issuer": {
+			claims: jwt.RegisteredClaims{Issuer: "elsewhere"},
+			err:    jwt.ErrTokenInvalidIssuer,
+		},
+		"audience": {
+			claims: jwt.RegisteredClaims{Audience: jwt.ClaimStrings{"billing"}},
+			err:    jwt.ErrTokenInvalidAudience,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			// sign directly, since Write refuses expired tokens
+			claims := tc.claims
+			if claims.ExpiresAt == nil {
+				claims.ExpiresAt = jwt.NewNumericDate(time.Now().Add(time.Hour))
+			}
+			if claims.Issuer == "" {
+				claims.Issuer = "auth"
+			}
+			if claims.Audience == nil {
+				claims.Audience = jwt.ClaimStrings{"api"}
+			}
+			token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(secretKey)
+			require.NoError(t, err)
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.AddCookie(&http.Cookie{Name: defaultName, Value: token})
+			_, err = j.Read(r)
+			require.ErrorIs(t, err, ErrToken)
+			require.ErrorIs(t, err, tc.err)
+		})
+	}
+
+	t.Run("delete", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		j.Delete(w)
+		_, err := j.Read(cookietest.RequestFrom(t, w))
+		require.ErrorIs(t, err, cookie.ErrCookie)
+	})
+}
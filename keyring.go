@@ -0,0 +1,113 @@
+package cookie
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+)
+
+// KeyRing is a SecretSource holding secrets under short, stable IDs.
+// The ID of the writing key is embedded in each value's envelope, so reading
+// looks up exactly one key: an unknown ID fails with ErrUnknownKey, while a
+// known ID with a bad signature is unambiguously a tampered value.
+type KeyRing struct {
+	mu   sync.RWMutex
+	keys []RingKey // newest first
+	byID map[string][]byte
+}
+
+// RingKey is a secret and its ID, at most 255 bytes long.
+type RingKey struct {
+	ID     string
+	Secret []byte
+}
+
+// NewKeyRing creates a KeyRing from keys, newest first.
+func NewKeyRing(keys ...RingKey) (*KeyRing, error) {
+	if len(keys) == 0 {
+		return nil, ErrSecretMissing
+	}
+	k := &KeyRing{byID: make(map[string][]byte, len(keys))}
+	for i := len(keys) - 1; i >= 0; i-- {
+		if err := k.Add(keys[i].ID, keys[i].Secret); err != nil {
+			return nil, err
+		}
+	}
+	return k, nil
+}
+
+// WithKeyRing reads secrets from ring, embedding ring IDs in place of
+// secret fingerprints.
+func WithKeyRing(ring *KeyRing) Option {
+	return WithSecretSource(ring)
+}
+
+// Add makes secret the current key under id. Existing keys remain readable.
+func (k *KeyRing) Add(id string, secret []byte) error {
+	if id == "" || len(id) > 255 {
+		return fmt.Errorf("invalid key id %q", id)
+	}
+	if len(secret) == 0 {
+		return fmt.Errorf("key %s: %w", id, ErrSecretMissing)
+	}
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if _, ok := k.byID[id]; ok {
+		return fmt.Errorf("duplicate key id %q", id)
+	}
+	k.keys = slices.Insert(k.keys, 0, RingKey{ID: id, Secret: secret})
+	k.byID[id] = secret
+	return nil
+}
+
+// Remove retires the key with id; values written with it no longer read.
+// The current key cannot be removed while it is the only key.
+func (k *KeyRing) Remove(id string) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if _, ok := k.byID[id]; !ok {
+		return fmt.Errorf("%w %q", ErrUnknownKey, id)
+	}
+	if len(k.keys) == 1 {
+		return errors.New("cannot remove the last key")
+	}
+	k.keys = slices.DeleteFunc(k.keys, func(key RingKey) bool { return key.ID == id })
+	delete(k.byID, id)
+	return nil
+}
+
+// Current implements SecretSource.
+func (k *KeyRing) Current() ([]byte, error) {
+	_, secret, err := k.current()
+	return secret, err
+}
+
+// All implements SecretSource.
+func (k *KeyRing) All() ([][]byte, error) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	all := make([][]byte, len(k.keys))
+	for i, key := range k.keys {
+		all[i] = key.Secret
+	}
+	return all, nil
+}
+
+// current returns the ID and secret of the newest key together.
+func (k *KeyRing) current() ([]byte, []byte, error) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	if len(k.keys) == 0 {
+		return nil, nil, ErrSecretMissing
+	}
+	return []byte(k.keys[0].ID), k.keys[0].Secret, nil
+}
+
+// lookup returns the secret with id.
+func (k *KeyRing) lookup(id []byte) ([]byte, bool) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	secret, ok := k.byID[string(id)]
+	return secret, ok
+}
@@ -0,0 +1,56 @@
+package cookie
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeyRing(t *testing.T) {
+	secret := func() []byte {
+		secretKey, err := NewCookieSecret()
+		require.NoError(t, err)
+		return secretKey
+	}
+	ring, err := NewKeyRing(RingKey{ID: "2024-01", Secret: secret()})
+	require.NoError(t, err)
+	m, err := New(WithKeyRing(ring))
+	require.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	require.NoError(t, m.WriteSigned(w, "s", "chocolate fudge"))
+	require.NoError(t, m.WriteEncryptedValue(w, "e", "oatmeal raisin"))
+
+	raw, err := Read(requestWith(w), "s")
+	require.NoError(t, err)
+	h, _, _, err := parseHeader(raw)
+	require.NoError(t, err)
+	require.Equal(t, "2024-01", string(h.keyID))
+
+	// rotating keeps older values readable by their ID
+	require.NoError(t, ring.Add("2024-02", secret()))
+	value, err := m.ReadSigned(requestWith(w), "s")
+	require.NoError(t, err)
+	require.Equal(t, "chocolate fudge", value)
+	value, err = m.ReadEncryptedValue(requestWith(w), "e")
+	require.NoError(t, err)
+	require.Equal(t, "oatmeal raisin", value)
+
+	// tampering and retired keys fail differently
+	tampered := httptest.NewRecorder()
+	require.NoError(t, Write(tampered, http.Cookie{Name: "s", Value: raw + "!"}))
+	_, err = m.ReadSigned(requestWith(tampered), "s")
+	require.ErrorIs(t, err, ErrCookie)
+	require.NotErrorIs(t, err, ErrUnknownKey)
+
+	require.NoError(t, ring.Remove("2024-01"))
+	_, err = m.ReadSigned(requestWith(w), "s")
+	require.ErrorIs(t, err, ErrUnknownKey)
+
+	require.Error(t, ring.Add("2024-02", secret()))
+	require.Error(t, ring.Remove("2024-02"))
+	_, err = NewKeyRing()
+	require.ErrorIs(t, err, ErrSecretMissing)
+}
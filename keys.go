@@ -0,0 +1,68 @@
+package cookie
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// ReadSignedKeys reads a signed cookie, verifying the signature against each
+// secret in order. Pass the newest secret first, followed by any retired secrets
+// whose cookies should remain valid during a rotation window.
+func ReadSignedKeys(r *http.Request, name string, secretKeys [][]byte) (string, error) {
+	if len(secretKeys) == 0 {
+		return "", ErrSecretMissing
+	}
+	signedValue, err := Read(r, name)
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrCookie, err)
+	}
+	return verifyKeys(name, signedValue, secretKeys)
+}
+
+// ReadEncryptedKeys reads an encrypted cookie, attempting decryption with each
+// secret in order. Pass the newest secret first.
+func ReadEncryptedKeys(r *http.Request, name string, secretKeys [][]byte) (int, string, error) {
+	if len(secretKeys) == 0 {
+		return 0, "", ErrSecretMissing
+	}
+	for i, secretKey := range secretKeys {
+		if err := ValidateSecret(secretKey); err != nil {
+			return 0, "", fmt.Errorf("secret %d: %w", i, err)
+		}
+	}
+	plaintext, err := readEncrypted(r, name, secretKeys)
+	if err != nil {
+		return 0, "", err
+	}
+	return splitUserID(plaintext)
+}
+
+// verifyKeys returns the value verified by the first matching secret,
+// or the error from the last secret attempted. An expired cookie is
+// reported as soon as its signature matches.
+func verifyKeys(name, signedValue string, secretKeys [][]byte) (string, error) {
+	var err error
+	for _, secretKey := range secretKeys {
+		var value string
+		value, err = verify(name, signedValue, secretKey)
+		if err == nil || errors.Is(err, ErrExpired) {
+			return value, err
+		}
+	}
+	return "", err
+}
+
+// decryptKeys returns the plaintext opened by the first matching secret,
+// or the error from the last secret attempted.
+func decryptKeys(name, encryptedValue string, secretKeys [][]byte) (string, error) {
+	var err error
+	for _, secretKey := range secretKeys {
+		var plaintext string
+		plaintext, err = decrypt(name, encryptedValue, secretKey)
+		if err == nil {
+			return plaintext, nil
+		}
+	}
+	return "", err
+}
@@ -0,0 +1,63 @@
+package cookie
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadKeys(t *testing.T) {
+	oldKey, err := NewCookieSecret()
+	require.NoError(t, err)
+	newKey, err := NewCookieSecret()
+	require.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	require.NoError(t, WriteSigned(w, testCookie, oldKey))
+	r := requestWith(w)
+
+	_, err = ReadSignedKeys(r, testCookie.Name, [][]byte{newKey})
+	require.ErrorIs(t, err, ErrCookie)
+	value, err := ReadSignedKeys(r, testCookie.Name, [][]byte{newKey, oldKey})
+	require.NoError(t, err)
+	require.Equal(t, testCookie.Value, value)
+
+	w = httptest.NewRecorder()
+	require.NoError(t, WriteEncrypted(w, testUserID, testCookie, oldKey))
+	r = requestWith(w)
+
+	_, _, err = ReadEncryptedKeys(r, testCookie.Name, [][]byte{newKey})
+	require.Error(t, err)
+	id, value, err := ReadEncryptedKeys(r, testCookie.Name, [][]byte{newKey, oldKey})
+	require.NoError(t, err)
+	require.Equal(t, testUserID, id)
+	require.Equal(t, testCookie.Value, value)
+
+	_, err = ReadSignedKeys(r, testCookie.Name, nil)
+	require.ErrorIs(t, err, ErrSecretMissing)
+}
+
+func TestManagerRotation(t *testing.T) {
+	oldKey, err := NewCookieSecret()
+	require.NoError(t, err)
+	newKey, err := NewCookieSecret()
+	require.NoError(t, err)
+
+	before, err := New(WithSecret(oldKey))
+	require.NoError(t, err)
+	after, err := New(WithSecrets(newKey, oldKey))
+	require.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	require.NoError(t, before.WriteSigned(w, "a", "issued before rotation"))
+	value, err := after.ReadSigned(requestWith(w), "a")
+	require.NoError(t, err)
+	require.Equal(t, "issued before rotation", value)
+
+	// writes after rotation use only the newest key
+	w = httptest.NewRecorder()
+	require.NoError(t, after.WriteSigned(w, "a", "issued after rotation"))
+	_, err = before.ReadSigned(requestWith(w), "a")
+	require.ErrorIs(t, err, ErrCookie)
+}
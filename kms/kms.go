@@ -0,0 +1,320 @@
+// package kms implements envelope encryption for cookies: values are
+// encrypted with AES-GCM data keys generated locally, and each data key is
+// wrapped by a key management service such as AWS KMS. Data keys rotate
+// automatically, and every cookie carries the ID of the key that encrypted it.
+//
+// An Encrypter plugs into a cookie.Manager with cookie.WithEncrypter.
+// To use AWS KMS, adapt the SDK client to KeyWrapper:
+//
+//	func (k awsKMS) Wrap(ctx context.Context, key []byte) ([]byte, error) {
+//		out, err := k.client.Encrypt(ctx, &kms.EncryptInput{KeyId: &k.keyID, Plaintext: key})
+//		if err != nil {
+//			return nil, err
+//		}
+//		return out.CiphertextBlob, nil
+//	}
+//
+//	func (k awsKMS) Unwrap(ctx context.Context, wrapped []byte) ([]byte, error) {
+//		out, err := k.client.Decrypt(ctx, &kms.DecryptInput{KeyId: &k.keyID, CiphertextBlob: wrapped})
+//		if err != nil {
+//			return nil, err
+//		}
+//		return out.Plaintext, nil
+//	}
+package kms
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/grackleclub/cookie/v2"
+)
+
+const (
+	dataKeyLength   = 32
+	keyIDLength     = 8
+	defaultRotation = 24 * time.Hour
+	defaultTimeout  = 5 * time.Second
+	defaultMissTTL  = 10 * time.Second
+	maxMisses       = 1024
+)
+
+var ErrKeyNotFound = errors.New("data key not found")
+
+// KeyWrapper encrypts and decrypts data keys with a master key that never
+// leaves the key management service.
+type KeyWrapper interface {
+	Wrap(ctx context.Context, key []byte) ([]byte, error)
+	Unwrap(ctx context.Context, wrapped []byte) ([]byte, error)
+}
+
+// KeyStore shares wrapped data keys between servers, so any server can
+// decrypt a cookie encrypted by another. Get returns ErrKeyNotFound for
+// unknown IDs.
+type KeyStore interface {
+	Put(ctx context.Context, id string, wrapped []byte) error
+	Get(ctx context.Context, id string) ([]byte, error)
+}
+
+// Encrypter is a cookie.Encrypter using envelope encryption. Reads pass the
+// request's context to the KeyStore and KeyWrapper, as a cookie.ContextDecrypter.
+type Encrypter struct {
+	wrapper  KeyWrapper
+	store    KeyStore
+	rotation time.Duration
+	timeout  time.Duration
+	missTTL  time.Duration
+
+	mu      sync.Mutex
+	current *dataKey
+	keys    map[string]cipher.AEAD // unwrapped data keys by ID
+	misses  map[string]time.Time   // IDs missing from the store, until when
+}
+
+type dataKey struct {
+	id      string
+	aead    cipher.AEAD
+	created time.Time
+}
+
+// Option configures an Encrypter.
+type Option func(*Encrypter) error
+
+// New creates an Encrypter that wraps data keys with wrapper and shares
+// them through store.
+func New(wrapper KeyWrapper, store KeyStore, opts ...Option) (*Encrypter, error) {
+	if wrapper == nil || store == nil {
+		return nil, fmt.Errorf("%w: key wrapper and key store are required", cookie.ErrInitiation)
+	}
+	e := &Encrypter{
+		wrapper:  wrapper,
+		store:    store,
+		rotation: defaultRotation,
+		timeout:  defaultTimeout,
+		missTTL:  defaultMissTTL,
+		keys:     map[string]cipher.AEAD{},
+		misses:   map[string]time.Time{},
+	}
+	for _, opt := range opts {
+		if err := opt(e); err != nil {
+			return nil, fmt.Errorf("%w: %w", cookie.ErrInitiation, err)
+		}
+	}
+	return e, nil
+}
+
+// WithRotation sets how long a data key encrypts new cookies before a
+// fresh one is generated. Old keys still decrypt. The default is 24 hours.
+func WithRotation(rotation time.Duration) Option {
+	return func(e *Encrypter) error {
+		if rotation <= 0 {
+			return fmt.Errorf("invalid rotation: %s", rotation)
+		}
+		e.rotation = rotation
+		return nil
+	}
+}
+
+// WithTimeout bounds each call to the KeyWrapper and KeyStore, within the
+// request's deadline when reading. The default is 5 seconds.
+func WithTimeout(timeout time.Duration) Option {
+	return func(e *Encrypter) error {
+		if timeout <= 0 {
+			return fmt.Errorf("invalid timeout: %s", timeout)
+		}
+		e.timeout = timeout
+		return nil
+	}
+}
+
+// WithMissTTL sets how long an unknown data key ID is remembered, so cookies
+// forged with random IDs fail without a KeyStore call each. Zero looks up
+// every time. The default is 10 seconds.
+func WithMissTTL(ttl time.Duration) Option {
+	return func(e *Encrypter) error {
+		if ttl < 0 {
+			return fmt.Errorf("negative miss ttl: %s", ttl)
+		}
+		e.missTTL = ttl
+		return nil
+	}
+}
+
+// Encrypt implements cookie.Encrypter. The ciphertext is the data key ID,
+// a nonce, and the AES-GCM sealed plaintext.
+func (e *Encrypter) Encrypt(plaintext, associatedData []byte) ([]byte, error) {
+	key, err := e.dataKey()
+	if err != nil {
+		return nil, err
+	}
+	id, err := hex.DecodeString(key.id)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, key.aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, fmt.Errorf("unable to read random bytes into nonce: %w", err)
+	}
+	out := append(id, nonce...)
+	return key.aead.Seal(out, nonce, plaintext, associatedData), nil
+}
+
+// Decrypt implements cookie.Encrypter, unwrapping the data key on first use.
+func (e *Encrypter) Decrypt(ciphertext, associatedData []byte) ([]byte, error) {
+	return e.DecryptContext(context.Background(), ciphertext, associatedData)
+}
+
+// DecryptContext implements cookie.ContextDecrypter, unwrapping the data key
+// on first use until ctx is done.
+func (e *Encrypter) DecryptContext(ctx context.Context, ciphertext, associatedData []byte) ([]byte, error) {
+	if len(ciphertext) < keyIDLength {
+		return nil, errors.New("ciphertext too short")
+	}
+	aead, err := e.lookup(ctx, hex.EncodeToString(ciphertext[:keyIDLength]))
+	if err != nil {
+		return nil, err
+	}
+	ciphertext = ciphertext[keyIDLength:]
+	if len(ciphertext) < aead.NonceSize() {
+		return nil, errors.New("ciphertext too short")
+	}
+	nonce, sealed := ciphertext[:aead.NonceSize()], ciphertext[aead.NonceSize():]
+	return aead.Open(nil, nonce, sealed, associatedData)
+}
+
+// dataKey returns the current data key, generating one if it is due for rotation.
+func (e *Encrypter) dataKey() (*dataKey, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.current != nil && time.Since(e.current.created) < e.rotation {
+		return e.current, nil
+	}
+
+	key := make([]byte, dataKeyLength)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return nil, fmt.Errorf("unable to generate data key: %w", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), e.timeout)
+	defer cancel()
+	wrapped, err := e.wrapper.Wrap(ctx, key)
+	if err != nil {
+		return nil, fmt.Errorf("unable to wrap data key: %w", err)
+	}
+	sum := sha256.Sum256(wrapped)
+	id := hex.EncodeToString(sum[:keyIDLength])
+	if err := e.store.Put(ctx, id, wrapped); err != nil {
+		return nil, fmt.Errorf("unable to store data key: %w", err)
+	}
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	e.current = &dataKey{id: id, aead: aead, created: time.Now()}
+	e.keys[id] = aead
+	return e.current, nil
+}
+
+// lookup returns the data key for id, unwrapping it from the store if needed.
+func (e *Encrypter) lookup(ctx context.Context, id string) (cipher.AEAD, error) {
+	now := time.Now()
+	e.mu.Lock()
+	aead, ok := e.keys[id]
+	missed := now.Before(e.misses[id])
+	e.mu.Unlock()
+	if ok {
+		return aead, nil
+	}
+	if missed {
+		return nil, fmt.Errorf("data key %s: %w", id, ErrKeyNotFound)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, e.timeout)
+	defer cancel()
+	wrapped, err := e.store.Get(ctx, id)
+	if errors.Is(err, ErrKeyNotFound) {
+		e.miss(id, now)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("data key %s: %w", id, err)
+	}
+	key, err := e.wrapper.Unwrap(ctx, wrapped)
+	if err != nil {
+		return nil, fmt.Errorf("unable to unwrap data key %s: %w", id, err)
+	}
+	aead, err = newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	e.mu.Lock()
+	e.keys[id] = aead
+	e.mu.Unlock()
+	return aead, nil
+}
+
+// miss remembers that id is not in the store, forgetting expired misses,
+// or all of them, once there are too many.
+func (e *Encrypter) miss(id string, now time.Time) {
+	if e.missTTL == 0 {
+		return
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if len(e.misses) >= maxMisses {
+		for missed, until := range e.misses {
+			if !now.Before(until) {
+				delete(e.misses, missed)
+			}
+		}
+		if len(e.misses) >= maxMisses {
+			clear(e.misses)
+		}
+	}
+	e.misses[id] = now.Add(e.missTTL)
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid data key: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
+
+// MemoryKeyStore is a KeyStore for a single process, or for tests.
+type MemoryKeyStore struct {
+	mu   sync.RWMutex
+	keys map[string][]byte
+}
+
+// NewMemoryKeyStore creates an empty MemoryKeyStore.
+func NewMemoryKeyStore() *MemoryKeyStore {
+	return &MemoryKeyStore{keys: map[string][]byte{}}
+}
+
+// Put implements KeyStore.
+func (s *MemoryKeyStore) Put(_ context.Context, id string, wrapped []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys[id] = append([]byte(nil), wrapped...)
+	return nil
+}
+
+// Get implements KeyStore.
+func (s *MemoryKeyStore) Get(_ context.Context, id string) ([]byte, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	wrapped, ok := s.keys[id]
+	if !ok {
+		return nil, ErrKeyNotFound
+	}
+	return wrapped, nil
+}
@@ -0,0 +1,159 @@
+package kms
+
+import (
+	"bytes"
+	"context"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/grackleclub/cookie/v2"
+	"github.com/grackleclub/cookie/v2/cookietest"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeKMS wraps keys by encrypting them with a master key, counting calls.
+type fakeKMS struct {
+	master        []byte
+	wraps, unwrap atomic.Int32
+}
+
+func (k *fakeKMS) Wrap(_ context.Context, key []byte) ([]byte, error) {
+	k.wraps.Add(1)
+	aead, err := newAEAD(k.master)
+	if err != nil {
+		return nil, err
+	}
+	nonce := bytes.Repeat([]byte{byte(k.wraps.Load())}, aead.NonceSize())
+	return aead.Seal(nonce, nonce, key, nil), nil
+}
+
+func (k *fakeKMS) Unwrap(_ context.Context, wrapped []byte) ([]byte, error) {
+	k.unwrap.Add(1)
+	aead, err := newAEAD(k.master)
+	if err != nil {
+		return nil, err
+	}
+	return aead.Open(nil, wrapped[:aead.NonceSize()], wrapped[aead.NonceSize():], nil)
+}
+
+func TestEncrypter(t *testing.T) {
+	master, err := cookie.NewCookieSecret()
+	require.NoError(t, err)
+	wrapper := &fakeKMS{master: master}
+	store := NewMemoryKeyStore()
+
+	e, err := New(wrapper, store)
+	require.NoError(t, err)
+	m, err := cookie.New(cookie.WithEncrypter(e))
+	require.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	require.NoError(t, m.WriteEncryptedValue(w, "a", "chocolate fudge"))
+	require.NoError(t, m.WriteEncryptedValue(w, "b", "oatmeal raisin"))
+	require.Equal(t, int32(1), wrapper.wraps.Load())
+
+	value, err := m.ReadEncryptedValue(cookietest.RequestFrom(t, w), "a")
+	require.NoError(t, err)
+	require.Equal(t, "chocolate fudge", value)
+	require.Equal(t, int32(0), wrapper.unwrap.Load())
+
+	// another server unwraps the data key from the shared store once
+	other, err := New(wrapper, store)
+	require.NoError(t, err)
+	otherManager, err := cookie.New(cookie.WithEncrypter(other))
+	require.NoError(t, err)
+	for _, name := range []string{"a", "b"} {
+		_, err := otherManager.ReadEncryptedValue(cookietest.RequestFrom(t, w), name)
+		require.NoError(t, err)
+	}
+	require.Equal(t, int32(1), wrapper.unwrap.Load())
+
+	// an unshared store cannot find the key
+	isolated, err := New(wrapper, NewMemoryKeyStore())
+	require.NoError(t, err)
+	_, err = isolated.Decrypt([]byte("0123456789abcdef"), nil)
+	require.ErrorIs(t, err, ErrKeyNotFound)
+}
+
+// countingStore counts Get calls, failing them once its context is done.
+type countingStore struct {
+	*MemoryKeyStore
+	gets atomic.Int32
+}
+
+func (s *countingStore) Get(ctx context.Context, id string) ([]byte, error) {
+	s.gets.Add(1)
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return s.MemoryKeyStore.Get(ctx, id)
+}
+
+func TestLookup(t *testing.T) {
+	master, err := cookie.NewCookieSecret()
+	require.NoError(t, err)
+	wrapper := &fakeKMS{master: master}
+	store := &countingStore{MemoryKeyStore: NewMemoryKeyStore()}
+	e, err := New(wrapper, store, WithMissTTL(time.Hour))
+	require.NoError(t, err)
+
+	t.Run("misses cached", func(t *testing.T) {
+		forged := []byte("0123456789abcdef")
+		for range 3 {
+			_, err := e.Decrypt(forged, nil)
+			require.ErrorIs(t, err, ErrKeyNotFound)
+		}
+		require.Equal(t, int32(1), store.gets.Load())
+	})
+
+	t.Run("request context", func(t *testing.T) {
+		writer, err := New(wrapper, store.MemoryKeyStore)
+		require.NoError(t, err)
+		m, err := cookie.New(cookie.WithEncrypter(writer))
+		require.NoError(t, err)
+		w := httptest.NewRecorder()
+		require.NoError(t, m.WriteEncryptedValue(w, "a", "chocolate fudge"))
+
+		reader, err := cookie.New(cookie.WithEncrypter(e))
+		require.NoError(t, err)
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, err = reader.ReadEncryptedValue(cookietest.RequestFrom(t, w).WithContext(ctx), "a")
+		require.ErrorIs(t, err, context.Canceled)
+
+		// a canceled read is not a miss
+		value, err := reader.ReadEncryptedValue(cookietest.RequestFrom(t, w), "a")
+		require.NoError(t, err)
+		require.Equal(t, "chocolate fudge", value)
+	})
+
+	_, err = New(wrapper, store, WithMissTTL(-time.Second))
+	require.ErrorIs(t, err, cookie.ErrInitiation)
+}
+
+func TestRotation(t *testing.T) {
+	master, err := cookie.NewCookieSecret()
+	require.NoError(t, err)
+	wrapper := &fakeKMS{master: master}
+	e, err := New(wrapper, NewMemoryKeyStore(), WithRotation(time.Millisecond))
+	require.NoError(t, err)
+
+	first, err := e.Encrypt([]byte("a"), []byte("ad"))
+	require.NoError(t, err)
+	time.Sleep(2 * time.Millisecond)
+	second, err := e.Encrypt([]byte("b"), []byte("ad"))
+	require.NoError(t, err)
+	require.Equal(t, int32(2), wrapper.wraps.Load())
+	require.NotEqual(t, first[:keyIDLength], second[:keyIDLength])
+
+	plaintext, err := e.Decrypt(first, []byte("ad"))
+	require.NoError(t, err)
+	require.Equal(t, "a", string(plaintext))
+	_, err = e.Decrypt(first, []byte("other"))
+	require.Error(t, err)
+
+	_, err = New(wrapper, nil)
+	require.ErrorIs(t, err, cookie.ErrInitiation)
+}
@@ -0,0 +1,62 @@
+package cookie
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+)
+
+// WithLogger logs security-relevant read failures to logger, such as signature
+// mismatches, failed decryption, unknown key IDs, replays, revoked and expired
+// values, with the cookie name, mode, reason, client address, and the ID of
+// the key the value was issued under, as returned by KeyID. Missing
+// cookies are not logged. The failures are still returned as errors.
+func WithLogger(logger *slog.Logger) Option {
+	return func(m *Manager) error {
+		m.logger = logger
+		return nil
+	}
+}
+
+// readFailures maps read errors to the reason and level they are logged with, most specific first.
+var readFailures = []struct {
+	err    error
+	reason string
+	level  slog.Level
+}{
+	{ErrSignatureMismatch, "signature mismatch", slog.LevelWarn},
+	{ErrDecryptFailed, "decryption failed", slog.LevelWarn},
+	{ErrUnknownKey, "unknown key id", slog.LevelWarn},
+	{ErrReplayed, "replayed", slog.LevelWarn},
+	{ErrRevoked, "revoked", slog.LevelWarn},
+	{ErrUnsupportedVersion, "unsupported version", slog.LevelWarn},
+	{ErrTooShort, "malformed", slog.LevelWarn},
+	{ErrExpired, "expired", slog.LevelInfo},
+	{ErrNotYetValid, "not yet valid", slog.LevelInfo},
+}
+
+// logRead logs err from reading the named cookie, if it is a failure worth logging,
+// along with the ID of the key the value claims to be issued under, if any.
+func (m *Manager) logRead(r *http.Request, name string, mode Mode, err error) {
+	if m.logger == nil || err == nil {
+		return
+	}
+	for _, failure := range readFailures {
+		if errors.Is(err, failure.err) {
+			attrs := []slog.Attr{
+				slog.String("cookie", m.prefix+name),
+				slog.String("mode", mode.String()),
+				slog.String("reason", failure.reason),
+				slog.String("remote_addr", r.RemoteAddr),
+				slog.String("error", err.Error()),
+			}
+			if value, err := m.read(r, name); err == nil && isEnvelope(value) {
+				if h, _, _, err := parseHeader(value); err == nil && len(h.keyID) > 0 {
+					attrs = append(attrs, slog.String("key_id", m.keyName(h.keyID)))
+				}
+			}
+			m.logger.LogAttrs(r.Context(), failure.level, "cookie rejected", attrs...)
+			return
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package cookie
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+	m := newTestManager(t, WithLogger(logger))
+
+	w := httptest.NewRecorder()
+	require.NoError(t, m.WriteSigned(w, "a", "chocolate fudge"))
+	raw, err := Read(requestWith(w), "a")
+	require.NoError(t, err)
+	tampered := httptest.NewRecorder()
+	require.NoError(t, Write(tampered, http.Cookie{Name: "a", Value: raw[:len(raw)-1] + "!"}))
+
+	r := requestWith(tampered)
+	r.RemoteAddr = "192.0.2.1:1234"
+	_, err = m.ReadSigned(r, "a")
+	require.ErrorIs(t, err, ErrSignatureMismatch)
+	_, err = m.AppendSigned(nil, r, "a")
+	require.ErrorIs(t, err, ErrSignatureMismatch)
+
+	// missing cookies and successful reads are not logged
+	_, err = m.ReadSigned(httptest.NewRequest("GET", "/", nil), "missing")
+	require.ErrorIs(t, err, ErrNotFound)
+	_, err = m.ReadSigned(requestWith(w), "a")
+	require.NoError(t, err)
+
+	lines := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+	require.Len(t, lines, 2)
+	for _, line := range lines {
+		var entry map[string]any
+		require.NoError(t, json.Unmarshal(line, &entry))
+		require.Equal(t, "WARN", entry["level"])
+		require.Equal(t, "cookie rejected", entry["msg"])
+		require.Equal(t, "a", entry["cookie"])
+		require.Equal(t, "signed", entry["mode"])
+		require.Equal(t, "signature mismatch", entry["reason"])
+		require.Equal(t, "192.0.2.1:1234", entry["remote_addr"])
+	}
+}
+
+func TestLoggerKeyID(t *testing.T) {
+	oldKey, err := NewCookieSecret()
+	require.NoError(t, err)
+	old, err := New(WithSecret(oldKey))
+	require.NoError(t, err)
+	w := httptest.NewRecorder()
+	require.NoError(t, old.WriteEncryptedValue(w, "a", "chocolate fudge"))
+
+	var buf bytes.Buffer
+	m := newTestManager(t, WithLogger(slog.New(slog.NewJSONHandler(&buf, nil))))
+	_, err = m.ReadEncryptedValue(requestWith(w), "a")
+	require.ErrorIs(t, err, ErrUnknownKey)
+	require.ErrorContains(t, err, KeyID(oldKey))
+
+	var entry map[string]any
+	require.NoError(t, json.Unmarshal(bytes.TrimSpace(buf.Bytes()), &entry))
+	require.Equal(t, "unknown key id", entry["reason"])
+	require.Equal(t, KeyID(oldKey), entry["key_id"])
+	require.Len(t, KeyID(oldKey), 8)
+
+	buf.Reset()
+	ring, err := NewKeyRing(RingKey{ID: "2024-05", Secret: oldKey})
+	require.NoError(t, err)
+	m = newTestManager(t, WithKeyRing(ring), WithLogger(slog.New(slog.NewJSONHandler(&buf, nil))))
+	w = httptest.NewRecorder()
+	require.NoError(t, m.WriteSigned(w, "a", "x"))
+	require.NoError(t, ring.Add("2024-06", make([]byte, 32)))
+	require.NoError(t, ring.Remove("2024-05"))
+	_, err = m.ReadSigned(requestWith(w), "a")
+	require.ErrorIs(t, err, ErrUnknownKey)
+	require.Contains(t, buf.String(), `"key_id":"2024-05"`)
+}
@@ -0,0 +1,295 @@
+package cookie
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/netip"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// Manager holds a secret key and default cookie attributes,
+// so handlers can write and read cookies by name and value alone.
+type Manager struct {
+	secretKeys [][]byte     // newest first; only the newest is used to write
+	source     SecretSource // replaces secretKeys when set
+	derive     bool         // secrets are master secrets for DeriveKey
+	defaults   http.Cookie  // attributes applied to every written cookie
+	maxSize    int
+	prefix     string // prepended to every cookie name
+	chunkLimit int    // largest value split across cookies; zero disables chunking
+	codec      Codec
+	cache      *keyCache       // shared with copies made by With
+	cipher     Cipher          // zero means AESGCM
+	hash       Hash            // zero means SHA256
+	managed    []managedCookie // read by Middleware on every request
+	refresh    float64         // fraction of Max-Age in which Middleware re-issues managed cookies
+
+	signedMaxAge  time.Duration // oldest signed value accepted; zero disables
+	replay        ReplayStore   // records one-time signed values already read
+	replayTTL     time.Duration // lifetime of one-time values without an explicit expiry
+	oneTime       ReplayStore   // records values written by WriteOneTime already read
+	revocation    RevocationStore
+	revocationTTL time.Duration  // how long revocation records must be kept
+	binding       Binding        // client attributes covered by WriteSignedBound
+	proxies       []netip.Prefix // whose X-Forwarded-Proto is trusted
+	policy        Policy         // enforced on every write
+	insecureDev   bool           // PolicyDev acknowledged by WithInsecureDevelopment
+	clockSkew     time.Duration  // tolerance for ReadClaims time checks
+	context       string         // associated data for every encrypted value
+	dataKeys      []string       // cookies encrypted under per-value data keys; empty for all, nil for none
+	signer        Signer         // replaces HMAC signing when set
+	encrypter     Encrypter      // replaces the Cipher when set
+	logger        *slog.Logger   // receives security-relevant read failures
+	clock         Clock          // replaces time.Now when set
+	shareClock    bool           // clock or a store changed since stores were given the clock
+	rand          io.Reader      // replaces crypto/rand when set
+
+	compression       bool
+	compressThreshold int // smallest value worth compressing
+}
+
+// Option configures a Manager.
+type Option func(*Manager) error
+
+// New creates a Manager. Without options, cookies are written with
+// Path=/, Secure, HttpOnly, and SameSite=Lax, and are limited to 4096 bytes.
+func New(opts ...Option) (*Manager, error) {
+	m := &Manager{
+		defaults: http.Cookie{
+			Path:     "/",
+			Secure:   true,
+			HttpOnly: true,
+			SameSite: http.SameSiteLaxMode,
+		},
+		maxSize: maxCookieSize,
+		codec:   JSON,
+		cache:   newKeyCache(),
+	}
+	for _, opt := range opts {
+		if err := opt(m); err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInitiation, err)
+		}
+	}
+	if err := m.checkOptions(); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInitiation, err)
+	}
+	m.setStoreClocks()
+	return m, nil
+}
+
+// With returns a copy of the Manager with additional options applied,
+// leaving the original unchanged.
+func (m *Manager) With(opts ...Option) (*Manager, error) {
+	clone := *m
+	clone.managed = slices.Clone(m.managed)
+	for _, opt := range opts {
+		if err := opt(&clone); err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInitiation, err)
+		}
+	}
+	if err := clone.checkOptions(); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInitiation, err)
+	}
+	clone.setStoreClocks()
+	return &clone, nil
+}
+
+// checkOptions fails for combinations of options that no single option can check.
+func (m *Manager) checkOptions() error {
+	if m.policy == PolicyDev && !m.insecureDev {
+		return errors.New("dev policy requires WithInsecureDevelopment")
+	}
+	return nil
+}
+
+// WithSecret sets the key used for signing and encryption.
+func WithSecret(secretKey []byte) Option {
+	return WithSecrets(secretKey)
+}
+
+// WithSecrets sets the keys used for signing and encryption, newest first.
+// Cookies are always written with the newest key, and read with each key
+// in turn, so cookies issued under a retired key remain valid until it is removed.
+func WithSecrets(secretKeys ...[]byte) Option {
+	return func(m *Manager) error {
+		if len(secretKeys) == 0 {
+			return ErrSecretMissing
+		}
+		for i, secretKey := range secretKeys {
+			if len(secretKey) == 0 {
+				return fmt.Errorf("secret %d: %w", i, ErrSecretMissing)
+			}
+		}
+		m.secretKeys = secretKeys
+		m.source = nil
+		m.derive = false
+		return nil
+	}
+}
+
+// WithAssociatedData binds every encrypted value to context, such as an
+// application or tenant name, in addition to its cookie name. Values
+// encrypted under one context cannot be decrypted under another.
+func WithAssociatedData(context string) Option {
+	return func(m *Manager) error {
+		m.context = context
+		return nil
+	}
+}
+
+// WithPath sets the default Path attribute.
+func WithPath(path string) Option {
+	return func(m *Manager) error {
+		m.defaults.Path = path
+		return nil
+	}
+}
+
+// WithDomain sets the default Domain attribute.
+func WithDomain(domain string) Option {
+	return func(m *Manager) error {
+		m.defaults.Domain = domain
+		return nil
+	}
+}
+
+// WithSecure sets the default Secure attribute.
+func WithSecure(secure bool) Option {
+	return func(m *Manager) error {
+		m.defaults.Secure = secure
+		return nil
+	}
+}
+
+// WithHttpOnly sets the default HttpOnly attribute.
+func WithHttpOnly(httpOnly bool) Option {
+	return func(m *Manager) error {
+		m.defaults.HttpOnly = httpOnly
+		return nil
+	}
+}
+
+// WithSameSite sets the default SameSite attribute.
+func WithSameSite(sameSite http.SameSite) Option {
+	return func(m *Manager) error {
+		m.defaults.SameSite = sameSite
+		return nil
+	}
+}
+
+// WithPartitioned sets the default Partitioned attribute,
+// storing cookies separately per top-level site for embedded contexts.
+func WithPartitioned(partitioned bool) Option {
+	return func(m *Manager) error {
+		m.defaults.Partitioned = partitioned
+		return nil
+	}
+}
+
+// WithMaxAge sets the default Max-Age attribute, rounded down to the second.
+func WithMaxAge(maxAge time.Duration) Option {
+	return func(m *Manager) error {
+		if maxAge < 0 {
+			return fmt.Errorf("negative max age: %s", maxAge)
+		}
+		m.defaults.MaxAge = int(maxAge.Seconds())
+		return nil
+	}
+}
+
+// WithMaxSize sets the largest serialized cookie the Manager will write,
+// in place of the 4096-byte default. See EstimateSize.
+func WithMaxSize(size int) Option {
+	return func(m *Manager) error {
+		if size <= 0 {
+			return fmt.Errorf("invalid max size: %d", size)
+		}
+		m.maxSize = size
+		return nil
+	}
+}
+
+// Cookie returns a cookie with the given name and value
+// and the Manager's default attributes and name prefix.
+func (m *Manager) Cookie(name, value string) http.Cookie {
+	cookie := m.defaults
+	cookie.Name = m.prefix + name
+	cookie.Value = value
+	return cookie
+}
+
+// NewCookie returns a cookie with the given name and value and the attributes
+// set by opts, such as WithMaxAge, WithSameSite, WithHttpOnly, and WithSecure.
+// Unset attributes take the defaults of New, so every cookie starts from the
+// same Path=/, Secure, HttpOnly, SameSite=Lax baseline. The value is not encoded.
+func NewCookie(name, value string, opts ...Option) (http.Cookie, error) {
+	m, err := New(opts...)
+	if err != nil {
+		return http.Cookie{}, err
+	}
+	cookie := m.Cookie(name, value)
+	if err := validateName(cookie.Name); err != nil {
+		return http.Cookie{}, err
+	}
+	if err := validatePrefix(cookie); err != nil {
+		return http.Cookie{}, err
+	}
+	return cookie, nil
+}
+
+// Write writes a base64 encoded cookie with the Manager's defaults.
+func (m *Manager) Write(w http.ResponseWriter, name, value string) error {
+	return m.writeMode(w, name, value, Plain)
+}
+
+// Read reads a base64 encoded cookie, returning the decoded string.
+func (m *Manager) Read(r *http.Request, name string) (string, error) {
+	return m.readMode(r, name, Plain)
+}
+
+// WriteSigned writes a cookie with an HMAC signature using the Manager's hash and newest secret.
+func (m *Manager) WriteSigned(w http.ResponseWriter, name, value string) error {
+	return m.writeMode(w, name, value, Signed)
+}
+
+// ReadSigned reads a cookie and verifies its signature using the Manager's secrets.
+func (m *Manager) ReadSigned(r *http.Request, name string) (string, error) {
+	return m.readMode(r, name, Signed)
+}
+
+// WriteEncryptedValue writes a cookie encrypted with the Manager's cipher and newest secret.
+func (m *Manager) WriteEncryptedValue(w http.ResponseWriter, name, value string) error {
+	return m.writeMode(w, name, value, Encrypted)
+}
+
+// ReadEncryptedValue reads and decrypts a cookie written by WriteEncryptedValue.
+func (m *Manager) ReadEncryptedValue(r *http.Request, name string) (string, error) {
+	return m.readMode(r, name, Encrypted)
+}
+
+// WriteEncrypted writes an encrypted 'id:value' cookie using the Manager's newest secret.
+func (m *Manager) WriteEncrypted(w http.ResponseWriter, userID int, name, value string) error {
+	return m.WriteEncryptedValue(w, name, fmt.Sprintf("%d:%s", userID, value))
+}
+
+// ReadEncrypted reads and decrypts a cookie written by WriteEncrypted.
+// With a revocation store, values issued before RevokeAll for the user fail with ErrRevoked.
+func (m *Manager) ReadEncrypted(r *http.Request, name string) (int, string, error) {
+	o, err := m.readOpened(r, name, Encrypted)
+	if err != nil {
+		return 0, "", err
+	}
+	userID, value, err := splitUserID(o.value)
+	if err != nil {
+		return 0, "", err
+	}
+	if err := m.CheckRevoked(r.Context(), strconv.Itoa(userID), o.issued); err != nil {
+		return 0, "", err
+	}
+	return userID, value, nil
+}
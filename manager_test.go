@@ -0,0 +1,122 @@
+package cookie
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newTestManager(t *testing.T, opts ...Option) *Manager {
+	t.Helper()
+	secretKey, err := NewCookieSecret()
+	require.NoError(t, err)
+	m, err := New(append([]Option{WithSecret(secretKey)}, opts...)...)
+	require.NoError(t, err)
+	return m
+}
+
+func TestManager(t *testing.T) {
+	m := newTestManager(t,
+		WithDomain("example.com"),
+		WithSameSite(http.SameSiteStrictMode),
+		WithMaxAge(time.Hour),
+	)
+
+	w := httptest.NewRecorder()
+	require.NoError(t, m.WriteSigned(w, "signed", "chocolate fudge"))
+	require.NoError(t, m.WriteEncrypted(w, testUserID, "encrypted", "oatmeal raisin"))
+	require.NoError(t, m.WriteEncryptedValue(w, "value", "snickerdoodle"))
+
+	setCookie := w.Result().Cookies()[0]
+	require.Equal(t, "/", setCookie.Path)
+	require.Equal(t, "example.com", setCookie.Domain)
+	require.Equal(t, 3600, setCookie.MaxAge)
+	require.True(t, setCookie.Secure)
+	require.True(t, setCookie.HttpOnly)
+	require.Equal(t, http.SameSiteStrictMode, setCookie.SameSite)
+
+	r := requestWith(w)
+	value, err := m.ReadSigned(r, "signed")
+	require.NoError(t, err)
+	require.Equal(t, "chocolate fudge", value)
+
+	id, value, err := m.ReadEncrypted(r, "encrypted")
+	require.NoError(t, err)
+	require.Equal(t, testUserID, id)
+	require.Equal(t, "oatmeal raisin", value)
+
+	value, err = m.ReadEncryptedValue(r, "value")
+	require.NoError(t, err)
+	require.Equal(t, "snickerdoodle", value)
+}
+
+func TestManagerOptions(t *testing.T) {
+	_, err := New(WithSecret(nil))
+	require.ErrorIs(t, err, ErrInitiation)
+	require.ErrorIs(t, err, ErrSecretMissing)
+
+	m, err := New()
+	require.NoError(t, err)
+	require.ErrorIs(t, m.WriteSigned(httptest.NewRecorder(), "a", "b"), ErrSecretMissing)
+
+	m, err = New(WithMaxSize(64))
+	require.NoError(t, err)
+	err = m.Write(httptest.NewRecorder(), "big", strings.Repeat("x", 64))
+	require.ErrorIs(t, err, ErrCookie)
+}
+
+func TestManagerPartitioned(t *testing.T) {
+	m := newTestManager(t, WithPartitioned(true), WithSameSite(http.SameSiteNoneMode))
+
+	w := httptest.NewRecorder()
+	require.NoError(t, m.Write(w, "embedded", "chocolate fudge"))
+	require.Contains(t, w.Header().Get("Set-Cookie"), "; Partitioned")
+
+	m = newTestManager(t, WithPartitioned(true), WithSecure(false))
+	require.ErrorIs(t, m.Write(httptest.NewRecorder(), "embedded", "x"), ErrCookie)
+}
+
+func TestManagerSameSiteNone(t *testing.T) {
+	m := newTestManager(t, WithSameSite(http.SameSiteNoneMode), WithSecure(false))
+	w := httptest.NewRecorder()
+	err := m.WriteSigned(w, "embedded", "x")
+	require.ErrorIs(t, err, ErrCookie)
+	require.Contains(t, err.Error(), "SameSite=None")
+	require.Empty(t, w.Header().Get("Set-Cookie"))
+
+	err = Write(httptest.NewRecorder(), http.Cookie{Name: "embedded", Value: "x", SameSite: http.SameSiteNoneMode})
+	require.ErrorIs(t, err, ErrCookie)
+}
+
+func TestNewCookie(t *testing.T) {
+	cookie, err := NewCookie("theme", "dark",
+		WithMaxAge(time.Hour),
+		WithSameSite(http.SameSiteStrictMode),
+		WithHttpOnly(false),
+	)
+	require.NoError(t, err)
+	require.Equal(t, http.Cookie{
+		Name:     "theme",
+		Value:    "dark",
+		Path:     "/",
+		MaxAge:   3600,
+		Secure:   true,
+		HttpOnly: false,
+		SameSite: http.SameSiteStrictMode,
+	}, cookie)
+
+	cookie, err = NewCookie("id", "x", WithPrefix(HostPrefix))
+	require.NoError(t, err)
+	require.Equal(t, "__Host-id", cookie.Name)
+
+	_, err = NewCookie("id", "x", WithPrefix(HostPrefix), WithSecure(false))
+	require.ErrorIs(t, err, ErrInvalidPrefix)
+	_, err = NewCookie("bad name", "x")
+	require.ErrorIs(t, err, ErrInvalidName)
+	_, err = NewCookie("a", "x", WithMaxAge(-time.Second))
+	require.ErrorIs(t, err, ErrInitiation)
+}
@@ -0,0 +1,216 @@
+package cookie
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Marshal writes each tagged field of the struct v as its own cookie, so
+// preference-style state is declared once on a struct:
+//
+//	type Prefs struct {
+//		Theme    string        `cookie:"theme,maxage=720h,signed"`
+//		Sidebar  bool          `cookie:"sidebar"`
+//		Timezone string        `cookie:"tz,encrypted,omitempty"`
+//		Skipped  time.Duration `cookie:"-"`
+//	}
+//
+// A tag names the cookie, defaulting to the field name, followed by options:
+// plain (the default), signed, or encrypted for the Mode; maxage=<duration>
+// to override the Manager's Max-Age; and omitempty to skip zero values.
+// Fields without a tag are ignored. Strings, bools, numbers, time.Duration,
+// and encoding.TextMarshaler values are written as text; anything else is
+// encoded with the Manager's Codec. Every field is attempted; the returned
+// error joins the failures, each naming its cookie.
+func (m *Manager) Marshal(w http.ResponseWriter, v any) error {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return fmt.Errorf("%w: cannot marshal %T, want a struct", ErrCookie, v)
+	}
+	fields, err := cookieFields(rv.Type())
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, f := range fields {
+		fv := rv.Field(f.index)
+		if f.omitEmpty && fv.IsZero() {
+			continue
+		}
+		value, err := m.formatField(fv)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%w: unable to encode '%s': %w", ErrCookie, f.name, err))
+			continue
+		}
+		cookie := m.Cookie(f.name, value)
+		if f.maxAge != nil {
+			cookie.MaxAge = *f.maxAge
+		}
+		if err := m.writeSealed(w, cookie, f.mode); err != nil {
+			errs = append(errs, fmt.Errorf("'%s': %w", f.name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Unmarshal reads the cookies written by Marshal into the tagged fields of
+// the struct v points to. Fields whose cookie is absent are left unchanged.
+// Every field is attempted; the returned error joins the failures, each
+// naming its cookie.
+func (m *Manager) Unmarshal(r *http.Request, v any) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("%w: cannot unmarshal into %T, want a pointer to a struct", ErrCookie, v)
+	}
+	rv = rv.Elem()
+	fields, err := cookieFields(rv.Type())
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, f := range fields {
+		value, err := m.readMode(r, f.name, f.mode)
+		if errors.Is(err, ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("'%s': %w", f.name, err))
+			continue
+		}
+		if err := m.parseField(value, rv.Field(f.index)); err != nil {
+			errs = append(errs, fmt.Errorf("%w: unable to decode '%s': %w", ErrCookie, f.name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// cookieField is a struct field parsed from its cookie tag.
+type cookieField struct {
+	index     int
+	name      string
+	mode      Mode
+	maxAge    *int // seconds; nil keeps the Manager's default
+	omitEmpty bool
+}
+
+// cookieFields parses the cookie tags of the exported fields of t.
+func cookieFields(t reflect.Type) ([]cookieField, error) {
+	var fields []cookieField
+	for i := range t.NumField() {
+		sf := t.Field(i)
+		tag, ok := sf.Tag.Lookup("cookie")
+		if !ok || tag == "-" || !sf.IsExported() {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		f := cookieField{index: i, name: name}
+		if f.name == "" {
+			f.name = sf.Name
+		}
+		for _, opt := range strings.Split(opts, ",") {
+			key, value, _ := strings.Cut(opt, "=")
+			switch key {
+			case "":
+			case "plain":
+				f.mode = Plain
+			case "signed":
+				f.mode = Signed
+			case "encrypted":
+				f.mode = Encrypted
+			case "omitempty":
+				f.omitEmpty = true
+			case "maxage":
+				d, err := time.ParseDuration(value)
+				if err != nil || d < 0 {
+					return nil, fmt.Errorf("%w: field %s: invalid maxage '%s'", ErrCookie, sf.Name, value)
+				}
+				seconds := int(d.Seconds())
+				f.maxAge = &seconds
+			default:
+				return nil, fmt.Errorf("%w: field %s: unknown cookie tag option '%s'", ErrCookie, sf.Name, key)
+			}
+		}
+		fields = append(fields, f)
+	}
+	return fields, nil
+}
+
+var durationType = reflect.TypeFor[time.Duration]()
+
+// formatField returns the cookie value of a field.
+func (m *Manager) formatField(v reflect.Value) (string, error) {
+	if tm, ok := v.Interface().(encoding.TextMarshaler); ok {
+		text, err := tm.MarshalText()
+		return string(text), err
+	}
+	if v.Type() == durationType {
+		return time.Duration(v.Int()).String(), nil
+	}
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'g', -1, v.Type().Bits()), nil
+	}
+	data, err := m.codec.Encode(v.Interface())
+	return string(data), err
+}
+
+// parseField sets a field from its cookie value, leaving it unchanged on error.
+func (m *Manager) parseField(value string, v reflect.Value) error {
+	if tu, ok := v.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		return tu.UnmarshalText([]byte(value))
+	}
+	if v.Type() == durationType {
+		d, err := time.ParseDuration(value)
+		if err == nil {
+			v.SetInt(int64(d))
+		}
+		return err
+	}
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(value)
+		return nil
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err == nil {
+			v.SetBool(b)
+		}
+		return err
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, v.Type().Bits())
+		if err == nil {
+			v.SetInt(n)
+		}
+		return err
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(value, 10, v.Type().Bits())
+		if err == nil {
+			v.SetUint(n)
+		}
+		return err
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(value, v.Type().Bits())
+		if err == nil {
+			v.SetFloat(n)
+		}
+		return err
+	}
+	return m.codec.Decode([]byte(value), v.Addr().Interface())
+}
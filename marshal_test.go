@@ -0,0 +1,72 @@
+package cookie
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type prefs struct {
+	Theme    string        `cookie:"theme,maxage=720h,signed"`
+	Sidebar  bool          `cookie:"sidebar"`
+	Timezone string        `cookie:"tz,encrypted,omitempty"`
+	Size     int           `cookie:",signed"`
+	Timeout  time.Duration `cookie:"timeout"`
+	Seen     time.Time     `cookie:"seen,encrypted"`
+	Recent   []string      `cookie:"recent,signed"`
+	Skipped  string        `cookie:"-"`
+	Untagged string
+}
+
+func TestMarshalUnmarshal(t *testing.T) {
+	m := newTestManager(t, WithMaxAge(time.Hour))
+	in := prefs{
+		Theme:   "dark",
+		Sidebar: true,
+		Size:    14,
+		Timeout: 90 * time.Second,
+		Seen:    time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+		Recent:  []string{"a", "b"},
+		Skipped: "x",
+	}
+	w := httptest.NewRecorder()
+	require.NoError(t, m.Marshal(w, &in))
+
+	maxAges := map[string]int{}
+	for _, c := range w.Result().Cookies() {
+		maxAges[c.Name] = c.MaxAge
+	}
+	require.Equal(t, map[string]int{
+		"theme": 720 * 3600, "sidebar": 3600, "Size": 3600, "timeout": 3600, "seen": 3600, "recent": 3600,
+	}, maxAges)
+
+	out := prefs{Timezone: "UTC", Untagged: "kept"}
+	require.NoError(t, m.Unmarshal(requestWith(w), &out))
+	in.Timezone, in.Skipped, in.Untagged = "UTC", "", "kept"
+	require.Equal(t, in, out)
+}
+
+func TestUnmarshalErrors(t *testing.T) {
+	m := newTestManager(t)
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.AddCookie(&http.Cookie{Name: "theme", Value: "forged"})
+	r.AddCookie(&http.Cookie{Name: "sidebar", Value: "bm90IGEgYm9vbA"}) // "not a bool"
+
+	out := prefs{Sidebar: true}
+	err := m.Unmarshal(r, &out)
+	require.ErrorIs(t, err, ErrCookie)
+	require.ErrorContains(t, err, "'theme'")
+	require.ErrorContains(t, err, "'sidebar'")
+	require.True(t, out.Sidebar)
+
+	require.ErrorIs(t, m.Unmarshal(r, out), ErrCookie)
+	require.ErrorIs(t, m.Marshal(httptest.NewRecorder(), "theme"), ErrCookie)
+
+	var bad struct {
+		Theme string `cookie:"theme,sealed"`
+	}
+	require.ErrorContains(t, m.Marshal(httptest.NewRecorder(), bad), "unknown cookie tag option 'sealed'")
+}
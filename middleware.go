@@ -0,0 +1,105 @@
+package cookie
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// managedCookie is a cookie read by Middleware on every request.
+type managedCookie struct {
+	name string
+	mode Mode
+}
+
+// loaded is the outcome of reading one managed cookie.
+type loaded struct {
+	value string
+	err   error
+}
+
+type loadedKey struct{}
+
+// WithManaged registers a cookie for Middleware to read and verify
+// on every request, according to mode.
+func WithManaged(name string, mode Mode) Option {
+	return func(m *Manager) error {
+		if mode < Plain || mode > Encrypted {
+			return fmt.Errorf("unsupported mode for '%s': %s", name, mode)
+		}
+		m.managed = append(m.managed, managedCookie{name: name, mode: mode})
+		return nil
+	}
+}
+
+// WithRefresh makes Middleware re-issue a managed signed or encrypted cookie
+// once it enters the final window of its Max-Age, such as 0.2 for the last 20%,
+// so cookies of active clients never lapse while those of idle clients still expire.
+// Values are stamped with their creation time to tell their age; values with an
+// explicit expiry, one-time values, and cookies without a default Max-Age are left alone.
+func WithRefresh(window float64) Option {
+	return func(m *Manager) error {
+		if window <= 0 || window >= 1 {
+			return fmt.Errorf("refresh window not between 0 and 1: %v", window)
+		}
+		m.refresh = window
+		return nil
+	}
+}
+
+// Middleware reads and verifies the Manager's managed cookies once per request,
+// storing the results in the request context for FromContext.
+// With WithRefresh, cookies nearing expiry are re-issued on the response.
+func Middleware(m *Manager) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			results := make(map[string]loaded, len(m.managed))
+			for _, c := range m.managed {
+				o, err := m.readOpened(r, c.name, c.mode)
+				results[c.name] = loaded{value: o.value, err: err}
+				if err == nil && m.needsRefresh(o) {
+					// a failed refresh leaves the current cookie in place until it expires
+					_ = m.reissue(w, r, c, o)
+				}
+			}
+			ctx := context.WithValue(r.Context(), loadedKey{}, results)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// FromContext returns the decoded value of a managed cookie loaded by Middleware,
+// or the error encountered reading it.
+func FromContext(ctx context.Context, name string) (string, error) {
+	results, _ := ctx.Value(loadedKey{}).(map[string]loaded)
+	result, ok := results[name]
+	if !ok {
+		return "", fmt.Errorf("%w: '%s' not loaded by middleware", ErrCookie, name)
+	}
+	return result.value, result.err
+}
+
+// needsRefresh reports whether a value read by Middleware is in the refresh window.
+func (m *Manager) needsRefresh(o opened) bool {
+	if m.refresh == 0 || m.defaults.MaxAge <= 0 || o.issued.IsZero() || o.flags&(flagExpires|flagOnce) != 0 {
+		return false
+	}
+	maxAge := time.Duration(m.defaults.MaxAge) * time.Second
+	return m.Now().Sub(o.issued) >= maxAge-time.Duration(float64(maxAge)*m.refresh)
+}
+
+// reissue seals a managed value again with a new creation stamp, keeping any client binding.
+func (m *Manager) reissue(w http.ResponseWriter, r *http.Request, c managedCookie, o opened) error {
+	var opts sealOptions
+	if o.flags&flagBound != 0 {
+		opts.fingerprint = m.binding.fingerprint(r)
+	}
+	cookie := m.Cookie(c.name, "")
+	sealed, err := m.seal(c.mode, cookie.Name, o.value, opts)
+	if err != nil {
+		return err
+	}
+	cookie.Value = sealed
+	return m.write(w, cookie)
+}
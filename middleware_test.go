@@ -0,0 +1,94 @@
+package cookie
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMiddleware(t *testing.T) {
+	m := newTestManager(t,
+		WithManaged("theme", Plain),
+		WithManaged("user", Signed),
+		WithManaged("token", Encrypted),
+		WithManaged("absent", Signed),
+	)
+
+	w := httptest.NewRecorder()
+	require.NoError(t, m.Write(w, "theme", "dark"))
+	require.NoError(t, m.WriteSigned(w, "user", "alice"))
+	require.NoError(t, m.WriteEncryptedValue(w, "token", "s3cr3t"))
+
+	var handled bool
+	h := Middleware(m)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handled = true
+		for name, want := range map[string]string{"theme": "dark", "user": "alice", "token": "s3cr3t"} {
+			value, err := FromContext(r.Context(), name)
+			require.NoError(t, err)
+			require.Equal(t, want, value)
+		}
+		_, err := FromContext(r.Context(), "absent")
+		require.ErrorIs(t, err, http.ErrNoCookie)
+		_, err = FromContext(r.Context(), "unmanaged")
+		require.ErrorIs(t, err, ErrCookie)
+	}))
+	h.ServeHTTP(httptest.NewRecorder(), requestWith(w))
+	require.True(t, handled)
+}
+
+func TestMiddlewareRefresh(t *testing.T) {
+	secretKey, err := NewCookieSecret()
+	require.NoError(t, err)
+	opts := []Option{
+		WithSecret(secretKey),
+		WithManaged("user", Signed),
+		WithManaged("token", Encrypted),
+		WithManaged("theme", Plain),
+		WithRefresh(0.5),
+	}
+
+	serve := func(m *Manager, r *http.Request) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		Middleware(m)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			value, err := FromContext(r.Context(), "user")
+			require.NoError(t, err)
+			require.Equal(t, "alice", value)
+		})).ServeHTTP(w, r)
+		return w
+	}
+	write := func(m *Manager) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		require.NoError(t, m.WriteSigned(w, "user", "alice"))
+		require.NoError(t, m.WriteEncryptedValue(w, "token", "s3cr3t"))
+		require.NoError(t, m.Write(w, "theme", "dark"))
+		return w
+	}
+
+	// fresh cookies are left alone
+	fresh, err := New(append(opts, WithMaxAge(time.Hour))...)
+	require.NoError(t, err)
+	require.Empty(t, serve(fresh, requestWith(write(fresh))).Result().Cookies())
+
+	// cookies in the last half of their Max-Age are re-issued
+	m, err := New(append(opts, WithMaxAge(2*time.Second))...)
+	require.NoError(t, err)
+	w := write(m)
+	time.Sleep(1100 * time.Millisecond)
+	refreshed := serve(m, requestWith(w))
+	cookies := refreshed.Result().Cookies()
+	require.Len(t, cookies, 2)
+	for _, c := range cookies {
+		require.Contains(t, []string{"user", "token"}, c.Name)
+		require.Equal(t, 2, c.MaxAge)
+	}
+	r := requestWith(refreshed)
+	value, err := m.ReadEncryptedValue(r, "token")
+	require.NoError(t, err)
+	require.Equal(t, "s3cr3t", value)
+
+	_, err = New(WithRefresh(1))
+	require.ErrorIs(t, err, ErrInitiation)
+}
@@ -0,0 +1,36 @@
+package cookie
+
+import (
+	"errors"
+	"fmt"
+)
+
+var ErrInvalidName = errors.New("invalid cookie name")
+
+// validateName checks that name is an RFC 6265 token: non-empty, and made only
+// of visible ASCII characters other than separators. net/http would otherwise
+// drop such a cookie from the response without an error.
+func validateName(name string) error {
+	if name == "" {
+		return fmt.Errorf("%w: empty name", ErrInvalidName)
+	}
+	for i := range len(name) {
+		if !isTokenChar(name[i]) {
+			return fmt.Errorf("%w: %q has invalid character %q at %d", ErrInvalidName, name, name[i], i)
+		}
+	}
+	return nil
+}
+
+// isTokenChar reports whether c may appear in an RFC 7230 token.
+func isTokenChar(c byte) bool {
+	switch {
+	case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9':
+		return true
+	}
+	switch c {
+	case '!', '#', '$', '%', '&', '\'', '*', '+', '-', '.', '^', '_', '`', '|', '~':
+		return true
+	}
+	return false
+}
@@ -0,0 +1,30 @@
+package cookie
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateName(t *testing.T) {
+	for _, name := range []string{"session", "__Host-id", "a.1", "x!#$%&'*+-^_`|~9"} {
+		require.NoError(t, validateName(name), name)
+	}
+	for _, name := range []string{"", "a b", "a;b", "a=b", "a,b", "a\"b", "a\tb", "a\x00b", "a\x7fb", "(a)", "a/b", "ä"} {
+		require.ErrorIs(t, validateName(name), ErrInvalidName, name)
+	}
+}
+
+func TestWriteInvalidName(t *testing.T) {
+	w := httptest.NewRecorder()
+	err := Write(w, http.Cookie{Name: "bad name", Value: "value"})
+	require.ErrorIs(t, err, ErrInvalidName)
+	require.Empty(t, w.Header().Get("Set-Cookie"))
+
+	m := newTestManager(t)
+	err = m.WriteSigned(w, "bad;name", "value")
+	require.ErrorIs(t, err, ErrInvalidName)
+	require.Empty(t, w.Header().Get("Set-Cookie"))
+}
@@ -0,0 +1,157 @@
+// package oauth keeps the OAuth2 state parameter and PKCE code verifier of a
+// login in a short-lived encrypted cookie, and checks them on the callback,
+// so a callback cannot be completed with a state the client did not start.
+package oauth
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/grackleclub/cookie/v2"
+	"github.com/grackleclub/cookie/v2/internal/token"
+)
+
+const (
+	ChallengeMethod = "S256" // PKCE code challenge method used by Begin
+
+	defaultName    = "oauth"
+	defaultTTL     = 10 * time.Minute
+	stateLength    = 32
+	verifierLength = 32 // encodes to 43 characters, the RFC 7636 minimum
+)
+
+var ErrState = errors.New("oauth state invalid")
+
+// Auth holds the parameters of a login started by Begin.
+type Auth struct {
+	State     string // sent as the state parameter
+	Verifier  string // sent as code_verifier with the token request
+	Challenge string // sent as code_challenge, using ChallengeMethod
+}
+
+// Values returns the state and PKCE parameters to add to the authorization URL.
+func (a Auth) Values() url.Values {
+	return url.Values{
+		"state":                 {a.State},
+		"code_challenge":        {a.Challenge},
+		"code_challenge_method": {ChallengeMethod},
+	}
+}
+
+// stored is the state cookie of a login in progress: the state and PKCE
+// verifier the callback must match, and when the login must complete by.
+type stored struct {
+	State    string `json:"s"`
+	Verifier string `json:"v"`
+	Expires  int64  `json:"e"`
+}
+
+// Flow starts and completes logins.
+type Flow struct {
+	cookies *cookie.Manager
+	name    string
+	ttl     time.Duration
+}
+
+// Option configures a Flow.
+type Option func(*Flow) error
+
+// New creates a Flow. The cookie.Manager must hold a secret,
+// which is used to encrypt the state cookie.
+func New(cookies *cookie.Manager, opts ...Option) (*Flow, error) {
+	if cookies == nil {
+		return nil, fmt.Errorf("%w: cookie manager is required", cookie.ErrInitiation)
+	}
+	f := &Flow{
+		cookies: cookies,
+		name:    defaultName,
+		ttl:     defaultTTL,
+	}
+	for _, opt := range opts {
+		if err := opt(f); err != nil {
+			return nil, fmt.Errorf("%w: %w", cookie.ErrInitiation, err)
+		}
+	}
+	// the callback is a top-level cross-site navigation, so Strict would withhold the cookie
+	cookies, err := cookies.With(cookie.WithMaxAge(f.ttl), cookie.WithSameSite(http.SameSiteLaxMode))
+	if err != nil {
+		return nil, err
+	}
+	f.cookies = cookies
+	return f, nil
+}
+
+// WithName sets the name of the state cookie.
+func WithName(name string) Option {
+	return func(f *Flow) error {
+		if name == "" {
+			return errors.New("empty oauth cookie name")
+		}
+		f.name = name
+		return nil
+	}
+}
+
+// WithTTL sets how long a login may take to complete. Defaults to 10 minutes.
+func WithTTL(ttl time.Duration) Option {
+	return func(f *Flow) error {
+		if ttl < time.Second {
+			return fmt.Errorf("oauth ttl too short: %s", ttl)
+		}
+		f.ttl = ttl
+		return nil
+	}
+}
+
+// Begin generates a state and PKCE verifier and stores them in the state cookie,
+// replacing any login in progress. Redirect the client to the authorization URL
+// with the returned Auth's Values.
+func (f *Flow) Begin(w http.ResponseWriter) (Auth, error) {
+	state, err := token.Random(stateLength)
+	if err != nil {
+		return Auth{}, fmt.Errorf("%w: %w", ErrState, err)
+	}
+	verifier, err := token.Random(verifierLength)
+	if err != nil {
+		return Auth{}, fmt.Errorf("%w: %w", ErrState, err)
+	}
+	payload := stored{State: state, Verifier: verifier, Expires: f.cookies.Now().Add(f.ttl).Unix()}
+	if err := cookie.WriteJSON(f.cookies, w, f.name, payload, cookie.Encrypted); err != nil {
+		return Auth{}, err
+	}
+	return Auth{State: state, Verifier: verifier, Challenge: challenge(verifier)}, nil
+}
+
+// Complete checks the state parameter of the callback request against the state
+// cookie, returning the PKCE verifier for the token request. The cookie is deleted
+// whether or not the check passes, so each state can be used only once.
+func (f *Flow) Complete(w http.ResponseWriter, r *http.Request) (string, error) {
+	payload, err := cookie.ReadJSON[stored](f.cookies, r, f.name, cookie.Encrypted)
+	f.cookies.Delete(w, f.name)
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrState, err)
+	}
+	if !f.cookies.Now().Before(time.Unix(payload.Expires, 0)) {
+		return "", fmt.Errorf("%w: %w", ErrState, cookie.ErrExpired)
+	}
+	state := r.URL.Query().Get("state")
+	if state == "" {
+		return "", fmt.Errorf("%w: callback has no state", ErrState)
+	}
+	if subtle.ConstantTimeCompare([]byte(state), []byte(payload.State)) != 1 {
+		return "", fmt.Errorf("%w: state mismatch", ErrState)
+	}
+	return payload.Verifier, nil
+}
+
+// challenge returns the S256 code challenge for verifier.
+func challenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
@@ -0,0 +1,83 @@
+package oauth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/grackleclub/cookie/v2"
+	"github.com/grackleclub/cookie/v2/cookietest"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestFlow(t *testing.T, opts ...Option) *Flow {
+	t.Helper()
+	secretKey, err := cookie.NewCookieSecret()
+	require.NoError(t, err)
+	cookies, err := cookie.New(cookie.WithSecret(secretKey), cookie.WithSameSite(http.SameSiteStrictMode))
+	require.NoError(t, err)
+	f, err := New(cookies, opts...)
+	require.NoError(t, err)
+	return f
+}
+
+// callback returns a callback request for state carrying the cookies set on w.
+func callback(t *testing.T, w *httptest.ResponseRecorder, state string) *http.Request {
+	return cookietest.NewRequest("/callback?code=abc&state="+state, cookietest.SetCookies(t, w)...)
+}
+
+func TestFlow(t *testing.T) {
+	f := newTestFlow(t, WithName("login"))
+
+	w := httptest.NewRecorder()
+	auth, err := f.Begin(w)
+	require.NoError(t, err)
+	require.Len(t, auth.Verifier, 43)
+	require.NotEqual(t, auth.State, auth.Verifier)
+
+	setCookie := w.Result().Cookies()[0]
+	require.Equal(t, "login", setCookie.Name)
+	require.Equal(t, 600, setCookie.MaxAge)
+	require.Equal(t, http.SameSiteLaxMode, setCookie.SameSite)
+	require.NotContains(t, setCookie.Value, auth.State)
+
+	values := auth.Values()
+	require.Equal(t, auth.State, values.Get("state"))
+	require.Equal(t, ChallengeMethod, values.Get("code_challenge_method"))
+
+	done := httptest.NewRecorder()
+	verifier, err := f.Complete(done, callback(t, w, auth.State))
+	require.NoError(t, err)
+	require.Equal(t, auth.Verifier, verifier)
+	require.Negative(t, done.Result().Cookies()[0].MaxAge)
+}
+
+// TestChallenge checks the example in RFC 7636 appendix B.
+func TestChallenge(t *testing.T) {
+	require.Equal(t,
+		"E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM",
+		challenge("dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"),
+	)
+}
+
+func TestFlowInvalid(t *testing.T) {
+	f := newTestFlow(t)
+	w := httptest.NewRecorder()
+	auth, err := f.Begin(w)
+	require.NoError(t, err)
+
+	for name, r := range map[string]*http.Request{
+		"mismatch":  callback(t, w, "forged"),
+		"no state":  callback(t, w, ""),
+		"no cookie": httptest.NewRequest(http.MethodGet, "/callback?state="+auth.State, nil),
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := f.Complete(httptest.NewRecorder(), r)
+			require.ErrorIs(t, err, ErrState)
+		})
+	}
+
+	// a cookie from another Flow's secret is rejected
+	_, err = newTestFlow(t).Complete(httptest.NewRecorder(), callback(t, w, auth.State))
+	require.ErrorIs(t, err, ErrState)
+}
@@ -0,0 +1,74 @@
+package cookie
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// ErrAlreadyUsed is returned by ReadOneTime for a token that was already read.
+// It is ErrReplayed, so either matches.
+var ErrAlreadyUsed = ErrReplayed
+
+// WithOneTimeStore sets the store in which WriteOneTime tokens are claimed
+// when they are read, without making every signed value one-time use as
+// WithReplayStore does. Without it, the replay store is used.
+func WithOneTimeStore(store ReplayStore) Option {
+	return func(m *Manager) error {
+		if store == nil {
+			return errors.New("one-time store is required")
+		}
+		m.oneTime = store
+		m.shareClock = true
+		return nil
+	}
+}
+
+// WriteOneTime writes value as a token that ReadOneTime accepts only once
+// before expires, for download links, email verification, and confirmations.
+// The mode must be Signed or Encrypted, and the Manager needs a one-time or
+// replay store. The cookie's Max-Age is set to match expires.
+func (m *Manager) WriteOneTime(w http.ResponseWriter, name, value string, expires time.Time, mode Mode) error {
+	if mode == Plain {
+		return fmt.Errorf("%w: one-time values must be signed or encrypted", ErrCookie)
+	}
+	if m.claimStore() == nil {
+		return fmt.Errorf("%w: one-time value written without a one-time store", ErrCookie)
+	}
+	maxAge := int(expires.Sub(m.Now()).Seconds())
+	if maxAge <= 0 {
+		return fmt.Errorf("%w: expiry %s is not in the future", ErrCookie, expires.UTC().Format(time.RFC3339))
+	}
+	cookie := m.Cookie(name, "")
+	sealed, err := m.seal(mode, cookie.Name, value, sealOptions{expires: expires, once: true})
+	if err != nil {
+		return err
+	}
+	cookie.Value = sealed
+	cookie.MaxAge = maxAge
+	return m.write(w, cookie)
+}
+
+// ReadOneTime reads a token written by WriteOneTime with the same mode,
+// claiming it in the store once verified, so every later read fails with
+// ErrAlreadyUsed. Values not written by WriteOneTime are rejected.
+// Delete the cookie once it has been read.
+func (m *Manager) ReadOneTime(r *http.Request, name string, mode Mode) (string, error) {
+	o, err := m.readOpened(r, name, mode)
+	if err != nil {
+		return "", err
+	}
+	if o.flags&flagOnce == 0 {
+		return "", fmt.Errorf("%w: '%s' is not a one-time value", ErrCookie, name)
+	}
+	return o.value, nil
+}
+
+// claimStore returns the store one-time values are claimed in, or nil.
+func (m *Manager) claimStore() ReplayStore {
+	if m.oneTime != nil {
+		return m.oneTime
+	}
+	return m.replay
+}
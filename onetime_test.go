@@ -0,0 +1,90 @@
+package cookie
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestOneTime(t *testing.T) {
+	plain := newTestManager(t)
+	m, err := plain.With(WithOneTimeStore(NewMemoryReplayStore()))
+	require.NoError(t, err)
+	expires := time.Now().Add(time.Hour)
+
+	for _, mode := range []Mode{Signed, Encrypted} {
+		t.Run(mode.String(), func(t *testing.T) {
+			w := httptest.NewRecorder()
+			require.NoError(t, m.WriteOneTime(w, "verify", "1312", expires, mode))
+			require.InDelta(t, 3600, w.Result().Cookies()[0].MaxAge, 1)
+
+			r := requestWith(w)
+			value, err := m.ReadOneTime(r, "verify", mode)
+			require.NoError(t, err)
+			require.Equal(t, "1312", value)
+
+			_, err = m.ReadOneTime(r, "verify", mode)
+			require.ErrorIs(t, err, ErrAlreadyUsed)
+			require.ErrorIs(t, err, ErrCookie)
+		})
+	}
+
+	t.Run("not claimed when tampered", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		require.NoError(t, m.WriteOneTime(w, "verify", "1312", expires, Signed))
+		c := w.Result().Cookies()[0]
+		tampered := httptest.NewRequest("GET", "/", nil)
+		c.Value = c.Value[:len(c.Value)-2] + "AA"
+		tampered.AddCookie(c)
+		_, err := m.ReadOneTime(tampered, "verify", Signed)
+		require.Error(t, err)
+		require.NotErrorIs(t, err, ErrAlreadyUsed)
+
+		_, err = m.ReadOneTime(requestWith(w), "verify", Signed)
+		require.NoError(t, err)
+	})
+
+	t.Run("cached reads still claim", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		require.NoError(t, m.WriteOneTime(w, "verify", "1312", expires, Signed))
+		r := requestWith(w)
+		r = r.WithContext(WithVerifiedCache(r.Context()))
+		_, err := m.ReadOneTime(r, "verify", Signed)
+		require.NoError(t, err)
+		_, err = m.ReadOneTime(r, "verify", Signed)
+		require.ErrorIs(t, err, ErrAlreadyUsed)
+	})
+
+	t.Run("signed values stay reusable", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		require.NoError(t, m.WriteSigned(w, "verify", "1312"))
+		_, err := m.ReadSigned(requestWith(w), "verify")
+		require.NoError(t, err)
+		_, err = m.ReadSigned(requestWith(w), "verify")
+		require.NoError(t, err)
+		_, err = m.ReadOneTime(requestWith(w), "verify", Signed)
+		require.ErrorIs(t, err, ErrCookie)
+	})
+
+	w := httptest.NewRecorder()
+	require.Error(t, plain.WriteOneTime(w, "verify", "1312", expires, Signed))
+	require.Error(t, m.WriteOneTime(w, "verify", "1312", expires, Plain))
+	require.Error(t, m.WriteOneTime(w, "verify", "1312", time.Now().Add(-time.Second), Signed))
+
+	_, err = New(WithOneTimeStore(nil))
+	require.ErrorIs(t, err, ErrInitiation)
+}
+
+func TestOneTimeClock(t *testing.T) {
+	clock := &testClock{t: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)}
+	m := newTestManager(t, WithClock(clock), WithOneTimeStore(NewMemoryReplayStore()))
+
+	w := httptest.NewRecorder()
+	require.NoError(t, m.WriteOneTime(w, "verify", "1312", clock.t.Add(time.Hour), Signed))
+	_, err := m.ReadOneTime(requestWith(w), "verify", Signed)
+	require.NoError(t, err)
+	_, err = m.ReadOneTime(requestWith(w), "verify", Signed)
+	require.ErrorIs(t, err, ErrAlreadyUsed)
+}
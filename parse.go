@@ -0,0 +1,99 @@
+package cookie
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// ErrMalformed matches errors parsing raw Cookie and Set-Cookie header text.
+var ErrMalformed = errors.New("malformed cookie header")
+
+// ParseCookieHeader parses the value of a Cookie request header leniently,
+// as browsers send it rather than as RFC 6265 specifies: values may contain
+// spaces, commas, and other unquoted characters, and surrounding quotes are
+// removed. Pairs without a valid name are skipped; the returned error joins
+// a failure for each, alongside the cookies that could be parsed.
+func ParseCookieHeader(line string) ([]http.Cookie, error) {
+	var cookies []http.Cookie
+	var errs []error
+	for _, pair := range strings.Split(line, ";") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(pair, "=")
+		name = strings.TrimSpace(name)
+		if !ok {
+			errs = append(errs, fmt.Errorf("%w: %w: '%s' has no value", ErrCookie, ErrMalformed, name))
+			continue
+		}
+		if err := validateName(name); err != nil {
+			errs = append(errs, fmt.Errorf("%w: %w: %w", ErrCookie, ErrMalformed, err))
+			continue
+		}
+		cookies = append(cookies, http.Cookie{Name: name, Value: unquote(strings.TrimSpace(value))})
+	}
+	return cookies, errors.Join(errs...)
+}
+
+// ParseCookieHeaderStrict parses the value of a Cookie request header as
+// RFC 6265 specifies, failing on the first malformed pair.
+func ParseCookieHeaderStrict(line string) ([]http.Cookie, error) {
+	parsed, err := http.ParseCookie(line)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w: %w", ErrCookie, ErrMalformed, err)
+	}
+	cookies := make([]http.Cookie, len(parsed))
+	for i, c := range parsed {
+		cookies[i] = *c
+	}
+	return cookies, nil
+}
+
+// ParseSetCookie parses the value of a Set-Cookie response header leniently:
+// the cookie value may contain characters RFC 6265 forbids, surrounding quotes
+// are removed, and unknown or invalid attributes are ignored, left in Unparsed.
+// Only a missing or invalid name fails.
+func ParseSetCookie(line string) (http.Cookie, error) {
+	pair, attributes, _ := strings.Cut(strings.TrimSpace(line), ";")
+	name, value, ok := strings.Cut(pair, "=")
+	name = strings.TrimSpace(name)
+	if !ok {
+		return http.Cookie{}, fmt.Errorf("%w: %w: '%s' has no value", ErrCookie, ErrMalformed, name)
+	}
+	if err := validateName(name); err != nil {
+		return http.Cookie{}, fmt.Errorf("%w: %w: %w", ErrCookie, ErrMalformed, err)
+	}
+	// let net/http parse the attributes behind a placeholder pair
+	c, err := http.ParseSetCookie("x=x;" + attributes)
+	if err != nil {
+		return http.Cookie{}, fmt.Errorf("%w: %w: %w", ErrCookie, ErrMalformed, err)
+	}
+	c.Name = name
+	c.Value = unquote(strings.TrimSpace(value))
+	c.Raw = line
+	return *c, nil
+}
+
+// ParseSetCookieStrict parses the value of a Set-Cookie response header as
+// RFC 6265 specifies, also failing on unknown or invalid attributes.
+func ParseSetCookieStrict(line string) (http.Cookie, error) {
+	c, err := http.ParseSetCookie(line)
+	if err != nil {
+		return http.Cookie{}, fmt.Errorf("%w: %w: %w", ErrCookie, ErrMalformed, err)
+	}
+	if len(c.Unparsed) > 0 {
+		return http.Cookie{}, fmt.Errorf("%w: %w: '%s' has unknown or invalid attributes %q", ErrCookie, ErrMalformed, c.Name, c.Unparsed)
+	}
+	return *c, nil
+}
+
+// unquote removes one pair of surrounding double quotes.
+func unquote(value string) string {
+	if len(value) > 1 && value[0] == '"' && value[len(value)-1] == '"' {
+		return value[1 : len(value)-1]
+	}
+	return value
+}
@@ -0,0 +1,58 @@
+package cookie
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCookieHeader(t *testing.T) {
+	cookies, err := ParseCookieHeader(`theme=dark; note=hello, world; quoted="abc"; ;bad name=x; flag`)
+	require.Equal(t, []http.Cookie{
+		{Name: "theme", Value: "dark"},
+		{Name: "note", Value: "hello, world"},
+		{Name: "quoted", Value: "abc"},
+	}, cookies)
+	require.ErrorIs(t, err, ErrMalformed)
+	require.ErrorIs(t, err, ErrInvalidName)
+	require.ErrorIs(t, err, ErrCookie)
+	require.ErrorContains(t, err, "'flag' has no value")
+
+	cookies, err = ParseCookieHeaderStrict(`theme=dark; quoted="abc"`)
+	require.NoError(t, err)
+	require.Equal(t, []http.Cookie{{Name: "theme", Value: "dark"}, {Name: "quoted", Value: "abc", Quoted: true}}, cookies)
+
+	_, err = ParseCookieHeaderStrict(`theme=dark; bad name=x`)
+	require.ErrorIs(t, err, ErrMalformed)
+}
+
+func TestParseSetCookie(t *testing.T) {
+	line := `note=hello world; Path=/; Max-Age=60; Secure; HttpOnly; SameSite=Lax; Priority=High`
+	c, err := ParseSetCookie(line)
+	require.NoError(t, err)
+	require.Equal(t, "note", c.Name)
+	require.Equal(t, "hello world", c.Value)
+	require.Equal(t, "/", c.Path)
+	require.Equal(t, 60, c.MaxAge)
+	require.True(t, c.Secure)
+	require.True(t, c.HttpOnly)
+	require.Equal(t, http.SameSiteLaxMode, c.SameSite)
+	require.Equal(t, []string{"Priority=High"}, c.Unparsed)
+	require.Equal(t, line, c.Raw)
+
+	_, err = ParseSetCookieStrict(line)
+	require.ErrorIs(t, err, ErrMalformed)
+
+	_, err = ParseSetCookieStrict("note=hello; Path=/; Max-Age=soon")
+	require.ErrorContains(t, err, "Max-Age=soon")
+
+	c, err = ParseSetCookieStrict("theme=dark; Path=/; Secure")
+	require.NoError(t, err)
+	require.Equal(t, "dark", c.Value)
+
+	_, err = ParseSetCookie("; Path=/")
+	require.ErrorIs(t, err, ErrMalformed)
+	_, err = ParseSetCookie("bad name=x")
+	require.ErrorIs(t, err, ErrInvalidName)
+}
@@ -0,0 +1,141 @@
+package cookie
+
+import (
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+)
+
+const minSaltLength = 16
+
+// ErrSecretMismatch is returned when a passphrase does not derive a PassphraseSecret.
+var ErrSecretMismatch = errors.New("passphrase does not match secret")
+
+// PassphraseParams are argon2id cost parameters for SecretFromPassphraseParams.
+// Version is mixed into the derivation, so changing parameters under a new
+// version can never silently reproduce a key from an older one.
+type PassphraseParams struct {
+	Version uint8
+	Time    uint32 // iterations
+	Memory  uint32 // KiB
+	Threads uint8
+}
+
+// PassphraseV1 follows the RFC 9106 second recommended option:
+// 3 iterations over 64 MiB of memory.
+var PassphraseV1 = PassphraseParams{Version: 1, Time: 3, Memory: 64 * 1024, Threads: 4}
+
+// SecretFromPassphrase derives a 32-byte secret from a human-memorable passphrase
+// with argon2id and PassphraseV1. The same passphrase and salt always produce the
+// same secret; the salt should be random, at least 16 bytes, and stored alongside
+// the passphrase in configuration.
+func SecretFromPassphrase(passphrase string, salt []byte) ([]byte, error) {
+	return SecretFromPassphraseParams(passphrase, salt, PassphraseV1)
+}
+
+// SecretFromPassphraseParams derives a 32-byte secret with explicit argon2id
+// parameters. Use NewPassphraseSecret to keep the parameters with the secret.
+func SecretFromPassphraseParams(passphrase string, salt []byte, params PassphraseParams) ([]byte, error) {
+	secret, err := NewPassphraseSecret(passphrase, salt, params)
+	if err != nil {
+		return nil, err
+	}
+	return secret.Key, nil
+}
+
+// PassphraseSecret is a secret derived from a passphrase, with the salt and
+// parameters that derived it, so it can be checked against the passphrase
+// and derived again at a higher cost once the parameters are outdated.
+type PassphraseSecret struct {
+	Params PassphraseParams
+	Salt   []byte
+	Key    []byte
+}
+
+// NewPassphraseSecret derives a 32-byte secret as SecretFromPassphraseParams does.
+func NewPassphraseSecret(passphrase string, salt []byte, params PassphraseParams) (PassphraseSecret, error) {
+	if passphrase == "" {
+		return PassphraseSecret{}, ErrSecretMissing
+	}
+	if len(salt) < minSaltLength {
+		return PassphraseSecret{}, fmt.Errorf("salt must be at least %d bytes, got %d", minSaltLength, len(salt))
+	}
+	if params.Version == 0 || params.Time == 0 || params.Memory == 0 || params.Threads == 0 {
+		return PassphraseSecret{}, errors.New("passphrase parameters must all be nonzero")
+	}
+	versioned := append([]byte(fmt.Sprintf("cookie/passphrase/v%d\x00", params.Version)), salt...)
+	key := argon2.IDKey(
+		[]byte(passphrase),
+		versioned,
+		params.Time,
+		params.Memory,
+		params.Threads,
+		secretLength,
+	)
+	return PassphraseSecret{Params: params, Salt: append([]byte(nil), salt...), Key: key}, nil
+}
+
+// String encodes the secret in the PHC string format, with the parameter
+// version as cv, such as "$argon2id$v=19$m=65536,t=3,p=4,cv=1$salt$key",
+// where salt and key are unpadded base64. ParsePassphraseSecret decodes it.
+func (s PassphraseSecret) String() string {
+	return fmt.Sprintf("$argon2id$v=%d$%s$%s$%s",
+		argon2.Version,
+		s.paramString(),
+		base64.RawStdEncoding.EncodeToString(s.Salt),
+		base64.RawStdEncoding.EncodeToString(s.Key),
+	)
+}
+
+func (s PassphraseSecret) paramString() string {
+	return fmt.Sprintf("m=%d,t=%d,p=%d,cv=%d", s.Params.Memory, s.Params.Time, s.Params.Threads, s.Params.Version)
+}
+
+// Verify returns nil if passphrase derives the secret, or ErrSecretMismatch.
+func (s PassphraseSecret) Verify(passphrase string) error {
+	derived, err := NewPassphraseSecret(passphrase, s.Salt, s.Params)
+	if err != nil {
+		return err
+	}
+	if subtle.ConstantTimeCompare(derived.Key, s.Key) != 1 {
+		return ErrSecretMismatch
+	}
+	return nil
+}
+
+// ParsePassphraseSecret decodes a secret encoded by PassphraseSecret.String.
+func ParsePassphraseSecret(encoded string) (PassphraseSecret, error) {
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 || parts[0] != "" || parts[1] != "argon2id" {
+		return PassphraseSecret{}, errors.New("passphrase secret is not an argon2id PHC string")
+	}
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return PassphraseSecret{}, fmt.Errorf("unsupported argon2 version: %s", parts[2])
+	}
+	var s PassphraseSecret
+	_, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d,cv=%d", &s.Params.Memory, &s.Params.Time, &s.Params.Threads, &s.Params.Version)
+	if err != nil || s.paramString() != parts[3] {
+		return PassphraseSecret{}, fmt.Errorf("invalid passphrase parameters: %s", parts[3])
+	}
+	if s.Params.Version == 0 || s.Params.Time == 0 || s.Params.Memory == 0 || s.Params.Threads == 0 {
+		return PassphraseSecret{}, errors.New("passphrase parameters must all be nonzero")
+	}
+	if s.Salt, err = base64.RawStdEncoding.DecodeString(parts[4]); err != nil {
+		return PassphraseSecret{}, fmt.Errorf("invalid passphrase salt: %w", err)
+	}
+	if len(s.Salt) < minSaltLength {
+		return PassphraseSecret{}, fmt.Errorf("salt must be at least %d bytes, got %d", minSaltLength, len(s.Salt))
+	}
+	if s.Key, err = base64.RawStdEncoding.DecodeString(parts[5]); err != nil {
+		return PassphraseSecret{}, fmt.Errorf("invalid passphrase secret key: %w", err)
+	}
+	if len(s.Key) != secretLength {
+		return PassphraseSecret{}, fmt.Errorf("%w: got %d bytes", ErrBadKeyLength, len(s.Key))
+	}
+	return s, nil
+}
@@ -0,0 +1,79 @@
+package cookie
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSecretFromPassphrase(t *testing.T) {
+	salt := []byte("0123456789abcdef")
+	fast := PassphraseParams{Version: 1, Time: 1, Memory: 1024, Threads: 1}
+
+	secret, err := SecretFromPassphraseParams("correct horse battery staple", salt, fast)
+	require.NoError(t, err)
+	require.Len(t, secret, secretLength)
+
+	again, err := SecretFromPassphraseParams("correct horse battery staple", salt, fast)
+	require.NoError(t, err)
+	require.Equal(t, secret, again)
+
+	fast.Version = 2
+	other, err := SecretFromPassphraseParams("correct horse battery staple", salt, fast)
+	require.NoError(t, err)
+	require.NotEqual(t, secret, other)
+
+	_, err = New(WithSecret(secret), WithCipher(XChaCha20Poly1305))
+	require.NoError(t, err)
+
+	_, err = SecretFromPassphrase("", salt)
+	require.ErrorIs(t, err, ErrSecretMissing)
+	_, err = SecretFromPassphrase("correct horse battery staple", []byte("short"))
+	require.Error(t, err)
+}
+
+func TestSecretFromPassphraseV1(t *testing.T) {
+	if testing.Short() {
+		t.Skip("argon2id with 64 MiB is slow")
+	}
+	secret, err := SecretFromPassphrase("correct horse battery staple", []byte("0123456789abcdef"))
+	require.NoError(t, err)
+	require.Len(t, secret, secretLength)
+}
+
+func TestPassphraseSecret(t *testing.T) {
+	salt := []byte("0123456789abcdef")
+	fast := PassphraseParams{Version: 1, Time: 1, Memory: 1024, Threads: 1}
+
+	secret, err := NewPassphraseSecret("correct horse battery staple", salt, fast)
+	require.NoError(t, err)
+	key, err := SecretFromPassphraseParams("correct horse battery staple", salt, fast)
+	require.NoError(t, err)
+	require.Equal(t, key, secret.Key)
+
+	encoded := secret.String()
+	require.True(t, strings.HasPrefix(encoded, "$argon2id$v=19$m=1024,t=1,p=1,cv=1$MDEyMzQ1Njc4OWFiY2RlZg$"), encoded)
+	parsed, err := ParsePassphraseSecret(encoded)
+	require.NoError(t, err)
+	require.Equal(t, secret, parsed)
+	require.NoError(t, parsed.Verify("correct horse battery staple"))
+	require.ErrorIs(t, parsed.Verify("wrong horse"), ErrSecretMismatch)
+
+	// outdated parameters can be read back and upgraded
+	require.NotEqual(t, PassphraseV1, parsed.Params)
+
+	for _, invalid := range []string{
+		"",
+		"$argon2i$v=19$m=1024,t=1,p=1,cv=1$MDEyMzQ1Njc4OWFiY2RlZg$" + strings.Repeat("A", 43),
+		"$argon2id$v=16$m=1024,t=1,p=1,cv=1$MDEyMzQ1Njc4OWFiY2RlZg$" + strings.Repeat("A", 43),
+		"$argon2id$v=19$m=1024,t=1,p=1$MDEyMzQ1Njc4OWFiY2RlZg$" + strings.Repeat("A", 43),
+		"$argon2id$v=19$m=1024,t=0,p=1,cv=1$MDEyMzQ1Njc4OWFiY2RlZg$" + strings.Repeat("A", 43),
+		"$argon2id$v=19$m=1024,t=1,p=1,cv=1x$MDEyMzQ1Njc4OWFiY2RlZg$" + strings.Repeat("A", 43),
+		"$argon2id$v=19$m=1024,t=1,p=1,cv=1$c2hvcnQ$" + strings.Repeat("A", 43),
+		"$argon2id$v=19$m=1024,t=1,p=1,cv=1$MDEyMzQ1Njc4OWFiY2RlZg$AAAA",
+	} {
+		_, err := ParsePassphraseSecret(invalid)
+		require.Error(t, err, invalid)
+	}
+}
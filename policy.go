@@ -0,0 +1,125 @@
+package cookie
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// ErrPolicy is matched by every PolicyError.
+var ErrPolicy = errors.New("cookie violates policy")
+
+// Policy is a preset of cookie attributes that a Manager applies to its
+// defaults and then enforces on every write, so a later option or a cookie
+// built by hand cannot weaken them.
+type Policy int
+
+const (
+	PolicyNone   Policy = iota // no enforcement
+	PolicyStrict               // Secure, HttpOnly, SameSite=Strict, and the __Host- prefix
+	PolicyLax                  // Secure, HttpOnly, and SameSite=Lax or Strict
+	PolicyDev                  // as PolicyLax without Secure, for plain-HTTP development; see WithInsecureDevelopment
+)
+
+func (p Policy) String() string {
+	switch p {
+	case PolicyNone:
+		return "none"
+	case PolicyStrict:
+		return "strict"
+	case PolicyLax:
+		return "lax"
+	case PolicyDev:
+		return "dev"
+	default:
+		return fmt.Sprintf("Policy(%d)", int(p))
+	}
+}
+
+// PolicyError is returned for a write that violates the Manager's Policy.
+// It matches ErrPolicy and ErrCookie.
+type PolicyError struct {
+	Policy Policy
+	Cookie string // full cookie name
+	Reason string
+}
+
+func (e *PolicyError) Error() string {
+	return fmt.Sprintf("%s: '%s' %s under %s policy", ErrPolicy, e.Cookie, e.Reason, e.Policy)
+}
+
+func (e *PolicyError) Unwrap() []error {
+	return []error{ErrPolicy, ErrCookie}
+}
+
+// WithPolicy applies the attributes of policy to the Manager's defaults, and
+// makes every write that does not meet them fail with a PolicyError.
+// Deleting cookies is always allowed.
+func WithPolicy(policy Policy) Option {
+	return func(m *Manager) error {
+		switch policy {
+		case PolicyNone:
+		case PolicyStrict:
+			m.defaults.Secure = true
+			m.defaults.HttpOnly = true
+			m.defaults.SameSite = http.SameSiteStrictMode
+			m.defaults.Path = "/"
+			m.defaults.Domain = ""
+			m.prefix = HostPrefix
+		case PolicyLax:
+			m.defaults.Secure = true
+			m.defaults.HttpOnly = true
+			m.defaults.SameSite = http.SameSiteLaxMode
+		case PolicyDev:
+			m.defaults.Secure = false
+			m.defaults.HttpOnly = true
+			m.defaults.SameSite = http.SameSiteLaxMode
+		default:
+			return fmt.Errorf("unsupported policy: %s", policy)
+		}
+		m.policy = policy
+		return nil
+	}
+}
+
+// WithInsecureDevelopment acknowledges that PolicyDev writes cookies without
+// Secure, readable by anyone on the network path over plain HTTP. A Manager
+// with PolicyDev and without this option fails to build, so set both only
+// behind a development flag, and never in production:
+//
+//	if *dev {
+//		opts = append(opts, cookie.WithPolicy(cookie.PolicyDev), cookie.WithInsecureDevelopment())
+//	}
+func WithInsecureDevelopment() Option {
+	return func(m *Manager) error {
+		m.insecureDev = true
+		return nil
+	}
+}
+
+// checkPolicy fails with a PolicyError if cookie does not meet the Manager's Policy.
+func (m *Manager) checkPolicy(cookie http.Cookie) error {
+	if m.policy == PolicyNone {
+		return nil
+	}
+	fail := func(reason string) error {
+		return &PolicyError{Policy: m.policy, Cookie: cookie.Name, Reason: reason}
+	}
+	if !cookie.HttpOnly {
+		return fail("lacks HttpOnly")
+	}
+	if !cookie.Secure && m.policy != PolicyDev {
+		return fail("lacks Secure")
+	}
+	switch {
+	case m.policy == PolicyStrict && cookie.SameSite != http.SameSiteStrictMode:
+		return fail("lacks SameSite=Strict")
+	case cookie.SameSite != http.SameSiteStrictMode && cookie.SameSite != http.SameSiteLaxMode:
+		return fail("lacks SameSite=Lax or Strict")
+	}
+	if m.policy == PolicyStrict && !strings.HasPrefix(cookie.Name, HostPrefix) {
+		return fail("lacks the __Host- prefix")
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package cookie
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPolicyStrict(t *testing.T) {
+	m := newTestManager(t, WithPolicy(PolicyStrict))
+	w := httptest.NewRecorder()
+	require.NoError(t, m.WriteSigned(w, "user", "alice"))
+	c := w.Result().Cookies()[0]
+	require.Equal(t, "__Host-user", c.Name)
+	require.True(t, c.Secure)
+	require.True(t, c.HttpOnly)
+	require.Equal(t, http.SameSiteStrictMode, c.SameSite)
+	require.Equal(t, "/", c.Path)
+
+	m = newTestManager(t, WithPolicy(PolicyStrict), WithSameSite(http.SameSiteLaxMode))
+	err := m.WriteSigned(httptest.NewRecorder(), "user", "alice")
+	var policyErr *PolicyError
+	require.ErrorAs(t, err, &policyErr)
+	require.Equal(t, PolicyStrict, policyErr.Policy)
+	require.Equal(t, "__Host-user", policyErr.Cookie)
+	require.ErrorIs(t, err, ErrPolicy)
+	require.ErrorIs(t, err, ErrCookie)
+	require.Contains(t, err.Error(), "SameSite=Strict")
+
+	m = newTestManager(t, WithPolicy(PolicyStrict), WithPrefix(SecurePrefix))
+	err = m.WriteSigned(httptest.NewRecorder(), "user", "alice")
+	require.ErrorIs(t, err, ErrPolicy)
+	require.Contains(t, err.Error(), "__Host-")
+
+	// deleting is always allowed
+	w = httptest.NewRecorder()
+	m.Delete(w, "user")
+	require.Len(t, w.Result().Cookies(), 1)
+}
+
+func TestPolicyLax(t *testing.T) {
+	m := newTestManager(t, WithPolicy(PolicyLax))
+	w := httptest.NewRecorder()
+	require.NoError(t, m.WriteEncryptedValue(w, "user", "alice"))
+	c := w.Result().Cookies()[0]
+	require.True(t, c.Secure)
+	require.Equal(t, http.SameSiteLaxMode, c.SameSite)
+
+	m = newTestManager(t, WithPolicy(PolicyLax), WithSameSite(http.SameSiteNoneMode))
+	require.ErrorIs(t, m.WriteSigned(httptest.NewRecorder(), "user", "alice"), ErrPolicy)
+
+	m = newTestManager(t, WithPolicy(PolicyLax))
+	err := m.WriteAll(httptest.NewRecorder(), []http.Cookie{
+		{Name: "theme", Value: "dark", Secure: true, SameSite: http.SameSiteLaxMode},
+	}, Signed)
+	require.ErrorIs(t, err, ErrPolicy)
+	require.Contains(t, err.Error(), "HttpOnly")
+}
+
+func TestPolicyDev(t *testing.T) {
+	// dev policy is refused unless acknowledged, in either order
+	_, err := New(WithPolicy(PolicyDev))
+	require.ErrorIs(t, err, ErrInitiation)
+	_, err = newTestManager(t).With(WithPolicy(PolicyDev))
+	require.ErrorIs(t, err, ErrInitiation)
+	_, err = New(WithInsecureDevelopment(), WithPolicy(PolicyDev))
+	require.NoError(t, err)
+
+	m := newTestManager(t, WithPolicy(PolicyDev), WithInsecureDevelopment())
+	w := httptest.NewRecorder()
+	require.NoError(t, m.WriteSigned(w, "user", "alice"))
+	require.False(t, w.Result().Cookies()[0].Secure)
+	value, err := m.ReadSigned(requestWith(w), "user")
+	require.NoError(t, err)
+	require.Equal(t, "alice", value)
+
+	m = newTestManager(t, WithPolicy(PolicyDev), WithInsecureDevelopment(), WithHttpOnly(false))
+	require.ErrorIs(t, m.WriteSigned(httptest.NewRecorder(), "user", "alice"), ErrPolicy)
+}
+
+func TestPolicyChunked(t *testing.T) {
+	m := newTestManager(t, WithPolicy(PolicyStrict), WithChunking(16<<10))
+	w := httptest.NewRecorder()
+	require.NoError(t, m.WriteEncryptedValue(w, "cart", strings.Repeat("x", 6000)))
+	for _, c := range w.Result().Cookies() {
+		require.True(t, strings.HasPrefix(c.Name, HostPrefix))
+	}
+}
+
+func TestWithPolicyInvalid(t *testing.T) {
+	_, err := New(WithSecret(make([]byte, 32)), WithPolicy(Policy(42)))
+	require.ErrorIs(t, err, ErrInitiation)
+	require.Equal(t, "Policy(42)", Policy(42).String())
+}
@@ -0,0 +1,216 @@
+// package pow issues proof-of-work challenges in signed cookies. A client
+// proves it spent some CPU by finding a solution whose SHA-256 hash with the
+// challenge nonce starts with a number of zero bits, and is then given a
+// signed "passed" cookie for a while: cheap for one visitor, costly for bots
+// making many requests. Solutions are not claimed, so one solution can mint
+// passed cookies until its challenge expires; keep challenge lifetimes short.
+package pow
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"math/bits"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/grackleclub/cookie/v2"
+	"github.com/grackleclub/cookie/v2/internal/token"
+)
+
+const (
+	defaultChallengeName = "pow_challenge"
+	defaultPassedName    = "pow_passed"
+	defaultDifficulty    = 20
+	defaultChallengeTTL  = 5 * time.Minute
+	defaultPassedTTL     = 24 * time.Hour
+	nonceLength          = 16
+	maxSolutionLength    = 64
+)
+
+var (
+	ErrNoChallenge = errors.New("proof of work challenge missing")
+	ErrSolution    = errors.New("proof of work solution invalid")
+)
+
+// Challenge is what a client needs to compute a solution.
+type Challenge struct {
+	Nonce      string    `json:"nonce"`
+	Difficulty int       `json:"difficulty"` // leading zero bits required
+	Expires    time.Time `json:"expires"`
+}
+
+// stored is an issued challenge, kept by the client until it sends a solution.
+type stored struct {
+	Nonce      string `json:"n"`
+	Difficulty int    `json:"d"`
+	Expires    int64  `json:"e"`
+}
+
+// passed marks a client that solved a challenge, until Expires in unix seconds.
+type passed struct {
+	Expires int64 `json:"e"`
+}
+
+// Challenger issues and verifies challenges.
+type Challenger struct {
+	challenges    *cookie.Manager
+	passes        *cookie.Manager
+	challengeName string
+	passedName    string
+	difficulty    int
+	challengeTTL  time.Duration
+	passedTTL     time.Duration
+}
+
+// Option configures a Challenger.
+type Option func(*Challenger) error
+
+// New creates a Challenger. The cookie.Manager must hold a secret,
+// which is used to sign the challenge and passed cookies.
+func New(cookies *cookie.Manager, opts ...Option) (*Challenger, error) {
+	if cookies == nil {
+		return nil, fmt.Errorf("%w: cookie manager is required", cookie.ErrInitiation)
+	}
+	c := &Challenger{
+		challengeName: defaultChallengeName,
+		passedName:    defaultPassedName,
+		difficulty:    defaultDifficulty,
+		challengeTTL:  defaultChallengeTTL,
+		passedTTL:     defaultPassedTTL,
+	}
+	for _, opt := range opts {
+		if err := opt(c); err != nil {
+			return nil, fmt.Errorf("%w: %w", cookie.ErrInitiation, err)
+		}
+	}
+	var err error
+	if c.challenges, err = cookies.With(cookie.WithMaxAge(c.challengeTTL)); err != nil {
+		return nil, err
+	}
+	if c.passes, err = cookies.With(cookie.WithMaxAge(c.passedTTL)); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// WithNames sets the names of the challenge and passed cookies.
+func WithNames(challenge, passed string) Option {
+	return func(c *Challenger) error {
+		if challenge == "" || passed == "" || challenge == passed {
+			return errors.New("proof of work cookie names must be distinct and non-empty")
+		}
+		c.challengeName = challenge
+		c.passedName = passed
+		return nil
+	}
+}
+
+// WithDifficulty sets the leading zero bits a solution's hash needs. Each bit
+// doubles the expected work; the default of 20 takes around a million hashes.
+func WithDifficulty(difficulty int) Option {
+	return func(c *Challenger) error {
+		if difficulty < 1 || difficulty > 32 {
+			return fmt.Errorf("proof of work difficulty not between 1 and 32: %d", difficulty)
+		}
+		c.difficulty = difficulty
+		return nil
+	}
+}
+
+// WithTTL sets how long a challenge may be solved in, and how long
+// a passed cookie lasts. Defaults to 5 minutes and a day.
+func WithTTL(challenge, passed time.Duration) Option {
+	return func(c *Challenger) error {
+		if challenge < time.Second || passed < time.Minute {
+			return fmt.Errorf("proof of work ttls too short: %s, %s", challenge, passed)
+		}
+		c.challengeTTL = challenge
+		c.passedTTL = passed
+		return nil
+	}
+}
+
+// Issue sets a new challenge cookie, returning the challenge to send to the client.
+func (c *Challenger) Issue(w http.ResponseWriter) (Challenge, error) {
+	nonce, err := token.Random(nonceLength)
+	if err != nil {
+		return Challenge{}, err
+	}
+	challenge := Challenge{
+		Nonce:      nonce,
+		Difficulty: c.difficulty,
+		Expires:    c.challenges.Now().Add(c.challengeTTL).Truncate(time.Second),
+	}
+	payload := stored{Nonce: challenge.Nonce, Difficulty: challenge.Difficulty, Expires: challenge.Expires.Unix()}
+	if err := cookie.WriteJSON(c.challenges, w, c.challengeName, payload, cookie.Signed); err != nil {
+		return Challenge{}, err
+	}
+	return challenge, nil
+}
+
+// Verify checks solution against the request's challenge cookie. Once it
+// passes, the challenge cookie is replaced with a passed cookie.
+func (c *Challenger) Verify(w http.ResponseWriter, r *http.Request, solution string) error {
+	payload, err := cookie.ReadJSON[stored](c.challenges, r, c.challengeName, cookie.Signed)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrNoChallenge, err)
+	}
+	if !c.challenges.Now().Before(time.Unix(payload.Expires, 0)) {
+		return fmt.Errorf("%w: %w", ErrNoChallenge, cookie.ErrExpired)
+	}
+	if !Valid(payload.Nonce, solution, payload.Difficulty) {
+		return ErrSolution
+	}
+	c.challenges.Delete(w, c.challengeName)
+	return cookie.WriteJSON(c.passes, w, c.passedName, passed{Expires: c.passes.Now().Add(c.passedTTL).Unix()}, cookie.Signed)
+}
+
+// Passed reports whether the request carries a valid passed cookie.
+func (c *Challenger) Passed(r *http.Request) bool {
+	payload, err := cookie.ReadJSON[passed](c.passes, r, c.passedName, cookie.Signed)
+	return err == nil && c.passes.Now().Before(time.Unix(payload.Expires, 0))
+}
+
+// Middleware calls next for requests that have passed a challenge,
+// and challenge, such as a page that solves one in JavaScript, for others.
+func (c *Challenger) Middleware(challenge http.Handler) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !c.Passed(r) {
+				challenge.ServeHTTP(w, r)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// Valid reports whether the SHA-256 hash of nonce, a colon, and solution
+// starts with difficulty zero bits.
+func Valid(nonce, solution string, difficulty int) bool {
+	if solution == "" || len(solution) > maxSolutionLength {
+		return false
+	}
+	sum := sha256.Sum256([]byte(nonce + ":" + solution))
+	zeros := 0
+	for _, b := range sum {
+		zeros += bits.LeadingZeros8(b)
+		if b != 0 {
+			break
+		}
+	}
+	return zeros >= difficulty
+}
+
+// Solve finds the smallest decimal solution to a challenge, as a client
+// would. It is for tests and Go clients; browsers solve challenges in JavaScript.
+func Solve(challenge Challenge) string {
+	for i := uint64(0); ; i++ {
+		solution := strconv.FormatUint(i, 10)
+		if Valid(challenge.Nonce, solution, challenge.Difficulty) {
+			return solution
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package pow
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/grackleclub/cookie/v2"
+	"github.com/grackleclub/cookie/v2/cookietest"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestChallenger(t *testing.T, opts ...Option) *Challenger {
+	t.Helper()
+	secretKey, err := cookie.NewCookieSecret()
+	require.NoError(t, err)
+	cookies, err := cookie.New(cookie.WithSecret(secretKey))
+	require.NoError(t, err)
+	c, err := New(cookies, append([]Option{WithDifficulty(8)}, opts...)...)
+	require.NoError(t, err)
+	return c
+}
+
+func TestValid(t *testing.T) {
+	challenge := Challenge{Nonce: "nonce", Difficulty: 12}
+	solution := Solve(challenge)
+	require.True(t, Valid(challenge.Nonce, solution, 12))
+	require.False(t, Valid("other", solution, 12))
+	require.False(t, Valid(challenge.Nonce, "", 0))
+}
+
+func TestVerify(t *testing.T) {
+	c := newTestChallenger(t)
+	require.False(t, c.Passed(httptest.NewRequest(http.MethodGet, "/", nil)))
+
+	w := httptest.NewRecorder()
+	challenge, err := c.Issue(w)
+	require.NoError(t, err)
+	require.Equal(t, 8, challenge.Difficulty)
+	r := cookietest.RequestFrom(t, w)
+
+	wrong := "0"
+	for Valid(challenge.Nonce, wrong, challenge.Difficulty) {
+		wrong += "0"
+	}
+	require.ErrorIs(t, c.Verify(httptest.NewRecorder(), r, wrong), ErrSolution)
+
+	w = httptest.NewRecorder()
+	require.NoError(t, c.Verify(w, r, Solve(challenge)))
+	r = cookietest.RequestFrom(t, w)
+	require.True(t, c.Passed(r))
+	_, err = r.Cookie(defaultChallengeName)
+	require.ErrorIs(t, err, http.ErrNoCookie)
+
+	require.ErrorIs(t, c.Verify(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/", nil), "1"), ErrNoChallenge)
+
+	// cookies signed with another secret are rejected
+	require.False(t, newTestChallenger(t).Passed(r))
+}
+
+func TestMiddleware(t *testing.T) {
+	c := newTestChallenger(t)
+	handler := c.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	require.Equal(t, http.StatusForbidden, w.Code)
+
+	w = httptest.NewRecorder()
+	challenge, err := c.Issue(w)
+	require.NoError(t, err)
+	r := cookietest.RequestFrom(t, w)
+	w = httptest.NewRecorder()
+	require.NoError(t, c.Verify(w, r, Solve(challenge)))
+	w2 := httptest.NewRecorder()
+	handler.ServeHTTP(w2, cookietest.RequestFrom(t, w))
+	require.Equal(t, http.StatusOK, w2.Code)
+
+	cookies, err := cookie.New()
+	require.NoError(t, err)
+	_, err = New(cookies, WithDifficulty(40))
+	require.ErrorIs(t, err, cookie.ErrInitiation)
+	_, err = New(cookies, WithNames("same", "same"))
+	require.ErrorIs(t, err, cookie.ErrInitiation)
+}
+
+func TestExpiry(t *testing.T) {
+	secretKey, err := cookie.NewCookieSecret()
+	require.NoError(t, err)
+	clock := cookietest.NewClock(time.Unix(1_700_000_000, 0))
+	cookies, err := cookie.New(cookie.WithSecret(secretKey), cookie.WithClock(clock))
+	require.NoError(t, err)
+	c, err := New(cookies, WithDifficulty(8))
+	require.NoError(t, err)
+
+	// the Manager's clock sets and checks every expiry
+	w := httptest.NewRecorder()
+	challenge, err := c.Issue(w)
+	require.NoError(t, err)
+	require.Equal(t, clock.Now().Add(defaultChallengeTTL), challenge.Expires)
+	clock.Advance(defaultChallengeTTL)
+	require.ErrorIs(t, c.Verify(httptest.NewRecorder(), cookietest.RequestFrom(t, w), Solve(challenge)), cookie.ErrExpired)
+
+	w = httptest.NewRecorder()
+	challenge, err = c.Issue(w)
+	require.NoError(t, err)
+	passed := httptest.NewRecorder()
+	require.NoError(t, c.Verify(passed, cookietest.RequestFrom(t, w), Solve(challenge)))
+	require.True(t, c.Passed(cookietest.RequestFrom(t, passed)))
+	clock.Advance(defaultPassedTTL)
+	require.False(t, c.Passed(cookietest.RequestFrom(t, passed)))
+}
@@ -0,0 +1,116 @@
+package cookie
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// Cookie name prefixes that browsers enforce. For more information see:
+// https://developer.mozilla.org/en-US/docs/Web/HTTP/Headers/Set-Cookie#cookie_prefixes
+const (
+	// SecurePrefix requires the Secure attribute.
+	SecurePrefix = "__Secure-"
+	// HostPrefix requires the Secure attribute, Path=/, and no Domain,
+	// locking the cookie to the exact host that set it.
+	HostPrefix = "__Host-"
+)
+
+var ErrInvalidPrefix = errors.New("cookie prefix requirements not met")
+
+// WithPrefix prepends SecurePrefix or HostPrefix to every cookie name the
+// Manager writes and reads, so callers keep using the bare name.
+// Writes that violate the prefix requirements fail with ErrInvalidPrefix,
+// as do reads of prefixed cookies from requests that did not arrive over HTTPS.
+func WithPrefix(prefix string) Option {
+	return func(m *Manager) error {
+		if prefix != SecurePrefix && prefix != HostPrefix {
+			return fmt.Errorf("unsupported prefix: %q", prefix)
+		}
+		m.prefix = prefix
+		return nil
+	}
+}
+
+// WithTrustedProxies trusts the X-Forwarded-Proto header of requests whose
+// RemoteAddr is one of proxies, given as addresses or CIDR prefixes, such as a
+// load balancer terminating TLS. Without it, a request counts as HTTPS only
+// if Request.TLS is set, since any client can send the header.
+func WithTrustedProxies(proxies ...string) Option {
+	return func(m *Manager) error {
+		for _, proxy := range proxies {
+			prefix, err := netip.ParsePrefix(proxy)
+			if err != nil {
+				addr, addrErr := netip.ParseAddr(proxy)
+				if addrErr != nil {
+					return fmt.Errorf("invalid trusted proxy %q: %w", proxy, err)
+				}
+				prefix = netip.PrefixFrom(addr, addr.BitLen())
+			}
+			m.proxies = append(m.proxies, prefix.Masked())
+		}
+		return nil
+	}
+}
+
+// secureRequest reports whether r arrived over HTTPS, directly or through a trusted proxy.
+func (m *Manager) secureRequest(r *http.Request) bool {
+	if r.TLS != nil {
+		return true
+	}
+	if len(m.proxies) == 0 || r.Header.Get("X-Forwarded-Proto") != "https" {
+		return false
+	}
+	addr, err := parseRemoteAddr(r.RemoteAddr)
+	if err != nil {
+		return false
+	}
+	for _, proxy := range m.proxies {
+		if proxy.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseRemoteAddr returns the address of a Request.RemoteAddr, with or without a port.
+func parseRemoteAddr(remoteAddr string) (netip.Addr, error) {
+	if addrPort, err := netip.ParseAddrPort(remoteAddr); err == nil {
+		return addrPort.Addr().Unmap(), nil
+	}
+	addr, err := netip.ParseAddr(remoteAddr)
+	return addr.Unmap(), err
+}
+
+// validatePrefix checks a cookie against the requirements of its name prefix, if any.
+func validatePrefix(cookie http.Cookie) error {
+	switch {
+	case strings.HasPrefix(cookie.Name, HostPrefix):
+		if !cookie.Secure || cookie.Path != "/" || cookie.Domain != "" {
+			return fmt.Errorf(
+				"%w: '%s' requires Secure, Path=/, and no Domain",
+				ErrInvalidPrefix,
+				cookie.Name,
+			)
+		}
+	case strings.HasPrefix(cookie.Name, SecurePrefix):
+		if !cookie.Secure {
+			return fmt.Errorf("%w: '%s' requires Secure", ErrInvalidPrefix, cookie.Name)
+		}
+	}
+	return nil
+}
+
+// validatePrefixRequest checks that a prefixed cookie was received over HTTPS,
+// the only transport on which a browser would have accepted it.
+func (m *Manager) validatePrefixRequest(r *http.Request, name string) error {
+	if !strings.HasPrefix(name, HostPrefix) && !strings.HasPrefix(name, SecurePrefix) {
+		return nil
+	}
+	if m.secureRequest(r) {
+		return nil
+	}
+	return fmt.Errorf("%w: '%s' received over an insecure connection", ErrInvalidPrefix, name)
+}
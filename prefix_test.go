@@ -0,0 +1,77 @@
+package cookie
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidatePrefix(t *testing.T) {
+	tests := []struct {
+		name   string
+		cookie http.Cookie
+		valid  bool
+	}{
+		{"unprefixed", http.Cookie{Name: "a"}, true},
+		{"secure", http.Cookie{Name: "__Secure-a", Secure: true}, true},
+		{"secure insecure", http.Cookie{Name: "__Secure-a"}, false},
+		{"host", http.Cookie{Name: "__Host-a", Secure: true, Path: "/"}, true},
+		{"host domain", http.Cookie{Name: "__Host-a", Secure: true, Path: "/", Domain: "example.com"}, false},
+		{"host path", http.Cookie{Name: "__Host-a", Secure: true, Path: "/admin"}, false},
+		{"host insecure", http.Cookie{Name: "__Host-a", Path: "/"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Write(httptest.NewRecorder(), tt.cookie)
+			if tt.valid {
+				require.NoError(t, err)
+			} else {
+				require.ErrorIs(t, err, ErrInvalidPrefix)
+			}
+		})
+	}
+}
+
+func TestManagerPrefix(t *testing.T) {
+	m := newTestManager(t, WithPrefix(HostPrefix))
+
+	w := httptest.NewRecorder()
+	require.NoError(t, m.WriteSigned(w, "session", "chocolate fudge"))
+	require.Equal(t, "__Host-session", w.Result().Cookies()[0].Name)
+
+	r := httptest.NewRequest(http.MethodGet, "https://example.com/", nil)
+	r.AddCookie(w.Result().Cookies()[0])
+	value, err := m.ReadSigned(r, "session")
+	require.NoError(t, err)
+	require.Equal(t, "chocolate fudge", value)
+
+	_, err = m.ReadSigned(requestWith(w), "session")
+	require.ErrorIs(t, err, ErrInvalidPrefix)
+
+	// X-Forwarded-Proto counts only from a trusted proxy
+	forwarded := requestWith(w)
+	forwarded.Header.Set("X-Forwarded-Proto", "https")
+	_, err = m.ReadSigned(forwarded, "session")
+	require.ErrorIs(t, err, ErrInvalidPrefix)
+	_, err = m.AppendSigned(nil, forwarded, "session")
+	require.ErrorIs(t, err, ErrInvalidPrefix)
+	proxied, err := m.With(WithTrustedProxies("10.0.0.0/8", "192.0.2.1"))
+	require.NoError(t, err)
+	value, err = proxied.ReadSigned(forwarded, "session")
+	require.NoError(t, err)
+	require.Equal(t, "chocolate fudge", value)
+	forwarded.RemoteAddr = "198.51.100.7:4000"
+	_, err = proxied.ReadSigned(forwarded, "session")
+	require.ErrorIs(t, err, ErrInvalidPrefix)
+
+	_, err = New(WithTrustedProxies("proxy.internal"))
+	require.ErrorIs(t, err, ErrInitiation)
+
+	m = newTestManager(t, WithPrefix(HostPrefix), WithDomain("example.com"))
+	require.ErrorIs(t, m.Write(httptest.NewRecorder(), "session", "x"), ErrInvalidPrefix)
+
+	_, err = New(WithPrefix("__Nope-"))
+	require.ErrorIs(t, err, ErrInitiation)
+}
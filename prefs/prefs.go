@@ -0,0 +1,180 @@
+// package prefs keeps locale, time zone, and theme preferences in cookies,
+// validating each against an allowlist when read, so a hand-edited cookie
+// can only ever select a supported value. Without a locale cookie, the
+// locale is negotiated from the Accept-Language header.
+package prefs
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/grackleclub/cookie/v2"
+	"golang.org/x/text/language"
+)
+
+const (
+	localeName   = "locale"
+	timezoneName = "tz"
+	themeName    = "theme"
+	defaultTTL   = 365 * 24 * time.Hour
+)
+
+var ErrPreference = errors.New("preference invalid")
+
+// Prefs reads and writes preference cookies.
+type Prefs struct {
+	cookies *cookie.Manager
+	locales []language.Tag // supported, the first being the default
+	matcher language.Matcher
+	themes  []string // allowed, the first being the default
+	ttl     time.Duration
+}
+
+// Option configures Prefs.
+type Option func(*Prefs) error
+
+// New creates Prefs supporting the given BCP 47 locales, the first of which
+// is the default. Preference cookies are written as the cookie.Manager's
+// plain cookies, so client-side code can read them too.
+func New(cookies *cookie.Manager, locales []string, opts ...Option) (*Prefs, error) {
+	if cookies == nil {
+		return nil, fmt.Errorf("%w: cookie manager is required", cookie.ErrInitiation)
+	}
+	if len(locales) == 0 {
+		return nil, fmt.Errorf("%w: at least one locale is required", cookie.ErrInitiation)
+	}
+	p := &Prefs{
+		cookies: cookies,
+		themes:  []string{"system", "light", "dark"},
+		ttl:     defaultTTL,
+	}
+	for _, locale := range locales {
+		tag, err := language.Parse(locale)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid locale '%s': %w", cookie.ErrInitiation, locale, err)
+		}
+		p.locales = append(p.locales, tag)
+	}
+	p.matcher = language.NewMatcher(p.locales)
+	for _, opt := range opts {
+		if err := opt(p); err != nil {
+			return nil, fmt.Errorf("%w: %w", cookie.ErrInitiation, err)
+		}
+	}
+	cookies, err := cookies.With(cookie.WithMaxAge(p.ttl))
+	if err != nil {
+		return nil, err
+	}
+	p.cookies = cookies
+	return p, nil
+}
+
+// WithThemes sets the allowed themes, the first being the default.
+// Defaults to "system", "light", and "dark".
+func WithThemes(themes ...string) Option {
+	return func(p *Prefs) error {
+		if len(themes) == 0 || slices.Contains(themes, "") {
+			return errors.New("themes must be non-empty")
+		}
+		p.themes = slices.Clone(themes)
+		return nil
+	}
+}
+
+// WithTTL sets how long preferences are kept. Defaults to a year.
+func WithTTL(ttl time.Duration) Option {
+	return func(p *Prefs) error {
+		if ttl < time.Hour {
+			return fmt.Errorf("preference ttl too short: %s", ttl)
+		}
+		p.ttl = ttl
+		return nil
+	}
+}
+
+// Locale returns the supported locale that best matches the locale cookie,
+// or without one the request's Accept-Language header, or the default.
+func (p *Prefs) Locale(r *http.Request) language.Tag {
+	if value, err := p.cookies.Read(r, localeName); err == nil {
+		if tag, err := p.supported(value); err == nil {
+			return tag
+		}
+	}
+	accepted, _, _ := language.ParseAcceptLanguage(r.Header.Get("Accept-Language"))
+	_, i, _ := p.matcher.Match(accepted...)
+	return p.locales[i]
+}
+
+// SetLocale stores locale, failing with ErrPreference unless
+// it is a valid BCP 47 tag matching a supported locale.
+func (p *Prefs) SetLocale(w http.ResponseWriter, locale string) error {
+	tag, err := p.supported(locale)
+	if err != nil {
+		return err
+	}
+	return p.cookies.Write(w, localeName, tag.String())
+}
+
+// supported returns the supported locale matching locale.
+func (p *Prefs) supported(locale string) (language.Tag, error) {
+	tag, err := language.Parse(locale)
+	if err != nil {
+		return language.Und, fmt.Errorf("%w: locale '%s': %w", ErrPreference, locale, err)
+	}
+	_, i, confidence := p.matcher.Match(tag)
+	if confidence < language.High {
+		return language.Und, fmt.Errorf("%w: locale '%s' is not supported", ErrPreference, locale)
+	}
+	return p.locales[i], nil
+}
+
+// Timezone returns the time zone of the timezone cookie, or UTC.
+func (p *Prefs) Timezone(r *http.Request) *time.Location {
+	if value, err := p.cookies.Read(r, timezoneName); err == nil {
+		if loc, err := location(value); err == nil {
+			return loc
+		}
+	}
+	return time.UTC
+}
+
+// SetTimezone stores name, failing with ErrPreference unless it is
+// an IANA time zone name such as "Europe/Paris".
+func (p *Prefs) SetTimezone(w http.ResponseWriter, name string) error {
+	if _, err := location(name); err != nil {
+		return err
+	}
+	return p.cookies.Write(w, timezoneName, name)
+}
+
+// location loads the IANA time zone name, rejecting the empty
+// and "Local" names time.LoadLocation also accepts.
+func location(name string) (*time.Location, error) {
+	if name == "" || name == "Local" {
+		return nil, fmt.Errorf("%w: time zone '%s'", ErrPreference, name)
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("%w: time zone '%s': %w", ErrPreference, name, err)
+	}
+	return loc, nil
+}
+
+// Theme returns the theme of the theme cookie if allowed, or the default.
+func (p *Prefs) Theme(r *http.Request) string {
+	if value, err := p.cookies.Read(r, themeName); err == nil && slices.Contains(p.themes, value) {
+		return value
+	}
+	return p.themes[0]
+}
+
+// SetTheme stores theme, failing with ErrPreference unless it is allowed.
+func (p *Prefs) SetTheme(w http.ResponseWriter, theme string) error {
+	if !slices.Contains(p.themes, theme) {
+		return fmt.Errorf("%w: theme '%s' is not allowed", ErrPreference, theme)
+	}
+	return p.cookies.Write(w, themeName, theme)
+}
@@ -0,0 +1,88 @@
+package prefs
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/grackleclub/cookie/v2"
+	"github.com/grackleclub/cookie/v2/cookietest"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/text/language"
+)
+
+func newTestPrefs(t *testing.T, opts ...Option) *Prefs {
+	t.Helper()
+	cookies, err := cookie.New()
+	require.NoError(t, err)
+	p, err := New(cookies, []string{"en", "fr", "pt-BR"}, opts...)
+	require.NoError(t, err)
+	return p
+}
+
+// withCookie returns a request carrying a plain cookie as a client could set it.
+func withCookie(t *testing.T, name, value string) *http.Request {
+	t.Helper()
+	w := httptest.NewRecorder()
+	cookies, err := cookie.New()
+	require.NoError(t, err)
+	require.NoError(t, cookies.Write(w, name, value))
+	return cookietest.RequestFrom(t, w)
+}
+
+func TestLocale(t *testing.T) {
+	p := newTestPrefs(t)
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	require.Equal(t, language.English, p.Locale(r))
+	r.Header.Set("Accept-Language", "de-DE, fr;q=0.8, en;q=0.5")
+	require.Equal(t, language.French, p.Locale(r))
+
+	w := httptest.NewRecorder()
+	require.NoError(t, p.SetLocale(w, "pt-BR"))
+	r = cookietest.RequestFrom(t, w)
+	r.Header.Set("Accept-Language", "fr")
+	require.Equal(t, language.BrazilianPortuguese, p.Locale(r))
+
+	require.ErrorIs(t, p.SetLocale(httptest.NewRecorder(), "de"), ErrPreference)
+	require.ErrorIs(t, p.SetLocale(httptest.NewRecorder(), "not a tag"), ErrPreference)
+
+	// an unsupported cookie falls back to negotiation
+	r = withCookie(t, "locale", "zz-<script>")
+	r.Header.Set("Accept-Language", "fr")
+	require.Equal(t, language.French, p.Locale(r))
+
+	cookies, err := cookie.New()
+	require.NoError(t, err)
+	_, err = New(cookies, nil)
+	require.ErrorIs(t, err, cookie.ErrInitiation)
+	_, err = New(cookies, []string{"english"})
+	require.ErrorIs(t, err, cookie.ErrInitiation)
+}
+
+func TestTimezone(t *testing.T) {
+	p := newTestPrefs(t)
+	require.Equal(t, time.UTC, p.Timezone(httptest.NewRequest(http.MethodGet, "/", nil)))
+
+	w := httptest.NewRecorder()
+	require.NoError(t, p.SetTimezone(w, "America/New_York"))
+	require.Equal(t, "America/New_York", p.Timezone(cookietest.RequestFrom(t, w)).String())
+
+	for _, name := range []string{"", "Local", "Mars/Olympus_Mons", "../../etc/passwd"} {
+		require.ErrorIs(t, p.SetTimezone(httptest.NewRecorder(), name), ErrPreference, name)
+	}
+	require.Equal(t, time.UTC, p.Timezone(withCookie(t, "tz", "Local")))
+}
+
+func TestTheme(t *testing.T) {
+	p := newTestPrefs(t, WithThemes("light", "dark"))
+	require.Equal(t, "light", p.Theme(httptest.NewRequest(http.MethodGet, "/", nil)))
+
+	w := httptest.NewRecorder()
+	require.NoError(t, p.SetTheme(w, "dark"))
+	require.Equal(t, "dark", p.Theme(cookietest.RequestFrom(t, w)))
+
+	require.ErrorIs(t, p.SetTheme(httptest.NewRecorder(), "neon"), ErrPreference)
+	require.Equal(t, "light", p.Theme(withCookie(t, "theme", "neon")))
+}
@@ -0,0 +1,203 @@
+// package proxy re-seals cookies at a gateway, so the edge and the origin
+// never share keys. Cookies arriving from clients are opened with the external
+// Manager and sealed again with the internal one before reaching the origin;
+// cookies the origin sets are opened with the internal Manager and sealed
+// again with the external one before reaching the client.
+//
+// Creation stamps, client bindings, and one-time flags do not survive the
+// crossing; each zone checks its own.
+package proxy
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/grackleclub/cookie/v2"
+)
+
+// Proxy re-seals a fixed set of cookies between two Managers.
+type Proxy struct {
+	internal *cookie.Manager
+	external *cookie.Manager
+	cookies  map[string]cookie.Mode
+}
+
+// Option configures a Proxy.
+type Option func(*Proxy) error
+
+// New creates a Proxy between the origin's internal Manager and the edge's
+// external Manager. Only cookies registered with WithCookie are re-sealed;
+// others pass through unchanged.
+func New(internal, external *cookie.Manager, opts ...Option) (*Proxy, error) {
+	if internal == nil || external == nil {
+		return nil, fmt.Errorf("%w: internal and external managers are required", cookie.ErrInitiation)
+	}
+	p := &Proxy{
+		internal: internal,
+		external: external,
+		cookies:  map[string]cookie.Mode{},
+	}
+	for _, opt := range opts {
+		if err := opt(p); err != nil {
+			return nil, fmt.Errorf("%w: %w", cookie.ErrInitiation, err)
+		}
+	}
+	return p, nil
+}
+
+// WithCookie registers a signed or encrypted cookie, by its unprefixed name, for re-sealing.
+func WithCookie(name string, mode cookie.Mode) Option {
+	return func(p *Proxy) error {
+		if mode != cookie.Signed && mode != cookie.Encrypted {
+			return fmt.Errorf("unsupported mode for '%s': %s", name, mode)
+		}
+		p.cookies[name] = mode
+		return nil
+	}
+}
+
+// Inbound rewrites r's Cookie header for the origin, replacing each registered
+// cookie sealed by the external Manager with the same value sealed by the
+// internal one. Cookies that fail to open are dropped, so the origin sees
+// them as missing. Use it in httputil.ReverseProxy's Rewrite or Director.
+func (p *Proxy) Inbound(r *http.Request) error {
+	cookies := r.Cookies()
+	var sealed []*http.Cookie
+	for name, mode := range p.cookies {
+		cookies = without(cookies, p.external.Cookie(name, "").Name)
+		values, err := p.external.ReadAll(r, mode, name)
+		if err != nil {
+			continue
+		}
+		lines, err := reseal(p.internal, name, values[name], mode, nil)
+		if err != nil {
+			return err
+		}
+		for _, line := range lines {
+			c, err := http.ParseSetCookie(line)
+			if err != nil {
+				return fmt.Errorf("%w: %w", cookie.ErrCookie, err)
+			}
+			if c.MaxAge >= 0 {
+				sealed = append(sealed, &http.Cookie{Name: c.Name, Value: c.Value})
+			}
+		}
+	}
+	r.Header.Del("Cookie")
+	for _, c := range append(cookies, sealed...) {
+		r.AddCookie(c)
+	}
+	return nil
+}
+
+// Outbound rewrites the Set-Cookie headers of the origin's response for the
+// client, replacing each registered cookie sealed by the internal Manager with
+// the same value sealed by the external one, keeping its Max-Age and Expires.
+// Deleted cookies are deleted in the external zone. It fails if the origin
+// sets a registered cookie that does not open. Use it as
+// httputil.ReverseProxy's ModifyResponse.
+func (p *Proxy) Outbound(resp *http.Response) error {
+	var errs []error
+	for name, mode := range p.cookies {
+		internalName := p.internal.Cookie(name, "").Name
+		var kept, matched []string
+		for _, line := range resp.Header.Values("Set-Cookie") {
+			c, err := http.ParseSetCookie(line)
+			if err == nil && isChunkOf(c.Name, internalName) {
+				matched = append(matched, line)
+			} else {
+				kept = append(kept, line)
+			}
+		}
+		if len(matched) == 0 {
+			continue
+		}
+		lines, err := p.outbound(name, mode, matched)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("'%s': %w", name, err))
+			lines = nil
+		}
+		resp.Header["Set-Cookie"] = append(kept, lines...)
+	}
+	return errors.Join(errs...)
+}
+
+// outbound re-seals the Set-Cookie lines of one registered cookie.
+func (p *Proxy) outbound(name string, mode cookie.Mode, lines []string) ([]string, error) {
+	probe, err := http.NewRequest(http.MethodGet, "/", nil)
+	if err != nil {
+		return nil, err
+	}
+	var first *http.Cookie
+	for _, line := range lines {
+		c, err := http.ParseSetCookie(line)
+		if err != nil || c.MaxAge < 0 {
+			continue
+		}
+		if first == nil {
+			first = c
+		}
+		probe.AddCookie(&http.Cookie{Name: c.Name, Value: c.Value})
+	}
+	if first == nil {
+		w := &headerWriter{header: http.Header{}}
+		p.external.Delete(w, name)
+		return w.header.Values("Set-Cookie"), nil
+	}
+	values, err := p.internal.ReadAll(probe, mode, name)
+	if err != nil {
+		return nil, err
+	}
+	return reseal(p.external, name, values[name], mode, first)
+}
+
+// reseal seals value with m, returning the Set-Cookie lines written.
+// Max-Age and Expires are taken from like, when given.
+func reseal(m *cookie.Manager, name, value string, mode cookie.Mode, like *http.Cookie) ([]string, error) {
+	c := m.Cookie(name, value)
+	if like != nil {
+		c.MaxAge = like.MaxAge
+		c.Expires = like.Expires
+	}
+	w := &headerWriter{header: http.Header{}}
+	if err := m.WriteAll(w, []http.Cookie{c}, mode); err != nil {
+		return nil, err
+	}
+	return w.header.Values("Set-Cookie"), nil
+}
+
+// without returns cookies without the one named name and its chunks.
+func without(cookies []*http.Cookie, name string) []*http.Cookie {
+	kept := cookies[:0]
+	for _, c := range cookies {
+		if !isChunkOf(c.Name, name) {
+			kept = append(kept, c)
+		}
+	}
+	return kept
+}
+
+// isChunkOf reports whether candidate is name or one of its chunks.
+func isChunkOf(candidate, name string) bool {
+	if candidate == name {
+		return true
+	}
+	index, ok := strings.CutPrefix(candidate, name+".")
+	if !ok {
+		return false
+	}
+	_, err := strconv.Atoi(index)
+	return err == nil
+}
+
+// headerWriter collects the headers written by a Manager; bodies are discarded.
+type headerWriter struct {
+	header http.Header
+}
+
+func (w *headerWriter) Header() http.Header         { return w.header }
+func (w *headerWriter) Write(b []byte) (int, error) { return len(b), nil }
+func (w *headerWriter) WriteHeader(int)             {}
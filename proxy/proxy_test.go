@@ -0,0 +1,120 @@
+package proxy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/http/httputil"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/grackleclub/cookie/v2"
+	"github.com/grackleclub/cookie/v2/cookietest"
+	"github.com/stretchr/testify/require"
+)
+
+// requestWith returns a request carrying every live cookie set on resp.
+func requestWith(target string, resp *http.Response) *http.Request {
+	r := httptest.NewRequest(http.MethodGet, target, nil)
+	for _, c := range resp.Cookies() {
+		if c.MaxAge >= 0 {
+			r.AddCookie(c)
+		}
+	}
+	return r
+}
+
+func TestProxy(t *testing.T) {
+	internal := cookietest.NewManager(t, cookie.WithChunking(16<<10))
+	external := cookietest.NewManager(t, cookie.WithChunking(16<<10))
+	large := strings.Repeat("x", 6000)
+
+	origin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/login":
+			require.NoError(t, internal.WriteEncryptedValue(w, "session", "alice"))
+			require.NoError(t, internal.WriteSigned(w, "large", large))
+			http.SetCookie(w, &http.Cookie{Name: "theme", Value: "dark"})
+		case "/logout":
+			internal.Delete(w, "session")
+		case "/forged":
+			http.SetCookie(w, &http.Cookie{Name: "session", Value: "forged"})
+		default:
+			user, err := internal.ReadEncryptedValue(r, "session")
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusUnauthorized)
+				return
+			}
+			value, err := internal.ReadSigned(r, "large")
+			require.NoError(t, err)
+			require.Equal(t, large, value)
+			theme, err := r.Cookie("theme")
+			require.NoError(t, err)
+			w.Write([]byte(user + " " + theme.Value))
+		}
+	}))
+	defer origin.Close()
+	target, err := url.Parse(origin.URL)
+	require.NoError(t, err)
+
+	p, err := New(internal, external,
+		WithCookie("session", cookie.Encrypted),
+		WithCookie("large", cookie.Signed),
+	)
+	require.NoError(t, err)
+	gateway := &httputil.ReverseProxy{
+		Rewrite: func(pr *httputil.ProxyRequest) {
+			pr.SetURL(target)
+			require.NoError(t, p.Inbound(pr.Out))
+		},
+		ModifyResponse: p.Outbound,
+	}
+
+	w := httptest.NewRecorder()
+	gateway.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/login", nil))
+	login := w.Result()
+	require.Equal(t, http.StatusOK, login.StatusCode)
+
+	// the client holds only cookies sealed by the external Manager
+	session, err := external.ReadEncryptedValue(requestWith("/", login), "session")
+	require.NoError(t, err)
+	require.Equal(t, "alice", session)
+	_, err = internal.ReadEncryptedValue(requestWith("/", login), "session")
+	require.Error(t, err)
+
+	w = httptest.NewRecorder()
+	gateway.ServeHTTP(w, requestWith("/", login))
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, "alice dark", w.Body.String())
+
+	// a cookie sealed by the internal Manager is not accepted from the client
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	rec := httptest.NewRecorder()
+	require.NoError(t, internal.WriteEncryptedValue(rec, "session", "mallory"))
+	for _, c := range rec.Result().Cookies() {
+		r.AddCookie(c)
+	}
+	w = httptest.NewRecorder()
+	gateway.ServeHTTP(w, r)
+	require.Equal(t, http.StatusUnauthorized, w.Code)
+
+	w = httptest.NewRecorder()
+	gateway.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/logout", nil))
+	cookies := w.Result().Cookies()
+	require.Len(t, cookies, 2) // with chunking, the chunk count is deleted too
+	require.Equal(t, "session", cookies[0].Name)
+	require.Negative(t, cookies[0].MaxAge)
+	require.Negative(t, cookies[1].MaxAge)
+
+	w = httptest.NewRecorder()
+	gateway.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/forged", nil))
+	require.Equal(t, http.StatusBadGateway, w.Code)
+	require.Empty(t, w.Result().Cookies())
+}
+
+func TestNew(t *testing.T) {
+	_, err := New(nil, cookietest.NewManager(t))
+	require.ErrorIs(t, err, cookie.ErrInitiation)
+	_, err = New(cookietest.NewManager(t), cookietest.NewManager(t), WithCookie("theme", cookie.Plain))
+	require.ErrorIs(t, err, cookie.ErrInitiation)
+}
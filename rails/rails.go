@@ -0,0 +1,247 @@
+// package rails reads the encrypted and signed cookies of a Rails 6 or later
+// application, so Go services split from a Rails monolith can trust the
+// cookies it issues.
+//
+// Keys are derived from secret_key_base with PBKDF2 and the standard salts,
+// under both the SHA-256 key digest of Rails 7 and the SHA-1 of Rails 6.
+// Values must use the :json cookie serializer; Marshal payloads cannot be read.
+package rails
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/grackleclub/cookie/v2"
+	"golang.org/x/crypto/pbkdf2"
+)
+
+const (
+	defaultEncryptedSalt = "authenticated encrypted cookie"
+	defaultSignedSalt    = "signed cookie"
+	iterations           = 1000 // used by Rails.application.key_generator
+	encryptKeyLength     = 32   // aes-256-gcm
+	signKeyLength        = 64
+	separator            = "--"
+	marshalHeader        = "\x04\x08" // Marshal format version 4.8
+)
+
+// ErrMarshal is returned for values written with Ruby's Marshal serializer.
+var ErrMarshal = errors.New("marshal-serialized cookie not supported")
+
+// Reader decrypts and verifies Rails cookies.
+type Reader struct {
+	cookies       *cookie.Manager
+	encryptedSalt string
+	signedSalt    string
+	encryptKeys   [][]byte // one per key digest, newest Rails default first
+	signKeys      [][]byte
+	now           func() time.Time // replaced in tests
+}
+
+// Option configures a Reader.
+type Option func(*Reader) error
+
+// New creates a Reader for the application with secretKeyBase.
+// Cookie names are looked up with the cookie.Manager's name prefix.
+func New(cookies *cookie.Manager, secretKeyBase []byte, opts ...Option) (*Reader, error) {
+	if cookies == nil {
+		return nil, fmt.Errorf("%w: cookie manager is required", cookie.ErrInitiation)
+	}
+	if len(secretKeyBase) == 0 {
+		return nil, fmt.Errorf("%w: %w", cookie.ErrInitiation, cookie.ErrSecretMissing)
+	}
+	rd := &Reader{
+		cookies:       cookies,
+		encryptedSalt: defaultEncryptedSalt,
+		signedSalt:    defaultSignedSalt,
+		now:           cookies.Now,
+	}
+	for _, opt := range opts {
+		if err := opt(rd); err != nil {
+			return nil, fmt.Errorf("%w: %w", cookie.ErrInitiation, err)
+		}
+	}
+	for _, digest := range []func() hash.Hash{sha256.New, sha1.New} {
+		rd.encryptKeys = append(rd.encryptKeys, pbkdf2.Key(secretKeyBase, []byte(rd.encryptedSalt), iterations, encryptKeyLength, digest))
+		rd.signKeys = append(rd.signKeys, pbkdf2.Key(secretKeyBase, []byte(rd.signedSalt), iterations, signKeyLength, digest))
+	}
+	return rd, nil
+}
+
+// WithEncryptedSalt sets the salt of encrypted cookies, as
+// config.action_dispatch.authenticated_encrypted_cookie_salt.
+func WithEncryptedSalt(salt string) Option {
+	return func(rd *Reader) error {
+		if salt == "" {
+			return errors.New("empty encrypted cookie salt")
+		}
+		rd.encryptedSalt = salt
+		return nil
+	}
+}
+
+// WithSignedSalt sets the salt of signed cookies, as
+// config.action_dispatch.signed_cookie_salt.
+func WithSignedSalt(salt string) Option {
+	return func(rd *Reader) error {
+		if salt == "" {
+			return errors.New("empty signed cookie salt")
+		}
+		rd.signedSalt = salt
+		return nil
+	}
+}
+
+// ReadEncrypted decrypts the named cookie, as cookies.encrypted[name],
+// decoding its JSON value into dst.
+func (rd *Reader) ReadEncrypted(r *http.Request, name string, dst any) error {
+	fullName, value, err := rd.read(r, name)
+	if err != nil {
+		return err
+	}
+	return rd.Decrypt(fullName, value, dst)
+}
+
+// ReadSigned verifies the named cookie, as cookies.signed[name],
+// decoding its JSON value into dst.
+func (rd *Reader) ReadSigned(r *http.Request, name string, dst any) error {
+	fullName, value, err := rd.read(r, name)
+	if err != nil {
+		return err
+	}
+	return rd.Verify(fullName, value, dst)
+}
+
+// Decrypt decrypts an unescaped encrypted cookie value of the named cookie.
+func (rd *Reader) Decrypt(name, value string, dst any) error {
+	parts := strings.Split(value, separator)
+	if len(parts) != 3 {
+		return fmt.Errorf("%w: %w: expected data--iv--tag", cookie.ErrCookie, cookie.ErrTooShort)
+	}
+	var fields [3][]byte
+	for i, part := range parts {
+		b, err := base64.StdEncoding.DecodeString(part)
+		if err != nil {
+			return fmt.Errorf("%w: cannot decode '%s': %w", cookie.ErrCookie, name, err)
+		}
+		fields[i] = b
+	}
+	data, iv, tag := fields[0], fields[1], fields[2]
+	if len(iv) != 12 || len(tag) != 16 {
+		return fmt.Errorf("%w: %w: invalid iv or tag length", cookie.ErrCookie, cookie.ErrDecryptFailed)
+	}
+	sealed := append(data[:len(data):len(data)], tag...)
+	for _, key := range rd.encryptKeys {
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return fmt.Errorf("%w: %w", cookie.ErrEncryption, err)
+		}
+		gcm, err := cipher.NewGCM(block)
+		if err != nil {
+			return fmt.Errorf("%w: %w", cookie.ErrEncryption, err)
+		}
+		plaintext, err := gcm.Open(nil, iv, sealed, nil)
+		if err == nil {
+			return rd.unwrap(name, plaintext, dst)
+		}
+	}
+	return fmt.Errorf("%w: %w", cookie.ErrCookie, cookie.ErrDecryptFailed)
+}
+
+// Verify checks an unescaped signed cookie value of the named cookie.
+// The HMAC digest, SHA-1 or SHA-256, is chosen by the signature's length.
+func (rd *Reader) Verify(name, value string, dst any) error {
+	data, signature, ok := strings.Cut(value, separator)
+	if !ok {
+		return fmt.Errorf("%w: %w: expected data--digest", cookie.ErrCookie, cookie.ErrTooShort)
+	}
+	mac, err := hex.DecodeString(signature)
+	if err != nil {
+		return fmt.Errorf("%w: %w", cookie.ErrCookie, cookie.ErrSignatureMismatch)
+	}
+	digest := sha1.New
+	if len(mac) == sha256.Size {
+		digest = sha256.New
+	}
+	for _, key := range rd.signKeys {
+		h := hmac.New(digest, key)
+		h.Write([]byte(data))
+		if hmac.Equal(mac, h.Sum(nil)) {
+			plaintext, err := base64.StdEncoding.DecodeString(data)
+			if err != nil {
+				return fmt.Errorf("%w: cannot decode '%s': %w", cookie.ErrCookie, name, err)
+			}
+			return rd.unwrap(name, plaintext, dst)
+		}
+	}
+	return fmt.Errorf("%w: %w", cookie.ErrCookie, cookie.ErrSignatureMismatch)
+}
+
+// read returns the prefixed name and unescaped value of the named cookie.
+func (rd *Reader) read(r *http.Request, name string) (string, string, error) {
+	fullName := rd.cookies.Cookie(name, "").Name
+	c, err := r.Cookie(fullName)
+	if err != nil {
+		return "", "", fmt.Errorf("%w: '%s': %w", cookie.ErrNotFound, fullName, err)
+	}
+	value, err := url.QueryUnescape(c.Value)
+	if err != nil {
+		return "", "", fmt.Errorf("%w: cannot decode '%s': %w", cookie.ErrCookie, fullName, err)
+	}
+	return fullName, value, nil
+}
+
+// envelope is the metadata Rails wraps cookie values in. Rails 6 and 7.0
+// store the base64 serialized value as message; Rails 7.1 embeds it as data.
+type envelope struct {
+	Rails *struct {
+		Message []byte          `json:"message"`
+		Data    json.RawMessage `json:"data"`
+		Expires *string         `json:"exp"`
+		Purpose string          `json:"pur"`
+	} `json:"_rails"`
+}
+
+// unwrap checks the metadata of plaintext and decodes its value into dst.
+// Values written without metadata are decoded as they are.
+func (rd *Reader) unwrap(name string, plaintext []byte, dst any) error {
+	var env envelope
+	if err := json.Unmarshal(plaintext, &env); err == nil && env.Rails != nil {
+		if env.Rails.Purpose != "cookie."+name {
+			return fmt.Errorf("%w: '%s' purpose '%s' does not match", cookie.ErrCookie, name, env.Rails.Purpose)
+		}
+		if env.Rails.Expires != nil {
+			expires, err := time.Parse(time.RFC3339, *env.Rails.Expires)
+			if err != nil {
+				return fmt.Errorf("%w: invalid expiry: %w", cookie.ErrCookie, err)
+			}
+			if !rd.now().Before(expires) {
+				return fmt.Errorf("%w: %w at %s", cookie.ErrCookie, cookie.ErrExpired, expires.UTC().Format(time.RFC3339))
+			}
+		}
+		plaintext = env.Rails.Message
+		if env.Rails.Data != nil {
+			plaintext = env.Rails.Data
+		}
+	}
+	if strings.HasPrefix(string(plaintext), marshalHeader) {
+		return fmt.Errorf("%w: '%s': %w", cookie.ErrCookie, name, ErrMarshal)
+	}
+	if err := json.Unmarshal(plaintext, dst); err != nil {
+		return fmt.Errorf("%w: unable to decode '%s': %w", cookie.ErrCookie, name, err)
+	}
+	return nil
+}
@@ -0,0 +1,133 @@
+package rails
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/grackleclub/cookie/v2"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/pbkdf2"
+)
+
+var secretKeyBase = []byte(strings.Repeat("a", 128))
+
+// encrypted by the aes-256-gcm MessageEncryptor of Rails 7 and Rails 6,
+// as cookies.encrypted[:session] = { user_id: 42 }
+const (
+	rails7Vector = "UP4EOOkTWqGgCO4abslwb4Epkqmrs9P1qcM4hau3wjDDCDOKkC%2FP5W7jdhaF8zbw1ynuLNF%2Fe0UeUs4jRh7Vqhu3nJQyU0fJBxpDVROd6A%3D%3D--BwcHBwcHBwcHBwcH--yEmqTI9V1mKXSnelCmhKqg%3D%3D"
+	rails6Vector = "HSwAoVxhYOud4hHEVRk3kSWw9U023YwORI8DGB7foXmPuHrEZl2ai2CNblvtSzR0AHMtO2d4WMZUhDdVBrctlad%2FPVVrMcX%2Fjr7oQoCQmw%3D%3D--BwcHBwcHBwcHBwcH--BueEP63wFKdQFt2T3CF0Kw%3D%3D"
+)
+
+// signed by the Rails 6 MessageVerifier, as cookies.signed[:user] = "alice"
+const signedVector = "eyJfcmFpbHMiOnsibWVzc2FnZSI6IkltRnNhV05sSWc9PSIsImV4cCI6bnVsbCwicHVyIjoiY29va2llLnVzZXIifX0%3D--b6bb09f4fce59d9d8eea2be8d71663d379144b8b"
+
+func newReader(t *testing.T) *Reader {
+	t.Helper()
+	m, err := cookie.New()
+	require.NoError(t, err)
+	rd, err := New(m, secretKeyBase)
+	require.NoError(t, err)
+	return rd
+}
+
+func request(name, value string) *http.Request {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Cookie", name+"="+value)
+	return r
+}
+
+// encrypt seals plaintext as Rails 7 does.
+func encrypt(t *testing.T, plaintext string) string {
+	t.Helper()
+	key := pbkdf2.Key(secretKeyBase, []byte(defaultEncryptedSalt), iterations, encryptKeyLength, sha256.New)
+	block, err := aes.NewCipher(key)
+	require.NoError(t, err)
+	gcm, err := cipher.NewGCM(block)
+	require.NoError(t, err)
+	iv := make([]byte, gcm.NonceSize())
+	sealed := gcm.Seal(nil, iv, []byte(plaintext), nil)
+	data, tag := sealed[:len(sealed)-gcm.Overhead()], sealed[len(sealed)-gcm.Overhead():]
+	value := strings.Join([]string{
+		base64.StdEncoding.EncodeToString(data),
+		base64.StdEncoding.EncodeToString(iv),
+		base64.StdEncoding.EncodeToString(tag),
+	}, separator)
+	return url.QueryEscape(value)
+}
+
+func TestReadEncrypted(t *testing.T) {
+	rd := newReader(t)
+	for name, vector := range map[string]string{"rails 7": rails7Vector, "rails 6": rails6Vector} {
+		t.Run(name, func(t *testing.T) {
+			var got struct {
+				UserID int `json:"user_id"`
+			}
+			require.NoError(t, rd.ReadEncrypted(request("session", vector), "session", &got))
+			require.Equal(t, 42, got.UserID)
+		})
+	}
+
+	var got map[string]any
+	err := rd.ReadEncrypted(request("other", rails7Vector), "other", &got)
+	require.ErrorIs(t, err, cookie.ErrCookie)
+
+	tampered := strings.Replace(rails7Vector, "UP4", "UP5", 1)
+	err = rd.ReadEncrypted(request("session", tampered), "session", &got)
+	require.ErrorIs(t, err, cookie.ErrDecryptFailed)
+
+	err = rd.ReadEncrypted(httptest.NewRequest(http.MethodGet, "/", nil), "session", &got)
+	require.ErrorIs(t, err, cookie.ErrNotFound)
+}
+
+func TestMetadata(t *testing.T) {
+	rd := newReader(t)
+	rd.now = func() time.Time { return time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC) }
+
+	var got string
+	value := encrypt(t, `{"_rails":{"data":"alice","pur":"cookie.user"}}`)
+	require.NoError(t, rd.ReadEncrypted(request("user", value), "user", &got))
+	require.Equal(t, "alice", got)
+
+	value = encrypt(t, `{"_rails":{"message":"ImFsaWNlIg==","exp":"2023-12-31T23:59:59.000Z","pur":"cookie.user"}}`)
+	require.ErrorIs(t, rd.ReadEncrypted(request("user", value), "user", &got), cookie.ErrExpired)
+
+	value = encrypt(t, `{"_rails":{"message":"ImFsaWNlIg==","exp":"2024-01-02T00:00:00.000Z","pur":"cookie.user"}}`)
+	require.NoError(t, rd.ReadEncrypted(request("user", value), "user", &got))
+
+	value = encrypt(t, "\x04\x08I\"\x0aalice\x06:\x06ET")
+	require.ErrorIs(t, rd.ReadEncrypted(request("user", value), "user", &got), ErrMarshal)
+}
+
+func TestReadSigned(t *testing.T) {
+	rd := newReader(t)
+	var got string
+	require.NoError(t, rd.ReadSigned(request("user", signedVector), "user", &got))
+	require.Equal(t, "alice", got)
+
+	tampered := strings.Replace(signedVector, "b6bb", "b6bc", 1)
+	require.ErrorIs(t, rd.ReadSigned(request("user", tampered), "user", &got), cookie.ErrSignatureMismatch)
+}
+
+func TestNew(t *testing.T) {
+	m, err := cookie.New()
+	require.NoError(t, err)
+	_, err = New(nil, secretKeyBase)
+	require.ErrorIs(t, err, cookie.ErrInitiation)
+	_, err = New(m, nil)
+	require.ErrorIs(t, err, cookie.ErrSecretMissing)
+	_, err = New(m, secretKeyBase, WithEncryptedSalt(""))
+	require.ErrorIs(t, err, cookie.ErrInitiation)
+
+	rd, err := New(m, secretKeyBase, WithEncryptedSalt("custom"))
+	require.NoError(t, err)
+	var got map[string]any
+	require.ErrorIs(t, rd.ReadEncrypted(request("session", rails7Vector), "session", &got), cookie.ErrDecryptFailed)
+}
@@ -0,0 +1,177 @@
+// package ratelimit keeps a per-client token bucket in a signed cookie, so edge
+// servers can rate limit without shared state. Clients cannot add tokens, but
+// can drop the cookie or replay an older one for a fresh bucket, so pair it
+// with a server-side limit where abuse matters; it throttles well-behaved
+// clients, such as browsers retrying in a loop, for free.
+package ratelimit
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/grackleclub/cookie/v2"
+)
+
+const (
+	defaultName = "ratelimit"
+	defaultSkew = 5 * time.Second
+)
+
+var ErrLimit = errors.New("rate limit state invalid")
+
+// stored is a client's token bucket as of its last refill.
+type stored struct {
+	Tokens float64 `json:"t"`
+	Last   int64   `json:"l"` // unix milliseconds of the last refill
+}
+
+// Limiter allows a burst of requests, then one request per interval.
+type Limiter struct {
+	cookies *cookie.Manager
+	name    string
+	burst   float64
+	every   time.Duration
+	skew    time.Duration
+	denied  http.Handler
+}
+
+// Option configures a Limiter.
+type Option func(*Limiter) error
+
+// New creates a Limiter whose buckets hold burst tokens, refilled at one per
+// every. Each allowed request takes a token. The cookie.Manager must hold a
+// secret, which is used to sign the bucket cookie.
+func New(cookies *cookie.Manager, burst int, every time.Duration, opts ...Option) (*Limiter, error) {
+	if cookies == nil {
+		return nil, fmt.Errorf("%w: cookie manager is required", cookie.ErrInitiation)
+	}
+	if burst < 1 || every <= 0 {
+		return nil, fmt.Errorf("%w: invalid rate limit %d per %s", cookie.ErrInitiation, burst, every)
+	}
+	l := &Limiter{
+		cookies: cookies,
+		name:    defaultName,
+		burst:   float64(burst),
+		every:   every,
+		skew:    defaultSkew,
+		denied:  http.HandlerFunc(tooManyRequests),
+	}
+	for _, opt := range opts {
+		if err := opt(l); err != nil {
+			return nil, fmt.Errorf("%w: %w", cookie.ErrInitiation, err)
+		}
+	}
+	// a bucket left alone until full is the same as no bucket, so the cookie may lapse
+	full := time.Duration(burst) * every
+	cookies, err := cookies.With(cookie.WithMaxAge(max(full.Round(time.Second), time.Second)))
+	if err != nil {
+		return nil, err
+	}
+	l.cookies = cookies
+	return l, nil
+}
+
+// WithName sets the name of the bucket cookie.
+func WithName(name string) Option {
+	return func(l *Limiter) error {
+		if name == "" {
+			return errors.New("empty rate limit cookie name")
+		}
+		l.name = name
+		return nil
+	}
+}
+
+// WithSkew sets how far in the future a bucket's last refill may be, for
+// buckets refilled by servers whose clocks run ahead. Such buckets gain no
+// tokens until the time passes; buckets further ahead are discarded for a
+// full one. Defaults to 5 seconds.
+func WithSkew(skew time.Duration) Option {
+	return func(l *Limiter) error {
+		if skew < 0 {
+			return fmt.Errorf("negative clock skew: %s", skew)
+		}
+		l.skew = skew
+		return nil
+	}
+}
+
+// WithDeniedHandler sets the handler called by Middleware for limited
+// requests, after Retry-After is set. The default responds 429 Too Many Requests.
+func WithDeniedHandler(h http.Handler) Option {
+	return func(l *Limiter) error {
+		if h == nil {
+			return errors.New("denied handler is nil")
+		}
+		l.denied = h
+		return nil
+	}
+}
+
+// Allow takes a token from the request's bucket, reporting whether there
+// was one, and if not, how long until there will be. A missing or invalid
+// bucket cookie is replaced with a full bucket.
+func (l *Limiter) Allow(w http.ResponseWriter, r *http.Request) (bool, time.Duration, error) {
+	now := l.cookies.Now()
+	tokens := l.tokens(r, now)
+	if tokens < 1 {
+		wait := time.Duration(math.Ceil((1 - tokens) * float64(l.every)))
+		return false, wait, nil
+	}
+	payload := stored{Tokens: tokens - 1, Last: now.UnixMilli()}
+	if err := cookie.WriteJSON(l.cookies, w, l.name, payload, cookie.Signed); err != nil {
+		return false, 0, err
+	}
+	return true, 0, nil
+}
+
+// tokens returns the tokens in the request's bucket at now.
+func (l *Limiter) tokens(r *http.Request, now time.Time) float64 {
+	payload, err := l.read(r, now)
+	if err != nil {
+		return l.burst
+	}
+	elapsed := max(now.Sub(time.UnixMilli(payload.Last)), 0)
+	return min(payload.Tokens+float64(elapsed)/float64(l.every), l.burst)
+}
+
+func (l *Limiter) read(r *http.Request, now time.Time) (stored, error) {
+	payload, err := cookie.ReadJSON[stored](l.cookies, r, l.name, cookie.Signed)
+	if err != nil {
+		return stored{}, err
+	}
+	if payload.Tokens < 0 || payload.Tokens > l.burst {
+		return stored{}, fmt.Errorf("%w: %v tokens", ErrLimit, payload.Tokens)
+	}
+	if time.UnixMilli(payload.Last).After(now.Add(l.skew)) {
+		return stored{}, fmt.Errorf("%w: refilled in the future", ErrLimit)
+	}
+	return payload, nil
+}
+
+// Middleware allows each request through Allow before calling next, passing
+// limited requests to the denied handler with a Retry-After header instead.
+func (l *Limiter) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ok, wait, err := l.Allow(w, r)
+		if err != nil {
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+		if !ok {
+			seconds := int64(math.Ceil(wait.Seconds()))
+			w.Header().Set("Retry-After", strconv.FormatInt(seconds, 10))
+			l.denied.ServeHTTP(w, r)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func tooManyRequests(w http.ResponseWriter, r *http.Request) {
+	http.Error(w, http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
+}
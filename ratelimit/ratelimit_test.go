@@ -0,0 +1,129 @@
+package ratelimit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/grackleclub/cookie/v2"
+	"github.com/grackleclub/cookie/v2/cookietest"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestLimiter(t *testing.T, burst int, every time.Duration, opts ...Option) (*Limiter, *cookietest.Clock) {
+	t.Helper()
+	secretKey, err := cookie.NewCookieSecret()
+	require.NoError(t, err)
+	clock := cookietest.NewClock(time.Unix(1_700_000_000, 0))
+	cookies, err := cookie.New(cookie.WithSecret(secretKey), cookie.WithClock(clock))
+	require.NoError(t, err)
+	l, err := New(cookies, burst, every, opts...)
+	require.NoError(t, err)
+	return l, clock
+}
+
+// client carries the latest bucket cookie between requests.
+type client struct {
+	t      *testing.T
+	bucket *http.Cookie
+}
+
+func (c *client) request() *http.Request {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	if c.bucket != nil {
+		r.AddCookie(c.bucket)
+	}
+	return r
+}
+
+func (c *client) allow(l *Limiter) (bool, time.Duration) {
+	c.t.Helper()
+	w := httptest.NewRecorder()
+	ok, wait, err := l.Allow(w, c.request())
+	require.NoError(c.t, err)
+	if cookies := w.Result().Cookies(); len(cookies) > 0 {
+		c.bucket = cookies[0]
+	}
+	return ok, wait
+}
+
+func TestAllow(t *testing.T) {
+	l, clock := newTestLimiter(t, 3, time.Second)
+	c := &client{t: t}
+
+	for range 3 {
+		ok, _ := c.allow(l)
+		require.True(t, ok)
+	}
+	require.Equal(t, 3, c.bucket.MaxAge)
+	ok, wait := c.allow(l)
+	require.False(t, ok)
+	require.Equal(t, time.Second, wait)
+
+	clock.Advance(400 * time.Millisecond)
+	ok, wait = c.allow(l)
+	require.False(t, ok)
+	require.Equal(t, 600*time.Millisecond, wait)
+
+	clock.Advance(600 * time.Millisecond)
+	ok, _ = c.allow(l)
+	require.True(t, ok)
+
+	// refills stop at the burst
+	clock.Advance(time.Hour)
+	for range 3 {
+		ok, _ = c.allow(l)
+		require.True(t, ok)
+	}
+	ok, _ = c.allow(l)
+	require.False(t, ok)
+
+	// a bucket signed with another secret is replaced
+	other, _ := newTestLimiter(t, 3, time.Second)
+	ok, _ = c.allow(other)
+	require.True(t, ok)
+}
+
+func TestSkew(t *testing.T) {
+	l, clock := newTestLimiter(t, 1, time.Second, WithSkew(2*time.Second))
+	c := &client{t: t}
+	ok, _ := c.allow(l)
+	require.True(t, ok)
+
+	// a server running a second behind gives no tokens for negative time
+	clock.Advance(-time.Second)
+	ok, wait := c.allow(l)
+	require.False(t, ok)
+	require.Equal(t, time.Second, wait)
+
+	// refills too far in the future are discarded
+	clock.Advance(-time.Minute)
+	ok, _ = c.allow(l)
+	require.True(t, ok)
+
+	_, err := New(l.cookies, 1, time.Second, WithSkew(-time.Second))
+	require.ErrorIs(t, err, cookie.ErrInitiation)
+	_, err = New(l.cookies, 0, time.Second)
+	require.ErrorIs(t, err, cookie.ErrInitiation)
+}
+
+func TestMiddleware(t *testing.T) {
+	l, _ := newTestLimiter(t, 1, 90*time.Second)
+	calls := 0
+	handler := l.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+	}))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.AddCookie(w.Result().Cookies()[0])
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	require.Equal(t, http.StatusTooManyRequests, w.Code)
+	require.Equal(t, "90", w.Header().Get("Retry-After"))
+	require.Equal(t, 1, calls)
+}
@@ -0,0 +1,27 @@
+package cookie
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"sync/atomic"
+)
+
+var verboseErrors atomic.Bool
+
+// SetVerboseErrors controls whether errors quote the cookie values they describe.
+// By default values are redacted to a short hash, which still tells two values
+// apart, so that session keys and other secrets do not end up in logs.
+// Enable verbose errors only for local debugging.
+func SetVerboseErrors(verbose bool) {
+	verboseErrors.Store(verbose)
+}
+
+// redact returns value quoted if errors are verbose, or a short hash of it otherwise.
+func redact(value string) string {
+	if verboseErrors.Load() {
+		return strconv.Quote(value)
+	}
+	sum := sha256.Sum256([]byte(value))
+	return "[redacted " + hex.EncodeToString(sum[:4]) + "]"
+}
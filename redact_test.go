@@ -0,0 +1,28 @@
+package cookie
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedact(t *testing.T) {
+	r := httptest.NewRequest("GET", "/", nil)
+	r.AddCookie(&http.Cookie{Name: "session", Value: "s3cr3t-token!"})
+	_, err := Read(r, "session")
+	require.Error(t, err)
+	require.NotContains(t, err.Error(), "s3cr3t")
+	require.Contains(t, err.Error(), "[redacted ")
+
+	_, _, err = splitUserID("alice:s3cr3t-token")
+	require.ErrorIs(t, err, ErrCookie)
+	require.NotContains(t, err.Error(), "s3cr3t")
+	require.NotContains(t, err.Error(), "alice")
+
+	SetVerboseErrors(true)
+	t.Cleanup(func() { SetVerboseErrors(false) })
+	_, err = Read(r, "session")
+	require.Contains(t, err.Error(), `"s3cr3t-token!"`)
+}
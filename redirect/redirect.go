@@ -0,0 +1,213 @@
+// package redirect keeps the URL to return to after login in a short-lived
+// signed cookie, and checks it against an allowlist of hosts and paths when
+// read, so a crafted login link cannot send the user to another site.
+package redirect
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/grackleclub/cookie/v2"
+)
+
+const (
+	defaultName = "return_to"
+	defaultTTL  = 15 * time.Minute
+)
+
+var ErrRedirect = errors.New("redirect target not allowed")
+
+// Redirector saves and checks post-login redirect targets.
+type Redirector struct {
+	cookies *cookie.Manager
+	name    string
+	ttl     time.Duration
+	hosts   []string // absolute targets must match one; none allows only relative targets
+	paths   []string // targets must start with one; none allows any path
+}
+
+// Option configures a Redirector.
+type Option func(*Redirector) error
+
+// New creates a Redirector. The cookie.Manager must hold a secret,
+// which is used to sign the redirect cookie. Without WithHosts,
+// only relative targets on the same site are allowed.
+func New(cookies *cookie.Manager, opts ...Option) (*Redirector, error) {
+	if cookies == nil {
+		return nil, fmt.Errorf("%w: cookie manager is required", cookie.ErrInitiation)
+	}
+	rd := &Redirector{
+		cookies: cookies,
+		name:    defaultName,
+		ttl:     defaultTTL,
+	}
+	for _, opt := range opts {
+		if err := opt(rd); err != nil {
+			return nil, fmt.Errorf("%w: %w", cookie.ErrInitiation, err)
+		}
+	}
+	// the return from an identity provider is a top-level cross-site navigation
+	cookies, err := cookies.With(cookie.WithSameSite(http.SameSiteLaxMode))
+	if err != nil {
+		return nil, err
+	}
+	rd.cookies = cookies
+	return rd, nil
+}
+
+// WithName sets the name of the redirect cookie.
+func WithName(name string) Option {
+	return func(rd *Redirector) error {
+		if name == "" {
+			return errors.New("empty redirect cookie name")
+		}
+		rd.name = name
+		return nil
+	}
+}
+
+// WithTTL sets how long a saved target remains valid. Defaults to 15 minutes.
+func WithTTL(ttl time.Duration) Option {
+	return func(rd *Redirector) error {
+		if ttl < time.Second {
+			return fmt.Errorf("redirect ttl too short: %s", ttl)
+		}
+		rd.ttl = ttl
+		return nil
+	}
+}
+
+// WithHosts allows absolute http and https targets on the given hosts.
+// A host of the form "*.example.com" matches any subdomain of example.com;
+// "*" is allowed nowhere else.
+func WithHosts(hosts ...string) Option {
+	return func(rd *Redirector) error {
+		for _, host := range hosts {
+			domain, _ := strings.CutPrefix(host, "*.")
+			if domain == "" || strings.ContainsAny(domain, "/:@*") {
+				return fmt.Errorf("invalid redirect host: %q", host)
+			}
+			rd.hosts = append(rd.hosts, strings.ToLower(host))
+		}
+		return nil
+	}
+}
+
+// WithPaths allows only targets whose cleaned path starts with one of prefixes,
+// such as "/app/".
+func WithPaths(prefixes ...string) Option {
+	return func(rd *Redirector) error {
+		for _, prefix := range prefixes {
+			if !strings.HasPrefix(prefix, "/") {
+				return fmt.Errorf("redirect path must be absolute: %q", prefix)
+			}
+			rd.paths = append(rd.paths, prefix)
+		}
+		return nil
+	}
+}
+
+// Save stores target in the redirect cookie, failing with ErrRedirect
+// if it is not allowed.
+func (rd *Redirector) Save(w http.ResponseWriter, target string) error {
+	if err := rd.Check(target); err != nil {
+		return err
+	}
+	return rd.cookies.WriteSignedWithExpiry(w, rd.name, target, rd.cookies.Now().Add(rd.ttl))
+}
+
+// SaveRequest stores the path and query of r, typically a request
+// redirected to the login page, as the target.
+func (rd *Redirector) SaveRequest(w http.ResponseWriter, r *http.Request) error {
+	return rd.Save(w, r.URL.RequestURI())
+}
+
+// Target returns the saved target, checking it again against the allowlist.
+func (rd *Redirector) Target(r *http.Request) (string, error) {
+	target, err := rd.cookies.ReadSigned(r, rd.name)
+	if err != nil {
+		return "", err
+	}
+	if err := rd.Check(target); err != nil {
+		return "", err
+	}
+	return target, nil
+}
+
+// Pop returns the saved target, or fallback if there is none or it is not
+// allowed, and deletes the redirect cookie. Call it once login succeeds.
+func (rd *Redirector) Pop(w http.ResponseWriter, r *http.Request, fallback string) string {
+	target, err := rd.Target(r)
+	rd.cookies.Delete(w, rd.name)
+	if err != nil {
+		return fallback
+	}
+	return target
+}
+
+// Check reports whether target is an allowed redirect, failing with ErrRedirect if not.
+// Relative targets must be paths on the same site; scheme-relative targets such as
+// "//evil.example" and backslashes, which browsers read as slashes, are treated
+// as absolute.
+func (rd *Redirector) Check(target string) error {
+	if target == "" || strings.Contains(target, `\`) {
+		return fmt.Errorf("%w: %q", ErrRedirect, target)
+	}
+	u, err := url.Parse(target)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrRedirect, err)
+	}
+	if u.Scheme == "" && u.Host == "" {
+		if !strings.HasPrefix(target, "/") {
+			return fmt.Errorf("%w: relative target must start with '/': %q", ErrRedirect, target)
+		}
+	} else {
+		if u.Scheme != "http" && u.Scheme != "https" {
+			return fmt.Errorf("%w: scheme '%s'", ErrRedirect, u.Scheme)
+		}
+		if u.User != nil || !rd.allowedHost(u.Hostname()) {
+			return fmt.Errorf("%w: host '%s'", ErrRedirect, u.Host)
+		}
+	}
+	if !rd.allowedPath(u.Path) {
+		return fmt.Errorf("%w: path '%s'", ErrRedirect, u.Path)
+	}
+	return nil
+}
+
+func (rd *Redirector) allowedHost(host string) bool {
+	host = strings.ToLower(host)
+	for _, allowed := range rd.hosts {
+		if host == allowed {
+			return true
+		}
+		if domain, ok := strings.CutPrefix(allowed, "*."); ok && strings.HasSuffix(host, "."+domain) {
+			return true
+		}
+	}
+	return false
+}
+
+func (rd *Redirector) allowedPath(p string) bool {
+	if len(rd.paths) == 0 {
+		return true
+	}
+	if p == "" {
+		p = "/"
+	}
+	cleaned := path.Clean(p)
+	if strings.HasSuffix(p, "/") && cleaned != "/" {
+		cleaned += "/"
+	}
+	for _, prefix := range rd.paths {
+		if strings.HasPrefix(cleaned, prefix) {
+			return true
+		}
+	}
+	return false
+}
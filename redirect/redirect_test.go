@@ -0,0 +1,103 @@
+package redirect
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/grackleclub/cookie/v2"
+	"github.com/grackleclub/cookie/v2/cookietest"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestRedirector(t *testing.T, opts ...Option) *Redirector {
+	t.Helper()
+	secretKey, err := cookie.NewCookieSecret()
+	require.NoError(t, err)
+	cookies, err := cookie.New(cookie.WithSecret(secretKey))
+	require.NoError(t, err)
+	rd, err := New(cookies, opts...)
+	require.NoError(t, err)
+	return rd
+}
+
+func TestRedirector(t *testing.T) {
+	rd := newTestRedirector(t, WithName("next"))
+
+	w := httptest.NewRecorder()
+	require.NoError(t, rd.SaveRequest(w, httptest.NewRequest(http.MethodGet, "/orders/7?tab=items", nil)))
+	setCookie := w.Result().Cookies()[0]
+	require.Equal(t, "next", setCookie.Name)
+	require.Equal(t, http.SameSiteLaxMode, setCookie.SameSite)
+
+	r := cookietest.RequestFrom(t, w)
+	target, err := rd.Target(r)
+	require.NoError(t, err)
+	require.Equal(t, "/orders/7?tab=items", target)
+
+	w = httptest.NewRecorder()
+	require.Equal(t, "/orders/7?tab=items", rd.Pop(w, r, "/"))
+	require.Equal(t, -1, w.Result().Cookies()[0].MaxAge)
+
+	require.Equal(t, "/", rd.Pop(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil), "/"))
+	require.ErrorIs(t, rd.Save(httptest.NewRecorder(), "https://evil.example/"), ErrRedirect)
+
+	t.Run("tampered", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		require.NoError(t, rd.Save(w, "/account"))
+		c := w.Result().Cookies()[0]
+		c.Value = c.Value[:len(c.Value)-2] + "AA"
+		require.Equal(t, "/", rd.Pop(httptest.NewRecorder(), cookietest.NewRequest("/", c), "/"))
+	})
+
+	_, err = New(nil)
+	require.ErrorIs(t, err, cookie.ErrInitiation)
+	for _, host := range []string{"example.com/path", "*", "*.", "*example.com", "shop.*.example.com", "*.*.example.com"} {
+		_, err = New(rd.cookies, WithHosts(host))
+		require.ErrorIs(t, err, cookie.ErrInitiation, host)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	rd := newTestRedirector(t, WithHosts("example.com", "*.example.com"), WithPaths("/app/", "/account"))
+	tests := []struct {
+		target string
+		ok     bool
+	}{
+		{"/app/", true},
+		{"/app/orders?id=1#top", true},
+		{"/account", true},
+		{"https://example.com/app/x", true},
+		{"https://shop.EXAMPLE.com/app/x", true},
+		{"http://example.com:8080/account", true},
+		{"", false},
+		{"app/orders", false},
+		{"/admin", false},
+		{"/app/../admin", false},
+		{"/app/%2e%2e/admin", false},
+		{"//evil.example/app/", false},
+		{`/\evil.example/app/`, false},
+		{"https://evil.example/app/", false},
+		{"https://example.com.evil.example/app/", false},
+		{"https://evilexample.com/app/", false},
+		{"https://example.com@evil.example/app/", false},
+		{"https://user@example.com/app/", false},
+		{"https:evil.example", false},
+		{"javascript:alert(1)", false},
+		{"ftp://example.com/app/", false},
+		{"/app/\nSet-Cookie: x=y", false},
+	}
+	for _, tt := range tests {
+		err := rd.Check(tt.target)
+		if tt.ok {
+			require.NoError(t, err, tt.target)
+		} else {
+			require.ErrorIs(t, err, ErrRedirect, tt.target)
+		}
+	}
+
+	// without WithHosts, only relative targets are allowed
+	rd = newTestRedirector(t)
+	require.NoError(t, rd.Check("/anywhere"))
+	require.ErrorIs(t, rd.Check("https://example.com/"), ErrRedirect)
+}
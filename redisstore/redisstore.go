@@ -0,0 +1,85 @@
+// package redisstore implements the cookie package's shared stores on Redis,
+// so one-time values and revocations hold across every server in a deployment.
+package redisstore
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const defaultPrefix = "cookie:"
+
+// Store is a cookie.ReplayStore and cookie.RevocationStore backed by Redis.
+type Store struct {
+	client redis.UniversalClient
+	prefix string
+}
+
+// Option configures a Store.
+type Option func(*Store)
+
+// WithPrefix sets the prefix of every key the Store writes. Defaults to "cookie:".
+func WithPrefix(prefix string) Option {
+	return func(s *Store) {
+		s.prefix = prefix
+	}
+}
+
+// New creates a Store using client, which may be a single node, ring, or cluster client.
+func New(client redis.UniversalClient, opts ...Option) *Store {
+	s := &Store{client: client, prefix: defaultPrefix}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Claim implements cookie.ReplayStore with SET NX, expiring the key with the value.
+func (s *Store) Claim(ctx context.Context, id string, expires time.Time) (bool, error) {
+	err := s.client.SetArgs(ctx, s.prefix+"replay:"+id, 1, redis.SetArgs{
+		Mode:     "NX",
+		ExpireAt: expires,
+	}).Err()
+	if errors.Is(err, redis.Nil) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// Revoke implements cookie.RevocationStore, keeping the latest revocation time of subject.
+func (s *Store) Revoke(ctx context.Context, subject string, at, expires time.Time) error {
+	key := s.prefix + "revoked:" + subject
+	// a Lua script keeps the later of two concurrent revocations
+	return revoke.Run(ctx, s.client, []string{key}, at.Unix(), expires.UnixMilli()).Err()
+}
+
+// RevokedAt implements cookie.RevocationStore.
+func (s *Store) RevokedAt(ctx context.Context, subject string) (time.Time, error) {
+	value, err := s.client.Get(ctx, s.prefix+"revoked:"+subject).Result()
+	if errors.Is(err, redis.Nil) {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, err
+	}
+	unix, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(unix, 0), nil
+}
+
+var revoke = redis.NewScript(`
+local current = tonumber(redis.call("GET", KEYS[1]) or "0")
+local at = tonumber(ARGV[1])
+if current > at then
+	at = current
+end
+redis.call("SET", KEYS[1], at)
+redis.call("PEXPIREAT", KEYS[1], ARGV[2])
+return at
+`)
@@ -0,0 +1,77 @@
+package redisstore
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/grackleclub/cookie/v2"
+	"github.com/grackleclub/cookie/v2/cookietest"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestStore(t *testing.T) (*Store, *miniredis.Miniredis) {
+	t.Helper()
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+	return New(client, WithPrefix("test:")), server
+}
+
+func TestClaim(t *testing.T) {
+	ctx := context.Background()
+	s, server := newTestStore(t)
+
+	ok, err := s.Claim(ctx, "a", time.Now().Add(time.Minute))
+	require.NoError(t, err)
+	require.True(t, ok)
+	ok, err = s.Claim(ctx, "a", time.Now().Add(time.Minute))
+	require.NoError(t, err)
+	require.False(t, ok)
+	require.True(t, server.Exists("test:replay:a"))
+
+	server.FastForward(2 * time.Minute)
+	ok, err = s.Claim(ctx, "a", time.Now().Add(time.Minute))
+	require.NoError(t, err)
+	require.True(t, ok)
+}
+
+func TestRevoke(t *testing.T) {
+	ctx := context.Background()
+	s, server := newTestStore(t)
+
+	at, err := s.RevokedAt(ctx, "alice")
+	require.NoError(t, err)
+	require.True(t, at.IsZero())
+
+	now := time.Now().Truncate(time.Second)
+	require.NoError(t, s.Revoke(ctx, "alice", now, now.Add(time.Hour)))
+	require.NoError(t, s.Revoke(ctx, "alice", now.Add(-time.Minute), now.Add(time.Hour)))
+	at, err = s.RevokedAt(ctx, "alice")
+	require.NoError(t, err)
+	require.True(t, now.Equal(at))
+	require.Greater(t, server.TTL("test:revoked:alice"), 59*time.Minute)
+}
+
+func TestManager(t *testing.T) {
+	s, _ := newTestStore(t)
+	secretKey, err := cookie.NewCookieSecret()
+	require.NoError(t, err)
+	m, err := cookie.New(
+		cookie.WithSecret(secretKey),
+		cookie.WithReplayStore(s, time.Minute),
+		cookie.WithRevocationStore(s, time.Hour),
+	)
+	require.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	require.NoError(t, m.WriteSigned(w, "link", "reset"))
+	r := cookietest.RequestFrom(t, w)
+	_, err = m.ReadSigned(r, "link")
+	require.NoError(t, err)
+	_, err = m.ReadSigned(r, "link")
+	require.ErrorIs(t, err, cookie.ErrReplayed)
+}
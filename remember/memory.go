@@ -0,0 +1,90 @@
+package remember
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/grackleclub/cookie/v2"
+)
+
+// MemoryStore keeps remember-me tokens in a map by selector. Nothing sweeps
+// it in the background: an expired token is dropped when it is read, or when
+// DeleteUser runs for any user. Tokens are lost when the process exits,
+// logging every user out.
+type MemoryStore struct {
+	mu     sync.Mutex
+	tokens map[string]Token
+	clock  cookie.Clock
+}
+
+// NewMemoryStore creates an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{tokens: map[string]Token{}}
+}
+
+// Get returns the token with selector, or ErrNotFound if there is none or it has expired.
+func (s *MemoryStore) Get(_ context.Context, selector string) (Token, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	token, ok := s.tokens[selector]
+	if !ok {
+		return Token{}, ErrNotFound
+	}
+	if !s.now().Before(token.Expires) {
+		delete(s.tokens, selector)
+		return Token{}, ErrNotFound
+	}
+	return token, nil
+}
+
+// Save stores token.
+func (s *MemoryStore) Save(_ context.Context, token Token) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tokens[token.Selector] = token
+	return nil
+}
+
+// Delete removes the token with selector.
+func (s *MemoryStore) Delete(_ context.Context, selector string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.tokens, selector)
+	return nil
+}
+
+// DeleteUser removes every token issued to userID, along with any expired tokens.
+func (s *MemoryStore) DeleteUser(_ context.Context, userID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	for selector, token := range s.tokens {
+		if token.UserID == userID || !now.Before(token.Expires) {
+			delete(s.tokens, selector)
+		}
+	}
+	return nil
+}
+
+// SetClock implements cookie.ClockSetter.
+func (s *MemoryStore) SetClock(clock cookie.Clock) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.clock = clock
+}
+
+// now returns the time from the store's clock, or the system clock. s.mu must be held.
+func (s *MemoryStore) now() time.Time {
+	if s.clock == nil {
+		return time.Now()
+	}
+	return s.clock.Now()
+}
+
+// Len returns the number of tokens held, including any expired but not yet removed.
+func (s *MemoryStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.tokens)
+}
@@ -0,0 +1,202 @@
+// package remember implements persistent logins with split tokens: a long-lived
+// signed cookie carries a selector, used to look the token up, and a validator,
+// of which the Store keeps only a hash. Tokens are rotated on every use, and a
+// validator mismatch, a sign of a stolen token, revokes every token of the user.
+package remember
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/grackleclub/cookie/v2"
+	"github.com/grackleclub/cookie/v2/internal/token"
+)
+
+const (
+	defaultName     = "remember"
+	defaultTTL      = 30 * 24 * time.Hour
+	selectorLength  = 12
+	validatorLength = 32
+)
+
+var (
+	ErrNotFound = errors.New("remember-me token not found")
+	ErrToken    = errors.New("remember-me token invalid")
+)
+
+// Token is an issued remember-me token as kept by a Store.
+type Token struct {
+	Selector      string
+	ValidatorHash []byte // sha256 of the validator held by the client
+	UserID        string
+	Expires       time.Time
+}
+
+// Store persists issued tokens by selector.
+type Store interface {
+	// Get returns the token with selector, or ErrNotFound
+	// if there is none or it has expired.
+	Get(ctx context.Context, selector string) (Token, error)
+	// Save stores token, replacing any token with the same selector.
+	Save(ctx context.Context, token Token) error
+	// Delete removes the token with selector. Deleting a missing token is not an error.
+	Delete(ctx context.Context, selector string) error
+	// DeleteUser removes every token issued to userID.
+	DeleteUser(ctx context.Context, userID string) error
+}
+
+// Remember issues, verifies, and revokes remember-me tokens.
+type Remember struct {
+	cookies *cookie.Manager
+	store   Store
+	name    string
+	ttl     time.Duration
+}
+
+// Option configures a Remember.
+type Option func(*Remember) error
+
+// New creates a Remember backed by store. The cookie.Manager
+// must hold a secret, which is used to sign the token cookie.
+func New(cookies *cookie.Manager, store Store, opts ...Option) (*Remember, error) {
+	if cookies == nil || store == nil {
+		return nil, fmt.Errorf("%w: cookie manager and store are required", cookie.ErrInitiation)
+	}
+	m := &Remember{
+		cookies: cookies,
+		store:   store,
+		name:    defaultName,
+		ttl:     defaultTTL,
+	}
+	for _, opt := range opts {
+		if err := opt(m); err != nil {
+			return nil, fmt.Errorf("%w: %w", cookie.ErrInitiation, err)
+		}
+	}
+	cookies, err := cookies.With(cookie.WithMaxAge(m.ttl))
+	if err != nil {
+		return nil, err
+	}
+	m.cookies = cookies
+	if setter, ok := store.(cookie.ClockSetter); ok {
+		setter.SetClock(cookies)
+	}
+	return m, nil
+}
+
+// WithName sets the name of the token cookie.
+func WithName(name string) Option {
+	return func(m *Remember) error {
+		if name == "" {
+			return errors.New("empty remember-me cookie name")
+		}
+		m.name = name
+		return nil
+	}
+}
+
+// WithTTL sets how long a token lives after it is issued. Defaults to 30 days.
+func WithTTL(ttl time.Duration) Option {
+	return func(m *Remember) error {
+		if ttl < time.Minute {
+			return fmt.Errorf("remember-me ttl too short: %s", ttl)
+		}
+		m.ttl = ttl
+		return nil
+	}
+}
+
+// Issue stores a new token for userID and writes its cookie, typically at login.
+func (m *Remember) Issue(w http.ResponseWriter, r *http.Request, userID string) error {
+	split, err := token.New(selectorLength, validatorLength)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrToken, err)
+	}
+	issued := Token{
+		Selector:      split.Selector,
+		ValidatorHash: split.Hash(),
+		UserID:        userID,
+		Expires:       m.cookies.Now().Add(m.ttl),
+	}
+	if err := m.store.Save(r.Context(), issued); err != nil {
+		return fmt.Errorf("%w: unable to save token: %w", ErrToken, err)
+	}
+	return m.cookies.WriteSigned(w, m.name, split.String())
+}
+
+// Verify checks the request's token cookie, returning the user it was issued to.
+// The token is used up: a replacement is issued in its place, so a copied cookie
+// stops working once either copy is used. A valid selector with the wrong
+// validator revokes every token of the user. Failures delete the cookie.
+func (m *Remember) Verify(w http.ResponseWriter, r *http.Request) (string, error) {
+	userID, err := m.verify(r)
+	if err != nil {
+		m.cookies.Delete(w, m.name)
+		return "", err
+	}
+	if err := m.Issue(w, r, userID); err != nil {
+		return "", err
+	}
+	return userID, nil
+}
+
+// Revoke deletes the request's token from the store and expires its cookie, typically at logout.
+func (m *Remember) Revoke(w http.ResponseWriter, r *http.Request) error {
+	m.cookies.Delete(w, m.name)
+	split, err := m.read(r)
+	if err != nil {
+		return nil
+	}
+	if err := m.store.Delete(r.Context(), split.Selector); err != nil {
+		return fmt.Errorf("%w: unable to delete token: %w", ErrToken, err)
+	}
+	return nil
+}
+
+// RevokeAll deletes every token issued to userID, such as after a password change.
+func (m *Remember) RevokeAll(ctx context.Context, userID string) error {
+	if err := m.store.DeleteUser(ctx, userID); err != nil {
+		return fmt.Errorf("%w: unable to delete tokens: %w", ErrToken, err)
+	}
+	return nil
+}
+
+// verify checks the request's token and removes it from the store.
+func (m *Remember) verify(r *http.Request) (string, error) {
+	split, err := m.read(r)
+	if err != nil {
+		return "", err
+	}
+	ctx := r.Context()
+	stored, err := m.store.Get(ctx, split.Selector)
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrToken, err)
+	}
+	revoke := func() error { return m.store.DeleteUser(ctx, stored.UserID) }
+	if err := split.Verify(stored.ValidatorHash, revoke); err != nil {
+		return "", fmt.Errorf("%w: %w", ErrToken, err)
+	}
+	if !m.cookies.Now().Before(stored.Expires) {
+		return "", fmt.Errorf("%w: %w", ErrToken, cookie.ErrExpired)
+	}
+	if err := m.store.Delete(ctx, split.Selector); err != nil {
+		return "", fmt.Errorf("%w: unable to delete token: %w", ErrToken, err)
+	}
+	return stored.UserID, nil
+}
+
+// read returns the selector and validator of the request's token cookie.
+func (m *Remember) read(r *http.Request) (token.Split, error) {
+	value, err := m.cookies.ReadSigned(r, m.name)
+	if err != nil {
+		return token.Split{}, fmt.Errorf("%w: %w", ErrToken, err)
+	}
+	split, ok := token.Parse(value)
+	if !ok {
+		return token.Split{}, fmt.Errorf("%w: malformed token", ErrToken)
+	}
+	return split, nil
+}
@@ -0,0 +1,120 @@
+package remember
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/grackleclub/cookie/v2"
+	"github.com/grackleclub/cookie/v2/cookietest"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestRemember(t *testing.T, store Store, opts ...Option) *Remember {
+	t.Helper()
+	secretKey, err := cookie.NewCookieSecret()
+	require.NoError(t, err)
+	cookies, err := cookie.New(cookie.WithSecret(secretKey))
+	require.NoError(t, err)
+	m, err := New(cookies, store, opts...)
+	require.NoError(t, err)
+	return m
+}
+
+func TestRemember(t *testing.T) {
+	store := NewMemoryStore()
+	m := newTestRemember(t, store, WithName("rm"))
+
+	w := httptest.NewRecorder()
+	require.NoError(t, m.Issue(w, httptest.NewRequest(http.MethodGet, "/", nil), "1312"))
+	setCookie := w.Result().Cookies()[0]
+	require.Equal(t, "rm", setCookie.Name)
+	require.Equal(t, int(defaultTTL.Seconds()), setCookie.MaxAge)
+	require.Equal(t, 1, store.Len())
+
+	// verifying rotates the token
+	first := cookietest.RequestFrom(t, w)
+	w = httptest.NewRecorder()
+	userID, err := m.Verify(w, first)
+	require.NoError(t, err)
+	require.Equal(t, "1312", userID)
+	require.Equal(t, 1, store.Len())
+
+	second := cookietest.RequestFrom(t, w)
+	w = httptest.NewRecorder()
+	userID, err = m.Verify(w, second)
+	require.NoError(t, err)
+	require.Equal(t, "1312", userID)
+
+	// the used token no longer works
+	_, err = m.Verify(httptest.NewRecorder(), first)
+	require.ErrorIs(t, err, ErrToken)
+	require.ErrorIs(t, err, ErrNotFound)
+
+	require.NoError(t, m.Revoke(httptest.NewRecorder(), cookietest.RequestFrom(t, w)))
+	require.Zero(t, store.Len())
+	_, err = m.Verify(httptest.NewRecorder(), cookietest.RequestFrom(t, w))
+	require.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestRememberTheft(t *testing.T) {
+	store := NewMemoryStore()
+	m := newTestRemember(t, store)
+
+	w := httptest.NewRecorder()
+	require.NoError(t, m.Issue(w, httptest.NewRequest(http.MethodGet, "/", nil), "1312"))
+	require.NoError(t, m.Issue(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil), "1312"))
+	require.NoError(t, m.Issue(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil), "42"))
+	require.Equal(t, 3, store.Len())
+
+	// a stolen selector with a forged validator revokes every token of the user
+	split, err := m.read(cookietest.RequestFrom(t, w))
+	require.NoError(t, err)
+	forged := httptest.NewRecorder()
+	require.NoError(t, m.cookies.WriteSigned(forged, m.name, split.Selector+":forged"))
+
+	verify := httptest.NewRecorder()
+	_, err = m.Verify(verify, cookietest.RequestFrom(t, forged))
+	require.ErrorIs(t, err, ErrToken)
+	require.Equal(t, 1, store.Len())
+	require.Negative(t, verify.Result().Cookies()[0].MaxAge)
+
+	require.NoError(t, m.RevokeAll(context.Background(), "42"))
+	require.Zero(t, store.Len())
+}
+
+func TestRememberOptions(t *testing.T) {
+	_, err := New(nil, NewMemoryStore())
+	require.ErrorIs(t, err, cookie.ErrInitiation)
+
+	cookies, err := cookie.New()
+	require.NoError(t, err)
+	_, err = New(cookies, NewMemoryStore(), WithTTL(0))
+	require.ErrorIs(t, err, cookie.ErrInitiation)
+	_, err = New(cookies, NewMemoryStore(), WithName(""))
+	require.ErrorIs(t, err, cookie.ErrInitiation)
+}
+
+func TestRememberClock(t *testing.T) {
+	secretKey, err := cookie.NewCookieSecret()
+	require.NoError(t, err)
+	clock := cookietest.NewClock(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC))
+	cookies, err := cookie.New(cookie.WithSecret(secretKey), cookie.WithClock(clock))
+	require.NoError(t, err)
+	m, err := New(cookies, NewMemoryStore())
+	require.NoError(t, err)
+
+	// the store expires tokens by the Manager's clock, not the system clock
+	w := httptest.NewRecorder()
+	require.NoError(t, m.Issue(w, httptest.NewRequest(http.MethodGet, "/", nil), "1312"))
+	r := cookietest.RequestFrom(t, w)
+	w = httptest.NewRecorder()
+	_, err = m.Verify(w, r)
+	require.NoError(t, err)
+
+	clock.Advance(defaultTTL)
+	_, err = m.Verify(httptest.NewRecorder(), cookietest.RequestFrom(t, w))
+	require.Error(t, err)
+}
@@ -0,0 +1,106 @@
+package cookie
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const tokenIDLength = 16
+
+// ErrReplayed is returned when a one-time value has already been read.
+var ErrReplayed = errors.New("one-time value already used")
+
+// ReplayStore records the IDs of one-time values that have been accepted.
+// Implementations shared by several servers, such as the one in package
+// redisstore, make the guarantee hold across the whole deployment.
+type ReplayStore interface {
+	// Claim records id until expires, reporting false if it was already claimed.
+	Claim(ctx context.Context, id string, expires time.Time) (bool, error)
+}
+
+// WithReplayStore makes every signed value one-time use: WriteSigned embeds a
+// random token ID, and ReadSigned accepts each ID only once, failing with
+// ErrReplayed thereafter. Values written without an explicit expiry expire
+// after ttl, bounding how long the store must remember them.
+func WithReplayStore(store ReplayStore, ttl time.Duration) Option {
+	return func(m *Manager) error {
+		if store == nil {
+			return errors.New("replay store is required")
+		}
+		if ttl <= 0 {
+			return fmt.Errorf("invalid replay ttl: %s", ttl)
+		}
+		m.replay = store
+		m.replayTTL = ttl
+		m.shareClock = true
+		return nil
+	}
+}
+
+// claim strips the token ID from payload, recording it in the one-time or replay store.
+func (m *Manager) claim(ctx context.Context, name, payload string, expires time.Time) (string, error) {
+	if len(payload) < tokenIDLength {
+		return "", fmt.Errorf("%w: token id missing", ErrCookie)
+	}
+	store := m.claimStore()
+	if store == nil {
+		return "", fmt.Errorf("%w: one-time value read without a replay store", ErrCookie)
+	}
+	if expires.IsZero() {
+		expires = m.Now().Add(m.replayTTL)
+	}
+	id := name + ":" + base64.RawURLEncoding.EncodeToString([]byte(payload[:tokenIDLength]))
+	ok, err := store.Claim(ctx, id, expires)
+	if err != nil {
+		return "", fmt.Errorf("%w: unable to claim token: %w", ErrCookie, err)
+	}
+	if !ok {
+		return "", fmt.Errorf("%w: %w", ErrCookie, ErrReplayed)
+	}
+	return payload[tokenIDLength:], nil
+}
+
+// MemoryReplayStore is a ReplayStore for a single process.
+type MemoryReplayStore struct {
+	mu        sync.Mutex
+	claims    map[string]time.Time
+	lastSweep time.Time
+	clock     Clock
+}
+
+// NewMemoryReplayStore creates an empty MemoryReplayStore.
+// Expired IDs are swept at most once a minute as new IDs are claimed.
+func NewMemoryReplayStore() *MemoryReplayStore {
+	return &MemoryReplayStore{claims: make(map[string]time.Time)}
+}
+
+// SetClock implements ClockSetter.
+func (s *MemoryReplayStore) SetClock(clock Clock) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.clock = clock
+}
+
+// Claim implements ReplayStore.
+func (s *MemoryReplayStore) Claim(_ context.Context, id string, expires time.Time) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := clockNow(s.clock)
+	if until, ok := s.claims[id]; ok && now.Before(until) {
+		return false, nil
+	}
+	if now.Sub(s.lastSweep) >= time.Minute {
+		for claimed, until := range s.claims {
+			if !now.Before(until) {
+				delete(s.claims, claimed)
+			}
+		}
+		s.lastSweep = now
+	}
+	s.claims[id] = expires
+	return true, nil
+}
@@ -0,0 +1,79 @@
+package cookie
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type failingReplayStore struct{}
+
+func (failingReplayStore) Claim(context.Context, string, time.Time) (bool, error) {
+	return false, errors.New("store unavailable")
+}
+
+func TestWithReplayStore(t *testing.T) {
+	plain := newTestManager(t)
+	m, err := plain.With(WithReplayStore(NewMemoryReplayStore(), time.Hour))
+	require.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	require.NoError(t, m.WriteSigned(w, "reset", "1312"))
+	value, err := m.ReadSigned(requestWith(w), "reset")
+	require.NoError(t, err)
+	require.Equal(t, "1312", value)
+
+	_, err = m.ReadSigned(requestWith(w), "reset")
+	require.ErrorIs(t, err, ErrReplayed)
+	require.ErrorIs(t, err, ErrCookie)
+
+	// each write is a fresh token
+	w = httptest.NewRecorder()
+	require.NoError(t, m.WriteSigned(w, "reset", "1312"))
+	_, err = m.ReadSigned(requestWith(w), "reset")
+	require.NoError(t, err)
+
+	t.Run("no store", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		require.NoError(t, m.WriteSigned(w, "reset", "1312"))
+		_, err := plain.ReadSigned(requestWith(w), "reset")
+		require.ErrorIs(t, err, ErrCookie)
+	})
+
+	t.Run("store failure", func(t *testing.T) {
+		failing, err := plain.With(WithReplayStore(failingReplayStore{}, time.Hour))
+		require.NoError(t, err)
+		w := httptest.NewRecorder()
+		require.NoError(t, failing.WriteSigned(w, "reset", "1312"))
+		_, err = failing.ReadSigned(requestWith(w), "reset")
+		require.ErrorIs(t, err, ErrCookie)
+		require.NotErrorIs(t, err, ErrReplayed)
+	})
+
+	_, err = New(WithReplayStore(nil, time.Hour))
+	require.ErrorIs(t, err, ErrInitiation)
+}
+
+func TestMemoryReplayStore(t *testing.T) {
+	s := NewMemoryReplayStore()
+	ctx := context.Background()
+
+	ok, err := s.Claim(ctx, "a", time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	require.True(t, ok)
+	ok, err = s.Claim(ctx, "a", time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	// an expired claim no longer blocks the id
+	ok, err = s.Claim(ctx, "b", time.Now().Add(-time.Second))
+	require.NoError(t, err)
+	require.True(t, ok)
+	ok, err = s.Claim(ctx, "b", time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	require.True(t, ok)
+}
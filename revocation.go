@@ -0,0 +1,139 @@
+package cookie
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ErrRevoked is returned for a value issued to a subject before its values were revoked.
+var ErrRevoked = errors.New("cookie revoked")
+
+// RevocationStore records, per subject such as a user ID, the time before which
+// the subject's values are no longer accepted. Implementations shared by several
+// servers, such as the one in package redisstore, revoke across the whole deployment.
+type RevocationStore interface {
+	// Revoke records that values issued to subject before at are revoked.
+	// The record is no longer needed after expires.
+	Revoke(ctx context.Context, subject string, at, expires time.Time) error
+	// RevokedAt returns the latest revocation time for subject, or zero if there is none.
+	RevokedAt(ctx context.Context, subject string) (time.Time, error)
+}
+
+// WithRevocationStore stamps encrypted values with their creation time, and makes
+// ReadEncrypted reject values issued to a user before RevokeAll was called for
+// them with ErrRevoked. ttl is the longest any value lives, after which a
+// revocation record can be forgotten.
+func WithRevocationStore(store RevocationStore, ttl time.Duration) Option {
+	return func(m *Manager) error {
+		if store == nil {
+			return errors.New("revocation store is required")
+		}
+		if ttl <= 0 {
+			return fmt.Errorf("invalid revocation ttl: %s", ttl)
+		}
+		m.revocation = store
+		m.revocationTTL = ttl
+		m.shareClock = true
+		return nil
+	}
+}
+
+// RevokeAll revokes every value issued to subject so far, such as after a
+// password change. For ReadEncrypted, the subject is the decimal user ID.
+// Creation times are kept to the second, so values issued in the same second
+// as the revocation are still accepted, including one issued right after it.
+func (m *Manager) RevokeAll(ctx context.Context, subject string) error {
+	if m.revocation == nil {
+		return fmt.Errorf("%w: no revocation store", ErrCookie)
+	}
+	now := m.Now()
+	if err := m.revocation.Revoke(ctx, subject, now.Truncate(time.Second), now.Add(m.revocationTTL)); err != nil {
+		return fmt.Errorf("%w: unable to revoke '%s': %w", ErrCookie, subject, err)
+	}
+	return nil
+}
+
+// CheckRevoked fails with ErrRevoked if a value issued to subject at issued has been
+// revoked. A zero issued time, from a value written without a stamp, is revoked by any
+// revocation of the subject. Without a revocation store, nothing is revoked.
+func (m *Manager) CheckRevoked(ctx context.Context, subject string, issued time.Time) error {
+	if m.revocation == nil {
+		return nil
+	}
+	revokedAt, err := m.revocation.RevokedAt(ctx, subject)
+	if err != nil {
+		return fmt.Errorf("%w: unable to check revocation of '%s': %w", ErrCookie, subject, err)
+	}
+	if !revokedAt.IsZero() && issued.Before(revokedAt) {
+		return fmt.Errorf("%w: %w at %s", ErrCookie, ErrRevoked, revokedAt.UTC().Format(time.RFC3339))
+	}
+	return nil
+}
+
+// ReadEncryptedValueIssued reads a cookie like ReadEncryptedValue, also returning its
+// creation time, for checking with CheckRevoked. The time is zero for values written
+// without a revocation store or WithRefresh.
+func (m *Manager) ReadEncryptedValueIssued(r *http.Request, name string) (string, time.Time, error) {
+	o, err := m.readOpened(r, name, Encrypted)
+	return o.value, o.issued, err
+}
+
+// MemoryRevocationStore is a RevocationStore for a single process.
+type MemoryRevocationStore struct {
+	mu        sync.Mutex
+	revoked   map[string]revocation
+	lastSweep time.Time
+	clock     Clock
+}
+
+type revocation struct {
+	at, expires time.Time
+}
+
+// NewMemoryRevocationStore creates an empty MemoryRevocationStore.
+// Expired records are swept at most once a minute as new revocations are made.
+func NewMemoryRevocationStore() *MemoryRevocationStore {
+	return &MemoryRevocationStore{revoked: make(map[string]revocation)}
+}
+
+// SetClock implements ClockSetter.
+func (s *MemoryRevocationStore) SetClock(clock Clock) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.clock = clock
+}
+
+// Revoke implements RevocationStore.
+func (s *MemoryRevocationStore) Revoke(_ context.Context, subject string, at, expires time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := clockNow(s.clock)
+	if now.Sub(s.lastSweep) >= time.Minute {
+		for revoked, record := range s.revoked {
+			if !now.Before(record.expires) {
+				delete(s.revoked, revoked)
+			}
+		}
+		s.lastSweep = now
+	}
+	if record, ok := s.revoked[subject]; ok && record.at.After(at) {
+		at = record.at
+	}
+	s.revoked[subject] = revocation{at: at, expires: expires}
+	return nil
+}
+
+// RevokedAt implements RevocationStore.
+func (s *MemoryRevocationStore) RevokedAt(_ context.Context, subject string) (time.Time, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	record, ok := s.revoked[subject]
+	if !ok || !clockNow(s.clock).Before(record.expires) {
+		return time.Time{}, nil
+	}
+	return record.at, nil
+}
@@ -0,0 +1,81 @@
+package cookie
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRevocation(t *testing.T) {
+	ctx := context.Background()
+	m := newTestManager(t, WithRevocationStore(NewMemoryRevocationStore(), time.Hour))
+
+	w := httptest.NewRecorder()
+	require.NoError(t, m.WriteEncrypted(w, testUserID, "a", "chocolate fudge"))
+	require.NoError(t, m.WriteEncrypted(w, 42, "b", "oatmeal raisin"))
+	before := requestWith(w)
+
+	// values are issued to the second, so wait for values issued earlier to fall behind
+	time.Sleep(time.Until(time.Now().Truncate(time.Second).Add(time.Second)))
+	require.NoError(t, m.RevokeAll(ctx, "1312"))
+
+	_, _, err := m.ReadEncrypted(before, "a")
+	require.ErrorIs(t, err, ErrRevoked)
+	require.ErrorIs(t, err, ErrCookie)
+	id, value, err := m.ReadEncrypted(before, "b")
+	require.NoError(t, err)
+	require.Equal(t, 42, id)
+	require.Equal(t, "oatmeal raisin", value)
+
+	// a value issued right after the revocation is accepted
+	w = httptest.NewRecorder()
+	require.NoError(t, m.WriteEncrypted(w, testUserID, "a", "chocolate fudge"))
+	id, _, err = m.ReadEncrypted(requestWith(w), "a")
+	require.NoError(t, err)
+	require.Equal(t, testUserID, id)
+
+	// values written without a creation stamp are revoked too
+	unstamped := newTestManager(t)
+	unstamped.secretKeys = m.secretKeys
+	w = httptest.NewRecorder()
+	require.NoError(t, unstamped.WriteEncrypted(w, testUserID, "a", "chocolate fudge"))
+	_, _, err = m.ReadEncrypted(requestWith(w), "a")
+	require.ErrorIs(t, err, ErrRevoked)
+
+	require.ErrorIs(t, unstamped.RevokeAll(ctx, "1312"), ErrCookie)
+}
+
+func TestMemoryRevocationStore(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemoryRevocationStore()
+	at, err := s.RevokedAt(ctx, "a")
+	require.NoError(t, err)
+	require.True(t, at.IsZero())
+
+	now := time.Now().Truncate(time.Second)
+	require.NoError(t, s.Revoke(ctx, "a", now, now.Add(time.Hour)))
+	require.NoError(t, s.Revoke(ctx, "a", now.Add(-time.Minute), now.Add(time.Hour)))
+	at, err = s.RevokedAt(ctx, "a")
+	require.NoError(t, err)
+	require.Equal(t, now, at)
+
+	require.NoError(t, s.Revoke(ctx, "b", now, now.Add(-time.Second)))
+	at, err = s.RevokedAt(ctx, "b")
+	require.NoError(t, err)
+	require.True(t, at.IsZero())
+}
+
+func TestRevocationClock(t *testing.T) {
+	clock := &testClock{t: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)}
+	m := newTestManager(t, WithClock(clock), WithRevocationStore(NewMemoryRevocationStore(), time.Hour))
+
+	w := httptest.NewRecorder()
+	require.NoError(t, m.WriteEncrypted(w, testUserID, "a", "chocolate fudge"))
+	clock.t = clock.t.Add(time.Second)
+	require.NoError(t, m.RevokeAll(context.Background(), "1312"))
+	_, _, err := m.ReadEncrypted(requestWith(w), "a")
+	require.ErrorIs(t, err, ErrRevoked)
+}
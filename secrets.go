@@ -0,0 +1,223 @@
+package cookie
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// SecretSource supplies the Manager's secrets, so they can come from
+// configuration rather than raw byte slices, and can change while running.
+type SecretSource interface {
+	// Current returns the secret used to write new cookies.
+	Current() ([]byte, error)
+	// All returns every secret accepted when reading, newest first.
+	All() ([][]byte, error)
+}
+
+// WithSecretSource reads secrets from source on every write and read,
+// in place of WithSecrets.
+func WithSecretSource(source SecretSource) Option {
+	return func(m *Manager) error {
+		if source == nil {
+			return errors.New("nil secret source")
+		}
+		if _, err := source.Current(); err != nil {
+			return err
+		}
+		m.source = source
+		m.secretKeys = nil
+		m.derive = false
+		return nil
+	}
+}
+
+// Secrets is a fixed SecretSource, newest first.
+type Secrets [][]byte
+
+// Current implements SecretSource.
+func (s Secrets) Current() ([]byte, error) {
+	if len(s) == 0 || len(s[0]) == 0 {
+		return nil, ErrSecretMissing
+	}
+	return s[0], nil
+}
+
+// All implements SecretSource.
+func (s Secrets) All() ([][]byte, error) {
+	if len(s) == 0 {
+		return nil, ErrSecretMissing
+	}
+	return s, nil
+}
+
+// SecretsFromEnv reads secrets encoded as by EncodeSecret from environment variables,
+// newest first. Unset variables are skipped, so a retired key's variable
+// can be removed without a code change, but at least one must be set.
+func SecretsFromEnv(vars ...string) (Secrets, error) {
+	var secrets Secrets
+	for _, name := range vars {
+		encoded, ok := os.LookupEnv(name)
+		if !ok {
+			continue
+		}
+		secret, err := DecodeSecret(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("environment variable %s: %w", name, err)
+		}
+		secrets = append(secrets, secret)
+	}
+	if len(secrets) == 0 {
+		return nil, fmt.Errorf("%w: none of %s set", ErrSecretMissing, strings.Join(vars, ", "))
+	}
+	return secrets, nil
+}
+
+// SecretsFromFile reads secrets encoded as by EncodeSecret from a file, one per line,
+// newest first. Blank lines and lines beginning with # are ignored.
+func SecretsFromFile(path string) (Secrets, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read secrets: %w", err)
+	}
+	return parseSecrets(data)
+}
+
+// KeyDecrypter decrypts secrets wrapped by a key management service,
+// such as AWS KMS Decrypt or Google Cloud KMS Decrypt.
+type KeyDecrypter interface {
+	Decrypt(ctx context.Context, ciphertext []byte) ([]byte, error)
+}
+
+// SecretsFromKMS unwraps secrets encrypted by a key management service,
+// newest first, so only ciphertexts need to be stored in configuration.
+func SecretsFromKMS(ctx context.Context, kms KeyDecrypter, wrapped ...[]byte) (Secrets, error) {
+	if len(wrapped) == 0 {
+		return nil, ErrSecretMissing
+	}
+	secrets := make(Secrets, len(wrapped))
+	for i, ciphertext := range wrapped {
+		secret, err := kms.Decrypt(ctx, ciphertext)
+		if err != nil {
+			return nil, fmt.Errorf("unable to decrypt secret %d: %w", i, err)
+		}
+		if len(secret) == 0 {
+			return nil, fmt.Errorf("secret %d: %w", i, ErrSecretMissing)
+		}
+		secrets[i] = secret
+	}
+	return secrets, nil
+}
+
+// currentKey returns the secret used to write, and the ID embedded in
+// envelopes to identify it.
+func (m *Manager) currentKey() ([]byte, []byte, error) {
+	var secretKey []byte
+	switch source := m.source.(type) {
+	case nil:
+		if len(m.secretKeys) == 0 {
+			return nil, nil, ErrSecretMissing
+		}
+		secretKey = m.secretKeys[0]
+	case *KeyRing:
+		return source.current()
+	default:
+		var err error
+		if secretKey, err = source.Current(); err != nil {
+			return nil, nil, err
+		}
+	}
+	return keyID(secretKey), secretKey, nil
+}
+
+// secrets returns every secret accepted when reading, newest first.
+func (m *Manager) secrets() ([][]byte, error) {
+	if m.source != nil {
+		secretKeys, err := m.source.All()
+		if err == nil && len(secretKeys) == 0 {
+			err = ErrSecretMissing
+		}
+		return secretKeys, err
+	}
+	if len(m.secretKeys) == 0 {
+		return nil, ErrSecretMissing
+	}
+	return m.secretKeys, nil
+}
+
+// parseSecrets decodes one secret per line, skipping blanks and comments.
+func parseSecrets(data []byte) (Secrets, error) {
+	var secrets Secrets
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		secret, err := DecodeSecret(text)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		secrets = append(secrets, secret)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("unable to read secrets: %w", err)
+	}
+	if len(secrets) == 0 {
+		return nil, ErrSecretMissing
+	}
+	return secrets, nil
+}
+
+// SecretEncoding selects how EncodeSecret represents a secret as text.
+type SecretEncoding int
+
+const (
+	SecretBase64 SecretEncoding = iota // standard base64 with padding
+	SecretHex                          // lowercase hex, marked with a "hex:" prefix
+)
+
+// hexPrefix marks hex secrets, which would otherwise also be valid base64.
+const hexPrefix = "hex:"
+
+// EncodeSecret returns secret as text for an environment variable or
+// configuration file, which DecodeSecret, SecretsFromEnv, and SecretsFromFile read.
+func EncodeSecret(secret []byte, encoding SecretEncoding) string {
+	if encoding == SecretHex {
+		return hexPrefix + hex.EncodeToString(secret)
+	}
+	return base64.StdEncoding.EncodeToString(secret)
+}
+
+// DecodeSecret decodes a secret encoded by EncodeSecret. Hex must carry the
+// "hex:" prefix; anything else is read as standard or URL-safe base64, with
+// or without padding, and an optional "base64:" prefix.
+func DecodeSecret(encoded string) ([]byte, error) {
+	encoded = strings.TrimSpace(encoded)
+	var secret []byte
+	var err error
+	if text, ok := strings.CutPrefix(encoded, hexPrefix); ok {
+		if secret, err = hex.DecodeString(text); err != nil {
+			return nil, fmt.Errorf("invalid hex secret: %w", err)
+		}
+	} else {
+		encoded = strings.TrimRight(strings.TrimPrefix(encoded, "base64:"), "=")
+		secret, err = base64.RawStdEncoding.DecodeString(encoded)
+		if err != nil {
+			secret, err = base64.RawURLEncoding.DecodeString(encoded)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid base64 secret: %w", err)
+		}
+	}
+	if len(secret) == 0 {
+		return nil, ErrSecretMissing
+	}
+	return secret, nil
+}
@@ -0,0 +1,109 @@
+package cookie
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// reverseKMS "decrypts" by reversing bytes.
+type reverseKMS struct{}
+
+func (reverseKMS) Decrypt(_ context.Context, ciphertext []byte) ([]byte, error) {
+	if len(ciphertext) == 0 {
+		return nil, errors.New("empty ciphertext")
+	}
+	out := make([]byte, len(ciphertext))
+	for i, b := range ciphertext {
+		out[len(out)-1-i] = b
+	}
+	return out, nil
+}
+
+func TestSecretSources(t *testing.T) {
+	oldKey, err := NewCookieSecret()
+	require.NoError(t, err)
+	newKey, err := NewCookieSecret()
+	require.NoError(t, err)
+
+	// a cookie written under the old key must be readable from every source
+	old, err := New(WithSecret(oldKey))
+	require.NoError(t, err)
+	w := httptest.NewRecorder()
+	require.NoError(t, old.WriteEncryptedValue(w, "a", "b"))
+
+	t.Setenv("COOKIE_SECRET", base64.StdEncoding.EncodeToString(newKey))
+	t.Setenv("COOKIE_SECRET_OLD", base64.RawURLEncoding.EncodeToString(oldKey))
+	fromEnv, err := SecretsFromEnv("COOKIE_SECRET", "COOKIE_SECRET_UNSET", "COOKIE_SECRET_OLD")
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "secrets")
+	contents := "# newest first\n" + base64.StdEncoding.EncodeToString(newKey) + "\n\n" +
+		base64.StdEncoding.EncodeToString(oldKey) + "\n"
+	require.NoError(t, os.WriteFile(path, []byte(contents), 0o600))
+	fromFile, err := SecretsFromFile(path)
+	require.NoError(t, err)
+
+	fromKMS, err := SecretsFromKMS(context.Background(), reverseKMS{}, reverse(newKey), reverse(oldKey))
+	require.NoError(t, err)
+
+	for name, source := range map[string]Secrets{"env": fromEnv, "file": fromFile, "kms": fromKMS} {
+		t.Run(name, func(t *testing.T) {
+			require.Equal(t, Secrets{newKey, oldKey}, source)
+			m, err := New(WithSecretSource(source))
+			require.NoError(t, err)
+			value, err := m.ReadEncryptedValue(requestWith(w), "a")
+			require.NoError(t, err)
+			require.Equal(t, "b", value)
+		})
+	}
+
+	_, err = SecretsFromEnv("COOKIE_SECRET_UNSET")
+	require.ErrorIs(t, err, ErrSecretMissing)
+	t.Setenv("COOKIE_SECRET_BAD", "not base64!")
+	_, err = SecretsFromEnv("COOKIE_SECRET_BAD")
+	require.Error(t, err)
+	_, err = New(WithSecretSource(Secrets{}))
+	require.ErrorIs(t, err, ErrSecretMissing)
+}
+
+func reverse(b []byte) []byte {
+	out, _ := reverseKMS{}.Decrypt(context.Background(), b)
+	return out
+}
+
+func TestEncodeDecodeSecret(t *testing.T) {
+	secret, err := NewCookieSecretN(48)
+	require.NoError(t, err)
+	require.Len(t, secret, 48)
+	_, err = NewCookieSecretN(8)
+	require.Error(t, err)
+
+	for _, encoding := range []SecretEncoding{SecretBase64, SecretHex} {
+		encoded := EncodeSecret(secret, encoding)
+		decoded, err := DecodeSecret(encoded)
+		require.NoError(t, err)
+		require.Equal(t, secret, decoded)
+	}
+	require.Equal(t, "hex:00ff", EncodeSecret([]byte{0, 255}, SecretHex))
+
+	decoded, err := DecodeSecret("base64:" + base64.RawURLEncoding.EncodeToString(secret))
+	require.NoError(t, err)
+	require.Equal(t, secret, decoded)
+
+	_, err = DecodeSecret("hex:zz")
+	require.ErrorContains(t, err, "invalid hex secret")
+	_, err = DecodeSecret("hex:")
+	require.ErrorIs(t, err, ErrSecretMissing)
+
+	t.Setenv("TEST_HEX_SECRET", EncodeSecret(secret, SecretHex))
+	secrets, err := SecretsFromEnv("TEST_HEX_SECRET")
+	require.NoError(t, err)
+	require.Equal(t, Secrets{secret}, secrets)
+}
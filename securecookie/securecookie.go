@@ -0,0 +1,216 @@
+// package securecookie reads and writes values in the format of
+// github.com/gorilla/securecookie, so services moving from gorilla to
+// this module can keep accepting the cookies their users already hold.
+//
+// A value is base64("date|value|mac"), where the MAC covers "name|date|value"
+// and value is the base64 of the serialized payload, optionally encrypted
+// with AES-CTR under a random IV prepended to the ciphertext.
+package securecookie
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/grackleclub/cookie/v2"
+)
+
+const (
+	maxLength     = 4096                // gorilla's default limit on encoded values
+	defaultMaxAge = 30 * 24 * time.Hour // gorilla's default
+)
+
+// Codec encodes and decodes values compatible with a gorilla SecureCookie
+// built from the same keys and settings.
+type Codec struct {
+	cookies    *cookie.Manager
+	hashKey    []byte
+	block      cipher.Block // nil when values are only signed
+	hash       func() hash.Hash
+	serializer cookie.Codec
+	maxAge     time.Duration
+	now        func() time.Time // the Manager's clock
+}
+
+// Option configures a Codec.
+type Option func(*Codec) error
+
+// New creates a Codec from the keys passed to gorilla's securecookie.New.
+// blockKey may be nil for signed-only values, or an AES key of 16, 24, or 32 bytes.
+// Cookies are written with the cookie.Manager's default attributes and name prefix.
+func New(cookies *cookie.Manager, hashKey, blockKey []byte, opts ...Option) (*Codec, error) {
+	if cookies == nil {
+		return nil, fmt.Errorf("%w: cookie manager is required", cookie.ErrInitiation)
+	}
+	if len(hashKey) == 0 {
+		return nil, fmt.Errorf("%w: %w", cookie.ErrInitiation, cookie.ErrSecretMissing)
+	}
+	c := &Codec{
+		cookies:    cookies,
+		hashKey:    hashKey,
+		hash:       sha256.New,
+		serializer: cookie.Gob,
+		maxAge:     defaultMaxAge,
+		now:        cookies.Now,
+	}
+	if blockKey != nil {
+		block, err := aes.NewCipher(blockKey)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", cookie.ErrInitiation, err)
+		}
+		c.block = block
+	}
+	for _, opt := range opts {
+		if err := opt(c); err != nil {
+			return nil, fmt.Errorf("%w: %w", cookie.ErrInitiation, err)
+		}
+	}
+	return c, nil
+}
+
+// WithSerializer sets how values are serialized. The default, cookie.Gob,
+// matches gorilla's GobEncoder; cookie.JSON matches its JSONEncoder.
+func WithSerializer(serializer cookie.Codec) Option {
+	return func(c *Codec) error {
+		if serializer == nil {
+			return errors.New("serializer is nil")
+		}
+		c.serializer = serializer
+		return nil
+	}
+}
+
+// WithMaxAge sets the oldest value Decode accepts, matching gorilla's MaxAge;
+// the default is 30 days. Zero accepts values of any age.
+func WithMaxAge(maxAge time.Duration) Option {
+	return func(c *Codec) error {
+		if maxAge < 0 {
+			return fmt.Errorf("negative max age: %s", maxAge)
+		}
+		c.maxAge = maxAge
+		return nil
+	}
+}
+
+// WithHashFunc sets the HMAC hash, matching gorilla's HashFunc; the default is SHA-256.
+func WithHashFunc(h func() hash.Hash) Option {
+	return func(c *Codec) error {
+		if h == nil {
+			return errors.New("hash function is nil")
+		}
+		c.hash = h
+		return nil
+	}
+}
+
+// Encode serializes, optionally encrypts, and signs value for the named cookie.
+func (c *Codec) Encode(name string, value any) (string, error) {
+	data, err := c.serializer.Encode(value)
+	if err != nil {
+		return "", fmt.Errorf("%w: unable to encode '%s': %w", cookie.ErrCookie, name, err)
+	}
+	if c.block != nil {
+		iv := make([]byte, c.block.BlockSize())
+		if _, err := io.ReadFull(rand.Reader, iv); err != nil {
+			return "", fmt.Errorf("%w: unable to generate iv: %w", cookie.ErrEncryption, err)
+		}
+		cipher.NewCTR(c.block, iv).XORKeyStream(data, data)
+		data = append(iv, data...)
+	}
+	b := fmt.Appendf(nil, "%s|%d|%s|", name, c.now().Unix(), base64.URLEncoding.EncodeToString(data))
+	b = append(b, c.mac(b[:len(b)-1])...)[len(name)+1:]
+	encoded := base64.URLEncoding.EncodeToString(b)
+	if len(encoded) > maxLength {
+		return "", fmt.Errorf("%w: %w", cookie.ErrCookie, cookie.ErrTooLong)
+	}
+	return encoded, nil
+}
+
+// Decode verifies, optionally decrypts, and deserializes a value
+// of the named cookie into dst, which must be a pointer.
+func (c *Codec) Decode(name, value string, dst any) error {
+	if len(value) > maxLength {
+		return fmt.Errorf("%w: %w", cookie.ErrCookie, cookie.ErrTooLong)
+	}
+	b, err := base64.URLEncoding.DecodeString(value)
+	if err != nil {
+		return fmt.Errorf("%w: cannot decode '%s': %w", cookie.ErrCookie, name, err)
+	}
+	// date|value|mac, where the mac itself may contain '|'
+	parts := bytes.SplitN(b, []byte("|"), 3)
+	if len(parts) != 3 {
+		return fmt.Errorf("%w: %w", cookie.ErrCookie, cookie.ErrTooShort)
+	}
+	signed := append([]byte(name+"|"), b[:len(b)-len(parts[2])-1]...)
+	if !hmac.Equal(parts[2], c.mac(signed)) {
+		return fmt.Errorf("%w: %w", cookie.ErrCookie, cookie.ErrSignatureMismatch)
+	}
+	issued, err := strconv.ParseInt(string(parts[0]), 10, 64)
+	if err != nil {
+		return fmt.Errorf("%w: invalid timestamp: %w", cookie.ErrCookie, err)
+	}
+	if c.maxAge != 0 && issued < c.now().Unix()-int64(c.maxAge.Seconds()) {
+		return fmt.Errorf("%w: %w", cookie.ErrCookie, cookie.ErrExpired)
+	}
+	data, err := base64.URLEncoding.DecodeString(string(parts[1]))
+	if err != nil {
+		return fmt.Errorf("%w: cannot decode '%s' payload: %w", cookie.ErrCookie, name, err)
+	}
+	if c.block != nil {
+		size := c.block.BlockSize()
+		if len(data) <= size {
+			return fmt.Errorf("%w: %w: %w", cookie.ErrCookie, cookie.ErrDecryptFailed, cookie.ErrTooShort)
+		}
+		iv := data[:size]
+		data = data[size:]
+		cipher.NewCTR(c.block, iv).XORKeyStream(data, data)
+	}
+	if err := c.serializer.Decode(data, dst); err != nil {
+		return fmt.Errorf("%w: unable to decode '%s': %w", cookie.ErrCookie, name, err)
+	}
+	return nil
+}
+
+// Write encodes value and sets it as the named cookie with the Manager's defaults.
+// Encoded values are already URL-safe, so they are written as they are.
+func (c *Codec) Write(w http.ResponseWriter, name string, value any) error {
+	ck := c.cookies.Cookie(name, "")
+	encoded, err := c.Encode(ck.Name, value)
+	if err != nil {
+		return err
+	}
+	ck.Value = encoded
+	if len(ck.String()) > maxLength {
+		return fmt.Errorf("%w: %w", cookie.ErrCookie, cookie.ErrTooLong)
+	}
+	http.SetCookie(w, &ck)
+	return nil
+}
+
+// Read decodes the named cookie into dst, which must be a pointer.
+func (c *Codec) Read(r *http.Request, name string, dst any) error {
+	fullName := c.cookies.Cookie(name, "").Name
+	ck, err := r.Cookie(fullName)
+	if err != nil {
+		return fmt.Errorf("%w: '%s': %w", cookie.ErrNotFound, fullName, err)
+	}
+	return c.Decode(fullName, ck.Value, dst)
+}
+
+// mac returns the HMAC of b under the hash key.
+func (c *Codec) mac(b []byte) []byte {
+	h := hmac.New(c.hash, c.hashKey)
+	h.Write(b)
+	return h.Sum(nil)
+}
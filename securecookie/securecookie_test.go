@@ -0,0 +1,135 @@
+package securecookie
+
+import (
+	"crypto/sha512"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	gorilla "github.com/gorilla/securecookie"
+	"github.com/grackleclub/cookie/v2"
+	"github.com/grackleclub/cookie/v2/cookietest"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	hashKey  = []byte("0123456789abcdef0123456789abcdef")
+	blockKey = []byte("fedcba9876543210fedcba9876543210")
+)
+
+func newCodec(t *testing.T, blockKey []byte, opts ...Option) *Codec {
+	t.Helper()
+	m, err := cookie.New()
+	require.NoError(t, err)
+	c, err := New(m, hashKey, blockKey, opts...)
+	require.NoError(t, err)
+	return c
+}
+
+func TestInterop(t *testing.T) {
+	values := map[string]any{"user": "alice", "id": 42}
+	tests := []struct {
+		name     string
+		blockKey []byte
+		opts     []Option
+		setup    func(*gorilla.SecureCookie)
+	}{
+		{name: "signed"},
+		{name: "encrypted", blockKey: blockKey},
+		{
+			name:     "json",
+			blockKey: blockKey,
+			opts:     []Option{WithSerializer(cookie.JSON)},
+			setup:    func(s *gorilla.SecureCookie) { s.SetSerializer(gorilla.JSONEncoder{}) },
+		},
+		{
+			name:  "sha512",
+			opts:  []Option{WithHashFunc(sha512.New)},
+			setup: func(s *gorilla.SecureCookie) { s.HashFunc(sha512.New) },
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			theirs := gorilla.New(hashKey, tt.blockKey)
+			if tt.setup != nil {
+				tt.setup(theirs)
+			}
+			ours := newCodec(t, tt.blockKey, tt.opts...)
+
+			encoded, err := theirs.Encode("session", values)
+			require.NoError(t, err)
+			var got map[string]any
+			require.NoError(t, ours.Decode("session", encoded, &got))
+			require.EqualValues(t, "alice", got["user"])
+
+			encoded, err = ours.Encode("session", values)
+			require.NoError(t, err)
+			got = nil
+			require.NoError(t, theirs.Decode("session", encoded, &got))
+			require.EqualValues(t, "alice", got["user"])
+		})
+	}
+}
+
+func TestDecodeFailures(t *testing.T) {
+	c := newCodec(t, blockKey)
+	encoded, err := c.Encode("session", "alice")
+	require.NoError(t, err)
+
+	var got string
+	err = c.Decode("other", encoded, &got)
+	require.ErrorIs(t, err, cookie.ErrSignatureMismatch)
+	require.ErrorIs(t, err, cookie.ErrCookie)
+
+	past, err := cookie.New(cookie.WithClock(cookietest.NewClock(time.Now().Add(-time.Hour))))
+	require.NoError(t, err)
+	old, err := New(past, hashKey, blockKey)
+	require.NoError(t, err)
+	encoded, err = old.Encode("session", "alice")
+	require.NoError(t, err)
+	require.NoError(t, c.Decode("session", encoded, &got))
+	err = newCodec(t, blockKey, WithMaxAge(time.Minute)).Decode("session", encoded, &got)
+	require.ErrorIs(t, err, cookie.ErrExpired)
+
+	err = c.Decode("session", "not base64!", &got)
+	require.ErrorIs(t, err, cookie.ErrCookie)
+}
+
+func TestReadWrite(t *testing.T) {
+	m, err := cookie.New(cookie.WithPrefix("__Host-"))
+	require.NoError(t, err)
+	c, err := New(m, hashKey, blockKey)
+	require.NoError(t, err)
+
+	rec := httptest.NewRecorder()
+	require.NoError(t, c.Write(rec, "session", "alice"))
+	set := rec.Result().Cookies()
+	require.Len(t, set, 1)
+	require.Equal(t, "__Host-session", set[0].Name)
+	require.True(t, set[0].Secure)
+
+	var fromGorilla string
+	require.NoError(t, gorilla.New(hashKey, blockKey).Decode(set[0].Name, set[0].Value, &fromGorilla))
+	require.Equal(t, "alice", fromGorilla)
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.AddCookie(set[0])
+	var got string
+	require.NoError(t, c.Read(r, "session", &got))
+	require.Equal(t, "alice", got)
+
+	err = c.Read(httptest.NewRequest(http.MethodGet, "/", nil), "session", &got)
+	require.ErrorIs(t, err, cookie.ErrNotFound)
+}
+
+func TestNew(t *testing.T) {
+	m, err := cookie.New()
+	require.NoError(t, err)
+	_, err = New(nil, hashKey, nil)
+	require.ErrorIs(t, err, cookie.ErrInitiation)
+	_, err = New(m, nil, nil)
+	require.ErrorIs(t, err, cookie.ErrSecretMissing)
+	_, err = New(m, hashKey, []byte("short"))
+	require.ErrorIs(t, err, cookie.ErrInitiation)
+}
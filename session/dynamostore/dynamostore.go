@@ -0,0 +1,150 @@
+// package dynamostore implements session.Store on DynamoDB.
+package dynamostore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/grackleclub/cookie/v2"
+	"github.com/grackleclub/cookie/v2/session"
+)
+
+// Client is the subset of *dynamodb.Client a Store uses.
+type Client interface {
+	GetItem(ctx context.Context, params *dynamodb.GetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error)
+	PutItem(ctx context.Context, params *dynamodb.PutItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error)
+	DeleteItem(ctx context.Context, params *dynamodb.DeleteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DeleteItemOutput, error)
+}
+
+// Store is a session.Store backed by a DynamoDB table with a string partition key.
+// Expiry is stored as unix seconds in a number attribute; enable Time to Live
+// on that attribute to have DynamoDB delete expired sessions. Deletion can lag
+// expiry, so Get also ignores expired items.
+type Store struct {
+	client     Client
+	table      string
+	key        string
+	data       string
+	ttl        string
+	consistent bool
+	clock      cookie.Clock
+}
+
+// Option configures a Store.
+type Option func(*Store)
+
+// WithAttributes sets the names of the partition key, data, and expiry attributes.
+// Defaults to "id", "data", and "expires".
+func WithAttributes(key, data, ttl string) Option {
+	return func(s *Store) {
+		s.key, s.data, s.ttl = key, data, ttl
+	}
+}
+
+// WithEventualConsistency reads sessions with eventually consistent reads,
+// which cost half as much but may miss a session saved moments before.
+func WithEventualConsistency() Option {
+	return func(s *Store) {
+		s.consistent = false
+	}
+}
+
+// New creates a Store on table using client, typically a *dynamodb.Client.
+func New(client Client, table string, opts ...Option) (*Store, error) {
+	if client == nil {
+		return nil, errors.New("client is nil")
+	}
+	if table == "" {
+		return nil, errors.New("table name is empty")
+	}
+	s := &Store{
+		client:     client,
+		table:      table,
+		key:        "id",
+		data:       "data",
+		ttl:        "expires",
+		consistent: true,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s, nil
+}
+
+// SetClock implements cookie.ClockSetter. Call it before using the store.
+func (s *Store) SetClock(clock cookie.Clock) {
+	s.clock = clock
+}
+
+// Get returns the data saved for id, or session.ErrNotFound if there is none or it has expired.
+func (s *Store) Get(ctx context.Context, id string) ([]byte, error) {
+	out, err := s.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName:      aws.String(s.table),
+		Key:            s.itemKey(id),
+		ConsistentRead: aws.Bool(s.consistent),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to get session: %w", err)
+	}
+	if out.Item == nil {
+		return nil, session.ErrNotFound
+	}
+	ttl, ok := out.Item[s.ttl].(*types.AttributeValueMemberN)
+	if !ok {
+		return nil, fmt.Errorf("unable to get session: attribute '%s' is not a number", s.ttl)
+	}
+	expires, err := strconv.ParseInt(ttl.Value, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get session: attribute '%s': %w", s.ttl, err)
+	}
+	now := time.Now()
+	if s.clock != nil {
+		now = s.clock.Now()
+	}
+	if now.Unix() >= expires {
+		return nil, session.ErrNotFound
+	}
+	data, ok := out.Item[s.data].(*types.AttributeValueMemberB)
+	if !ok {
+		return nil, fmt.Errorf("unable to get session: attribute '%s' is not binary", s.data)
+	}
+	return data.Value, nil
+}
+
+// Save stores data for id until expires.
+func (s *Store) Save(ctx context.Context, id string, data []byte, expires time.Time) error {
+	_, err := s.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(s.table),
+		Item: map[string]types.AttributeValue{
+			s.key:  &types.AttributeValueMemberS{Value: id},
+			s.data: &types.AttributeValueMemberB{Value: data},
+			s.ttl:  &types.AttributeValueMemberN{Value: strconv.FormatInt(expires.Unix(), 10)},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("unable to save session: %w", err)
+	}
+	return nil
+}
+
+// Destroy removes the data for id.
+func (s *Store) Destroy(ctx context.Context, id string) error {
+	_, err := s.client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName: aws.String(s.table),
+		Key:       s.itemKey(id),
+	})
+	if err != nil {
+		return fmt.Errorf("unable to destroy session: %w", err)
+	}
+	return nil
+}
+
+func (s *Store) itemKey(id string) map[string]types.AttributeValue {
+	return map[string]types.AttributeValue{s.key: &types.AttributeValueMemberS{Value: id}}
+}
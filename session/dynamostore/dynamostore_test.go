@@ -0,0 +1,73 @@
+package dynamostore
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/grackleclub/cookie/v2/session"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeClient holds the items of one table keyed by "pk", without TTL deletion.
+type fakeClient struct {
+	items      map[string]map[string]types.AttributeValue
+	consistent bool
+}
+
+func (c *fakeClient) GetItem(_ context.Context, in *dynamodb.GetItemInput, _ ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error) {
+	c.consistent = aws.ToBool(in.ConsistentRead)
+	id := in.Key["pk"].(*types.AttributeValueMemberS).Value
+	return &dynamodb.GetItemOutput{Item: c.items[id]}, nil
+}
+
+func (c *fakeClient) PutItem(_ context.Context, in *dynamodb.PutItemInput, _ ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error) {
+	id := in.Item["pk"].(*types.AttributeValueMemberS).Value
+	c.items[id] = in.Item
+	return &dynamodb.PutItemOutput{}, nil
+}
+
+func (c *fakeClient) DeleteItem(_ context.Context, in *dynamodb.DeleteItemInput, _ ...func(*dynamodb.Options)) (*dynamodb.DeleteItemOutput, error) {
+	delete(c.items, in.Key["pk"].(*types.AttributeValueMemberS).Value)
+	return &dynamodb.DeleteItemOutput{}, nil
+}
+
+func TestStore(t *testing.T) {
+	ctx := context.Background()
+	client := &fakeClient{items: map[string]map[string]types.AttributeValue{}}
+
+	_, err := New(client, "")
+	require.Error(t, err)
+
+	s, err := New(client, "sessions", WithAttributes("pk", "blob", "ttl"))
+	require.NoError(t, err)
+
+	expires := time.Now().Add(time.Hour)
+	require.NoError(t, s.Save(ctx, "live", []byte("a"), expires))
+	require.NoError(t, s.Save(ctx, "live", []byte("b"), expires))
+	require.NoError(t, s.Save(ctx, "old", []byte("c"), time.Now().Add(-time.Hour)))
+	require.IsType(t, &types.AttributeValueMemberN{}, client.items["live"]["ttl"])
+
+	data, err := s.Get(ctx, "live")
+	require.NoError(t, err)
+	require.Equal(t, []byte("b"), data)
+	require.True(t, client.consistent)
+
+	// expired but not yet deleted by DynamoDB
+	_, err = s.Get(ctx, "old")
+	require.ErrorIs(t, err, session.ErrNotFound)
+
+	require.NoError(t, s.Destroy(ctx, "live"))
+	require.NoError(t, s.Destroy(ctx, "live"))
+	_, err = s.Get(ctx, "live")
+	require.ErrorIs(t, err, session.ErrNotFound)
+
+	s, err = New(client, "sessions", WithAttributes("pk", "blob", "ttl"), WithEventualConsistency())
+	require.NoError(t, err)
+	_, err = s.Get(ctx, "live")
+	require.ErrorIs(t, err, session.ErrNotFound)
+	require.False(t, client.consistent)
+}
@@ -0,0 +1,79 @@
+package session_test
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/grackleclub/cookie/v2"
+	"github.com/grackleclub/cookie/v2/session"
+)
+
+func ExampleManager_AuthenticateUpgrade() {
+	secretKey, err := cookie.NewCookieSecret()
+	if err != nil {
+		log.Fatal(err)
+	}
+	cookies, err := cookie.New(cookie.WithSecret(secretKey))
+	if err != nil {
+		log.Fatal(err)
+	}
+	sessions, err := session.New(cookies, session.NewMemoryStore(0))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/login", func(w http.ResponseWriter, r *http.Request) {
+		s, err := sessions.Load(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if err := s.Set("user", "alice"); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if err := sessions.Save(w, r, s); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+	mux.HandleFunc("/ws", func(w http.ResponseWriter, r *http.Request) {
+		// authenticate before upgrading, while the handshake can still be refused
+		s, err := sessions.AuthenticateUpgrade(r)
+		if err != nil {
+			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+			return
+		}
+		user, _ := session.Get[string](s, "user")
+		// upgrade here with a WebSocket library, such as gorilla/websocket
+		fmt.Fprintf(w, "hello, %s", user)
+	})
+
+	login := httptest.NewRecorder()
+	mux.ServeHTTP(login, httptest.NewRequest(http.MethodGet, "/login", nil))
+
+	handshake := func(authenticated bool) *http.Request {
+		r := httptest.NewRequest(http.MethodGet, "/ws", nil)
+		r.Header.Set("Connection", "Upgrade")
+		r.Header.Set("Upgrade", "websocket")
+		if authenticated {
+			for _, c := range login.Result().Cookies() {
+				r.AddCookie(c)
+			}
+		}
+		return r
+	}
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, handshake(false))
+	fmt.Println(w.Result().Status)
+
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, handshake(true))
+	fmt.Println(w.Body.String())
+	// Output:
+	// 401 Unauthorized
+	// hello, alice
+}
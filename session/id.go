@@ -0,0 +1,142 @@
+package session
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"math/big"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// IDFormat is the encoding of a session ID.
+type IDFormat int
+
+const (
+	// IDBase64 encodes random bytes as unpadded URL-safe base64. The default.
+	IDBase64 IDFormat = iota
+	// IDHex encodes random bytes as lowercase hex.
+	IDHex
+	// IDBase62 encodes random bytes with only letters and digits.
+	IDBase62
+	// IDUUID is a random version 4 UUID, carrying 122 bits of entropy.
+	IDUUID
+	// IDULID is a ULID: a millisecond timestamp followed by 80 random bits,
+	// so IDs sort by creation time and keep database index inserts local.
+	IDULID
+)
+
+func (f IDFormat) String() string {
+	switch f {
+	case IDBase64:
+		return "base64"
+	case IDHex:
+		return "hex"
+	case IDBase62:
+		return "base62"
+	case IDUUID:
+		return "uuid"
+	case IDULID:
+		return "ulid"
+	}
+	return fmt.Sprintf("IDFormat(%d)", int(f))
+}
+
+const (
+	defaultEntropy = 32 // bytes
+	minEntropy     = 16 // bytes
+)
+
+// NewSessionID returns a random session ID in format, drawn from entropy random
+// bytes, or 32 when entropy is zero. IDUUID and IDULID have fixed entropy, so
+// entropy must be zero for them.
+func NewSessionID(format IDFormat, entropy int) (string, error) {
+	if err := validateEntropy(format, entropy); err != nil {
+		return "", fmt.Errorf("%w: %w", ErrSession, err)
+	}
+	if entropy == 0 {
+		entropy = defaultEntropy
+	}
+	if format == IDUUID {
+		id, err := uuid.NewRandom()
+		if err != nil {
+			return "", fmt.Errorf("%w: unable to generate session id: %w", ErrSession, err)
+		}
+		return id.String(), nil
+	}
+	b := make([]byte, entropy)
+	if format == IDULID {
+		b = make([]byte, 10)
+	}
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("%w: unable to generate session id: %w", ErrSession, err)
+	}
+	switch format {
+	case IDHex:
+		return hex.EncodeToString(b), nil
+	case IDBase62:
+		return base62(b), nil
+	case IDULID:
+		return ulid(time.Now(), b), nil
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func validateEntropy(format IDFormat, entropy int) error {
+	switch format {
+	case IDBase64, IDHex, IDBase62:
+		if entropy != 0 && entropy < minEntropy {
+			return fmt.Errorf("session id entropy of %d bytes is below the minimum of %d", entropy, minEntropy)
+		}
+	case IDUUID, IDULID:
+		if entropy != 0 {
+			return fmt.Errorf("session id format %s has fixed entropy", format)
+		}
+	default:
+		return fmt.Errorf("unknown session id format: %s", format)
+	}
+	return nil
+}
+
+const base62Alphabet = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+// base62 encodes b as a number, left padded to the length of the largest
+// value of its size so every ID of the same entropy has the same length.
+func base62(b []byte) string {
+	n := int(math.Ceil(float64(len(b)*8) / math.Log2(62)))
+	out := make([]byte, n)
+	v := new(big.Int).SetBytes(b)
+	radix := big.NewInt(62)
+	digit := new(big.Int)
+	for i := n - 1; i >= 0; i-- {
+		v.DivMod(v, radix, digit)
+		out[i] = base62Alphabet[digit.Int64()]
+	}
+	return string(out)
+}
+
+const crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// ulid encodes a 48 bit millisecond timestamp and 80 random bits as
+// 26 characters of Crockford base32.
+func ulid(t time.Time, random []byte) string {
+	var b [16]byte
+	var ms [8]byte
+	binary.BigEndian.PutUint64(ms[:], uint64(t.UnixMilli()))
+	copy(b[:6], ms[2:])
+	copy(b[6:], random)
+	hi := binary.BigEndian.Uint64(b[:8])
+	lo := binary.BigEndian.Uint64(b[8:])
+	out := make([]byte, 26)
+	// 128 bits as 26 five bit groups, the first holding only 3 bits
+	for i := 25; i >= 0; i-- {
+		out[i] = crockford[lo&0x1f]
+		lo = lo>>5 | hi<<59
+		hi >>= 5
+	}
+	return string(out)
+}
@@ -0,0 +1,70 @@
+package session
+
+import (
+	"net/http/httptest"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/grackleclub/cookie/v2"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewSessionID(t *testing.T) {
+	tests := []struct {
+		format  IDFormat
+		entropy int
+		pattern string
+	}{
+		{IDBase64, 0, `^[A-Za-z0-9_-]{43}$`},
+		{IDHex, 0, `^[0-9a-f]{64}$`},
+		{IDHex, 16, `^[0-9a-f]{32}$`},
+		{IDBase62, 0, `^[0-9A-Za-z]{43}$`},
+		{IDBase62, 16, `^[0-9A-Za-z]{22}$`},
+		{IDUUID, 0, `^[0-9a-f-]{36}$`},
+		{IDULID, 0, `^[0-9A-HJKMNP-TV-Z]{26}$`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format.String(), func(t *testing.T) {
+			a, err := NewSessionID(tt.format, tt.entropy)
+			require.NoError(t, err)
+			b, err := NewSessionID(tt.format, tt.entropy)
+			require.NoError(t, err)
+			require.Regexp(t, regexp.MustCompile(tt.pattern), a)
+			require.NotEqual(t, a, b)
+		})
+	}
+
+	id, err := NewSessionID(IDUUID, 0)
+	require.NoError(t, err)
+	require.Equal(t, uuid.Version(4), uuid.MustParse(id).Version())
+
+	_, err = NewSessionID(IDHex, 8)
+	require.ErrorIs(t, err, ErrSession)
+	_, err = NewSessionID(IDULID, 16)
+	require.ErrorIs(t, err, ErrSession)
+	_, err = NewSessionID(IDFormat(99), 0)
+	require.ErrorIs(t, err, ErrSession)
+}
+
+func TestULIDOrder(t *testing.T) {
+	at := time.UnixMilli(1469918176385)
+	random := make([]byte, 10)
+	require.Equal(t, "01ARYZ6S410000000000000000", ulid(at, random))
+	require.Less(t, ulid(at, random), ulid(at.Add(time.Millisecond), random))
+}
+
+func TestWithIDFormat(t *testing.T) {
+	secretKey, err := cookie.NewCookieSecret()
+	require.NoError(t, err)
+	cookies, err := cookie.New(cookie.WithSecret(secretKey))
+	require.NoError(t, err)
+	_, err = New(cookies, NewMemoryStore(0), WithIDFormat(IDUUID, 32))
+	require.ErrorIs(t, err, cookie.ErrInitiation)
+
+	m := newTestManager(t, NewMemoryStore(0), WithIDFormat(IDHex, 16))
+	s, err := m.Load(httptest.NewRequest("GET", "/", nil))
+	require.NoError(t, err)
+	require.Regexp(t, `^[0-9a-f]{32}$`, s.ID())
+}
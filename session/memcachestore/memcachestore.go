@@ -0,0 +1,91 @@
+// package memcachestore implements session.Store on Memcached.
+package memcachestore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/bradfitz/gomemcache/memcache"
+	"github.com/grackleclub/cookie/v2/session"
+)
+
+const defaultPrefix = "session:"
+
+// maxRelative is the longest expiration Memcached accepts as relative seconds;
+// anything longer is read as a unix timestamp.
+const maxRelative = 30 * 24 * time.Hour
+
+// Client is the subset of *memcache.Client a Store uses.
+type Client interface {
+	Get(key string) (*memcache.Item, error)
+	Set(item *memcache.Item) error
+	Delete(key string) error
+}
+
+// Store is a session.Store backed by Memcached, which evicts each session
+// at its expiry. Memcached may also evict sessions early under memory pressure,
+// so size it for the working set.
+type Store struct {
+	client Client
+	prefix string
+}
+
+// Option configures a Store.
+type Option func(*Store)
+
+// WithPrefix sets the prefix of every key the Store writes. Defaults to "session:".
+func WithPrefix(prefix string) Option {
+	return func(s *Store) {
+		s.prefix = prefix
+	}
+}
+
+// New creates a Store using client, typically a *memcache.Client.
+func New(client Client, opts ...Option) *Store {
+	s := &Store{client: client, prefix: defaultPrefix}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Get returns the data saved for id, or session.ErrNotFound if there is none or it has expired.
+func (s *Store) Get(_ context.Context, id string) ([]byte, error) {
+	item, err := s.client.Get(s.prefix + id)
+	if errors.Is(err, memcache.ErrCacheMiss) {
+		return nil, session.ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to get session: %w", err)
+	}
+	return item.Value, nil
+}
+
+// Save stores data for id until expires. Data that has already expired is destroyed instead.
+func (s *Store) Save(ctx context.Context, id string, data []byte, expires time.Time) error {
+	ttl := time.Until(expires)
+	if ttl < time.Second {
+		return s.Destroy(ctx, id)
+	}
+	// relative seconds are immune to clock skew between servers
+	expiration := int32(ttl / time.Second)
+	if ttl > maxRelative {
+		expiration = int32(expires.Unix())
+	}
+	err := s.client.Set(&memcache.Item{Key: s.prefix + id, Value: data, Expiration: expiration})
+	if err != nil {
+		return fmt.Errorf("unable to save session: %w", err)
+	}
+	return nil
+}
+
+// Destroy removes the data for id.
+func (s *Store) Destroy(_ context.Context, id string) error {
+	err := s.client.Delete(s.prefix + id)
+	if err != nil && !errors.Is(err, memcache.ErrCacheMiss) {
+		return fmt.Errorf("unable to destroy session: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package memcachestore
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/bradfitz/gomemcache/memcache"
+	"github.com/grackleclub/cookie/v2/session"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeClient holds items in memory, ignoring their expiration.
+type fakeClient map[string]*memcache.Item
+
+func (c fakeClient) Get(key string) (*memcache.Item, error) {
+	item, ok := c[key]
+	if !ok {
+		return nil, memcache.ErrCacheMiss
+	}
+	return item, nil
+}
+
+func (c fakeClient) Set(item *memcache.Item) error {
+	c[item.Key] = item
+	return nil
+}
+
+func (c fakeClient) Delete(key string) error {
+	if _, ok := c[key]; !ok {
+		return memcache.ErrCacheMiss
+	}
+	delete(c, key)
+	return nil
+}
+
+func TestStore(t *testing.T) {
+	ctx := context.Background()
+	client := fakeClient{}
+	s := New(client, WithPrefix("app:"))
+
+	require.NoError(t, s.Save(ctx, "live", []byte("a"), time.Now().Add(time.Hour)))
+	require.NoError(t, s.Save(ctx, "live", []byte("b"), time.Now().Add(time.Hour)))
+	require.InDelta(t, 3600, client["app:live"].Expiration, 1)
+
+	data, err := s.Get(ctx, "live")
+	require.NoError(t, err)
+	require.Equal(t, []byte("b"), data)
+
+	expires := time.Now().Add(60 * 24 * time.Hour)
+	require.NoError(t, s.Save(ctx, "long", []byte("c"), expires))
+	require.EqualValues(t, expires.Unix(), client["app:long"].Expiration)
+
+	require.NoError(t, s.Save(ctx, "long", []byte("c"), time.Now().Add(-time.Hour)))
+	_, err = s.Get(ctx, "long")
+	require.ErrorIs(t, err, session.ErrNotFound)
+
+	require.NoError(t, s.Destroy(ctx, "live"))
+	require.NoError(t, s.Destroy(ctx, "live"))
+	_, err = s.Get(ctx, "live")
+	require.ErrorIs(t, err, session.ErrNotFound)
+}
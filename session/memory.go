@@ -0,0 +1,130 @@
+package session
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/grackleclub/cookie/v2"
+)
+
+// MemoryStore keeps encoded session data in a map, for tests and servers
+// running as one process. An expired session is dropped when it is read, and
+// by the background sweeper if NewMemoryStore started one. All sessions are
+// lost when the process exits.
+type MemoryStore struct {
+	mu       sync.RWMutex
+	sessions map[string]memorySession
+	clock    cookie.Clock
+	stop     chan struct{}
+	once     sync.Once
+}
+
+type memorySession struct {
+	data    []byte
+	expires time.Time
+}
+
+// NewMemoryStore creates a MemoryStore that removes expired sessions every interval.
+// An interval of zero disables the background sweeper; expired sessions are then
+// only removed when read. Call Close to stop the sweeper.
+func NewMemoryStore(interval time.Duration) *MemoryStore {
+	s := &MemoryStore{
+		sessions: map[string]memorySession{},
+		stop:     make(chan struct{}),
+	}
+	if interval > 0 {
+		go s.sweep(interval)
+	}
+	return s
+}
+
+// Get returns the data saved for id, or ErrNotFound if there is none or it has expired.
+func (s *MemoryStore) Get(_ context.Context, id string) ([]byte, error) {
+	s.mu.RLock()
+	session, ok := s.sessions[id]
+	now := clockNow(s.clock)
+	s.mu.RUnlock()
+	if !ok {
+		return nil, ErrNotFound
+	}
+	if !now.Before(session.expires) {
+		s.deleteIfExpired(id)
+		return nil, ErrNotFound
+	}
+	return session.data, nil
+}
+
+// SetClock implements cookie.ClockSetter.
+func (s *MemoryStore) SetClock(clock cookie.Clock) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.clock = clock
+}
+
+// Save stores a copy of data for id until expires.
+func (s *MemoryStore) Save(_ context.Context, id string, data []byte, expires time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sessions[id] = memorySession{
+		data:    append([]byte(nil), data...),
+		expires: expires,
+	}
+	return nil
+}
+
+// Destroy removes the data for id.
+func (s *MemoryStore) Destroy(_ context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.sessions, id)
+	return nil
+}
+
+// Len returns the number of sessions held, including any expired but not yet swept.
+func (s *MemoryStore) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.sessions)
+}
+
+// Close stops the background sweeper. It is safe to call more than once.
+func (s *MemoryStore) Close() error {
+	s.once.Do(func() { close(s.stop) })
+	return nil
+}
+
+func (s *MemoryStore) sweep(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+			s.deleteExpired()
+		}
+	}
+}
+
+// deleteIfExpired removes id if it is still expired, rechecking under the
+// write lock so a session saved since it was read survives.
+func (s *MemoryStore) deleteIfExpired(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if session, ok := s.sessions[id]; ok && !clockNow(s.clock).Before(session.expires) {
+		delete(s.sessions, id)
+	}
+}
+
+// deleteExpired removes every session that has expired.
+func (s *MemoryStore) deleteExpired() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := clockNow(s.clock)
+	for id, session := range s.sessions {
+		if !now.Before(session.expires) {
+			delete(s.sessions, id)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package session
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryStore(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemoryStore(10 * time.Millisecond)
+	defer s.Close()
+
+	require.NoError(t, s.Save(ctx, "live", []byte("a"), time.Now().Add(time.Hour)))
+	require.NoError(t, s.Save(ctx, "dying", []byte("b"), time.Now().Add(20*time.Millisecond)))
+
+	data, err := s.Get(ctx, "live")
+	require.NoError(t, err)
+	require.Equal(t, []byte("a"), data)
+
+	require.Eventually(t, func() bool { return s.Len() == 1 }, time.Second, 5*time.Millisecond)
+	_, err = s.Get(ctx, "dying")
+	require.ErrorIs(t, err, ErrNotFound)
+
+	require.NoError(t, s.Destroy(ctx, "live"))
+	_, err = s.Get(ctx, "live")
+	require.ErrorIs(t, err, ErrNotFound)
+
+	require.NoError(t, s.Close())
+	require.NoError(t, s.Close())
+}
+
+func TestMemoryStoreExpiresOnRead(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemoryStore(0)
+
+	require.NoError(t, s.Save(ctx, "old", []byte("a"), time.Now().Add(-time.Second)))
+	_, err := s.Get(ctx, "old")
+	require.ErrorIs(t, err, ErrNotFound)
+	require.Zero(t, s.Len())
+}
+
+func TestMemoryStoreKeepsResaved(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemoryStore(0)
+
+	// a Save between Get seeing the expired session and deleting it survives
+	require.NoError(t, s.Save(ctx, "id", []byte("old"), time.Now().Add(-time.Second)))
+	require.NoError(t, s.Save(ctx, "id", []byte("new"), time.Now().Add(time.Hour)))
+	s.deleteIfExpired("id")
+	data, err := s.Get(ctx, "id")
+	require.NoError(t, err)
+	require.Equal(t, []byte("new"), data)
+}
@@ -0,0 +1,392 @@
+// package session implements server-side sessions on top of encrypted cookies:
+// the client holds only an encrypted session ID, while values live in a Store.
+package session
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/grackleclub/cookie/v2"
+)
+
+const (
+	defaultName    = "session"
+	defaultTTL     = 24 * time.Hour
+	defaultRefresh = 0.5
+)
+
+var ErrSession = errors.New("session failure")
+
+// ErrUnauthenticated is returned by AuthenticateUpgrade for a handshake without a valid session.
+var ErrUnauthenticated = errors.New("session not authenticated")
+
+// Manager loads and saves sessions, keeping the session ID
+// in a cookie encrypted by the underlying cookie.Manager.
+type Manager struct {
+	cookies *cookie.Manager
+	store   Store
+	name    string
+	ttl     time.Duration
+	refresh float64               // final fraction of the TTL in which unmodified sessions are saved again
+	subject func(*Session) string // identifies the user for revocation checks
+	format  IDFormat
+	entropy int // random bytes per session ID; 0 for the format's default
+}
+
+// Option configures a Manager.
+type Option func(*Manager) error
+
+// New creates a session Manager backed by store. The cookie.Manager
+// must hold a secret, which is used to encrypt the session ID cookie.
+func New(cookies *cookie.Manager, store Store, opts ...Option) (*Manager, error) {
+	if cookies == nil || store == nil {
+		return nil, fmt.Errorf("%w: cookie manager and store are required", cookie.ErrInitiation)
+	}
+	m := &Manager{
+		cookies: cookies,
+		store:   store,
+		name:    defaultName,
+		ttl:     defaultTTL,
+		refresh: defaultRefresh,
+	}
+	for _, opt := range opts {
+		if err := opt(m); err != nil {
+			return nil, fmt.Errorf("%w: %w", cookie.ErrInitiation, err)
+		}
+	}
+	// WithRefresh stamps session cookies with their creation time, telling Save
+	// when an unmodified session was last saved
+	cookies, err := cookies.With(cookie.WithMaxAge(m.ttl), cookie.WithRefresh(m.refresh))
+	if err != nil {
+		return nil, err
+	}
+	m.cookies = cookies
+	if setter, ok := store.(cookie.ClockSetter); ok {
+		setter.SetClock(cookies)
+	}
+	return m, nil
+}
+
+// WithName sets the name of the session ID cookie.
+func WithName(name string) Option {
+	return func(m *Manager) error {
+		if name == "" {
+			return errors.New("empty session cookie name")
+		}
+		m.name = name
+		return nil
+	}
+}
+
+// WithTTL sets how long a session lives after it is last saved.
+func WithTTL(ttl time.Duration) Option {
+	return func(m *Manager) error {
+		if ttl < time.Second {
+			return fmt.Errorf("session ttl too short: %s", ttl)
+		}
+		m.ttl = ttl
+		return nil
+	}
+}
+
+// WithRefresh makes Save store an unmodified session again, extending its
+// lifetime, once it enters the final window of its TTL, such as 0.2 for the
+// last 20%, so sessions of active clients never lapse. Defaults to 0.5.
+func WithRefresh(window float64) Option {
+	return func(m *Manager) error {
+		if window <= 0 || window >= 1 {
+			return fmt.Errorf("session refresh window not between 0 and 1: %v", window)
+		}
+		m.refresh = window
+		return nil
+	}
+}
+
+// WithRevocation checks every loaded session against the cookie.Manager's
+// revocation store, with subject returning the user a session belongs to,
+// or "" for anonymous sessions. Sessions whose cookie was issued before
+// RevokeAll for their user are discarded, and Load returns a new session.
+func WithRevocation(subject func(*Session) string) Option {
+	return func(m *Manager) error {
+		if subject == nil {
+			return errors.New("revocation subject func is nil")
+		}
+		m.subject = subject
+		return nil
+	}
+}
+
+// WithIDFormat sets the format and entropy, in bytes, of new session IDs,
+// as NewSessionID takes them. SplitTokenStore needs IDs of at least
+// 32 characters, which rules out IDULID and short IDBase62 IDs.
+func WithIDFormat(format IDFormat, entropy int) Option {
+	return func(m *Manager) error {
+		if err := validateEntropy(format, entropy); err != nil {
+			return err
+		}
+		m.format = format
+		m.entropy = entropy
+		return nil
+	}
+}
+
+// Session holds the values of one client's session.
+type Session struct {
+	mu      sync.Mutex
+	id      string
+	isNew   bool
+	dirty   bool      // values changed, or Touch called, since load or last save
+	changes uint64    // count of modifications, so Save keeps those made while it stores
+	issued  time.Time // when the session cookie was last written; zero if unknown
+	values  map[string]json.RawMessage
+}
+
+// ID returns the session identifier.
+func (s *Session) ID() string {
+	return s.id
+}
+
+// IsNew reports whether the session was created by this request
+// rather than loaded from the store.
+func (s *Session) IsNew() bool {
+	return s.isNew
+}
+
+// Set stores v under key, replacing any existing value.
+// Setting the value already stored does not modify the session.
+func (s *Session) Set(key string, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("%w: unable to marshal '%s': %w", ErrSession, key, err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if current, ok := s.values[key]; ok && bytes.Equal(current, data) {
+		return nil
+	}
+	s.values[key] = data
+	s.modify()
+	return nil
+}
+
+// Delete removes the value stored under key.
+func (s *Session) Delete(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.values[key]; !ok {
+		return
+	}
+	delete(s.values, key)
+	s.modify()
+}
+
+// Modified reports whether the session's values changed, or Touch was called,
+// since it was loaded or last saved.
+func (s *Session) Modified() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.dirty
+}
+
+// Touch marks the session to be saved even if its values are unchanged,
+// extending its lifetime by the TTL.
+func (s *Session) Touch() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.modify()
+}
+
+// modify marks the session modified. The caller must hold s.mu.
+func (s *Session) modify() {
+	s.dirty = true
+	s.changes++
+}
+
+// Get returns the value stored under key as a T. ok is false
+// if there is no such value or it cannot be decoded as a T.
+func Get[T any](s *Session, key string) (value T, ok bool) {
+	s.mu.Lock()
+	data, found := s.values[key]
+	s.mu.Unlock()
+	if !found {
+		return value, false
+	}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return value, false
+	}
+	return value, true
+}
+
+// Load returns the session identified by the request's session cookie,
+// or a new empty session if the cookie is missing, invalid, or refers
+// to a session no longer in the store.
+func (m *Manager) Load(r *http.Request) (*Session, error) {
+	s, err := m.find(r)
+	if err != nil {
+		return nil, err
+	}
+	if s == nil {
+		return m.newSession()
+	}
+	return s, nil
+}
+
+// AuthenticateUpgrade returns the session of a WebSocket handshake, failing
+// with ErrUnauthenticated if r is not a WebSocket upgrade or carries no valid
+// session. Unlike Load, it never starts a new session, since no cookie can be
+// set once the connection is upgraded: call it before upgrading, and reject
+// the handshake on error.
+func (m *Manager) AuthenticateUpgrade(r *http.Request) (*Session, error) {
+	if !headerContains(r.Header, "Connection", "upgrade") || !headerContains(r.Header, "Upgrade", "websocket") {
+		return nil, fmt.Errorf("%w: %w: not a websocket handshake", ErrSession, ErrUnauthenticated)
+	}
+	s, err := m.find(r)
+	if err != nil {
+		return nil, err
+	}
+	if s == nil {
+		return nil, fmt.Errorf("%w: %w: no valid session", ErrSession, ErrUnauthenticated)
+	}
+	return s, nil
+}
+
+// find returns the session identified by the request's session cookie,
+// or nil if the cookie is missing, invalid, revoked, or refers to a session
+// no longer in the store.
+func (m *Manager) find(r *http.Request) (*Session, error) {
+	id, issued, err := m.cookies.ReadEncryptedValueIssued(r, m.name)
+	if err != nil {
+		return nil, nil
+	}
+	data, err := m.store.Get(r.Context(), id)
+	if errors.Is(err, ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: unable to load session: %w", ErrSession, err)
+	}
+	s := &Session{id: id, issued: issued}
+	if err := json.Unmarshal(data, &s.values); err != nil {
+		return nil, fmt.Errorf("%w: unable to decode session: %w", ErrSession, err)
+	}
+	if s.values == nil {
+		s.values = map[string]json.RawMessage{}
+	}
+	if m.subject != nil {
+		if subject := m.subject(s); subject != "" {
+			err := m.cookies.CheckRevoked(r.Context(), subject, issued)
+			if errors.Is(err, cookie.ErrRevoked) {
+				if err := m.store.Destroy(r.Context(), id); err != nil {
+					return nil, fmt.Errorf("%w: unable to destroy revoked session: %w", ErrSession, err)
+				}
+				return nil, nil
+			}
+			if err != nil {
+				return nil, fmt.Errorf("%w: %w", ErrSession, err)
+			}
+		}
+	}
+	return s, nil
+}
+
+// headerContains reports whether any comma-separated token of the header
+// matches token, ignoring case.
+func headerContains(header http.Header, name, token string) bool {
+	for _, value := range header.Values(name) {
+		for _, field := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(field), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Save stores the session and writes its ID cookie, extending its lifetime by the TTL.
+// A session loaded from the store and not modified is not written again until
+// it enters the refresh window set by WithRefresh, so most read-only requests
+// cost neither a store write nor a Set-Cookie header; call Touch first to
+// extend its lifetime anyway.
+func (m *Manager) Save(w http.ResponseWriter, r *http.Request, s *Session) error {
+	s.mu.Lock()
+	if !s.isNew && !s.dirty && !m.stale(s.issued) {
+		s.mu.Unlock()
+		return nil
+	}
+	changes := s.changes
+	data, err := json.Marshal(s.values)
+	s.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("%w: unable to encode session: %w", ErrSession, err)
+	}
+	expires := m.cookies.Now().Add(m.ttl)
+	if err := m.store.Save(r.Context(), s.id, data, expires); err != nil {
+		return fmt.Errorf("%w: unable to save session: %w", ErrSession, err)
+	}
+	if err := m.cookies.WriteEncryptedValue(w, m.name, s.id); err != nil {
+		return fmt.Errorf("%w: unable to write session cookie: %w", ErrSession, err)
+	}
+	s.mu.Lock()
+	s.isNew = false
+	s.issued = m.cookies.Now()
+	// changes made while storing are left for the next Save
+	if s.changes == changes {
+		s.dirty = false
+	}
+	s.mu.Unlock()
+	return nil
+}
+
+// stale reports whether a session whose cookie was issued at issued
+// has entered the refresh window, and should be saved again.
+func (m *Manager) stale(issued time.Time) bool {
+	if issued.IsZero() {
+		return true
+	}
+	return m.cookies.Now().Sub(issued) >= m.ttl-time.Duration(float64(m.ttl)*m.refresh)
+}
+
+// RotateID moves the session to a new ID, keeping its values, and removes the
+// old ID from the store so it can no longer be used. Call it at login and on any
+// change of privilege, so an ID planted before authentication is worthless after.
+func (m *Manager) RotateID(w http.ResponseWriter, r *http.Request, s *Session) error {
+	id, err := NewSessionID(m.format, m.entropy)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	old := s.id
+	s.id = id
+	s.modify()
+	s.mu.Unlock()
+	if err := m.Save(w, r, s); err != nil {
+		return err
+	}
+	if err := m.store.Destroy(r.Context(), old); err != nil {
+		return fmt.Errorf("%w: unable to destroy previous session: %w", ErrSession, err)
+	}
+	return nil
+}
+
+// Destroy removes the session from the store and expires its cookie.
+func (m *Manager) Destroy(w http.ResponseWriter, r *http.Request, s *Session) error {
+	if err := m.store.Destroy(r.Context(), s.id); err != nil {
+		return fmt.Errorf("%w: unable to destroy session: %w", ErrSession, err)
+	}
+	m.cookies.Delete(w, m.name)
+	return nil
+}
+
+func (m *Manager) newSession() (*Session, error) {
+	id, err := NewSessionID(m.format, m.entropy)
+	if err != nil {
+		return nil, err
+	}
+	return &Session{id: id, isNew: true, values: map[string]json.RawMessage{}}, nil
+}
@@ -0,0 +1,288 @@
+package session
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/grackleclub/cookie/v2"
+	"github.com/grackleclub/cookie/v2/cookietest"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestManager(t *testing.T, store Store, opts ...Option) *Manager {
+	t.Helper()
+	secretKey, err := cookie.NewCookieSecret()
+	require.NoError(t, err)
+	cookies, err := cookie.New(cookie.WithSecret(secretKey))
+	require.NoError(t, err)
+	m, err := New(cookies, store, opts...)
+	require.NoError(t, err)
+	return m
+}
+
+type cart struct {
+	Items []string
+	Total int
+}
+
+func TestSession(t *testing.T) {
+	store := NewMemoryStore(0)
+	m := newTestManager(t, store, WithName("sid"), WithTTL(time.Hour))
+
+	s, err := m.Load(httptest.NewRequest(http.MethodGet, "/", nil))
+	require.NoError(t, err)
+	require.True(t, s.IsNew())
+	require.NoError(t, s.Set("user", 1312))
+	require.NoError(t, s.Set("cart", cart{Items: []string{"fudge"}, Total: 3}))
+	require.NoError(t, s.Set("gone", true))
+	s.Delete("gone")
+
+	w := httptest.NewRecorder()
+	require.NoError(t, m.Save(w, httptest.NewRequest(http.MethodGet, "/", nil), s))
+	setCookie := w.Result().Cookies()[0]
+	require.Equal(t, "sid", setCookie.Name)
+	require.Equal(t, 3600, setCookie.MaxAge)
+	require.NotContains(t, setCookie.Value, s.ID())
+
+	loaded, err := m.Load(cookietest.RequestFrom(t, w))
+	require.NoError(t, err)
+	require.False(t, loaded.IsNew())
+	require.Equal(t, s.ID(), loaded.ID())
+
+	user, ok := Get[int](loaded, "user")
+	require.True(t, ok)
+	require.Equal(t, 1312, user)
+	c, ok := Get[cart](loaded, "cart")
+	require.True(t, ok)
+	require.Equal(t, cart{Items: []string{"fudge"}, Total: 3}, c)
+	_, ok = Get[bool](loaded, "gone")
+	require.False(t, ok)
+	_, ok = Get[int](loaded, "cart")
+	require.False(t, ok)
+
+	r := cookietest.RequestFrom(t, w)
+	d := httptest.NewRecorder()
+	require.NoError(t, m.Destroy(d, r, loaded))
+	require.Zero(t, store.Len())
+	require.Equal(t, -1, d.Result().Cookies()[0].MaxAge)
+
+	fresh, err := m.Load(r)
+	require.NoError(t, err)
+	require.True(t, fresh.IsNew())
+	require.NotEqual(t, s.ID(), fresh.ID())
+}
+
+func TestSaveUnmodified(t *testing.T) {
+	store := &countingStore{Store: NewMemoryStore(0)}
+	m := newTestManager(t, store)
+
+	s, err := m.Load(httptest.NewRequest(http.MethodGet, "/", nil))
+	require.NoError(t, err)
+	require.False(t, s.Modified())
+	require.NoError(t, s.Set("user", 1312))
+	require.True(t, s.Modified())
+	w := httptest.NewRecorder()
+	require.NoError(t, m.Save(w, httptest.NewRequest(http.MethodGet, "/", nil), s))
+	require.False(t, s.Modified())
+	require.Equal(t, 1, store.saves)
+
+	// a read-only request writes nothing
+	loaded, err := m.Load(cookietest.RequestFrom(t, w))
+	require.NoError(t, err)
+	_, ok := Get[int](loaded, "user")
+	require.True(t, ok)
+	require.NoError(t, loaded.Set("user", 1312))
+	loaded.Delete("missing")
+	require.False(t, loaded.Modified())
+	read := httptest.NewRecorder()
+	require.NoError(t, m.Save(read, cookietest.RequestFrom(t, w), loaded))
+	require.Empty(t, read.Result().Cookies())
+	require.Equal(t, 1, store.saves)
+
+	loaded.Touch()
+	touched := httptest.NewRecorder()
+	require.NoError(t, m.Save(touched, cookietest.RequestFrom(t, w), loaded))
+	require.Len(t, touched.Result().Cookies(), 1)
+	require.Equal(t, 2, store.saves)
+
+	loaded.Delete("user")
+	require.NoError(t, m.Save(httptest.NewRecorder(), cookietest.RequestFrom(t, w), loaded))
+	require.Equal(t, 3, store.saves)
+
+	// unmodified sessions are saved again once in the final half of the TTL
+	reloaded, err := m.Load(cookietest.RequestFrom(t, w))
+	require.NoError(t, err)
+	require.NoError(t, m.Save(httptest.NewRecorder(), cookietest.RequestFrom(t, w), reloaded))
+	require.Equal(t, 3, store.saves)
+	reloaded.issued = time.Now().Add(-13 * time.Hour)
+	refreshed := httptest.NewRecorder()
+	require.NoError(t, m.Save(refreshed, cookietest.RequestFrom(t, w), reloaded))
+	require.Len(t, refreshed.Result().Cookies(), 1)
+	require.Equal(t, 4, store.saves)
+}
+
+func TestSaveConcurrentChange(t *testing.T) {
+	store := &countingStore{Store: NewMemoryStore(0)}
+	m := newTestManager(t, store)
+	s, err := m.Load(httptest.NewRequest(http.MethodGet, "/", nil))
+	require.NoError(t, err)
+
+	// a change made while the session is being stored is kept for the next Save
+	store.onSave = func() { require.NoError(t, s.Set("late", true)) }
+	require.NoError(t, m.Save(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil), s))
+	require.True(t, s.Modified())
+	store.onSave = nil
+	w := httptest.NewRecorder()
+	require.NoError(t, m.Save(w, httptest.NewRequest(http.MethodGet, "/", nil), s))
+	require.False(t, s.Modified())
+
+	loaded, err := m.Load(cookietest.RequestFrom(t, w))
+	require.NoError(t, err)
+	late, ok := Get[bool](loaded, "late")
+	require.True(t, ok)
+	require.True(t, late)
+}
+
+// countingStore counts the sessions saved to a Store,
+// calling onSave, if set, during each save.
+type countingStore struct {
+	Store
+	saves  int
+	onSave func()
+}
+
+func (s *countingStore) Save(ctx context.Context, id string, data []byte, expires time.Time) error {
+	s.saves++
+	if s.onSave != nil {
+		s.onSave()
+	}
+	return s.Store.Save(ctx, id, data, expires)
+}
+
+func TestRotateID(t *testing.T) {
+	store := NewMemoryStore(0)
+	m := newTestManager(t, store)
+
+	s, err := m.Load(httptest.NewRequest(http.MethodGet, "/", nil))
+	require.NoError(t, err)
+	require.NoError(t, s.Set("user", 1312))
+	w := httptest.NewRecorder()
+	require.NoError(t, m.Save(w, httptest.NewRequest(http.MethodGet, "/", nil), s))
+	before := cookietest.RequestFrom(t, w)
+	old := s.ID()
+
+	rotated := httptest.NewRecorder()
+	require.NoError(t, m.RotateID(rotated, before, s))
+	require.NotEqual(t, old, s.ID())
+	require.Equal(t, 1, store.Len())
+
+	loaded, err := m.Load(cookietest.RequestFrom(t, rotated))
+	require.NoError(t, err)
+	require.Equal(t, s.ID(), loaded.ID())
+	user, ok := Get[int](loaded, "user")
+	require.True(t, ok)
+	require.Equal(t, 1312, user)
+
+	// the old cookie no longer loads the session
+	stale, err := m.Load(before)
+	require.NoError(t, err)
+	require.True(t, stale.IsNew())
+	require.NotEqual(t, old, stale.ID())
+}
+
+func TestRevocation(t *testing.T) {
+	secretKey, err := cookie.NewCookieSecret()
+	require.NoError(t, err)
+	cookies, err := cookie.New(
+		cookie.WithSecret(secretKey),
+		cookie.WithRevocationStore(cookie.NewMemoryRevocationStore(), time.Hour),
+	)
+	require.NoError(t, err)
+	store := NewMemoryStore(0)
+	m, err := New(cookies, store, WithRevocation(func(s *Session) string {
+		user, _ := Get[string](s, "user")
+		return user
+	}))
+	require.NoError(t, err)
+
+	save := func(user string) *http.Request {
+		s, err := m.Load(httptest.NewRequest(http.MethodGet, "/", nil))
+		require.NoError(t, err)
+		require.NoError(t, s.Set("user", user))
+		w := httptest.NewRecorder()
+		require.NoError(t, m.Save(w, httptest.NewRequest(http.MethodGet, "/", nil), s))
+		return cookietest.RequestFrom(t, w)
+	}
+	alice, bob := save("alice"), save("bob")
+	time.Sleep(time.Until(time.Now().Truncate(time.Second).Add(time.Second)))
+	require.NoError(t, cookies.RevokeAll(context.Background(), "alice"))
+
+	s, err := m.Load(alice)
+	require.NoError(t, err)
+	require.True(t, s.IsNew())
+	require.Equal(t, 1, store.Len())
+
+	s, err = m.Load(bob)
+	require.NoError(t, err)
+	require.False(t, s.IsNew())
+}
+
+func TestAuthenticateUpgrade(t *testing.T) {
+	m := newTestManager(t, NewMemoryStore(0))
+	handshake := func(r *http.Request) *http.Request {
+		r.Header.Set("Connection", "keep-alive, Upgrade")
+		r.Header.Set("Upgrade", "websocket")
+		return r
+	}
+
+	_, err := m.AuthenticateUpgrade(handshake(httptest.NewRequest(http.MethodGet, "/", nil)))
+	require.ErrorIs(t, err, ErrUnauthenticated)
+
+	s, err := m.Load(httptest.NewRequest(http.MethodGet, "/", nil))
+	require.NoError(t, err)
+	require.NoError(t, s.Set("user", "alice"))
+	w := httptest.NewRecorder()
+	require.NoError(t, m.Save(w, httptest.NewRequest(http.MethodGet, "/", nil), s))
+
+	_, err = m.AuthenticateUpgrade(cookietest.RequestFrom(t, w))
+	require.ErrorIs(t, err, ErrUnauthenticated)
+
+	loaded, err := m.AuthenticateUpgrade(handshake(cookietest.RequestFrom(t, w)))
+	require.NoError(t, err)
+	require.Equal(t, s.ID(), loaded.ID())
+	user, ok := Get[string](loaded, "user")
+	require.True(t, ok)
+	require.Equal(t, "alice", user)
+
+	require.NoError(t, m.Destroy(httptest.NewRecorder(), cookietest.RequestFrom(t, w), s))
+	_, err = m.AuthenticateUpgrade(handshake(cookietest.RequestFrom(t, w)))
+	require.ErrorIs(t, err, ErrUnauthenticated)
+	require.ErrorIs(t, err, ErrSession)
+}
+
+func TestSessionClock(t *testing.T) {
+	secretKey, err := cookie.NewCookieSecret()
+	require.NoError(t, err)
+	clock := cookietest.NewClock(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC))
+	cookies, err := cookie.New(cookie.WithSecret(secretKey), cookie.WithClock(clock))
+	require.NoError(t, err)
+	store := NewMemoryStore(0)
+	m, err := New(cookies, store, WithTTL(time.Hour))
+	require.NoError(t, err)
+
+	// the store expires sessions by the Manager's clock, not the system clock
+	s, err := m.Load(httptest.NewRequest(http.MethodGet, "/", nil))
+	require.NoError(t, err)
+	w := httptest.NewRecorder()
+	require.NoError(t, m.Save(w, httptest.NewRequest(http.MethodGet, "/", nil), s))
+	loaded, err := m.Load(cookietest.RequestFrom(t, w))
+	require.NoError(t, err)
+	require.Equal(t, s.ID(), loaded.ID())
+
+	clock.Advance(time.Hour)
+	_, err = store.Get(context.Background(), s.ID())
+	require.ErrorIs(t, err, ErrNotFound)
+}
@@ -0,0 +1,75 @@
+package session
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"time"
+
+	"github.com/grackleclub/cookie/v2"
+	"github.com/grackleclub/cookie/v2/internal/token"
+)
+
+// minSplitID is the shortest session ID SplitTokenStore accepts, so each
+// half carries enough entropy on its own.
+const minSplitID = 32
+
+// SplitTokenStore keeps sessions in another Store under half of their ID,
+// the selector, alongside only a sha256 hash of the other half, the verifier.
+// The session cookie still carries the whole ID, but a leak of the store
+// reveals no usable session IDs, and deleting a selector revokes its session.
+type SplitTokenStore struct {
+	store Store
+}
+
+// NewSplitTokenStore wraps store to keep sessions by selector and verifier hash.
+func NewSplitTokenStore(store Store) *SplitTokenStore {
+	return &SplitTokenStore{store: store}
+}
+
+// Selector returns the key under which the session with id is kept in the
+// underlying Store, for revoking a session found there.
+func Selector(id string) string {
+	return token.Halves(id).Selector
+}
+
+// SetClock passes clock to the underlying Store, if it keeps one.
+func (s *SplitTokenStore) SetClock(clock cookie.Clock) {
+	if setter, ok := s.store.(cookie.ClockSetter); ok {
+		setter.SetClock(clock)
+	}
+}
+
+// Get returns the data saved for id, or ErrNotFound if there is none,
+// it has expired, or the verifier does not match.
+func (s *SplitTokenStore) Get(ctx context.Context, id string) ([]byte, error) {
+	if len(id) < minSplitID {
+		return nil, ErrNotFound
+	}
+	split := token.Halves(id)
+	data, err := s.store.Get(ctx, split.Selector)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < sha256.Size {
+		return nil, fmt.Errorf("%w: stored session '%s' has no verifier", ErrSession, split.Selector)
+	}
+	if !split.Matches(data[:sha256.Size]) {
+		return nil, ErrNotFound
+	}
+	return data[sha256.Size:], nil
+}
+
+// Save stores data with the verifier hash of id under its selector until expires.
+func (s *SplitTokenStore) Save(ctx context.Context, id string, data []byte, expires time.Time) error {
+	if len(id) < minSplitID {
+		return fmt.Errorf("%w: session id too short to split", ErrSession)
+	}
+	split := token.Halves(id)
+	return s.store.Save(ctx, split.Selector, append(split.Hash(), data...), expires)
+}
+
+// Destroy removes the session kept under the selector of id.
+func (s *SplitTokenStore) Destroy(ctx context.Context, id string) error {
+	return s.store.Destroy(ctx, Selector(id))
+}
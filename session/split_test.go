@@ -0,0 +1,67 @@
+package session
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/grackleclub/cookie/v2/cookietest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitTokenStore(t *testing.T) {
+	ctx := context.Background()
+	inner := NewMemoryStore(0)
+	s := NewSplitTokenStore(inner)
+	id, err := NewSessionID(IDBase64, 0)
+	require.NoError(t, err)
+
+	require.NoError(t, s.Save(ctx, id, []byte(`{"a":1}`), time.Now().Add(time.Hour)))
+	data, err := s.Get(ctx, id)
+	require.NoError(t, err)
+	require.Equal(t, []byte(`{"a":1}`), data)
+
+	// the store holds only the selector and a hash of the verifier
+	stored, err := inner.Get(ctx, Selector(id))
+	require.NoError(t, err)
+	require.NotContains(t, string(stored), id[len(Selector(id)):])
+	_, err = inner.Get(ctx, id)
+	require.ErrorIs(t, err, ErrNotFound)
+
+	forged := Selector(id) + strings.Repeat("A", len(id)-len(Selector(id)))
+	_, err = s.Get(ctx, forged)
+	require.ErrorIs(t, err, ErrNotFound)
+	_, err = s.Get(ctx, "short")
+	require.ErrorIs(t, err, ErrNotFound)
+	require.ErrorIs(t, s.Save(ctx, "short", nil, time.Now().Add(time.Hour)), ErrSession)
+
+	require.NoError(t, inner.Destroy(ctx, Selector(id)))
+	_, err = s.Get(ctx, id)
+	require.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestSplitTokenSessions(t *testing.T) {
+	inner := NewMemoryStore(0)
+	m := newTestManager(t, NewSplitTokenStore(inner))
+
+	s, err := m.Load(httptest.NewRequest(http.MethodGet, "/", nil))
+	require.NoError(t, err)
+	require.NoError(t, s.Set("user", "alice"))
+	w := httptest.NewRecorder()
+	require.NoError(t, m.Save(w, httptest.NewRequest(http.MethodGet, "/", nil), s))
+
+	loaded, err := m.Load(cookietest.RequestFrom(t, w))
+	require.NoError(t, err)
+	require.False(t, loaded.IsNew())
+	user, ok := Get[string](loaded, "user")
+	require.True(t, ok)
+	require.Equal(t, "alice", user)
+
+	require.NoError(t, inner.Destroy(context.Background(), Selector(s.ID())))
+	loaded, err = m.Load(cookietest.RequestFrom(t, w))
+	require.NoError(t, err)
+	require.True(t, loaded.IsNew())
+}
@@ -0,0 +1,149 @@
+package session
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/grackleclub/cookie/v2"
+)
+
+// Dialect adapts SQLStore queries to a database's syntax.
+type Dialect struct {
+	name   string
+	schema string // CREATE TABLE statement with a %s placeholder for the table name
+	upsert string // INSERT or replace with a %s placeholder for the table name
+	bind   func(n int) string
+}
+
+func (d Dialect) String() string {
+	return d.name
+}
+
+// Schema returns the CREATE TABLE statement for a session table of the given name.
+func (d Dialect) Schema(table string) string {
+	return fmt.Sprintf(d.schema, table)
+}
+
+var (
+	Postgres = Dialect{
+		name: "postgres",
+		schema: `CREATE TABLE IF NOT EXISTS %s (
+	id      TEXT PRIMARY KEY,
+	data    BYTEA NOT NULL,
+	expires BIGINT NOT NULL
+)`,
+		upsert: `INSERT INTO %s (id, data, expires) VALUES ($1, $2, $3)
+ON CONFLICT (id) DO UPDATE SET data = EXCLUDED.data, expires = EXCLUDED.expires`,
+		bind: func(n int) string { return fmt.Sprintf("$%d", n) },
+	}
+	MySQL = Dialect{
+		name: "mysql",
+		schema: `CREATE TABLE IF NOT EXISTS %s (
+	id      VARCHAR(128) PRIMARY KEY,
+	data    MEDIUMBLOB NOT NULL,
+	expires BIGINT NOT NULL
+)`,
+		upsert: `INSERT INTO %s (id, data, expires) VALUES (?, ?, ?)
+ON DUPLICATE KEY UPDATE data = VALUES(data), expires = VALUES(expires)`,
+		bind: func(int) string { return "?" },
+	}
+	SQLite = Dialect{
+		name: "sqlite",
+		schema: `CREATE TABLE IF NOT EXISTS %s (
+	id      TEXT PRIMARY KEY,
+	data    BLOB NOT NULL,
+	expires INTEGER NOT NULL
+)`,
+		upsert: `INSERT INTO %s (id, data, expires) VALUES (?, ?, ?)
+ON CONFLICT (id) DO UPDATE SET data = excluded.data, expires = excluded.expires`,
+		bind: func(int) string { return "?" },
+	}
+)
+
+var tableName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// SQLStore is a Store backed by database/sql. Expiry is stored as unix seconds,
+// so the same schema works across dialects.
+type SQLStore struct {
+	db      *sql.DB
+	dialect Dialect
+	table   string
+	clock   cookie.Clock
+	queries struct {
+		get, save, destroy, deleteExpired string
+	}
+}
+
+// NewSQLStore creates a SQLStore using the given table, which can be created
+// with CreateTable or from Dialect.Schema.
+func NewSQLStore(db *sql.DB, dialect Dialect, table string) (*SQLStore, error) {
+	if db == nil {
+		return nil, errors.New("database is nil")
+	}
+	if !tableName.MatchString(table) {
+		return nil, fmt.Errorf("invalid table name: %q", table)
+	}
+	s := &SQLStore{db: db, dialect: dialect, table: table}
+	b := dialect.bind
+	s.queries.get = fmt.Sprintf("SELECT data FROM %s WHERE id = %s AND expires > %s", table, b(1), b(2))
+	s.queries.save = fmt.Sprintf(dialect.upsert, table)
+	s.queries.destroy = fmt.Sprintf("DELETE FROM %s WHERE id = %s", table, b(1))
+	s.queries.deleteExpired = fmt.Sprintf("DELETE FROM %s WHERE expires <= %s", table, b(1))
+	return s, nil
+}
+
+// CreateTable creates the session table if it does not already exist.
+func (s *SQLStore) CreateTable(ctx context.Context) error {
+	if _, err := s.db.ExecContext(ctx, s.dialect.Schema(s.table)); err != nil {
+		return fmt.Errorf("unable to create %s table '%s': %w", s.dialect, s.table, err)
+	}
+	return nil
+}
+
+// SetClock implements cookie.ClockSetter. Call it before using the store.
+func (s *SQLStore) SetClock(clock cookie.Clock) {
+	s.clock = clock
+}
+
+// Get returns the data saved for id, or ErrNotFound if there is none or it has expired.
+func (s *SQLStore) Get(ctx context.Context, id string) ([]byte, error) {
+	var data []byte
+	err := s.db.QueryRowContext(ctx, s.queries.get, id, clockNow(s.clock).Unix()).Scan(&data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to get session: %w", err)
+	}
+	return data, nil
+}
+
+// Save stores data for id until expires.
+func (s *SQLStore) Save(ctx context.Context, id string, data []byte, expires time.Time) error {
+	if _, err := s.db.ExecContext(ctx, s.queries.save, id, data, expires.Unix()); err != nil {
+		return fmt.Errorf("unable to save session: %w", err)
+	}
+	return nil
+}
+
+// Destroy removes the data for id.
+func (s *SQLStore) Destroy(ctx context.Context, id string) error {
+	if _, err := s.db.ExecContext(ctx, s.queries.destroy, id); err != nil {
+		return fmt.Errorf("unable to destroy session: %w", err)
+	}
+	return nil
+}
+
+// DeleteExpired removes every expired session, returning how many were removed.
+// Run it periodically, e.g. from a cron job or ticker.
+func (s *SQLStore) DeleteExpired(ctx context.Context) (int64, error) {
+	result, err := s.db.ExecContext(ctx, s.queries.deleteExpired, clockNow(s.clock).Unix())
+	if err != nil {
+		return 0, fmt.Errorf("unable to delete expired sessions: %w", err)
+	}
+	return result.RowsAffected()
+}
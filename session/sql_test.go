@@ -0,0 +1,13 @@
+package session
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDialectSchema(t *testing.T) {
+	for _, d := range []Dialect{Postgres, MySQL, SQLite} {
+		require.Contains(t, d.Schema("app_sessions"), "CREATE TABLE IF NOT EXISTS app_sessions")
+	}
+}
@@ -0,0 +1,3 @@
+// package sqltest runs session.SQLStore against real databases. It is a
+// separate module, so the session package does not depend on database drivers.
+package sqltest
@@ -0,0 +1,55 @@
+package sqltest
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/grackleclub/cookie/v2/cookietest"
+	"github.com/grackleclub/cookie/v2/session"
+	"github.com/stretchr/testify/require"
+	_ "modernc.org/sqlite"
+)
+
+func TestSQLStore(t *testing.T) {
+	ctx := context.Background()
+	db, err := sql.Open("sqlite", ":memory:")
+	require.NoError(t, err)
+	db.SetMaxOpenConns(1) // each connection to :memory: is a separate database
+	defer db.Close()
+
+	_, err = session.NewSQLStore(db, session.SQLite, "sessions; DROP TABLE users")
+	require.Error(t, err)
+
+	s, err := session.NewSQLStore(db, session.SQLite, "sessions")
+	require.NoError(t, err)
+	require.NoError(t, s.CreateTable(ctx))
+	require.NoError(t, s.CreateTable(ctx))
+
+	require.NoError(t, s.Save(ctx, "live", []byte("a"), time.Now().Add(time.Hour)))
+	require.NoError(t, s.Save(ctx, "live", []byte("b"), time.Now().Add(time.Hour)))
+	require.NoError(t, s.Save(ctx, "old", []byte("c"), time.Now().Add(-time.Hour)))
+
+	data, err := s.Get(ctx, "live")
+	require.NoError(t, err)
+	require.Equal(t, []byte("b"), data)
+
+	_, err = s.Get(ctx, "old")
+	require.ErrorIs(t, err, session.ErrNotFound)
+
+	n, err := s.DeleteExpired(ctx)
+	require.NoError(t, err)
+	require.EqualValues(t, 1, n)
+
+	require.NoError(t, s.Destroy(ctx, "live"))
+	_, err = s.Get(ctx, "live")
+	require.ErrorIs(t, err, session.ErrNotFound)
+
+	// expiry follows the clock a session Manager shares
+	require.NoError(t, s.Save(ctx, "later", []byte("d"), time.Now().Add(-time.Hour)))
+	s.SetClock(cookietest.NewClock(time.Now().Add(-2 * time.Hour)))
+	data, err = s.Get(ctx, "later")
+	require.NoError(t, err)
+	require.Equal(t, []byte("d"), data)
+}
@@ -0,0 +1,30 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/grackleclub/cookie/v2"
+)
+
+var ErrNotFound = errors.New("session not found")
+
+// Store persists encoded session data by session ID.
+type Store interface {
+	// Get returns the data saved for id, or ErrNotFound
+	// if there is none or it has expired.
+	Get(ctx context.Context, id string) ([]byte, error)
+	// Save stores data for id until expires, replacing any existing data.
+	Save(ctx context.Context, id string, data []byte, expires time.Time) error
+	// Destroy removes the data for id. Destroying a missing session is not an error.
+	Destroy(ctx context.Context, id string) error
+}
+
+// clockNow returns the time from clock, or the system clock if it is nil.
+func clockNow(clock cookie.Clock) time.Time {
+	if clock == nil {
+		return time.Now()
+	}
+	return clock.Now()
+}
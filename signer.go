@@ -0,0 +1,89 @@
+package cookie
+
+import (
+	"context"
+	"errors"
+	"net/http"
+)
+
+// Signer signs and verifies cookie values in place of the Manager's HMAC,
+// for example with a key held in an HSM or a remote signing service.
+type Signer interface {
+	Sign(data []byte) ([]byte, error)
+	// Verify returns an error unless signature was produced by Sign for data.
+	Verify(data, signature []byte) error
+}
+
+// Encrypter encrypts and decrypts cookie values in place of the Manager's
+// Cipher, for example with a cloud KMS. Implementations must authenticate
+// associatedData, as an AEAD does, and include any nonce in the ciphertext.
+type Encrypter interface {
+	Encrypt(plaintext, associatedData []byte) ([]byte, error)
+	Decrypt(ciphertext, associatedData []byte) ([]byte, error)
+}
+
+// ContextVerifier is implemented by Signers whose Verify makes remote calls,
+// so they stop once the request being read is canceled. The Manager calls
+// VerifyContext in place of Verify.
+type ContextVerifier interface {
+	VerifyContext(ctx context.Context, data, signature []byte) error
+}
+
+// ContextDecrypter is implemented by Encrypters whose Decrypt makes remote
+// calls, such as to unwrap a data key, so they stop once the request being
+// read is canceled. The Manager calls DecryptContext in place of Decrypt.
+type ContextDecrypter interface {
+	DecryptContext(ctx context.Context, ciphertext, associatedData []byte) ([]byte, error)
+}
+
+// WithSigner signs values with s instead of an HMAC over the Manager's secrets.
+// Values signed by either remain readable while both are configured.
+func WithSigner(s Signer) Option {
+	return func(m *Manager) error {
+		if s == nil {
+			return errors.New("nil signer")
+		}
+		m.signer = s
+		return nil
+	}
+}
+
+// WithEncrypter encrypts values with e instead of the Manager's Cipher.
+// Values encrypted by either remain readable while both are configured.
+func WithEncrypter(e Encrypter) Option {
+	return func(m *Manager) error {
+		if e == nil {
+			return errors.New("nil encrypter")
+		}
+		m.encrypter = e
+		return nil
+	}
+}
+
+// verify verifies signature with the Signer, in the context of r if it supports one.
+func (m *Manager) verify(r *http.Request, data, signature []byte) error {
+	if v, ok := m.signer.(ContextVerifier); ok && r != nil {
+		return v.VerifyContext(r.Context(), data, signature)
+	}
+	return m.signer.Verify(data, signature)
+}
+
+// decrypt decrypts ciphertext with the Encrypter, in the context of r if it supports one.
+func (m *Manager) decrypt(r *http.Request, ciphertext, associatedData []byte) ([]byte, error) {
+	if d, ok := m.encrypter.(ContextDecrypter); ok && r != nil {
+		return d.DecryptContext(r.Context(), ciphertext, associatedData)
+	}
+	return m.encrypter.Decrypt(ciphertext, associatedData)
+}
+
+// canRead reports whether the Manager has some way to open values in mode.
+func (m *Manager) canRead(mode Mode) error {
+	switch {
+	case mode == Plain || len(m.secretKeys) > 0 || m.source != nil:
+		return nil
+	case mode == Signed && m.signer != nil, mode == Encrypted && m.encrypter != nil:
+		return nil
+	default:
+		return ErrSecretMissing
+	}
+}
@@ -0,0 +1,98 @@
+package cookie
+
+import (
+	"crypto/ed25519"
+	"encoding/binary"
+	"errors"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type ed25519Signer struct {
+	privateKey ed25519.PrivateKey
+}
+
+func (s ed25519Signer) Sign(data []byte) ([]byte, error) {
+	return ed25519.Sign(s.privateKey, data), nil
+}
+
+func (s ed25519Signer) Verify(data, signature []byte) error {
+	if !ed25519.Verify(s.privateKey.Public().(ed25519.PublicKey), data, signature) {
+		return errors.New("invalid signature")
+	}
+	return nil
+}
+
+// xorEncrypter is a stand-in for a remote service: not secure, but it honors associated data.
+type xorEncrypter struct{}
+
+func (xorEncrypter) Encrypt(plaintext, associatedData []byte) ([]byte, error) {
+	return append([]byte{byte(len(associatedData))}, xor(plaintext)...), nil
+}
+
+func (xorEncrypter) Decrypt(ciphertext, associatedData []byte) ([]byte, error) {
+	if len(ciphertext) == 0 || ciphertext[0] != byte(len(associatedData)) {
+		return nil, errors.New("associated data mismatch")
+	}
+	return xor(ciphertext[1:]), nil
+}
+
+func xor(b []byte) []byte {
+	out := make([]byte, len(b))
+	for i := range b {
+		out[i] = b[i] ^ 0x5A
+	}
+	return out
+}
+
+func TestSignerEncrypter(t *testing.T) {
+	_, privateKey, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+
+	// no secrets are needed when both are plugged in
+	m, err := New(WithSigner(ed25519Signer{privateKey}), WithEncrypter(xorEncrypter{}))
+	require.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	require.NoError(t, m.WriteSigned(w, "s", "chocolate fudge"))
+	require.NoError(t, m.WriteEncryptedValue(w, "e", "oatmeal raisin"))
+
+	value, err := m.ReadSigned(requestWith(w), "s")
+	require.NoError(t, err)
+	require.Equal(t, "chocolate fudge", value)
+	value, err = m.ReadEncryptedValue(requestWith(w), "e")
+	require.NoError(t, err)
+	require.Equal(t, "oatmeal raisin", value)
+
+	t.Run("name prefix", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		require.NoError(t, m.WriteSigned(w, "ab", "cX"))
+		r := renamed(t, w, "ab", "a", func(body string) int {
+			return 2 + int(binary.BigEndian.Uint16([]byte(body[:2])))
+		})
+		_, err := m.ReadSigned(r, "a")
+		require.ErrorIs(t, err, ErrSignatureMismatch)
+	})
+
+	t.Run("other signer", func(t *testing.T) {
+		_, otherKey, err := ed25519.GenerateKey(nil)
+		require.NoError(t, err)
+		other, err := New(WithSigner(ed25519Signer{otherKey}))
+		require.NoError(t, err)
+		_, err = other.ReadSigned(requestWith(w), "s")
+		require.ErrorIs(t, err, ErrCookie)
+	})
+
+	t.Run("without plugins", func(t *testing.T) {
+		plain := newTestManager(t)
+		_, err := plain.ReadSigned(requestWith(w), "s")
+		require.ErrorIs(t, err, ErrCookie)
+		_, err = plain.ReadEncryptedValue(requestWith(w), "e")
+		require.ErrorIs(t, err, ErrCookie)
+	})
+
+	_, err = New(WithSigner(nil))
+	require.ErrorIs(t, err, ErrInitiation)
+}
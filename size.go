@@ -0,0 +1,29 @@
+package cookie
+
+import (
+	"encoding/base64"
+	"net/http"
+)
+
+// EstimateSize returns the serialized length of cookie as Write would set it,
+// after its value is base64 encoded. Compare it to the 4096-byte limit
+// to catch payloads that are growing too large before Write refuses them.
+func EstimateSize(cookie http.Cookie) int {
+	n := base64.URLEncoding.EncodedLen(len(cookie.Value))
+	cookie.Value = ""
+	return len(cookie.String()) + n
+}
+
+// EstimateSize returns the serialized length of the cookie the Manager would
+// write for name and value in mode, after sealing and encoding but before any
+// chunking. Values larger than the Manager's max size are refused, or split
+// across cookies when chunking is enabled.
+func (m *Manager) EstimateSize(name, value string, mode Mode) (int, error) {
+	cookie := m.Cookie(name, "")
+	sealed, err := m.seal(mode, cookie.Name, value, sealOptions{})
+	if err != nil {
+		return 0, err
+	}
+	cookie.Value = sealed
+	return encodedLen(cookie), nil
+}
@@ -0,0 +1,43 @@
+package cookie
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEstimateSize(t *testing.T) {
+	for _, value := range []string{"", "a", "ab", "abc", testCookie.Value} {
+		cookie := testCookie
+		cookie.Value = value
+		w := httptest.NewRecorder()
+		require.NoError(t, Write(w, cookie))
+		require.Equal(t, len(w.Header().Get("Set-Cookie")), EstimateSize(cookie))
+	}
+}
+
+func TestManagerEstimateSize(t *testing.T) {
+	m := newTestManager(t, WithPrefix(HostPrefix))
+	for _, mode := range []Mode{Plain, Signed, Encrypted} {
+		t.Run(mode.String(), func(t *testing.T) {
+			size, err := m.EstimateSize("a", "chocolate fudge", mode)
+			require.NoError(t, err)
+			w := httptest.NewRecorder()
+			require.NoError(t, m.writeMode(w, "a", "chocolate fudge", mode))
+			require.Equal(t, len(w.Header().Get("Set-Cookie")), size)
+		})
+	}
+
+	t.Run("limit", func(t *testing.T) {
+		small, err := m.With(WithMaxSize(256))
+		require.NoError(t, err)
+		value := strings.Repeat("x", 256)
+		size, err := small.EstimateSize("a", value, Signed)
+		require.NoError(t, err)
+		require.Greater(t, size, 256)
+		err = small.WriteSigned(httptest.NewRecorder(), "a", value)
+		require.ErrorIs(t, err, ErrCookie)
+	})
+}
@@ -0,0 +1,244 @@
+package cookie
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TenantSecrets loads the secrets of one tenant, such as from a database or
+// a secrets manager, when the tenant is first seen.
+type TenantSecrets interface {
+	TenantSecrets(ctx context.Context, tenant string) (SecretSource, error)
+}
+
+// TenantSecretsFunc adapts a function to TenantSecrets.
+type TenantSecretsFunc func(ctx context.Context, tenant string) (SecretSource, error)
+
+// TenantSecrets implements TenantSecrets.
+func (f TenantSecretsFunc) TenantSecrets(ctx context.Context, tenant string) (SecretSource, error) {
+	return f(ctx, tenant)
+}
+
+const (
+	defaultTenantLimit      = 10_000
+	defaultTenantFailureTTL = 30 * time.Second
+)
+
+// ErrUnknownTenant is returned for tenants refused by WithTenantAllow.
+var ErrUnknownTenant = errors.New("unknown tenant")
+
+// Tenants hands out a Manager per tenant for applications serving many
+// customers or custom domains, so that cookies issued for one tenant never
+// verify for another. Each tenant's Manager is a copy of a base Manager using
+// the tenant's secrets, loaded lazily and cached. Concurrent requests for a
+// tenant share one load, and failed loads are cached briefly, so requests
+// naming unknown hosts cannot flood the secrets backend. A Tenants is safe
+// for concurrent use.
+type Tenants struct {
+	base       *Manager
+	secrets    TenantSecrets
+	ttl        time.Duration
+	failureTTL time.Duration
+	limit      int
+	allow      func(tenant string) bool
+
+	mu       sync.Mutex
+	managers map[string]*tenantManager
+}
+
+// tenantManager is a cached tenant, or one being loaded until done is closed.
+type tenantManager struct {
+	done   chan struct{}
+	m      *Manager
+	err    error
+	loaded time.Time
+}
+
+// TenantOption configures Tenants.
+type TenantOption func(*Tenants) error
+
+// NewTenants creates Tenants from base, which supplies every attribute and
+// option but the secrets, loading each tenant's secrets from secrets. Loaded
+// secrets are cached for ttl, or until Forget when ttl is zero.
+func NewTenants(base *Manager, secrets TenantSecrets, ttl time.Duration, opts ...TenantOption) (*Tenants, error) {
+	if base == nil || secrets == nil {
+		return nil, fmt.Errorf("%w: base manager and tenant secrets are required", ErrInitiation)
+	}
+	if ttl < 0 {
+		return nil, fmt.Errorf("%w: negative tenant cache ttl: %s", ErrInitiation, ttl)
+	}
+	t := &Tenants{
+		base:       base,
+		secrets:    secrets,
+		ttl:        ttl,
+		failureTTL: defaultTenantFailureTTL,
+		limit:      defaultTenantLimit,
+		managers:   map[string]*tenantManager{},
+	}
+	for _, opt := range opts {
+		if err := opt(t); err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInitiation, err)
+		}
+	}
+	return t, nil
+}
+
+// WithTenantAllow refuses tenants for which allow returns false with
+// ErrUnknownTenant, without loading their secrets, such as to accept only
+// hosts under a known domain.
+func WithTenantAllow(allow func(tenant string) bool) TenantOption {
+	return func(t *Tenants) error {
+		if allow == nil {
+			return errors.New("tenant allow func is nil")
+		}
+		t.allow = allow
+		return nil
+	}
+}
+
+// WithTenantLimit bounds the number of cached tenants, evicting expired
+// tenants, then any others, to make room. Defaults to 10,000.
+func WithTenantLimit(limit int) TenantOption {
+	return func(t *Tenants) error {
+		if limit < 1 {
+			return fmt.Errorf("invalid tenant limit: %d", limit)
+		}
+		t.limit = limit
+		return nil
+	}
+}
+
+// WithTenantFailureTTL sets how long a failed load is cached, during which
+// the tenant's requests fail without calling TenantSecrets again. Zero
+// retries every request. Defaults to 30 seconds.
+func WithTenantFailureTTL(ttl time.Duration) TenantOption {
+	return func(t *Tenants) error {
+		if ttl < 0 {
+			return fmt.Errorf("negative tenant failure ttl: %s", ttl)
+		}
+		t.failureTTL = ttl
+		return nil
+	}
+}
+
+// Manager returns the Manager of tenant, loading its secrets if they are
+// not cached.
+func (t *Tenants) Manager(ctx context.Context, tenant string) (*Manager, error) {
+	if tenant == "" {
+		return nil, errors.New("empty tenant")
+	}
+	if t.allow != nil && !t.allow(tenant) {
+		return nil, fmt.Errorf("%w: '%s'", ErrUnknownTenant, tenant)
+	}
+	now := t.base.Now()
+	t.mu.Lock()
+	cached, ok := t.managers[tenant]
+	if ok && !t.loading(cached) && !t.expired(cached, now) {
+		t.mu.Unlock()
+		return cached.m, cached.err
+	}
+	if ok && t.loading(cached) {
+		t.mu.Unlock()
+		select {
+		case <-cached.done:
+			return cached.m, cached.err
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	load := &tenantManager{done: make(chan struct{})}
+	t.insert(tenant, load, now)
+	t.mu.Unlock()
+
+	load.m, load.err = t.load(ctx, tenant)
+	load.loaded = now
+	if load.err != nil && (t.failureTTL == 0 || ctx.Err() != nil) {
+		// a canceled request says nothing about the tenant
+		t.mu.Lock()
+		if t.managers[tenant] == load {
+			delete(t.managers, tenant)
+		}
+		t.mu.Unlock()
+	}
+	close(load.done)
+	return load.m, load.err
+}
+
+func (t *Tenants) load(ctx context.Context, tenant string) (*Manager, error) {
+	source, err := t.secrets.TenantSecrets(ctx, tenant)
+	if err != nil {
+		return nil, fmt.Errorf("unable to load secrets of tenant '%s': %w", tenant, err)
+	}
+	m, err := t.base.With(WithSecretSource(source))
+	if err != nil {
+		return nil, fmt.Errorf("tenant '%s': %w", tenant, err)
+	}
+	return m, nil
+}
+
+// insert caches tm, evicting expired tenants, then arbitrary ones, once the
+// limit is reached. Callers waiting on an evicted load still get its result.
+// t.mu must be held.
+func (t *Tenants) insert(tenant string, tm *tenantManager, now time.Time) {
+	delete(t.managers, tenant)
+	if len(t.managers) >= t.limit {
+		for name, cached := range t.managers {
+			if !t.loading(cached) && t.expired(cached, now) {
+				delete(t.managers, name)
+			}
+		}
+	}
+	for name := range t.managers {
+		if len(t.managers) < t.limit {
+			break
+		}
+		delete(t.managers, name)
+	}
+	t.managers[tenant] = tm
+}
+
+func (t *Tenants) loading(tm *tenantManager) bool {
+	select {
+	case <-tm.done:
+		return false
+	default:
+		return true
+	}
+}
+
+func (t *Tenants) expired(tm *tenantManager, now time.Time) bool {
+	if tm.err != nil {
+		return now.Sub(tm.loaded) >= t.failureTTL
+	}
+	return t.ttl > 0 && now.Sub(tm.loaded) >= t.ttl
+}
+
+// ForRequest returns the Manager of the tenant named by the request's host,
+// as returned by TenantHost.
+func (t *Tenants) ForRequest(r *http.Request) (*Manager, error) {
+	return t.Manager(r.Context(), TenantHost(r))
+}
+
+// Forget drops the cached secrets of tenant, so they are loaded again on next use,
+// such as after the tenant's keys are rotated.
+func (t *Tenants) Forget(tenant string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.managers, tenant)
+}
+
+// TenantHost returns the request's host in lower case, without a port or
+// trailing dot, for use as a tenant ID.
+func TenantHost(r *http.Request) string {
+	host := r.Host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	return strings.TrimSuffix(strings.ToLower(host), ".")
+}
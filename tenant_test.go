@@ -0,0 +1,176 @@
+package cookie
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTenants(t *testing.T) {
+	clock := &testClock{t: time.Unix(1_700_000_000, 0)}
+	base, err := New(WithPrefix(HostPrefix), WithClock(clock))
+	require.NoError(t, err)
+
+	loads := map[string]int{}
+	secrets := map[string]Secrets{}
+	for _, tenant := range []string{"a.example.com", "b.example.com"} {
+		secret, err := NewCookieSecret()
+		require.NoError(t, err)
+		secrets[tenant] = Secrets{secret}
+	}
+	tenants, err := NewTenants(base, TenantSecretsFunc(func(_ context.Context, tenant string) (SecretSource, error) {
+		loads[tenant]++
+		source, ok := secrets[tenant]
+		if !ok {
+			return nil, errors.New("no such tenant")
+		}
+		return source, nil
+	}), time.Hour)
+	require.NoError(t, err)
+
+	r := httptest.NewRequest(http.MethodGet, "https://A.example.com:8443/", nil)
+	require.Equal(t, "a.example.com", TenantHost(r))
+	a, err := tenants.ForRequest(r)
+	require.NoError(t, err)
+	w := httptest.NewRecorder()
+	require.NoError(t, a.WriteSigned(w, "user", "alice"))
+	require.Equal(t, "__Host-user", w.Result().Cookies()[0].Name)
+
+	read := requestWith(w)
+	read.TLS = r.TLS
+	value, err := a.ReadSigned(read, "user")
+	require.NoError(t, err)
+	require.Equal(t, "alice", value)
+
+	// another tenant's keys do not verify the cookie
+	b, err := tenants.Manager(context.Background(), "b.example.com")
+	require.NoError(t, err)
+	_, err = b.ReadSigned(read, "user")
+	require.ErrorIs(t, err, ErrUnknownKey)
+
+	// cached until the ttl passes or the tenant is forgotten
+	_, err = tenants.ForRequest(r)
+	require.NoError(t, err)
+	require.Equal(t, 1, loads["a.example.com"])
+	clock.t = clock.t.Add(time.Hour)
+	_, err = tenants.ForRequest(r)
+	require.NoError(t, err)
+	require.Equal(t, 2, loads["a.example.com"])
+	tenants.Forget("a.example.com")
+	_, err = tenants.ForRequest(r)
+	require.NoError(t, err)
+	require.Equal(t, 3, loads["a.example.com"])
+
+	_, err = tenants.Manager(context.Background(), "c.example.com")
+	require.ErrorContains(t, err, "no such tenant")
+	_, err = tenants.Manager(context.Background(), "")
+	require.Error(t, err)
+
+	_, err = NewTenants(nil, nil, 0)
+	require.ErrorIs(t, err, ErrInitiation)
+}
+
+func TestTenantsLoads(t *testing.T) {
+	clock := &testClock{t: time.Unix(1_700_000_000, 0)}
+	base, err := New(WithClock(clock))
+	require.NoError(t, err)
+	secret, err := NewCookieSecret()
+	require.NoError(t, err)
+
+	var loads atomic.Int32
+	release := make(chan struct{})
+	tenants, err := NewTenants(base, TenantSecretsFunc(func(_ context.Context, tenant string) (SecretSource, error) {
+		loads.Add(1)
+		<-release
+		if tenant == "unknown.example.com" {
+			return nil, errors.New("no such tenant")
+		}
+		return Secrets{secret}, nil
+	}), 0,
+		WithTenantAllow(func(tenant string) bool { return strings.HasSuffix(tenant, ".example.com") }),
+		WithTenantLimit(2),
+		WithTenantFailureTTL(time.Minute),
+	)
+	require.NoError(t, err)
+
+	t.Run("allow", func(t *testing.T) {
+		_, err := tenants.Manager(context.Background(), "evil.test")
+		require.ErrorIs(t, err, ErrUnknownTenant)
+		require.Zero(t, loads.Load())
+	})
+
+	t.Run("coalesced", func(t *testing.T) {
+		var wg sync.WaitGroup
+		managers := make([]*Manager, 8)
+		for i := range managers {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				managers[i], _ = tenants.Manager(context.Background(), "a.example.com")
+			}()
+		}
+		require.Eventually(t, func() bool { return loads.Load() == 1 }, time.Second, time.Millisecond)
+		close(release)
+		wg.Wait()
+		require.EqualValues(t, 1, loads.Load())
+		for _, m := range managers {
+			require.NotNil(t, m)
+			require.Same(t, managers[0], m)
+		}
+	})
+
+	t.Run("failure cached", func(t *testing.T) {
+		loads.Store(0)
+		for range 3 {
+			_, err := tenants.Manager(context.Background(), "unknown.example.com")
+			require.ErrorContains(t, err, "no such tenant")
+		}
+		require.EqualValues(t, 1, loads.Load())
+		clock.t = clock.t.Add(time.Minute)
+		_, err := tenants.Manager(context.Background(), "unknown.example.com")
+		require.Error(t, err)
+		require.EqualValues(t, 2, loads.Load())
+	})
+
+	t.Run("canceled not cached", func(t *testing.T) {
+		loads.Store(0)
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		canceled, err := NewTenants(base, TenantSecretsFunc(func(ctx context.Context, _ string) (SecretSource, error) {
+			loads.Add(1)
+			return nil, ctx.Err()
+		}), 0)
+		require.NoError(t, err)
+		_, err = canceled.Manager(ctx, "a.example.com")
+		require.ErrorIs(t, err, context.Canceled)
+		_, err = canceled.Manager(ctx, "a.example.com")
+		require.ErrorIs(t, err, context.Canceled)
+		require.EqualValues(t, 2, loads.Load())
+	})
+
+	t.Run("bounded", func(t *testing.T) {
+		for _, tenant := range []string{"b.example.com", "c.example.com", "d.example.com"} {
+			_, err := tenants.Manager(context.Background(), tenant)
+			require.NoError(t, err)
+		}
+		tenants.mu.Lock()
+		defer tenants.mu.Unlock()
+		require.Len(t, tenants.managers, 2)
+		require.Contains(t, tenants.managers, "d.example.com")
+	})
+
+	for _, opt := range []TenantOption{WithTenantAllow(nil), WithTenantLimit(0), WithTenantFailureTTL(-time.Second)} {
+		_, err := NewTenants(base, TenantSecretsFunc(func(context.Context, string) (SecretSource, error) {
+			return Secrets{secret}, nil
+		}), 0, opt)
+		require.ErrorIs(t, err, ErrInitiation)
+	}
+}
@@ -0,0 +1,164 @@
+package cookie
+
+import (
+	"encoding/base64"
+	"net/http"
+	"sync"
+)
+
+// maxTrackedNames bounds how many cookie names Track remembers the scope of.
+const maxTrackedNames = 1024
+
+// Track records the cookies a Manager writes through the handler it wraps,
+// across requests, and coalesces each response's Set-Cookie headers as
+// Coalesce does, so a cookie written more than once reaches the client once.
+//
+// Delete reuses the Path, Domain, and other attributes a cookie was last
+// written with, in this response or an earlier one. A write is skipped when
+// the request already carries the same value under that name and the
+// attributes match those last written, saving a Set-Cookie header. Writes
+// with a Max-Age are always sent, since sending them extends the cookie's
+// lifetime, as are chunked values. Only writes made through a Manager are
+// recorded.
+func Track(next http.Handler) http.Handler {
+	scopes := &trackedScopes{byName: map[string]http.Cookie{}}
+	return Coalesce(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(&trackWriter{
+			ResponseWriter: w,
+			scopes:         scopes,
+			held:           heldCookies(r),
+			written:        map[string]http.Cookie{},
+			touched:        map[string]bool{},
+		}, r)
+	}))
+}
+
+// trackedScopes remembers, by full name, the attributes each cookie was last
+// written with, across the requests of one Track handler.
+type trackedScopes struct {
+	mu     sync.Mutex
+	byName map[string]http.Cookie // without values
+}
+
+func (s *trackedScopes) remember(cookie http.Cookie) {
+	cookie.Value = ""
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.byName[cookie.Name]; ok || len(s.byName) < maxTrackedNames {
+		s.byName[cookie.Name] = cookie
+	}
+}
+
+func (s *trackedScopes) lookup(name string) (http.Cookie, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	cookie, ok := s.byName[name]
+	return cookie, ok
+}
+
+// trackWriter records the cookies written to a response by full name.
+type trackWriter struct {
+	http.ResponseWriter
+	scopes  *trackedScopes
+	held    map[string]string      // raw values of the request's cookies, by name
+	written map[string]http.Cookie // written to this response and not since deleted
+	touched map[string]bool        // written or deleted in this response
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (w *trackWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// unchanged reports whether writing cookie would tell the client nothing new:
+// the request holds its value, it was last written with the same attributes,
+// and nothing in this response has written or deleted it. Such a cookie is
+// recorded as written, for Delete, without a Set-Cookie header.
+func (w *trackWriter) unchanged(cookie http.Cookie) bool {
+	if cookie.MaxAge != 0 || w.touched[cookie.Name] {
+		return false
+	}
+	held, ok := w.held[cookie.Name]
+	if !ok || held != base64.RawURLEncoding.EncodeToString([]byte(cookie.Value)) {
+		return false
+	}
+	prev, ok := w.scopes.lookup(cookie.Name)
+	if !ok || !sameAttributes(prev, cookie) {
+		return false
+	}
+	w.written[cookie.Name] = cookie
+	return true
+}
+
+// heldCookies returns the values of the request's cookies by name, leaving
+// out names sent more than once, whose scope cannot be told apart.
+func heldCookies(r *http.Request) map[string]string {
+	held := map[string]string{}
+	seen := map[string]bool{}
+	for _, c := range r.Cookies() {
+		if seen[c.Name] {
+			delete(held, c.Name)
+			continue
+		}
+		seen[c.Name] = true
+		held[c.Name] = c.Value
+	}
+	return held
+}
+
+// sameAttributes reports whether a and b set the same attributes, ignoring values.
+func sameAttributes(a, b http.Cookie) bool {
+	return a.Path == b.Path &&
+		a.Domain == b.Domain &&
+		a.Expires.Equal(b.Expires) &&
+		a.MaxAge == b.MaxAge &&
+		a.Secure == b.Secure &&
+		a.HttpOnly == b.HttpOnly &&
+		a.SameSite == b.SameSite &&
+		a.Partitioned == b.Partitioned
+}
+
+// tracker returns the trackWriter w is or wraps, if any.
+func tracker(w http.ResponseWriter) *trackWriter {
+	for {
+		switch v := w.(type) {
+		case *trackWriter:
+			return v
+		case interface{ Unwrap() http.ResponseWriter }:
+			w = v.Unwrap()
+		default:
+			return nil
+		}
+	}
+}
+
+// track records cookie as written to w.
+func track(w http.ResponseWriter, cookie http.Cookie) {
+	if t := tracker(w); t != nil {
+		t.written[cookie.Name] = cookie
+		t.touched[cookie.Name] = true
+		t.scopes.remember(cookie)
+	}
+}
+
+// unchanged reports whether w is tracked and cookie need not be written to it.
+func unchanged(w http.ResponseWriter, cookie http.Cookie) bool {
+	t := tracker(w)
+	return t != nil && t.unchanged(cookie)
+}
+
+// written returns the cookie last written under the full name, to this
+// response or, failing that, an earlier one through the same Track handler,
+// and forgets it for this response.
+func written(w http.ResponseWriter, name string) (http.Cookie, bool) {
+	t := tracker(w)
+	if t == nil {
+		return http.Cookie{}, false
+	}
+	t.touched[name] = true
+	if prev, ok := t.written[name]; ok {
+		delete(t.written, name)
+		return prev, true
+	}
+	return t.scopes.lookup(name)
+}
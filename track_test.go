@@ -0,0 +1,107 @@
+package cookie
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTrack(t *testing.T) {
+	m := newTestManager(t)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, m.WriteSigned(w, "theme", "dark"))
+		require.NoError(t, m.WriteSigned(w, "theme", "dark"))
+		require.NoError(t, m.WriteEncryptedValue(w, "theme", "dark")) // the last write wins
+
+		admin := m.Cookie("user", "alice")
+		admin.Path = "/admin"
+		admin.Domain = "example.com"
+		require.NoError(t, m.WriteAll(w, []http.Cookie{admin}, Signed))
+		m.Delete(w, "user")
+	})
+
+	w := httptest.NewRecorder()
+	Track(handler).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	cookies := w.Result().Cookies()
+	require.Len(t, cookies, 2)
+	require.Equal(t, "theme", cookies[0].Name)
+	value, err := m.ReadEncryptedValue(requestWith(w), "theme")
+	require.NoError(t, err)
+	require.Equal(t, "dark", value)
+	deleted := cookies[1]
+	require.Equal(t, "user", deleted.Name)
+	require.Equal(t, -1, deleted.MaxAge)
+	require.Equal(t, "/admin", deleted.Path)
+	require.Equal(t, "example.com", deleted.Domain)
+
+	// without Track every write is sent, and Delete uses the defaults
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	cookies = w.Result().Cookies()
+	require.Len(t, cookies, 5)
+	require.Equal(t, "/", cookies[4].Path)
+}
+
+func TestTrackDeleteThenWrite(t *testing.T) {
+	m := newTestManager(t, WithChunking(16<<10))
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, m.WriteSigned(w, "theme", "dark"))
+		m.Delete(w, "theme")
+		require.NoError(t, m.WriteSigned(w, "theme", "dark"))
+	})
+	w := httptest.NewRecorder()
+	Track(handler).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	cookies := w.Result().Cookies()
+	require.Len(t, cookies, 2)
+	require.Equal(t, "theme.0", cookies[0].Name)
+	require.Negative(t, cookies[0].MaxAge)
+	require.Equal(t, "theme", cookies[1].Name)
+	require.Zero(t, cookies[1].MaxAge)
+}
+
+func TestTrackAcrossRequests(t *testing.T) {
+	m := newTestManager(t)
+	lasting, err := m.With(WithMaxAge(time.Hour))
+	require.NoError(t, err)
+	var action func(w http.ResponseWriter)
+	handler := Track(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		action(w)
+	}))
+	serve := func(r *http.Request, do func(w http.ResponseWriter)) *httptest.ResponseRecorder {
+		action = do
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w
+	}
+	write := func(name, value string) func(w http.ResponseWriter) {
+		return func(w http.ResponseWriter) {
+			c := m.Cookie(name, value)
+			c.Path = "/admin"
+			require.NoError(t, m.WriteAll(w, []http.Cookie{c}, Signed))
+		}
+	}
+
+	first := serve(httptest.NewRequest(http.MethodGet, "/", nil), write("user", "alice"))
+	require.Len(t, first.Result().Cookies(), 1)
+
+	// a value the client already holds is not sent again
+	w := serve(requestWith(first), write("user", "alice"))
+	require.Empty(t, w.Result().Cookies())
+	w = serve(requestWith(first), write("user", "bob"))
+	require.Len(t, w.Result().Cookies(), 1)
+
+	// a later request deletes the cookie with the scope it was written with
+	w = serve(requestWith(first), func(w http.ResponseWriter) { m.Delete(w, "user") })
+	deleted := w.Result().Cookies()[0]
+	require.Equal(t, -1, deleted.MaxAge)
+	require.Equal(t, "/admin", deleted.Path)
+
+	// writes with a Max-Age are sent again, to extend the cookie's lifetime
+	writeLasting := func(w http.ResponseWriter) { require.NoError(t, lasting.Write(w, "theme", "dark")) }
+	first = serve(httptest.NewRequest(http.MethodGet, "/", nil), writeLasting)
+	w = serve(requestWith(first), writeLasting)
+	require.Len(t, w.Result().Cookies(), 1)
+}
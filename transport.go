@@ -0,0 +1,111 @@
+package cookie
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+)
+
+// Transport is an http.RoundTripper for clients sharing the Manager's secrets,
+// such as other services and integration tests. It attaches the cookies given
+// to Set to every request, and checks the cookies named in Verify on every
+// response, failing the round trip if one does not verify.
+//
+// Use it with a Client whose Jar keeps the cookies set by the server.
+type Transport struct {
+	Manager *Manager
+	Base    http.RoundTripper // nil uses http.DefaultTransport
+	Verify  map[string]Mode   // response cookies to check, by unprefixed name
+
+	mu      sync.Mutex
+	cookies map[string][]*http.Cookie // sealed by Set, including chunks
+}
+
+// Set seals value in mode with the Manager's defaults, to attach to every request.
+func (t *Transport) Set(name, value string, mode Mode) error {
+	w := &headerWriter{header: http.Header{}}
+	if err := t.Manager.writeMode(w, name, value, mode); err != nil {
+		return err
+	}
+	var cookies []*http.Cookie
+	for _, line := range w.header.Values("Set-Cookie") {
+		c, err := http.ParseSetCookie(line)
+		if err != nil {
+			return err
+		}
+		if c.MaxAge >= 0 {
+			cookies = append(cookies, c)
+		}
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.cookies == nil {
+		t.cookies = map[string][]*http.Cookie{}
+	}
+	t.cookies[name] = cookies
+	return nil
+}
+
+// Remove stops attaching the cookie named name.
+func (t *Transport) Remove(name string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.cookies, name)
+}
+
+// RoundTrip attaches the cookies to a copy of req, sends it with Base,
+// and verifies the response's cookies.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	t.mu.Lock()
+	for _, cookies := range t.cookies {
+		for _, c := range cookies {
+			req.AddCookie(c)
+		}
+	}
+	t.mu.Unlock()
+
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	resp, err := base.RoundTrip(req)
+	if err != nil || len(t.Verify) == 0 {
+		return resp, err
+	}
+	if err := t.verify(resp); err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+	return resp, nil
+}
+
+// verify reads each cookie in Verify that resp sets, as the server would on
+// the next request. Cookies resp does not set, or expires, are not checked.
+func (t *Transport) verify(resp *http.Response) error {
+	probe, err := http.NewRequestWithContext(resp.Request.Context(), http.MethodGet, resp.Request.URL.String(), nil)
+	if err != nil {
+		return err
+	}
+	probe.TLS = resp.TLS
+	for _, c := range resp.Cookies() {
+		if c.MaxAge >= 0 {
+			probe.AddCookie(c)
+		}
+	}
+	for name, mode := range t.Verify {
+		if _, err := t.Manager.readOpened(probe, name, mode); err != nil && !errors.Is(err, ErrNotFound) {
+			return err
+		}
+	}
+	return nil
+}
+
+// headerWriter collects the headers written by the Manager; bodies are discarded.
+type headerWriter struct {
+	header http.Header
+}
+
+func (w *headerWriter) Header() http.Header         { return w.header }
+func (w *headerWriter) Write(b []byte) (int, error) { return len(b), nil }
+func (w *headerWriter) WriteHeader(int)             {}
@@ -0,0 +1,61 @@
+package cookie
+
+import (
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransport(t *testing.T) {
+	m := newTestManager(t, WithChunking(16<<10))
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, err := m.ReadSigned(r, "user")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		if _, err := m.ReadEncryptedValue(r, "token"); err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/forged":
+			http.SetCookie(w, &http.Cookie{Name: "theme", Value: "forged"})
+		default:
+			require.NoError(t, m.WriteSigned(w, "theme", "dark"))
+		}
+		w.Write([]byte(user))
+	}))
+	defer srv.Close()
+
+	transport := &Transport{Manager: m, Base: srv.Client().Transport, Verify: map[string]Mode{"theme": Signed}}
+	jar, err := cookiejar.New(nil)
+	require.NoError(t, err)
+	client := &http.Client{Transport: transport, Jar: jar}
+
+	resp, err := client.Get(srv.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	require.NoError(t, transport.Set("user", "alice", Signed))
+	require.NoError(t, transport.Set("token", strings.Repeat("s", 6000), Encrypted))
+	resp, err = client.Get(srv.URL)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Len(t, jar.Cookies(resp.Request.URL), 1)
+
+	_, err = client.Get(srv.URL + "/forged")
+	require.ErrorIs(t, err, ErrCookie)
+
+	transport.Remove("user")
+	resp, err = client.Get(srv.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+}
@@ -0,0 +1,68 @@
+package cookie
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// WriteInt writes n as a decimal cookie value, sealed according to mode.
+func (m *Manager) WriteInt(w http.ResponseWriter, name string, n int64, mode Mode) error {
+	return m.writeMode(w, name, strconv.FormatInt(n, 10), mode)
+}
+
+// ReadInt reads a cookie written by WriteInt with the same mode.
+func (m *Manager) ReadInt(r *http.Request, name string, mode Mode) (int64, error) {
+	return readTyped(m, r, name, mode, "an integer", func(s string) (int64, error) {
+		return strconv.ParseInt(s, 10, 64)
+	})
+}
+
+// WriteBool writes b as "true" or "false", sealed according to mode.
+func (m *Manager) WriteBool(w http.ResponseWriter, name string, b bool, mode Mode) error {
+	return m.writeMode(w, name, strconv.FormatBool(b), mode)
+}
+
+// ReadBool reads a cookie written by WriteBool with the same mode.
+func (m *Manager) ReadBool(r *http.Request, name string, mode Mode) (bool, error) {
+	return readTyped(m, r, name, mode, "a bool", strconv.ParseBool)
+}
+
+// WriteTime writes t in RFC 3339 format with nanoseconds, sealed according to mode.
+func (m *Manager) WriteTime(w http.ResponseWriter, name string, t time.Time, mode Mode) error {
+	return m.writeMode(w, name, t.Format(time.RFC3339Nano), mode)
+}
+
+// ReadTime reads a cookie written by WriteTime with the same mode.
+func (m *Manager) ReadTime(r *http.Request, name string, mode Mode) (time.Time, error) {
+	return readTyped(m, r, name, mode, "an RFC 3339 time", func(s string) (time.Time, error) {
+		return time.Parse(time.RFC3339Nano, s)
+	})
+}
+
+// WriteUUID writes id in its canonical hyphenated form, sealed according to mode.
+func (m *Manager) WriteUUID(w http.ResponseWriter, name string, id uuid.UUID, mode Mode) error {
+	return m.writeMode(w, name, id.String(), mode)
+}
+
+// ReadUUID reads a cookie written by WriteUUID with the same mode.
+func (m *Manager) ReadUUID(r *http.Request, name string, mode Mode) (uuid.UUID, error) {
+	return readTyped(m, r, name, mode, "a UUID", uuid.Parse)
+}
+
+// readTyped reads a cookie with mode and converts its value with parse.
+func readTyped[T any](m *Manager, r *http.Request, name string, mode Mode, kind string, parse func(string) (T, error)) (T, error) {
+	var zero T
+	value, err := m.readMode(r, name, mode)
+	if err != nil {
+		return zero, err
+	}
+	v, err := parse(value)
+	if err != nil {
+		return zero, fmt.Errorf("%w: '%s' is not %s: %w", ErrCookie, name, kind, err)
+	}
+	return v, nil
+}
@@ -0,0 +1,57 @@
+package cookie
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTyped(t *testing.T) {
+	m := newTestManager(t)
+	when := time.Date(2024, 5, 1, 12, 30, 0, 500, time.FixedZone("EST", -5*3600))
+	id := uuid.New()
+	for _, mode := range []Mode{Plain, Signed, Encrypted} {
+		t.Run(mode.String(), func(t *testing.T) {
+			w := httptest.NewRecorder()
+			require.NoError(t, m.WriteInt(w, "visits", -42, mode))
+			require.NoError(t, m.WriteBool(w, "sidebar", true, mode))
+			require.NoError(t, m.WriteTime(w, "seen", when, mode))
+			require.NoError(t, m.WriteUUID(w, "device", id, mode))
+			r := requestWith(w)
+
+			n, err := m.ReadInt(r, "visits", mode)
+			require.NoError(t, err)
+			require.Equal(t, int64(-42), n)
+			b, err := m.ReadBool(r, "sidebar", mode)
+			require.NoError(t, err)
+			require.True(t, b)
+			seen, err := m.ReadTime(r, "seen", mode)
+			require.NoError(t, err)
+			require.True(t, when.Equal(seen))
+			device, err := m.ReadUUID(r, "device", mode)
+			require.NoError(t, err)
+			require.Equal(t, id, device)
+		})
+	}
+}
+
+func TestTypedErrors(t *testing.T) {
+	m := newTestManager(t)
+	w := httptest.NewRecorder()
+	require.NoError(t, m.WriteSigned(w, "visits", "many"))
+	r := requestWith(w)
+
+	_, err := m.ReadInt(r, "visits", Signed)
+	require.ErrorIs(t, err, ErrCookie)
+	require.ErrorContains(t, err, "'visits' is not an integer")
+
+	_, err = m.ReadUUID(r, "visits", Signed)
+	require.ErrorContains(t, err, "is not a UUID")
+
+	_, err = m.ReadBool(httptest.NewRequest(http.MethodGet, "/", nil), "sidebar", Plain)
+	require.ErrorIs(t, err, ErrNotFound)
+}
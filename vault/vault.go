@@ -0,0 +1,412 @@
+// package vault signs cookies with HashiCorp Vault's transit secrets engine,
+// so signing keys never leave Vault. A Transit plugs into a cookie.Manager
+// with cookie.WithSigner.
+//
+// By default a Transit computes HMACs, which only Vault can check, so every
+// cookie read is a round trip to Vault. With WithPublicKeys it signs with an
+// ed25519 or ecdsa transit key instead and verifies signatures locally.
+package vault
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/grackleclub/cookie/v2"
+)
+
+const (
+	defaultMount       = "transit"
+	defaultTimeout     = 5 * time.Second
+	defaultRenewBefore = 5 * time.Minute
+	publicKeyRefresh   = time.Minute
+)
+
+var ErrVault = errors.New("vault failure")
+
+// Transit is a cookie.Signer computing HMACs, or signatures, with a Vault
+// transit key. Verification honors the request's context, as a
+// cookie.ContextVerifier.
+type Transit struct {
+	client      *http.Client
+	addr        string
+	mount       string
+	key         string
+	timeout     time.Duration
+	renewBefore time.Duration
+	local       bool // sign with the private key, verify against the public keys
+
+	mu           sync.Mutex
+	token        string
+	tokenChecked bool
+	tokenExpires time.Time // zero for tokens that never expire
+
+	keysMu     sync.Mutex
+	publicKeys map[int]crypto.PublicKey // by key version
+	fetched    time.Time
+}
+
+// Option configures a Transit.
+type Option func(*Transit) error
+
+// New creates a Transit signing with the named transit key, authenticating
+// to the Vault server at addr with token. The token's TTL is looked up on
+// first use and the token is renewed before it expires.
+func New(addr, token, key string, opts ...Option) (*Transit, error) {
+	if addr == "" || key == "" {
+		return nil, fmt.Errorf("%w: vault address and key are required", cookie.ErrInitiation)
+	}
+	if token == "" {
+		return nil, fmt.Errorf("%w: %w", cookie.ErrInitiation, cookie.ErrSecretMissing)
+	}
+	t := &Transit{
+		client:      http.DefaultClient,
+		addr:        strings.TrimRight(addr, "/"),
+		mount:       defaultMount,
+		key:         key,
+		timeout:     defaultTimeout,
+		renewBefore: defaultRenewBefore,
+		token:       token,
+	}
+	for _, opt := range opts {
+		if err := opt(t); err != nil {
+			return nil, fmt.Errorf("%w: %w", cookie.ErrInitiation, err)
+		}
+	}
+	return t, nil
+}
+
+// WithMount sets the path the transit engine is mounted at; the default is "transit".
+func WithMount(mount string) Option {
+	return func(t *Transit) error {
+		mount = strings.Trim(mount, "/")
+		if mount == "" {
+			return errors.New("empty transit mount")
+		}
+		t.mount = mount
+		return nil
+	}
+}
+
+// WithHTTPClient sets the client used to reach Vault, for example to configure TLS.
+func WithHTTPClient(client *http.Client) Option {
+	return func(t *Transit) error {
+		if client == nil {
+			return errors.New("nil http client")
+		}
+		t.client = client
+		return nil
+	}
+}
+
+// WithTimeout bounds each request to Vault. The default is 5 seconds.
+func WithTimeout(timeout time.Duration) Option {
+	return func(t *Transit) error {
+		if timeout <= 0 {
+			return fmt.Errorf("invalid timeout: %s", timeout)
+		}
+		t.timeout = timeout
+		return nil
+	}
+}
+
+// WithRenewBefore sets how long before expiry the token is renewed.
+// The default is 5 minutes.
+func WithRenewBefore(renewBefore time.Duration) Option {
+	return func(t *Transit) error {
+		if renewBefore < 0 {
+			return fmt.Errorf("negative renewal window: %s", renewBefore)
+		}
+		t.renewBefore = renewBefore
+		return nil
+	}
+}
+
+// WithPublicKeys signs with the private half of an ed25519 or ecdsa transit
+// key, and verifies signatures locally against its public keys, so reads
+// make no request to Vault. The public keys are fetched on first use, and
+// again, at most once a minute, when a cookie names an unknown key version.
+func WithPublicKeys() Option {
+	return func(t *Transit) error {
+		t.local = true
+		return nil
+	}
+}
+
+// Sign implements cookie.Signer, returning Vault's versioned HMAC of data,
+// or its signature with WithPublicKeys.
+func (t *Transit) Sign(data []byte) ([]byte, error) {
+	if t.local {
+		var resp struct {
+			Data struct {
+				Signature string `json:"signature"`
+			} `json:"data"`
+		}
+		body := map[string]string{"input": base64.StdEncoding.EncodeToString(data)}
+		if err := t.do(context.Background(), http.MethodPost, t.mount+"/sign/"+url.PathEscape(t.key)+"/sha2-256", body, &resp); err != nil {
+			return nil, err
+		}
+		if resp.Data.Signature == "" {
+			return nil, fmt.Errorf("%w: empty signature", ErrVault)
+		}
+		return []byte(resp.Data.Signature), nil
+	}
+	var resp struct {
+		Data struct {
+			HMAC string `json:"hmac"`
+		} `json:"data"`
+	}
+	body := map[string]string{"input": base64.StdEncoding.EncodeToString(data)}
+	if err := t.do(context.Background(), http.MethodPost, t.mount+"/hmac/"+url.PathEscape(t.key)+"/sha2-256", body, &resp); err != nil {
+		return nil, err
+	}
+	if resp.Data.HMAC == "" {
+		return nil, fmt.Errorf("%w: empty hmac", ErrVault)
+	}
+	return []byte(resp.Data.HMAC), nil
+}
+
+// Verify implements cookie.Signer. Signature is checked against every key
+// version still allowed for decryption, so rotating the transit key does not
+// invalidate existing cookies. Without WithPublicKeys, this is a request to
+// Vault, adding its latency to every cookie read.
+func (t *Transit) Verify(data, signature []byte) error {
+	return t.VerifyContext(context.Background(), data, signature)
+}
+
+// VerifyContext implements cookie.ContextVerifier, as Verify does, stopping
+// any request to Vault once ctx is done.
+func (t *Transit) VerifyContext(ctx context.Context, data, signature []byte) error {
+	if t.local {
+		return t.verifyLocal(ctx, data, signature)
+	}
+	var resp struct {
+		Data struct {
+			Valid bool `json:"valid"`
+		} `json:"data"`
+	}
+	body := map[string]string{
+		"input": base64.StdEncoding.EncodeToString(data),
+		"hmac":  string(signature),
+	}
+	if err := t.do(ctx, http.MethodPost, t.mount+"/verify/"+url.PathEscape(t.key)+"/sha2-256", body, &resp); err != nil {
+		return err
+	}
+	if !resp.Data.Valid {
+		return errors.New("invalid signature")
+	}
+	return nil
+}
+
+// verifyLocal checks a transit signature, "vault:v<version>:<base64>",
+// against the public key of its version.
+func (t *Transit) verifyLocal(ctx context.Context, data, signature []byte) error {
+	rest, ok := strings.CutPrefix(string(signature), "vault:v")
+	version, encoded, found := strings.Cut(rest, ":")
+	n, err := strconv.Atoi(version)
+	if !ok || !found || err != nil || n < 1 {
+		return errors.New("malformed signature")
+	}
+	raw, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return fmt.Errorf("malformed signature: %w", err)
+	}
+	key, err := t.publicKey(ctx, n)
+	if err != nil {
+		return err
+	}
+	var valid bool
+	switch key := key.(type) {
+	case ed25519.PublicKey:
+		valid = ed25519.Verify(key, data, raw)
+	case *ecdsa.PublicKey:
+		digest := sha256.Sum256(data)
+		valid = ecdsa.VerifyASN1(key, digest[:], raw)
+	}
+	if !valid {
+		return errors.New("invalid signature")
+	}
+	return nil
+}
+
+// publicKey returns the public key of version, fetching the key's public
+// keys on first use or when version is unknown and they were not fetched recently.
+func (t *Transit) publicKey(ctx context.Context, version int) (crypto.PublicKey, error) {
+	t.keysMu.Lock()
+	defer t.keysMu.Unlock()
+	if key, ok := t.publicKeys[version]; ok {
+		return key, nil
+	}
+	if !t.fetched.IsZero() && time.Since(t.fetched) < publicKeyRefresh {
+		return nil, fmt.Errorf("unknown key version %d", version)
+	}
+
+	var resp struct {
+		Data struct {
+			Type                 string `json:"type"`
+			MinDecryptionVersion int    `json:"min_decryption_version"`
+			Keys                 map[string]struct {
+				PublicKey string `json:"public_key"`
+			} `json:"keys"`
+		} `json:"data"`
+	}
+	if err := t.do(ctx, http.MethodGet, t.mount+"/keys/"+url.PathEscape(t.key), nil, &resp); err != nil {
+		return nil, err
+	}
+	keys := map[int]crypto.PublicKey{}
+	for v, k := range resp.Data.Keys {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < resp.Data.MinDecryptionVersion {
+			continue
+		}
+		key, err := parsePublicKey(resp.Data.Type, k.PublicKey)
+		if err != nil {
+			return nil, fmt.Errorf("%w: key version %d: %w", ErrVault, n, err)
+		}
+		keys[n] = key
+	}
+	t.publicKeys = keys
+	t.fetched = time.Now()
+	if key, ok := keys[version]; ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("unknown key version %d", version)
+}
+
+// parsePublicKey parses a transit public key: base64 for ed25519, PEM for ecdsa.
+func parsePublicKey(keyType, encoded string) (crypto.PublicKey, error) {
+	switch keyType {
+	case "ed25519":
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, err
+		}
+		if len(key) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("ed25519 public key is %d bytes", len(key))
+		}
+		return ed25519.PublicKey(key), nil
+	case "ecdsa-p256", "ecdsa-p384", "ecdsa-p521":
+		block, _ := pem.Decode([]byte(encoded))
+		if block == nil {
+			return nil, errors.New("public key is not PEM")
+		}
+		key, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		ecdsaKey, ok := key.(*ecdsa.PublicKey)
+		if !ok {
+			return nil, errors.New("public key is not ecdsa")
+		}
+		return ecdsaKey, nil
+	default:
+		return nil, fmt.Errorf("transit key type '%s' cannot be verified locally", keyType)
+	}
+}
+
+// do sends an authenticated request to Vault, renewing the token first if needed.
+func (t *Transit) do(ctx context.Context, method, path string, body, out any) error {
+	token, err := t.currentToken(ctx)
+	if err != nil {
+		return err
+	}
+	return t.request(ctx, method, path, token, body, out)
+}
+
+// currentToken returns the token, looking up its TTL on first use and
+// renewing it when it is close to expiry.
+func (t *Transit) currentToken(ctx context.Context) (string, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if !t.tokenChecked {
+		var resp struct {
+			Data struct {
+				TTL int64 `json:"ttl"`
+			} `json:"data"`
+		}
+		if err := t.request(ctx, http.MethodGet, "auth/token/lookup-self", t.token, nil, &resp); err != nil {
+			return "", err
+		}
+		t.tokenExpires = expiresAfter(resp.Data.TTL)
+		t.tokenChecked = true
+	}
+	if !t.tokenExpires.IsZero() && time.Until(t.tokenExpires) < t.renewBefore {
+		var resp struct {
+			Auth struct {
+				ClientToken   string `json:"client_token"`
+				LeaseDuration int64  `json:"lease_duration"`
+			} `json:"auth"`
+		}
+		if err := t.request(ctx, http.MethodPost, "auth/token/renew-self", t.token, struct{}{}, &resp); err != nil {
+			return "", fmt.Errorf("unable to renew token: %w", err)
+		}
+		if resp.Auth.ClientToken != "" {
+			t.token = resp.Auth.ClientToken
+		}
+		t.tokenExpires = expiresAfter(resp.Auth.LeaseDuration)
+	}
+	return t.token, nil
+}
+
+// request sends a single request to the Vault HTTP API, decoding the JSON response into out.
+func (t *Transit) request(ctx context.Context, method, path, token string, body, out any) error {
+	ctx, cancel := context.WithTimeout(ctx, t.timeout)
+	defer cancel()
+
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("%w: %w", ErrVault, err)
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, t.addr+"/v1/"+path, reader)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrVault, err)
+	}
+	req.Header.Set("X-Vault-Token", token)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrVault, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		var vaultErr struct {
+			Errors []string `json:"errors"`
+		}
+		json.NewDecoder(resp.Body).Decode(&vaultErr)
+		return fmt.Errorf("%w: %s %s: %s %s", ErrVault, method, path, resp.Status, strings.Join(vaultErr.Errors, "; "))
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("%w: unable to decode response: %w", ErrVault, err)
+	}
+	return nil
+}
+
+// expiresAfter converts a Vault TTL in seconds to a time; zero means never.
+func expiresAfter(ttl int64) time.Time {
+	if ttl <= 0 {
+		return time.Time{}
+	}
+	return time.Now().Add(time.Duration(ttl) * time.Second)
+}
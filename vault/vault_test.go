@@ -0,0 +1,211 @@
+package vault
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/grackleclub/cookie/v2"
+	"github.com/grackleclub/cookie/v2/cookietest"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeVault implements the transit hmac and verify endpoints and token renewal.
+type fakeVault struct {
+	key      []byte
+	ttl      int64
+	token    atomic.Value
+	lookups  atomic.Int32
+	renewals atomic.Int32
+}
+
+func (v *fakeVault) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("X-Vault-Token") != v.token.Load().(string) {
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(map[string][]string{"errors": {"permission denied"}})
+		return
+	}
+	var body map[string]string
+	json.NewDecoder(r.Body).Decode(&body)
+	input, _ := base64.StdEncoding.DecodeString(body["input"])
+	mac := hmac.New(sha256.New, v.key)
+	mac.Write(input)
+	sum := "vault:v1:" + base64.StdEncoding.EncodeToString(mac.Sum(nil))
+
+	switch r.URL.Path {
+	case "/v1/auth/token/lookup-self":
+		v.lookups.Add(1)
+		json.NewEncoder(w).Encode(map[string]any{"data": map[string]any{"ttl": v.ttl}})
+	case "/v1/auth/token/renew-self":
+		v.renewals.Add(1)
+		v.token.Store("renewed")
+		json.NewEncoder(w).Encode(map[string]any{"auth": map[string]any{
+			"client_token": "renewed", "lease_duration": 3600,
+		}})
+	case "/v1/transit/hmac/cookies/sha2-256":
+		json.NewEncoder(w).Encode(map[string]any{"data": map[string]any{"hmac": sum}})
+	case "/v1/transit/verify/cookies/sha2-256":
+		valid := hmac.Equal([]byte(body["hmac"]), []byte(sum))
+		json.NewEncoder(w).Encode(map[string]any{"data": map[string]any{"valid": valid}})
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func TestTransit(t *testing.T) {
+	key, err := cookie.NewCookieSecret()
+	require.NoError(t, err)
+	// a 60 second token is inside the default renewal window
+	vault := &fakeVault{key: key, ttl: 60}
+	vault.token.Store("initial")
+	server := httptest.NewServer(vault)
+	t.Cleanup(server.Close)
+
+	transit, err := New(server.URL, "initial", "cookies", WithHTTPClient(server.Client()), WithTimeout(time.Second))
+	require.NoError(t, err)
+	m, err := cookie.New(cookie.WithSigner(transit))
+	require.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	require.NoError(t, m.WriteSigned(w, "a", "chocolate fudge"))
+	value, err := m.ReadSigned(cookietest.RequestFrom(t, w), "a")
+	require.NoError(t, err)
+	require.Equal(t, "chocolate fudge", value)
+
+	// the token is looked up once and renewed once, then reused
+	require.Equal(t, int32(1), vault.lookups.Load())
+	require.Equal(t, int32(1), vault.renewals.Load())
+
+	require.Error(t, transit.Verify([]byte("data"), []byte("vault:v1:forged")))
+
+	t.Run("bad token", func(t *testing.T) {
+		bad, err := New(server.URL, "wrong", "cookies", WithHTTPClient(server.Client()))
+		require.NoError(t, err)
+		_, err = bad.Sign([]byte("data"))
+		require.ErrorIs(t, err, ErrVault)
+	})
+
+	_, err = New(server.URL, "", "cookies")
+	require.ErrorIs(t, err, cookie.ErrInitiation)
+}
+
+// signingVault implements the transit sign and keys endpoints for an
+// asymmetric key, counting key fetches.
+type signingVault struct {
+	keyType string
+	sign    func(data []byte) []byte
+	public  string
+	fetches atomic.Int32
+}
+
+func (v *signingVault) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/v1/auth/token/lookup-self":
+		json.NewEncoder(w).Encode(map[string]any{"data": map[string]any{"ttl": 0}})
+	case "/v1/transit/sign/cookies/sha2-256":
+		var body map[string]string
+		json.NewDecoder(r.Body).Decode(&body)
+		input, _ := base64.StdEncoding.DecodeString(body["input"])
+		signature := "vault:v2:" + base64.StdEncoding.EncodeToString(v.sign(input))
+		json.NewEncoder(w).Encode(map[string]any{"data": map[string]any{"signature": signature}})
+	case "/v1/transit/keys/cookies":
+		v.fetches.Add(1)
+		json.NewEncoder(w).Encode(map[string]any{"data": map[string]any{
+			"type":                   v.keyType,
+			"min_decryption_version": 2,
+			"keys": map[string]any{
+				"1": map[string]any{"public_key": "retired"},
+				"2": map[string]any{"public_key": v.public},
+			},
+		}})
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func TestTransitPublicKeys(t *testing.T) {
+	edPublic, edPrivate, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	ecPrivate, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKIXPublicKey(&ecPrivate.PublicKey)
+	require.NoError(t, err)
+
+	for _, v := range []*signingVault{
+		{
+			keyType: "ed25519",
+			sign:    func(data []byte) []byte { return ed25519.Sign(edPrivate, data) },
+			public:  base64.StdEncoding.EncodeToString(edPublic),
+		},
+		{
+			keyType: "ecdsa-p256",
+			sign: func(data []byte) []byte {
+				digest := sha256.Sum256(data)
+				signature, _ := ecdsa.SignASN1(rand.Reader, ecPrivate, digest[:])
+				return signature
+			},
+			public: string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})),
+		},
+	} {
+		t.Run(v.keyType, func(t *testing.T) {
+			server := httptest.NewServer(v)
+			t.Cleanup(server.Close)
+			transit, err := New(server.URL, "token", "cookies", WithHTTPClient(server.Client()), WithPublicKeys())
+			require.NoError(t, err)
+			m, err := cookie.New(cookie.WithSigner(transit))
+			require.NoError(t, err)
+
+			w := httptest.NewRecorder()
+			require.NoError(t, m.WriteSigned(w, "a", "chocolate fudge"))
+			for range 3 {
+				value, err := m.ReadSigned(cookietest.RequestFrom(t, w), "a")
+				require.NoError(t, err)
+				require.Equal(t, "chocolate fudge", value)
+			}
+			require.Equal(t, int32(1), v.fetches.Load())
+
+			signature, err := transit.Sign([]byte("data"))
+			require.NoError(t, err)
+			require.Error(t, transit.Verify([]byte("other"), signature))
+			// unknown and retired versions are not fetched again within a minute
+			require.ErrorContains(t, transit.Verify([]byte("data"), []byte("vault:v9:AAAA")), "unknown key version")
+			require.ErrorContains(t, transit.Verify([]byte("data"), []byte("vault:v1:AAAA")), "unknown key version")
+			require.Error(t, transit.Verify([]byte("data"), []byte("forged")))
+			require.Equal(t, int32(1), v.fetches.Load())
+		})
+	}
+}
+
+func TestTransitContext(t *testing.T) {
+	key, err := cookie.NewCookieSecret()
+	require.NoError(t, err)
+	vault := &fakeVault{key: key}
+	vault.token.Store("token")
+	server := httptest.NewServer(vault)
+	t.Cleanup(server.Close)
+
+	transit, err := New(server.URL, "token", "cookies", WithHTTPClient(server.Client()))
+	require.NoError(t, err)
+	m, err := cookie.New(cookie.WithSigner(transit))
+	require.NoError(t, err)
+	w := httptest.NewRecorder()
+	require.NoError(t, m.WriteSigned(w, "a", "chocolate fudge"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = m.ReadSigned(cookietest.RequestFrom(t, w).WithContext(ctx), "a")
+	require.ErrorIs(t, err, context.Canceled)
+}
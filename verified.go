@@ -0,0 +1,81 @@
+package cookie
+
+import (
+	"context"
+	"net/http"
+	"sync"
+)
+
+// verifiedCache holds the values a request's cookies opened to, so each is
+// verified or decrypted at most once per request.
+type verifiedCache struct {
+	mu     sync.Mutex
+	values map[verifiedRead]opened
+}
+
+// verifiedRead identifies a cookie value opened by one Manager with one mode.
+type verifiedRead struct {
+	m     *Manager
+	name  string // full cookie name
+	mode  Mode
+	value string // as read, so a changed request header is opened again
+}
+
+type verifiedCacheKey struct{}
+
+// CacheVerified makes reads within each request of the handler it wraps
+// verify or decrypt a cookie at most once per Manager and mode, returning the
+// same value to middleware and handlers that read it again. Failed reads are
+// not cached. Use Invalidate to read a cookie afresh, such as after RevokeAll.
+func CacheVerified(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r.WithContext(WithVerifiedCache(r.Context())))
+	})
+}
+
+// WithVerifiedCache returns a copy of ctx carrying a new cache of verified
+// cookie values, as CacheVerified does for each request.
+func WithVerifiedCache(ctx context.Context) context.Context {
+	return context.WithValue(ctx, verifiedCacheKey{}, &verifiedCache{values: map[verifiedRead]opened{}})
+}
+
+// Invalidate drops any cached result of the Manager reading the named cookie
+// in the request, so the next read verifies it again.
+func (m *Manager) Invalidate(r *http.Request, name string) {
+	c, _ := r.Context().Value(verifiedCacheKey{}).(*verifiedCache)
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key := range c.values {
+		if key.m == m && key.name == m.prefix+name {
+			delete(c.values, key)
+		}
+	}
+}
+
+// openCached opens value as openEnvelope does, reusing the request's cached
+// result when CacheVerified is in use.
+func (m *Manager) openCached(r *http.Request, mode Mode, name, value string) (opened, error) {
+	c, _ := r.Context().Value(verifiedCacheKey{}).(*verifiedCache)
+	if c == nil {
+		return m.openEnvelope(r, mode, name, value)
+	}
+	key := verifiedRead{m: m, name: name, mode: mode, value: value}
+	c.mu.Lock()
+	o, ok := c.values[key]
+	c.mu.Unlock()
+	if ok {
+		return o, nil
+	}
+	o, err := m.openEnvelope(r, mode, name, value)
+	// one-time values must be claimed on every read
+	if err != nil || o.flags&flagOnce != 0 {
+		return o, err
+	}
+	c.mu.Lock()
+	c.values[key] = o
+	c.mu.Unlock()
+	return o, nil
+}
@@ -0,0 +1,68 @@
+package cookie
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// countingEncrypter counts the values it decrypts.
+type countingEncrypter struct {
+	xorEncrypter
+	decrypts int
+}
+
+func (e *countingEncrypter) Decrypt(ciphertext, associatedData []byte) ([]byte, error) {
+	e.decrypts++
+	return e.xorEncrypter.Decrypt(ciphertext, associatedData)
+}
+
+func TestCacheVerified(t *testing.T) {
+	encrypter := &countingEncrypter{}
+	m, err := New(WithEncrypter(encrypter))
+	require.NoError(t, err)
+	w := httptest.NewRecorder()
+	require.NoError(t, m.WriteEncryptedValue(w, "user", "alice"))
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for range 3 {
+			value, err := m.ReadEncryptedValue(r, "user")
+			require.NoError(t, err)
+			require.Equal(t, "alice", value)
+		}
+		m.Invalidate(r, "user")
+		_, err := m.ReadEncryptedValue(r, "user")
+		require.NoError(t, err)
+		_, err = m.ReadSigned(r, "user") // another mode is not served from the cache
+		require.Error(t, err)
+	})
+
+	CacheVerified(handler).ServeHTTP(httptest.NewRecorder(), requestWith(w))
+	require.Equal(t, 2, encrypter.decrypts)
+
+	// without the cache every read decrypts
+	encrypter.decrypts = 0
+	handler.ServeHTTP(httptest.NewRecorder(), requestWith(w))
+	require.Equal(t, 4, encrypter.decrypts)
+}
+
+func TestCacheVerifiedFailures(t *testing.T) {
+	m := newTestManager(t)
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r = r.WithContext(WithVerifiedCache(r.Context()))
+	r.AddCookie(&http.Cookie{Name: "user", Value: "forged"})
+	for range 2 {
+		_, err := m.ReadSigned(r, "user")
+		require.ErrorIs(t, err, ErrCookie)
+	}
+	other := newTestManager(t)
+	w := httptest.NewRecorder()
+	require.NoError(t, m.WriteSigned(w, "theme", "dark"))
+	r = requestWith(w).WithContext(r.Context())
+	_, err := m.ReadSigned(r, "theme")
+	require.NoError(t, err)
+	_, err = other.ReadSigned(r, "theme") // cached per Manager
+	require.ErrorIs(t, err, ErrCookie)
+}
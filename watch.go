@@ -0,0 +1,165 @@
+package cookie
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// FileSource is a SecretSource that reloads a secrets file, in the format
+// read by SecretsFromFile, whenever it changes. Secrets removed from the
+// file are still accepted for reading until a grace period has passed,
+// so rotating the file never invalidates cookies already issued.
+type FileSource struct {
+	path  string
+	grace time.Duration
+	state atomic.Pointer[fileState]
+
+	mu  sync.Mutex        // serializes reloads
+	sum [sha256.Size]byte // of the contents last loaded
+	err atomic.Pointer[error]
+
+	stop chan struct{}
+	once sync.Once
+}
+
+// fileState is an immutable snapshot, swapped in whole on reload.
+type fileState struct {
+	secrets Secrets
+	retired []retiredSecret
+}
+
+type retiredSecret struct {
+	secret  []byte
+	expires time.Time
+}
+
+// WatchSecretsFile loads the secrets file at path and checks it for changes
+// every interval. Secrets removed from the file remain readable for grace.
+// Call Close to stop watching.
+func WatchSecretsFile(path string, interval, grace time.Duration) (*FileSource, error) {
+	if interval <= 0 {
+		return nil, fmt.Errorf("invalid watch interval: %s", interval)
+	}
+	s := &FileSource{
+		path:  path,
+		grace: grace,
+		stop:  make(chan struct{}),
+	}
+	if err := s.Reload(); err != nil {
+		return nil, err
+	}
+	go s.watch(interval)
+	return s, nil
+}
+
+// Current implements SecretSource.
+func (s *FileSource) Current() ([]byte, error) {
+	return s.state.Load().secrets.Current()
+}
+
+// All implements SecretSource, returning the file's secrets followed by
+// any retired secrets still within their grace period.
+func (s *FileSource) All() ([][]byte, error) {
+	state := s.state.Load()
+	if len(state.retired) == 0 {
+		return state.secrets, nil
+	}
+	now := time.Now()
+	all := append([][]byte(nil), state.secrets...)
+	for _, r := range state.retired {
+		if now.Before(r.expires) {
+			all = append(all, r.secret)
+		}
+	}
+	return all, nil
+}
+
+// Reload reads the file, swapping in its secrets if its contents have
+// changed since they were last loaded. Contents are compared rather than
+// modification times, which a rename or copy can preserve.
+// On error, the previous secrets stay in use.
+func (s *FileSource) Reload() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		return s.fail(fmt.Errorf("unable to read secrets: %w", err))
+	}
+	sum := sha256.Sum256(data)
+	previous := s.state.Load()
+	if previous != nil && sum == s.sum {
+		s.err.Store(nil)
+		return nil
+	}
+	secrets, err := parseSecrets(data)
+	if err != nil {
+		return s.fail(err)
+	}
+
+	next := &fileState{secrets: secrets}
+	if previous != nil {
+		now := time.Now()
+		expires := now.Add(s.grace)
+		for _, secret := range previous.secrets {
+			if !containsSecret(secrets, secret) {
+				next.retired = append(next.retired, retiredSecret{secret: secret, expires: expires})
+			}
+		}
+		for _, r := range previous.retired {
+			if now.Before(r.expires) && !containsSecret(secrets, r.secret) {
+				next.retired = append(next.retired, r)
+			}
+		}
+	}
+	s.state.Store(next)
+	s.sum = sum
+	s.err.Store(nil)
+	return nil
+}
+
+// Err returns the error from the most recent failed reload,
+// or nil if the latest reload succeeded.
+func (s *FileSource) Err() error {
+	if err := s.err.Load(); err != nil {
+		return *err
+	}
+	return nil
+}
+
+// Close stops watching the file.
+func (s *FileSource) Close() error {
+	s.once.Do(func() { close(s.stop) })
+	return nil
+}
+
+func (s *FileSource) watch(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			s.Reload()
+		case <-s.stop:
+			return
+		}
+	}
+}
+
+func (s *FileSource) fail(err error) error {
+	s.err.Store(&err)
+	return err
+}
+
+func containsSecret(secrets [][]byte, secret []byte) bool {
+	for _, candidate := range secrets {
+		if bytes.Equal(candidate, secret) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,80 @@
+package cookie
+
+import (
+	"encoding/base64"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWatchSecretsFile(t *testing.T) {
+	oldKey, err := NewCookieSecret()
+	require.NoError(t, err)
+	newKey, err := NewCookieSecret()
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "secrets")
+	writeSecrets := func(age time.Duration, secretKeys ...[]byte) {
+		var contents string
+		for _, secretKey := range secretKeys {
+			contents += base64.StdEncoding.EncodeToString(secretKey) + "\n"
+		}
+		require.NoError(t, os.WriteFile(path, []byte(contents), 0o600))
+		modTime := time.Now().Add(-age)
+		require.NoError(t, os.Chtimes(path, modTime, modTime))
+	}
+	writeSecrets(time.Minute, oldKey)
+
+	source, err := WatchSecretsFile(path, 10*time.Millisecond, 200*time.Millisecond)
+	require.NoError(t, err)
+	t.Cleanup(func() { source.Close() })
+	m, err := New(WithSecretSource(source))
+	require.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	require.NoError(t, m.WriteSigned(w, "a", "b"))
+
+	// the watcher swaps in the new key without a restart
+	writeSecrets(0, newKey)
+	require.Eventually(t, func() bool {
+		current, err := source.Current()
+		return err == nil && string(current) == string(newKey)
+	}, time.Second, 10*time.Millisecond)
+	require.NoError(t, source.Err())
+
+	// the removed key is accepted during the grace period, then dropped
+	value, err := m.ReadSigned(requestWith(w), "a")
+	require.NoError(t, err)
+	require.Equal(t, "b", value)
+	require.Eventually(t, func() bool {
+		_, err := m.ReadSigned(requestWith(w), "a")
+		return err != nil
+	}, time.Second, 10*time.Millisecond)
+
+	// a same-size rotation that keeps the modification time is still seen
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	writeSecrets(0, oldKey)
+	require.NoError(t, os.Chtimes(path, info.ModTime(), info.ModTime()))
+	require.NoError(t, source.Reload())
+	current, err := source.Current()
+	require.NoError(t, err)
+	require.Equal(t, oldKey, current)
+	writeSecrets(0, newKey)
+	require.NoError(t, source.Reload())
+
+	// a broken file keeps the previous secrets in use
+	require.NoError(t, os.WriteFile(path, []byte("not base64!\n"), 0o600))
+	require.Error(t, source.Reload())
+	require.Error(t, source.Err())
+	current, err = source.Current()
+	require.NoError(t, err)
+	require.Equal(t, newKey, current)
+
+	_, err = WatchSecretsFile(filepath.Join(t.TempDir(), "missing"), time.Second, time.Minute)
+	require.Error(t, err)
+}